@@ -0,0 +1,83 @@
+// ============================================================
+// Paywall Server - Example Go service charging with x402
+// ============================================================
+//
+// Usage:
+//   go run ./examples/paywall-server --pay-to <wallet> \
+//       [--pricing ~/.machpay/config.yaml] [--network solana-devnet] \
+//       [--facilitator URL] [--listen :8080]
+//
+// Prices come from the vendor section of the MachPay config (or any
+// file in that format). Try it with:
+//
+//   machpay curl --max-price 0.01 http://localhost:8080/v1/quote
+//
+// ============================================================
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/pkg/paywall"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+func main() {
+	home, _ := os.UserHomeDir()
+	listen := flag.String("listen", ":8080", "Address to listen on")
+	payTo := flag.String("pay-to", "", "Wallet to receive payments (required)")
+	pricingFile := flag.String("pricing", filepath.Join(home, ".machpay", "config.yaml"), "Price list: a MachPay config or a file in its vendor format")
+	network := flag.String("network", x402.NetworkSolanaDevnet, "x402 network: solana or solana-devnet")
+	facilitatorURL := flag.String("facilitator", "https://facilitator-dev.machpay.xyz", "Facilitator URL")
+	flag.Parse()
+
+	pricing, err := paywall.LoadPricing(*pricingFile)
+	if err != nil {
+		log.Fatalf("load pricing: %v", err)
+	}
+	pw, err := paywall.New(paywall.Config{
+		Pricing:     *pricing,
+		PayTo:       *payTo,
+		Network:     *network,
+		Facilitator: x402.NewFacilitator(*facilitatorURL),
+		OnSettled: func(r *http.Request, p *paywall.Payment, s *x402.SettlementResponse, err error) {
+			switch {
+			case err != nil:
+				log.Printf("%s %s: settlement error: %v", r.Method, r.URL.Path, err)
+			case !s.Success:
+				log.Printf("%s %s: settlement failed: %s", r.Method, r.URL.Path, s.ErrorReason)
+			default:
+				log.Printf("%s %s: %d from %s, tx %s", r.Method, r.URL.Path, p.Amount, p.Payer, s.Transaction)
+			}
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/quote", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"symbol": "SOL",
+			"price":  "142.07",
+			"time":   time.Now().UTC().Format(time.RFC3339),
+			"payer":  paywall.Payer(r),
+		})
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+
+	log.Printf("listening on %s, paying to %s on %s", *listen, *payTo, *network)
+	server := &http.Server{Addr: *listen, Handler: pw.Handler(mux), ReadHeaderTimeout: 10 * time.Second}
+	log.Fatal(server.ListenAndServe())
+}
+
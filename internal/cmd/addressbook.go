@@ -0,0 +1,362 @@
+// ============================================================
+// Address Book Command - Trusted vendor and counterparty keys
+// ============================================================
+//
+// Usage:
+//   machpay addressbook add <label> <address> [--note <text>]
+//   machpay addressbook list [--json]
+//   machpay addressbook remove <label> [--yes]
+//   machpay addressbook verify [<address> | @<label>]
+//
+// Commands that take an address also accept @label, and warn when
+// a raw address looks like, but is not, a saved one.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+var (
+	addressbookAddNote     string
+	addressbookAddOffCurve bool
+	addressbookAddYes      bool
+	addressbookListJSON    bool
+	addressbookRemoveYes   bool
+)
+
+var addressbookCmd = &cobra.Command{
+	Use:     "addressbook",
+	Aliases: []string{"ab"},
+	Short:   "Manage labelled vendor and counterparty addresses",
+	Long: `Save addresses under short labels and use them as @label.
+
+Saved addresses must be valid 32-byte keys on the ed25519 curve.
+Any command that takes an address accepts @label instead, and warns
+when you pass an address that shares its first or last characters
+with a saved one but is different: the signature of an address
+poisoning attack.
+
+Examples:
+  machpay addressbook add openai-proxy 7o36UsWR1JQLpZ9PE2gn9L4SQ69CNNiWAXd4Jt7rqz9Z
+  machpay addressbook list
+  machpay tx build --to @openai-proxy --amount 5
+  machpay addressbook verify <address pasted from chat>`,
+}
+
+var addressbookAddCmd = &cobra.Command{
+	Use:   "add <label> <address>",
+	Short: "Save an address under a label",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runAddressbookAdd,
+}
+
+var addressbookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved addresses",
+	Args:  cobra.NoArgs,
+	RunE:  runAddressbookList,
+}
+
+var addressbookRemoveCmd = &cobra.Command{
+	Use:   "remove <label>",
+	Short: "Remove a saved address",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAddressbookRemove,
+}
+
+var addressbookVerifyCmd = &cobra.Command{
+	Use:   "verify [address | @label]",
+	Short: "Check an address against the address book",
+	Long: `Check an address against the address book.
+
+With an address, reports whether it is saved, and fails if it only
+resembles a saved address. Without arguments, re-validates every
+saved entry and checks the entries against each other.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAddressbookVerify,
+}
+
+func init() {
+	addressbookAddCmd.Flags().StringVar(&addressbookAddNote, "note", "", "Free-form note")
+	addressbookAddCmd.Flags().BoolVar(&addressbookAddOffCurve, "allow-off-curve", false, "Allow a program-derived (off-curve) address")
+	addressbookAddCmd.Flags().BoolVarP(&addressbookAddYes, "yes", "y", false, "Save even if it resembles a saved address")
+	addressbookListCmd.Flags().BoolVar(&addressbookListJSON, "json", false, "Output as JSON")
+	addressbookRemoveCmd.Flags().BoolVarP(&addressbookRemoveYes, "yes", "y", false, "Skip confirmation")
+
+	addressbookCmd.AddCommand(addressbookAddCmd)
+	addressbookCmd.AddCommand(addressbookListCmd)
+	addressbookCmd.AddCommand(addressbookRemoveCmd)
+	addressbookCmd.AddCommand(addressbookVerifyCmd)
+	rootCmd.AddCommand(addressbookCmd)
+}
+
+func addressBook() *wallet.AddressBook {
+	return wallet.NewAddressBook(config.GetAddressBookPath())
+}
+
+// ============================================================
+// Address Resolution
+// ============================================================
+
+// resolveAddress turns an address argument into an address. @label is
+// looked up in the address book; a raw address is validated and
+// checked for look-alikes of saved entries. Empty input stays empty.
+func resolveAddress(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", nil
+	}
+
+	book := addressBook()
+	if label, ok := strings.CutPrefix(input, "@"); ok {
+		entry, err := book.Get(label)
+		if err != nil {
+			return "", err
+		}
+		return entry.Address, nil
+	}
+
+	if _, err := wallet.ParsePublicKey(input); err != nil {
+		return "", err
+	}
+	if entry, err := book.Lookup(input); err != nil || entry != nil {
+		return input, nil
+	}
+	similar, err := book.Similar(input)
+	if err != nil {
+		return input, nil
+	}
+	for _, e := range similar {
+		warnStderr(fmt.Sprintf("%s resembles @%s (%s) but is a different address. Possible address poisoning: check every character.",
+			input, e.Label, e.Address))
+	}
+	return input, nil
+}
+
+// warnStderr prints a warning on stderr, so it is seen even when
+// stdout is piped (--json, --csv)
+func warnStderr(msg string) {
+	fmt.Fprintf(os.Stderr, "%s %s\n", tui.WarningIcon(), msg)
+}
+
+// addressLabels maps saved addresses to labels for display; it is
+// empty if the address book cannot be read
+func addressLabels() map[string]string {
+	labels, err := addressBook().Labels()
+	if err != nil {
+		return map[string]string{}
+	}
+	return labels
+}
+
+// ============================================================
+// Subcommands
+// ============================================================
+
+func runAddressbookAdd(cmd *cobra.Command, args []string) error {
+	label, address := strings.TrimPrefix(args[0], "@"), strings.TrimSpace(args[1])
+	book := addressBook()
+
+	if err := wallet.ValidateLabel(label); err != nil {
+		return err
+	}
+	if err := wallet.ValidateAddress(address, addressbookAddOffCurve); err != nil {
+		if errors.Is(err, wallet.ErrAddressOffCurve) {
+			fmt.Println(tui.Muted("  Pass --allow-off-curve if this really is a program-owned account"))
+		}
+		return err
+	}
+
+	similar, err := book.Similar(address)
+	if err != nil {
+		return err
+	}
+	if len(similar) > 0 {
+		fmt.Println()
+		tui.PrintWarning("This address resembles saved entries:")
+		for _, e := range similar {
+			fmt.Printf("  @%-20s %s\n", e.Label, e.Address)
+		}
+		fmt.Printf("  %-21s %s\n", "new", address)
+		if !addressbookAddYes {
+			confirmed, err := tui.Confirm("Save it anyway?", false)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Println(tui.Muted("Cancelled."))
+				return nil
+			}
+		}
+	}
+
+	entry, err := book.Add(label, address, addressbookAddNote, addressbookAddOffCurve)
+	if err != nil {
+		return err
+	}
+
+	tui.PrintSuccess(fmt.Sprintf("Saved %s", tui.Bold("@"+entry.Label)))
+	tui.PrintKeyValue("Address", entry.Address)
+	if entry.OffCurve {
+		tui.PrintKeyValue("Type", "off-curve (program-derived)")
+	}
+	return nil
+}
+
+func runAddressbookList(cmd *cobra.Command, args []string) error {
+	entries, err := addressBook().List()
+	if err != nil {
+		return err
+	}
+
+	if addressbookListJSON {
+		if entries == nil {
+			entries = []wallet.AddressEntry{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println(tui.Muted("Address book is empty"))
+		fmt.Println(tui.Muted("  Run 'machpay addressbook add <label> <address>' to save one"))
+		return nil
+	}
+
+	fmt.Println()
+	for _, e := range entries {
+		label := fmt.Sprintf("%-22s", "@"+e.Label)
+		fmt.Printf("  %s %s  %s\n", tui.Bold(label), tui.Primary(e.Address), tui.Muted(e.Note))
+	}
+	fmt.Println()
+	return nil
+}
+
+func runAddressbookRemove(cmd *cobra.Command, args []string) error {
+	label := strings.TrimPrefix(args[0], "@")
+	book := addressBook()
+
+	entry, err := book.Get(label)
+	if err != nil {
+		return err
+	}
+
+	if !addressbookRemoveYes {
+		confirmed, err := tui.Confirm(fmt.Sprintf("Remove @%s (%s)?", entry.Label, entry.Address), false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println(tui.Muted("Cancelled."))
+			return nil
+		}
+	}
+
+	if err := book.Remove(label); err != nil {
+		return err
+	}
+	tui.PrintSuccess(fmt.Sprintf("Removed @%s", label))
+	return nil
+}
+
+func runAddressbookVerify(cmd *cobra.Command, args []string) error {
+	book := addressBook()
+	if len(args) == 0 {
+		return verifyAddressBook(book)
+	}
+
+	input := strings.TrimSpace(args[0])
+	if label, ok := strings.CutPrefix(input, "@"); ok {
+		entry, err := book.Get(label)
+		if err != nil {
+			return err
+		}
+		input = entry.Address
+	}
+
+	if err := wallet.ValidateAddress(input, true); err != nil {
+		tui.PrintError(err.Error())
+		return fmt.Errorf("invalid address")
+	}
+	key, _ := wallet.ParsePublicKey(input)
+
+	fmt.Println()
+	if entry, err := book.Lookup(input); err != nil {
+		return err
+	} else if entry != nil {
+		tui.PrintSuccess(fmt.Sprintf("Matches %s exactly", tui.Bold("@"+entry.Label)))
+	} else {
+		tui.PrintInfo("Not in the address book")
+	}
+	if !wallet.IsOnCurve(key) {
+		tui.PrintInfo("Off-curve: a program-derived address, not a wallet")
+	}
+
+	similar, err := book.Similar(input)
+	if err != nil {
+		return err
+	}
+	if len(similar) > 0 {
+		tui.PrintWarning("Resembles saved addresses (possible address poisoning):")
+		fmt.Printf("  %-21s %s\n", "checked", input)
+		for _, e := range similar {
+			fmt.Printf("  @%-20s %s\n", e.Label, e.Address)
+		}
+		fmt.Println()
+		return fmt.Errorf("address resembles a saved address")
+	}
+	fmt.Println()
+	return nil
+}
+
+// verifyAddressBook re-validates every entry and looks for entries
+// that resemble each other
+func verifyAddressBook(book *wallet.AddressBook) error {
+	entries, err := book.List()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println(tui.Muted("Address book is empty"))
+		return nil
+	}
+
+	problems := 0
+	fmt.Println()
+	for i, e := range entries {
+		if err := wallet.ValidateAddress(e.Address, e.OffCurve); err != nil {
+			tui.PrintError(fmt.Sprintf("@%s: %v", e.Label, err))
+			problems++
+		}
+		for _, other := range entries[i+1:] {
+			if wallet.SimilarAddresses(e.Address, other.Address) {
+				tui.PrintWarning(fmt.Sprintf("@%s and @%s look alike:", e.Label, other.Label))
+				fmt.Printf("    %s\n    %s\n", e.Address, other.Address)
+				problems++
+			}
+		}
+	}
+
+	if problems > 0 {
+		fmt.Println()
+		return fmt.Errorf("%d problem(s) found in the address book", problems)
+	}
+	tui.PrintSuccess(fmt.Sprintf("%d addresses verified", len(entries)))
+	fmt.Println()
+	return nil
+}
+
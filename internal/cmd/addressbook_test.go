@@ -0,0 +1,70 @@
+// ============================================================
+// Address Book Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+func TestResolveAddress(t *testing.T) {
+	useTempConfig(t)
+
+	kp, _ := wallet.Generate()
+	vendor := kp.PublicKeyBase58()
+	if _, err := addressBook().Add("vendor", vendor, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"", "", nil},
+		{"@vendor", vendor, nil},
+		{" @vendor ", vendor, nil},
+		{vendor, vendor, nil},
+		{"@unknown", "", wallet.ErrLabelNotFound},
+		{"not-an-address", "", wallet.ErrInvalidPublicKey},
+	}
+	for _, tt := range tests {
+		got, err := resolveAddress(tt.input)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("resolveAddress(%q) err = %v, want %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolveAddress(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLabelCounterparties(t *testing.T) {
+	const (
+		alice = "7pxrn6LqK3rmvca2j1b2oV5jUGMNQaofjxLKiwYm15YN"
+		bob   = "CcyWFsS6KC6NxaCwFc7vtRGdGgkxioJa58ttBW2LNYnj"
+	)
+	page := &historyPage{Transfers: []solana.Transfer{
+		{Counterparty: alice},
+		{Counterparty: bob},
+	}}
+
+	labelCounterparties(page, map[string]string{alice: "alice", "other": "unused"})
+
+	if len(page.Labels) != 1 || page.Labels[alice] != "alice" {
+		t.Errorf("Labels = %v", page.Labels)
+	}
+	if got := counterpartyName(page, alice); got != "@alice" {
+		t.Errorf("counterpartyName(alice) = %s", got)
+	}
+	if got := counterpartyName(page, bob); got != truncateAddress(bob) {
+		t.Errorf("counterpartyName(bob) = %s", got)
+	}
+}
+
@@ -0,0 +1,321 @@
+// ============================================================
+// Agent Command - Tools for AI agents and other API clients
+// ============================================================
+//
+// Usage:
+//   machpay agent proxy [--listen 127.0.0.1:8403] [--upstream URL]
+//                       --max-price USDC [--cache]
+//                       [--session-deposit USDC] [--sign-requests]
+//   machpay agent register [--name NAME]
+//
+// The proxy lets any HTTP client pay for x402 APIs: point HTTP_PROXY
+// (or a base URL, with --upstream) at it and every 402 Payment
+// Required is paid from the configured wallet, up to --max-price per
+// request and within the spending policy. Each payment is logged.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Let AI agents and other clients pay for APIs",
+	Long: `Tools for software that calls paid APIs on your behalf.
+
+Examples:
+  machpay agent proxy --max-price 0.01                  # HTTP_PROXY for any client
+  machpay agent proxy --max-price 0.05 --upstream https://api.example.com
+  machpay agent register --name research-bot            # identity for signed requests`,
+}
+
+// ============================================================
+// agent proxy
+// ============================================================
+
+var (
+	agentProxyListen      string
+	agentProxyUpstream    string
+	agentProxyMaxPrice    string
+	agentProxyAllowRemote bool
+	agentProxyWallet      string
+	agentProxyKeypair     string
+	agentProxyCache       bool
+	agentProxyDeposit     string
+	agentProxySign        bool
+)
+
+var agentProxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Run a local HTTP proxy that pays x402 invoices",
+	Long: `Run a local HTTP proxy that pays for API requests.
+
+Requests pass through unchanged. When a server answers 402 Payment
+Required, the proxy pays the cheapest USDC offer on the active
+network if it costs no more than --max-price, retries the request
+with the payment and returns the paid response. Payments it cannot
+make come back as the server's 402 with the reason in the error.
+
+Use it as a forward proxy for plain HTTP APIs:
+
+  HTTP_PROXY=http://127.0.0.1:8403 python agent.py
+
+or give it an --upstream and use the proxy address as the API's base
+URL, which also works for HTTPS APIs:
+
+  machpay agent proxy --max-price 0.01 --upstream https://api.example.com
+  curl http://127.0.0.1:8403/v1/quote
+
+HTTPS requests sent through HTTP_PROXY/HTTPS_PROXY are tunnelled and
+cannot be paid for, since the proxy cannot see inside them.
+
+Payments are signed by the signing agent when it runs, otherwise by
+the active wallet, are held to the spending policy ('machpay
+policy') and are recorded in the spend ledger. Anyone who can reach
+the proxy can spend from the wallet, so it only listens on loopback
+unless --allow-remote is given. Browser requests (with an Origin or
+Sec-Fetch-Site header) are refused, and with --upstream the Host must
+be the listen address, localhost or an IP address, so web pages
+cannot spend through it.
+
+With --cache a paid GET response is kept and an identical request is
+answered from it instead of paying again, marked with an
+X-Machpay-Cache: HIT header ('machpay cache').
+
+With --session-deposit, vendors that offer prepaid sessions are paid
+once: the deposit buys a session whose token pays for later requests
+without a payment each, with a signed receipt for every charge. A
+used-up session is closed and a new one opened; on exit every session
+is closed and the unused credit reclaimed.
+
+With --sign-requests every request is signed with the key registered
+by 'machpay agent register', so upstream APIs can tell which agent is
+calling.`,
+	Args: cobra.NoArgs,
+	RunE: runAgentProxy,
+}
+
+func init() {
+	agentProxyCmd.Flags().StringVar(&agentProxyListen, "listen", "127.0.0.1:8403", "Address to listen on")
+	agentProxyCmd.Flags().StringVar(&agentProxyUpstream, "upstream", "", "Base URL to forward origin-form requests to (reverse proxy)")
+	agentProxyCmd.Flags().StringVar(&agentProxyMaxPrice, "max-price", "", "Most USDC to pay for a single request (required)")
+	agentProxyCmd.Flags().BoolVar(&agentProxyAllowRemote, "allow-remote", false, "Allow listening on a non-loopback address")
+	agentProxyCmd.Flags().StringVar(&agentProxyWallet, "wallet", "", "Registered wallet to pay with (default: signing agent or active wallet)")
+	agentProxyCmd.Flags().StringVar(&agentProxyKeypair, "keypair", "", "Keypair file to pay with")
+	agentProxyCmd.Flags().BoolVar(&agentProxyCache, "cache", false, "Reuse paid GET responses ('machpay cache')")
+	agentProxyCmd.Flags().StringVar(&agentProxyDeposit, "session-deposit", "", "Prepay vendors that offer sessions this much USDC at a time")
+	agentProxyCmd.Flags().BoolVar(&agentProxySign, "sign-requests", false, "Sign requests with the agent key ('machpay agent register')")
+	agentProxyCmd.MarkFlagRequired("max-price")
+
+	agentCmd.AddCommand(agentProxyCmd)
+	rootCmd.AddCommand(agentCmd)
+}
+
+func runAgentProxy(cmd *cobra.Command, args []string) error {
+	if err := checkProxyListen(agentProxyListen, agentProxyAllowRemote); err != nil {
+		return err
+	}
+	proxy, err := newAgentProxy()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", agentProxyListen)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: proxy, ReadHeaderTimeout: 30 * time.Second}
+
+	addr := listener.Addr().String()
+	proxy.Addr = addr
+	fmt.Println()
+	tui.PrintSuccess("Payment proxy started")
+	tui.PrintKeyValue("Listening", "http://"+addr)
+	tui.PrintKeyValue("Wallet", wallet.SignerAddress(proxy.Client.Signer))
+	tui.PrintKeyValue("Network", proxy.Client.Network)
+	tui.PrintKeyValue("Max price", solana.FormatTokenAmount(proxy.Client.MaxPrice, policy.USDCDecimals)+" USDC per request")
+	if proxy.Upstream != nil {
+		tui.PrintKeyValue("Upstream", proxy.Upstream.String())
+	}
+	fmt.Println()
+	fmt.Println(tui.Muted("  Forward proxy: HTTP_PROXY=http://" + addr))
+	if proxy.Upstream != nil {
+		fmt.Println(tui.Muted("  Base URL:      http://" + addr))
+	}
+	fmt.Println(tui.Muted("Press Ctrl+C to stop"))
+	fmt.Println()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		<-sigChan
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	closeSessions(os.Stdout, proxy.Client.Sessions)
+	stats := proxy.Stats()
+	fmt.Println()
+	tui.PrintSuccess("Payment proxy stopped")
+	tui.PrintKeyValue("Paid", fmt.Sprintf("%d requests, %s USDC", stats.Paid, solana.FormatTokenAmount(stats.Spent, policy.USDCDecimals)))
+	tui.PrintKeyValue("Declined", fmt.Sprintf("%d requests", stats.Declined))
+	if stats.Cached > 0 {
+		tui.PrintKeyValue("Cached", fmt.Sprintf("%d requests, %s USDC saved", stats.Cached, solana.FormatTokenAmount(stats.Saved, policy.USDCDecimals)))
+	}
+	return nil
+}
+
+// newAgentProxy builds the proxy from the flags and active config
+func newAgentProxy() (*payclient.Proxy, error) {
+	maxPrice, err := solana.ParseTokenAmount(agentProxyMaxPrice, policy.USDCDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("--max-price: %w", err)
+	}
+	if maxPrice == 0 {
+		return nil, errors.New("--max-price must be more than 0")
+	}
+
+	var upstream *url.URL
+	if agentProxyUpstream != "" {
+		upstream, err = url.Parse(agentProxyUpstream)
+		if err != nil || (upstream.Scheme != "http" && upstream.Scheme != "https") || upstream.Host == "" {
+			return nil, fmt.Errorf("--upstream: %q is not an http(s) URL", agentProxyUpstream)
+		}
+	}
+
+	cache, err := payCache(agentProxyCache)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := sessionPool(agentProxyDeposit, maxPrice)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := requestSigner(agentProxySign, agentProxyWallet, agentProxyKeypair)
+	if err != nil {
+		return nil, err
+	}
+	s, err := loadSigner(agentProxyWallet, agentProxyKeypair)
+	if err != nil {
+		return nil, err
+	}
+
+	name := vendorNamer()
+	return &payclient.Proxy{
+		Client: &payclient.Client{
+			// Redirects go back to the client, like any proxy
+			HTTP: signedClient(&http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			}}, identity),
+			Signer:   s,
+			RPC:      solana.NewClient(config.GetRPCURL()),
+			Network:  x402.NetworkForCluster(config.GetCluster()),
+			USDCMint: config.GetUSDCMint(),
+			MaxPrice: maxPrice,
+			Cache:    cache,
+			Sessions: sessions,
+		},
+		Upstream: upstream,
+		OnPayment: func(r *http.Request, p *payclient.Payment) {
+			if p.Session != nil {
+				fmt.Println(tui.Muted(time.Now().Format("15:04:05")+"  ") + tui.SuccessIcon() + fmt.Sprintf(" Deposited %s USDC with %s for a prepaid session",
+					solana.FormatTokenAmount(p.Amount, policy.USDCDecimals), name(p.Requirements.PayTo)))
+				return
+			}
+			fmt.Println(tui.Muted(time.Now().Format("15:04:05")+"  ") + tui.SuccessIcon() + fmt.Sprintf(" Paid %s USDC to %s for %s %s",
+				solana.FormatTokenAmount(p.Amount, policy.USDCDecimals), name(p.Requirements.PayTo), r.Method, r.URL))
+			switch s := p.Settlement; {
+			case s == nil:
+				fmt.Println(tui.Muted("          no settlement receipt"))
+			case !s.Success:
+				fmt.Println(tui.Muted("          settlement failed: " + s.ErrorReason))
+			case s.Transaction != "":
+				fmt.Println(tui.Muted("          tx " + s.Transaction))
+			}
+		},
+		Logf: func(format string, args ...interface{}) {
+			fmt.Println(tui.Muted(time.Now().Format("15:04:05") + "  " + fmt.Sprintf(format, args...)))
+		},
+	}, nil
+}
+
+// sessionPool returns the prepaid session pool for a --session-deposit
+// value, or nil when it is empty
+func sessionPool(deposit string, maxPrice uint64) (*payclient.SessionPool, error) {
+	if deposit == "" {
+		return nil, nil
+	}
+	amount, err := solana.ParseTokenAmount(deposit, policy.USDCDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("--session-deposit: %w", err)
+	}
+	if amount < maxPrice {
+		return nil, errors.New("--session-deposit must be at least --max-price")
+	}
+	return payclient.NewSessionPool(amount), nil
+}
+
+// closeSessions closes the pool's open sessions, reclaiming their
+// unused deposits, and reports each to w
+func closeSessions(w io.Writer, pool *payclient.SessionPool) {
+	if pool == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	closures, err := pool.Close(ctx)
+	name := vendorNamer()
+	for _, c := range closures {
+		fmt.Fprintf(w, "%s Closed session with %s: spent %s, %s reclaimed\n", tui.SuccessIcon(),
+			name(c.Session.Token.PayTo), formatUSDC(c.Receipt.Spent), formatUSDC(c.Refund))
+	}
+	if err != nil {
+		fmt.Fprintf(w, "%s Closing sessions: %v\n", tui.WarningIcon(), err)
+	}
+}
+
+// checkProxyListen refuses to expose the wallet on a non-loopback
+// address unless asked to
+func checkProxyListen(addr string, allowRemote bool) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("--listen: %w", err)
+	}
+	if allowRemote || host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("--listen %s is not a loopback address; anyone who can reach it could spend from your wallet (pass --allow-remote to allow)", addr)
+}
+
@@ -0,0 +1,196 @@
+// ============================================================
+// Agent Register - Give the agent a verifiable identity
+// ============================================================
+//
+// Usage:
+//   machpay agent register [--name NAME] [--wallet NAME | --keypair FILE]
+//                          [--json]
+//
+// Binds the wallet's public key to the logged-in console account,
+// proving the key with a signature. With --sign-requests (or
+// agent.sign_requests in the config), curl, agent proxy and mcp serve
+// sign every request with that key (HTTP Message Signatures, RFC
+// 9421), so vendors can tell which agent is calling with
+// pkg/httpsig, whether or not it pays. Like payments, these
+// signatures come from the signing agent when it holds the key.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/console"
+	"github.com/machpay-xyz/machpay-cli/internal/signer"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/httpsig"
+)
+
+var (
+	agentRegisterName    string
+	agentRegisterWallet  string
+	agentRegisterKeypair string
+	agentRegisterJSON    bool
+)
+
+var agentRegisterCmd = &cobra.Command{
+	Use:   "register",
+	Short: "Register the wallet key as this agent's identity",
+	Long: `Register the wallet's public key with your MachPay account.
+
+The key signs the registration, so only its holder can bind it. Once
+registered, requests signed with the key identify the agent to
+vendors separately from the wallet it pays with: pass --sign-requests
+to curl, agent proxy or mcp serve, or set
+
+  agent:
+    sign_requests: true
+
+in the config to sign always. Requests carry Signature-Input and
+Signature headers (HTTP Message Signatures, RFC 9421, ed25519) whose
+keyid is the key's address; the body is covered by a Content-Digest.
+When the signing agent runs, it signs the registration and requests.
+
+Registering again updates the name.
+
+Examples:
+  machpay agent register --name research-bot
+  machpay agent register --wallet trading --json`,
+	Args: cobra.NoArgs,
+	RunE: runAgentRegister,
+}
+
+func init() {
+	agentRegisterCmd.Flags().StringVar(&agentRegisterName, "name", "", "Name vendors and the console show (default: the registered name)")
+	agentRegisterCmd.Flags().StringVar(&agentRegisterWallet, "wallet", "", "Registered wallet whose key to register (default: active wallet)")
+	agentRegisterCmd.Flags().StringVar(&agentRegisterKeypair, "keypair", "", "Keypair file whose key to register")
+	agentRegisterCmd.Flags().BoolVar(&agentRegisterJSON, "json", false, "Output as JSON")
+
+	agentCmd.AddCommand(agentRegisterCmd)
+}
+
+func runAgentRegister(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+	if cfg.Auth.AccessToken == "" {
+		return console.ErrUnauthorized
+	}
+	if cfg.Auth.UserID == "" {
+		return fmt.Errorf("the account's user ID is unknown: run 'machpay login' again")
+	}
+
+	s, err := loadSigner(agentRegisterWallet, agentRegisterKeypair)
+	if err != nil {
+		return err
+	}
+	defer closeSigner(s)
+	key := wallet.SignerAddress(s)
+	name := agentRegisterName
+	if name == "" && cfg.Agent.PublicKey == key {
+		name = cfg.Agent.Name
+	}
+
+	signedAt := time.Now().UTC().Truncate(time.Second)
+	signature, err := identitySign(s, "agent registration")(console.RegistrationMessage(cfg.Auth.UserID, key, name, signedAt))
+	if err != nil {
+		return fmt.Errorf("sign the registration: %w", err)
+	}
+	reg := console.AgentRegistration{
+		UserID:    cfg.Auth.UserID,
+		PublicKey: key,
+		Name:      name,
+		SignedAt:  signedAt,
+		Signature: wallet.Base58Encode(signature),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), console.DefaultTimeout)
+	defer cancel()
+	agent, err := newConsoleClient().RegisterAgent(ctx, reg)
+	if err != nil {
+		return err
+	}
+
+	cfg.Agent.ID = agent.ID
+	cfg.Agent.Name = agent.Name
+	cfg.Agent.PublicKey = agent.PublicKey
+	if err := config.Save(); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+
+	if agentRegisterJSON {
+		return printJSON(agent)
+	}
+	fmt.Println()
+	tui.PrintSuccess("Agent registered")
+	tui.PrintKeyValue("ID", agent.ID)
+	if agent.Name != "" {
+		tui.PrintKeyValue("Name", agent.Name)
+	}
+	tui.PrintKeyValue("Key", agent.PublicKey)
+	fmt.Println()
+	if !cfg.Agent.SignRequests {
+		fmt.Println(tui.Muted("  Sign requests with this key: machpay curl --sign-requests ..."))
+		fmt.Println(tui.Muted("  or set agent.sign_requests: true in " + config.GetPath()))
+		fmt.Println()
+	}
+	return nil
+}
+
+// requestSigner returns the signer for --sign-requests, or nil when
+// neither the flag nor agent.sign_requests asks for signing. The key
+// is the paying wallet's, held by the signing agent when it runs.
+func requestSigner(enabled bool, walletName, keypairPath string) (*httpsig.Signer, error) {
+	if !enabled && !config.Get().Agent.SignRequests {
+		return nil, nil
+	}
+	s, err := loadSigner(walletName, keypairPath)
+	if err != nil {
+		return nil, fmt.Errorf("sign requests: %w", err)
+	}
+	return httpsig.NewSigner(wallet.SignerAddress(s), identitySign(s, "agent identity: HTTP request signature")), nil
+}
+
+// identitySign returns s.Sign for identity signatures. The signing
+// agent is shown what as the description and named in its errors,
+// since it holds the key and there is no file to fall back to.
+func identitySign(s wallet.Signer, what string) func(message []byte) ([]byte, error) {
+	agent, ok := s.(*signer.Client)
+	if !ok {
+		return s.Sign
+	}
+	return func(message []byte) ([]byte, error) {
+		sig, err := agent.SignWithDescription(message, what)
+		if err != nil {
+			return nil, fmt.Errorf("signing agent: %w", err)
+		}
+		return sig, nil
+	}
+}
+
+// closeSigner closes the connection of an agent signer
+func closeSigner(s wallet.Signer) {
+	if agent, ok := s.(*signer.Client); ok {
+		agent.Close()
+	}
+}
+
+// signedClient returns c (nil: a default client) sending requests
+// signed by s; with no signer it returns c unchanged
+func signedClient(c *http.Client, s *httpsig.Signer) *http.Client {
+	if s == nil {
+		return c
+	}
+	signed := &http.Client{}
+	if c != nil {
+		*signed = *c
+	}
+	signed.Transport = &httpsig.Transport{Signer: s, Base: signed.Transport}
+	return signed
+}
+
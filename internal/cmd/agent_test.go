@@ -0,0 +1,279 @@
+// ============================================================
+// Agent Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/console"
+	"github.com/machpay-xyz/machpay-cli/internal/payclient/sessiontest"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/signer"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/httpsig"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+func TestAgentProxy_PaysAndRecords(t *testing.T) {
+	setupCurl(t)
+	vendor, payTo, paid := newPaidAPI(t, "2500")
+
+	agentProxyKeypair, agentProxyMaxPrice, agentProxyUpstream = curlKeypair, "0.01", vendor.URL
+	t.Cleanup(func() { agentProxyKeypair, agentProxyMaxPrice, agentProxyUpstream = "", "", "" })
+
+	proxy, err := newAgentProxy()
+	if err != nil {
+		t.Fatalf("newAgentProxy: %v", err)
+	}
+	proxy.OnPayment = nil
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "paid content" || *paid != 1 {
+		t.Errorf("response = %d %q, paid %d times", resp.StatusCode, body, *paid)
+	}
+
+	// The spending policy still applies behind the proxy
+	if err := (&policy.Policy{MaxPerDay: "0.004"}).Save(config.GetPolicyPath()); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Get(server.URL + "/quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPaymentRequired || *paid != 1 {
+		t.Errorf("over the daily limit: status %d, paid %d times", resp.StatusCode, *paid)
+	}
+
+	entries, _ := policy.NewLedger(config.GetLedgerPath()).Since(time.Now().Add(-time.Minute))
+	if len(entries) != 1 || entries[0].Vendor != payTo {
+		t.Errorf("ledger = %+v, want the one payment", entries)
+	}
+}
+
+func TestAgentProxy_Sessions(t *testing.T) {
+	setupCurl(t)
+	vendor := sessiontest.NewVendor(x402.NetworkSolanaDevnet, config.GetUSDCMint(), 2500)
+	api := httptest.NewServer(vendor)
+	defer api.Close()
+
+	agentProxyKeypair, agentProxyMaxPrice, agentProxyUpstream, agentProxyDeposit = curlKeypair, "0.01", api.URL, "0.01"
+	t.Cleanup(func() { agentProxyKeypair, agentProxyMaxPrice, agentProxyUpstream, agentProxyDeposit = "", "", "", "" })
+
+	proxy, err := newAgentProxy()
+	if err != nil {
+		t.Fatalf("newAgentProxy: %v", err)
+	}
+	proxy.OnPayment = nil
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(server.URL + "/quote")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "paid content" {
+			t.Fatalf("response = %d %q", resp.StatusCode, body)
+		}
+	}
+
+	// One deposit paid for all three, and is the only ledger entry
+	entries, _ := policy.NewLedger(config.GetLedgerPath()).Since(time.Now().Add(-time.Minute))
+	if vendor.Settled() != 1 || len(entries) != 1 || entries[0].Amount != 10000 {
+		t.Errorf("settled %d payments, ledger %+v", vendor.Settled(), entries)
+	}
+
+	var out bytes.Buffer
+	closeSessions(&out, proxy.Client.Sessions)
+	if vendor.Refunded("sess_1") != 2500 || !strings.Contains(out.String(), "0.0025 USDC reclaimed") {
+		t.Errorf("refunded %d: %s", vendor.Refunded("sess_1"), out.String())
+	}
+}
+
+func TestNewAgentProxy_Flags(t *testing.T) {
+	t.Cleanup(func() { agentProxyMaxPrice, agentProxyUpstream, agentProxyDeposit = "", "", "" })
+
+	for _, tc := range []struct{ maxPrice, upstream, deposit string }{
+		{"0", "", ""},
+		{"abc", "", ""},
+		{"0.01", "ftp://example.com", ""},
+		{"0.01", "example.com", ""},
+		{"0.01", "", "lots"},
+		{"0.01", "", "0.005"},
+	} {
+		agentProxyMaxPrice, agentProxyUpstream, agentProxyDeposit = tc.maxPrice, tc.upstream, tc.deposit
+		if _, err := newAgentProxy(); err == nil {
+			t.Errorf("newAgentProxy(%q, %q, %q) succeeded", tc.maxPrice, tc.upstream, tc.deposit)
+		}
+	}
+}
+
+func TestCheckProxyListen(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:8403", "localhost:8403", "[::1]:8403"} {
+		if err := checkProxyListen(addr, false); err != nil {
+			t.Errorf("checkProxyListen(%s) = %v", addr, err)
+		}
+	}
+	for _, addr := range []string{"0.0.0.0:8403", ":8403", "192.168.1.5:8403"} {
+		if err := checkProxyListen(addr, false); err == nil {
+			t.Errorf("checkProxyListen(%s) allowed a remote address", addr)
+		}
+		if err := checkProxyListen(addr, true); err != nil {
+			t.Errorf("checkProxyListen(%s, allowRemote) = %v", addr, err)
+		}
+	}
+	if err := checkProxyListen("8403", false); err == nil {
+		t.Error("accepted an address without a port")
+	}
+}
+
+func TestAgentRegister(t *testing.T) {
+	setupMarketplace(t)
+	kp, _ := wallet.Generate()
+	keypairPath := filepath.Join(t.TempDir(), "agent.json")
+	kp.SaveToFile(keypairPath)
+	agentRegisterKeypair, agentRegisterName, agentRegisterJSON = keypairPath, "research-bot", true
+	t.Cleanup(func() { agentRegisterKeypair, agentRegisterName, agentRegisterJSON = "", "", false })
+
+	out, err := captureStdout(t, func() error { return runAgentRegister(agentRegisterCmd, nil) })
+	var agent console.Agent
+	if err != nil || json.Unmarshal([]byte(out), &agent) != nil || agent.ID != "agt_1" || agent.PublicKey != kp.PublicKeyBase58() {
+		t.Fatalf("agent register = %q, %v", out, err)
+	}
+	if got := config.Get().Agent; got.ID != "agt_1" || got.Name != "research-bot" || got.PublicKey != kp.PublicKeyBase58() {
+		t.Errorf("config agent = %+v", got)
+	}
+
+	// Registering again keeps the name
+	agentRegisterName = ""
+	captureStdout(t, func() error { return runAgentRegister(agentRegisterCmd, nil) })
+	if config.Get().Agent.Name != "research-bot" {
+		t.Errorf("name after re-registering = %q", config.Get().Agent.Name)
+	}
+
+	// The signature names the account, so its ID must be known
+	config.Get().Auth.UserID = ""
+	if err := runAgentRegister(agentRegisterCmd, nil); err == nil || !strings.Contains(err.Error(), "machpay login") {
+		t.Errorf("agent register without a user ID = %v, want a hint to log in again", err)
+	}
+
+	config.Get().Auth.AccessToken = ""
+	if err := runAgentRegister(agentRegisterCmd, nil); !errors.Is(err, console.ErrUnauthorized) {
+		t.Errorf("agent register logged out = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestAgentRegister_SigningAgent(t *testing.T) {
+	setupMarketplace(t)
+	t.Setenv("MACHPAY_SIGNER_SOCK", filepath.Join(t.TempDir(), "s.sock"))
+	kp, _ := wallet.Generate()
+	info, err := wallet.NewRegistry(config.GetWalletsDir()).Add("default", kp, wallet.SourceGenerated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	activateWallet(info)
+	agent, _ := signer.NewAgent(kp, signer.Options{Policy: signer.PolicyAuto})
+	l, err := signer.Listen(config.GetSignerSocket())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go agent.Serve(context.Background(), l)
+	t.Cleanup(agent.Stop)
+
+	// With the agent running the keypair file is not needed
+	os.Remove(info.Path)
+	agentRegisterJSON = true
+	t.Cleanup(func() { agentRegisterJSON = false })
+	out, err := captureStdout(t, func() error { return runAgentRegister(agentRegisterCmd, nil) })
+	if err != nil || !strings.Contains(out, kp.PublicKeyBase58()) {
+		t.Fatalf("agent register with the signing agent = %q, %v", out, err)
+	}
+
+	s, err := requestSigner(true, "", "")
+	if err != nil {
+		t.Fatalf("requestSigner: %v", err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/quote", nil)
+	if err := s.SignRequest(r); err != nil {
+		t.Fatalf("SignRequest: %v", err)
+	}
+	if id, err := httpsig.Verify(r); err != nil || id.KeyID != kp.PublicKeyBase58() {
+		t.Errorf("Verify = %+v, %v", id, err)
+	}
+
+	// A locked agent fails the signature rather than the file being read
+	agent.Lock()
+	r = httptest.NewRequest(http.MethodGet, "http://api.example.com/quote", nil)
+	if err := s.SignRequest(r); err == nil || !strings.Contains(err.Error(), "signing agent") {
+		t.Errorf("SignRequest with a locked agent = %v", err)
+	}
+}
+
+func TestCurl_SignRequests(t *testing.T) {
+	setupCurl(t)
+	vendor, _, paid := newPaidAPI(t, "2500")
+	target, _ := url.Parse(vendor.URL)
+	var signers []*httpsig.Identity
+	server := httptest.NewServer((&httpsig.Verifier{Required: true}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := httpsig.FromContext(r.Context())
+		signers = append(signers, id)
+		httputil.NewSingleHostReverseProxy(target).ServeHTTP(w, r)
+	})))
+	defer server.Close()
+	t.Cleanup(func() { curlSign = false; config.Get().Agent.SignRequests = false })
+	kp, _ := wallet.LoadFromFile(curlKeypair)
+
+	curlMaxPrice, curlSign = "0.01", true
+	if err := runCurl(curlCmd, []string{server.URL + "/quote?city=Lisbon"}); err != nil {
+		t.Fatalf("signed curl: %v", err)
+	}
+	if len(signers) != 2 || *paid != 1 {
+		t.Fatalf("%d signed requests, %d paid", len(signers), *paid)
+	}
+	for _, id := range signers {
+		if id.KeyID != kp.PublicKeyBase58() {
+			t.Errorf("signed by %s, want the wallet key", id.KeyID)
+		}
+	}
+	if covered := strings.Join(signers[1].Covered, " "); !strings.Contains(covered, "x-payment") {
+		t.Errorf("paid retry covers %s, want the payment", covered)
+	}
+
+	// Unsigned requests are refused; the config turns signing on
+	curlSign = false
+	runCurl(curlCmd, []string{server.URL + "/quote"})
+	if len(signers) != 2 {
+		t.Errorf("unsigned request reached the vendor")
+	}
+	config.Get().Agent.SignRequests = true
+	if err := runCurl(curlCmd, []string{server.URL + "/quote"}); err != nil || len(signers) != 4 {
+		t.Errorf("agent.sign_requests: %d signed requests, %v", len(signers), err)
+	}
+}
+
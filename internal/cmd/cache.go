@@ -0,0 +1,196 @@
+// ============================================================
+// Cache Command - Reuse paid responses instead of paying again
+// ============================================================
+//
+// Usage:
+//   machpay cache stats [--json]
+//   machpay cache clear
+//
+// machpay curl, agent proxy and mcp serve keep paid GET responses in
+// ~/.machpay/cache when run with --cache or with cache.enabled set
+// in the config, and answer identical requests from it for as long
+// as the vendor allows. Sizes and lifetimes come from the config:
+//
+//   cache:
+//     enabled: true
+//     max_size_mb: 100
+//     ttl: 10m
+//
+// ============================================================
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+)
+
+var cacheStatsJSON bool
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of paid API responses",
+	Long: `Manage the cache of paid API responses.
+
+With --cache (on machpay curl, agent proxy and mcp serve) or
+cache.enabled in the config, the response to a paid GET is kept on
+disk and a later identical request is answered from it instead of
+paying again. A request is identical when the method, URL, the
+headers the vendor varies on and the payment requirements match, so
+a new price is paid for.
+
+Responses are kept as long as the vendor's Cache-Control or Expires
+allows, at most cache.ttl (default 10m; also the lifetime when the
+vendor says nothing), and never when it sends no-store or no-cache.
+The least recently used responses are dropped beyond
+cache.max_size_mb (default 100).
+
+Examples:
+  machpay curl --cache --max-price 0.01 https://api.example.com/v1/quote
+  machpay cache stats
+  machpay cache clear`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show what is cached and the money saved",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheStats,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached response",
+	Long: `Remove every cached response. The record of money saved is
+kept.`,
+	Args: cobra.NoArgs,
+	RunE: runCacheClear,
+}
+
+func init() {
+	cacheStatsCmd.Flags().BoolVar(&cacheStatsJSON, "json", false, "Output as JSON")
+
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// payCache returns the paid-response cache from the config, or nil
+// when it is off and not enabled by flag
+func payCache(enabled bool) (*payclient.Cache, error) {
+	cfg := config.Get().Cache
+	if !enabled && !cfg.Enabled {
+		return nil, nil
+	}
+	var ttl time.Duration
+	if cfg.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(cfg.TTL); err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("config cache.ttl: %q is not a duration like 10m", cfg.TTL)
+		}
+	}
+	if cfg.MaxSizeMB < 0 {
+		return nil, fmt.Errorf("config cache.max_size_mb: %d is negative", cfg.MaxSizeMB)
+	}
+	return payclient.NewCache(config.GetCacheDir(), cfg.MaxSizeMB<<20, ttl), nil
+}
+
+// ============================================================
+// cache stats
+// ============================================================
+
+type cacheStatsOut struct {
+	Enabled  bool   `json:"enabled"`
+	Dir      string `json:"dir"`
+	Entries  int    `json:"entries"`
+	Bytes    int64  `json:"bytes"`
+	MaxBytes int64  `json:"max_bytes"`
+	TTL      string `json:"ttl"`
+	Stores   int    `json:"stores"`
+	Hits     int    `json:"hits"`
+	Saved    string `json:"saved_usdc"`
+	Since    string `json:"since,omitempty"`
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	cache, err := payCache(true)
+	if err != nil {
+		return err
+	}
+	stats, err := cache.Stats()
+	if err != nil {
+		return err
+	}
+
+	out := cacheStatsOut{
+		Enabled:  config.Get().Cache.Enabled,
+		Dir:      cache.Dir,
+		Entries:  stats.Entries,
+		Bytes:    stats.Bytes,
+		MaxBytes: cache.MaxBytes,
+		TTL:      cache.TTL.String(),
+		Stores:   stats.Stores,
+		Hits:     stats.Hits,
+		Saved:    solana.FormatTokenAmount(stats.Saved, policy.USDCDecimals),
+	}
+	if !stats.Since.IsZero() {
+		out.Since = stats.Since.UTC().Format(time.RFC3339)
+	}
+	if cacheStatsJSON {
+		return printJSON(out)
+	}
+
+	enabled := "only with --cache (set cache.enabled in the config to always cache)"
+	if out.Enabled {
+		enabled = "yes"
+	}
+	fmt.Println()
+	tui.PrintKeyValue("Enabled", enabled)
+	tui.PrintKeyValue("Directory", cache.Dir)
+	tui.PrintKeyValue("Stored", fmt.Sprintf("%d responses, %s of %s", stats.Entries, formatBytes(stats.Bytes), formatBytes(cache.MaxBytes)))
+	tui.PrintKeyValue("TTL", cache.TTL.String())
+	tui.PrintKeyValue("Hits", fmt.Sprintf("%d requests answered without paying", stats.Hits))
+	tui.PrintKeyValue("Saved", formatUSDC(stats.Saved))
+	if out.Since != "" {
+		tui.PrintKeyValue("Since", stats.Since.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Println()
+	return nil
+}
+
+// ============================================================
+// cache clear
+// ============================================================
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	cache, err := payCache(true)
+	if err != nil {
+		return err
+	}
+	n, err := cache.Clear()
+	if err != nil {
+		return err
+	}
+	tui.PrintSuccess(fmt.Sprintf("Removed %d cached responses", n))
+	return nil
+}
+
+// formatBytes formats a size in B, KB or MB
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
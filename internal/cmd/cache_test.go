@@ -0,0 +1,82 @@
+// ============================================================
+// Cache Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+)
+
+func TestCurl_Cache(t *testing.T) {
+	setupCurl(t)
+	server, _, paid := newPaidAPI(t, "2500")
+	t.Cleanup(func() { curlCache, cacheStatsJSON = false, false })
+
+	curlMaxPrice, curlCache = "0.01", true
+	for i := 0; i < 3; i++ {
+		if err := runCurl(curlCmd, []string{server.URL + "/quote"}); err != nil {
+			t.Fatalf("runCurl: %v", err)
+		}
+		if body, _ := os.ReadFile(curlOutput); string(body) != "paid content" {
+			t.Fatalf("body %q", body)
+		}
+	}
+	if *paid != 1 {
+		t.Errorf("paid %d times, want once", *paid)
+	}
+	if entries, _ := policy.NewLedger(config.GetLedgerPath()).Since(time.Now().Add(-time.Minute)); len(entries) != 1 {
+		t.Errorf("ledger = %+v, want one payment", entries)
+	}
+
+	cacheStatsJSON = true
+	out, err := captureStdout(t, func() error { return runCacheStats(cacheStatsCmd, nil) })
+	var stats cacheStatsOut
+	if err != nil || json.Unmarshal([]byte(out), &stats) != nil {
+		t.Fatalf("cache stats --json = %q, %v", out, err)
+	}
+	if stats.Entries != 1 || stats.Stores != 1 || stats.Hits != 2 || stats.Saved != "0.005" || stats.Enabled {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	if err := runCacheClear(cacheClearCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCurl(curlCmd, []string{server.URL + "/quote"}); err != nil || *paid != 2 {
+		t.Errorf("after clear: paid %d times, %v", *paid, err)
+	}
+
+	// Off without --cache or the config
+	curlCache = false
+	runCurl(curlCmd, []string{server.URL + "/quote"})
+	if *paid != 3 {
+		t.Errorf("cached without --cache: paid %d times", *paid)
+	}
+}
+
+func TestPayCache_Config(t *testing.T) {
+	useTempConfig(t)
+	cfg := config.Get()
+
+	if cache, err := payCache(false); cache != nil || err != nil {
+		t.Errorf("payCache(false) = %v, %v; want off", cache, err)
+	}
+
+	cfg.Cache = config.CacheConfig{Enabled: true, MaxSizeMB: 5, TTL: "1h"}
+	cache, err := payCache(false)
+	if err != nil || cache == nil || cache.MaxBytes != 5<<20 || cache.TTL != time.Hour || cache.Dir != config.GetCacheDir() {
+		t.Errorf("payCache = %+v, %v", cache, err)
+	}
+
+	cfg.Cache.TTL = "soon"
+	if _, err := payCache(false); err == nil {
+		t.Error("payCache accepted a bad ttl")
+	}
+}
+
@@ -56,6 +56,18 @@ func TestSubcommands(t *testing.T) {
 		"restart",
 		"logs",
 		"update",
+		"wallet",
+		"tx",
+		"signer",
+		"addressbook",
+		"policy",
+		"curl",
+		"agent",
+		"x402",
+		"facilitator",
+		"mcp",
+		"marketplace",
+		"cache",
 	}
 
 	commands := rootCmd.Commands()
@@ -131,6 +143,50 @@ func TestStatusCommandFlags(t *testing.T) {
 	}
 }
 
+func TestWalletSubcommands(t *testing.T) {
+	expected := []string{"ata", "airdrop", "list", "add", "use", "rename", "remove", "backup", "restore", "vanity", "history", "fund"}
+
+	commandMap := make(map[string]bool)
+	for _, cmd := range walletCmd.Commands() {
+		commandMap[cmd.Name()] = true
+	}
+
+	for _, name := range expected {
+		if !commandMap[name] {
+			t.Errorf("expected wallet subcommand %q not found", name)
+		}
+	}
+}
+
+func TestWalletATACommandFlags(t *testing.T) {
+	flags := []string{
+		"owner",
+		"mint",
+		"token-2022",
+	}
+
+	for _, flag := range flags {
+		f := walletATACmd.Flags().Lookup(flag)
+		if f == nil {
+			t.Errorf("wallet ata command should have --%s flag", flag)
+		}
+	}
+}
+
+func TestWalletAirdropCommandFlags(t *testing.T) {
+	flags := []string{
+		"sol",
+		"usdc",
+	}
+
+	for _, flag := range flags {
+		f := walletAirdropCmd.Flags().Lookup(flag)
+		if f == nil {
+			t.Errorf("wallet airdrop command should have --%s flag", flag)
+		}
+	}
+}
+
 func TestSetVersionInfo(t *testing.T) {
 	SetVersionInfo("2.0.0", "def456", "2024-12-31")
 
@@ -153,3 +209,80 @@ func TestGetConfigDir(t *testing.T) {
 	}
 }
 
+func TestTxCommandFlags(t *testing.T) {
+	flags := []string{
+		"to",
+		"amount",
+		"token",
+		"from",
+		"fee-payer",
+		"nonce-account",
+		"nonce-authority",
+		"output",
+	}
+
+	for _, flag := range flags {
+		f := txBuildCmd.Flags().Lookup(flag)
+		if f == nil {
+			t.Errorf("tx build command should have --%s flag", flag)
+		}
+	}
+
+	for _, flag := range []string{"wallet", "keypair", "output", "yes"} {
+		if txSignCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("tx sign command should have --%s flag", flag)
+		}
+	}
+}
+
+func TestSignerSubcommands(t *testing.T) {
+	expected := []string{"start", "status", "lock", "stop"}
+
+	commandMap := make(map[string]bool)
+	for _, cmd := range signerCmd.Commands() {
+		commandMap[cmd.Name()] = true
+	}
+
+	for _, name := range expected {
+		if !commandMap[name] {
+			t.Errorf("expected signer subcommand %q not found", name)
+		}
+	}
+
+	for _, flag := range []string{"wallet", "keypair", "policy", "idle-timeout"} {
+		if signerStartCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("signer start command should have --%s flag", flag)
+		}
+	}
+}
+
+func TestAddressbookSubcommands(t *testing.T) {
+	expected := []string{"add", "list", "remove", "verify"}
+
+	commandMap := make(map[string]bool)
+	for _, cmd := range addressbookCmd.Commands() {
+		commandMap[cmd.Name()] = true
+	}
+
+	for _, name := range expected {
+		if !commandMap[name] {
+			t.Errorf("expected addressbook subcommand %q not found", name)
+		}
+	}
+}
+
+func TestPolicySubcommands(t *testing.T) {
+	expected := []string{"show", "set", "test"}
+
+	commandMap := make(map[string]bool)
+	for _, cmd := range policyCmd.Commands() {
+		commandMap[cmd.Name()] = true
+	}
+
+	for _, name := range expected {
+		if !commandMap[name] {
+			t.Errorf("expected policy subcommand %q not found", name)
+		}
+	}
+}
+
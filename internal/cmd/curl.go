@@ -0,0 +1,313 @@
+// ============================================================
+// Curl Command - Call paid APIs from the terminal
+// ============================================================
+//
+// Usage:
+//   machpay curl <url> [-X METHOD] [-H 'Name: value']... [-d DATA]
+//                [-o FILE] [-i] [-s] [--max-price USDC] [--cache]
+//                [--sign-requests]
+//
+// Sends the request like curl. On 402 Payment Required the x402
+// payment requirements are checked against --max-price, paid from
+// the configured wallet (subject to the spending policy) and the
+// request is retried with the payment. The body goes to stdout or
+// -o; the payment receipt goes to stderr.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+var (
+	curlMethod   string
+	curlHeaders  []string
+	curlData     []string
+	curlOutput   string
+	curlInclude  bool
+	curlSilent   bool
+	curlMaxPrice string
+	curlMaxTime  time.Duration
+	curlWallet   string
+	curlKeypair  string
+	curlCache    bool
+	curlSign     bool
+)
+
+var curlCmd = &cobra.Command{
+	Use:   "curl <url>",
+	Short: "Make an HTTP request, paying x402 invoices",
+	Long: `Make an HTTP request like curl, paying for it if the server asks.
+
+When the server answers 402 Payment Required with x402 payment
+requirements, machpay pays the cheapest USDC offer on the active
+network if it costs no more than --max-price, then retries the
+request with the payment attached. Without --max-price nothing is
+paid and the price is shown instead.
+
+Payments are signed by the signing agent when it runs, otherwise by
+the active wallet, and are always held to the spending policy
+('machpay policy').
+
+The response body is written to stdout (or -o); payment details go
+to stderr.
+
+With --cache a paid GET response is kept and an identical request is
+answered from it instead of paying again ('machpay cache').
+
+With --sign-requests the request is signed with the wallet key
+registered by 'machpay agent register', identifying the agent to the
+server.
+
+Examples:
+  machpay curl https://api.example.com/v1/quote
+  machpay curl --max-price 0.01 https://api.example.com/v1/quote
+  machpay curl --max-price 0.05 -X POST -H 'Content-Type: application/json' \
+    -d '{"prompt":"hello"}' https://api.example.com/v1/complete
+  machpay curl --max-price 0.01 -d @query.json -o result.json https://api.example.com/v1/search`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCurl,
+}
+
+func init() {
+	curlCmd.Flags().StringVarP(&curlMethod, "request", "X", "", "HTTP method (default GET, or POST with -d)")
+	curlCmd.Flags().StringArrayVarP(&curlHeaders, "header", "H", nil, "Header 'Name: value' (repeatable)")
+	curlCmd.Flags().StringArrayVarP(&curlData, "data", "d", nil, "Request body; @file reads a file, @- stdin (repeatable, joined with &)")
+	curlCmd.Flags().StringVarP(&curlOutput, "output", "o", "", "Write the body to a file instead of stdout")
+	curlCmd.Flags().BoolVarP(&curlInclude, "include", "i", false, "Include the response status and headers in the output")
+	curlCmd.Flags().BoolVarP(&curlSilent, "silent", "s", false, "Do not print payment details")
+	curlCmd.Flags().StringVar(&curlMaxPrice, "max-price", "", "Most USDC to pay for this request")
+	curlCmd.Flags().DurationVarP(&curlMaxTime, "max-time", "m", 2*time.Minute, "Give up after this long")
+	curlCmd.Flags().StringVar(&curlWallet, "wallet", "", "Registered wallet to pay with (default: signing agent or active wallet)")
+	curlCmd.Flags().StringVar(&curlKeypair, "keypair", "", "Keypair file to pay with")
+	curlCmd.Flags().BoolVar(&curlCache, "cache", false, "Reuse paid GET responses ('machpay cache')")
+	curlCmd.Flags().BoolVar(&curlSign, "sign-requests", false, "Sign the request with the agent key ('machpay agent register')")
+	rootCmd.AddCommand(curlCmd)
+}
+
+func runCurl(cmd *cobra.Command, args []string) error {
+	var maxPrice uint64
+	if curlMaxPrice != "" {
+		var err error
+		if maxPrice, err = solana.ParseTokenAmount(curlMaxPrice, policy.USDCDecimals); err != nil {
+			return fmt.Errorf("--max-price: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), curlMaxTime)
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	req, err := newCurlRequest(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	cache, err := payCache(curlCache)
+	if err != nil {
+		return err
+	}
+	identity, err := requestSigner(curlSign, curlWallet, curlKeypair)
+	if err != nil {
+		return err
+	}
+
+	// A wallet is only needed if the server asks for payment
+	s, signerErr := loadSigner(curlWallet, curlKeypair)
+	client := &payclient.Client{
+		HTTP:     signedClient(nil, identity),
+		Signer:   s,
+		RPC:      solana.NewClient(config.GetRPCURL()),
+		Network:  x402.NetworkForCluster(config.GetCluster()),
+		USDCMint: config.GetUSDCMint(),
+		MaxPrice: maxPrice,
+		Cache:    cache,
+	}
+
+	resp, payment, err := client.Do(req)
+	if err != nil {
+		return curlPaymentError(err, signerErr)
+	}
+	defer resp.Body.Close()
+
+	if payment != nil && !curlSilent {
+		printPaymentReceipt(os.Stderr, payment)
+	}
+	if saved, ok := payclient.CacheHit(resp); ok && !curlSilent {
+		fmt.Fprintf(os.Stderr, "%s Served from cache, saved %s\n", tui.SuccessIcon(), formatUSDC(saved))
+	}
+	return writeCurlResponse(resp)
+}
+
+// newCurlRequest builds the request from the curl flags
+func newCurlRequest(ctx context.Context, url string) (*http.Request, error) {
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+
+	var body []byte
+	if len(curlData) > 0 {
+		parts := make([][]byte, 0, len(curlData))
+		for _, d := range curlData {
+			part, err := readCurlData(d)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		}
+		body = bytes.Join(parts, []byte("&"))
+	}
+
+	method := strings.ToUpper(curlMethod)
+	if method == "" {
+		method = http.MethodGet
+		if body != nil {
+			method = http.MethodPost
+		}
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "machpay-cli/"+versionInfo.Version)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for _, h := range curlHeaders {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q (use 'Name: value')", h)
+		}
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return req, nil
+}
+
+// readCurlData resolves a -d value: @file, @- for stdin, or literal
+func readCurlData(d string) ([]byte, error) {
+	switch {
+	case d == "@-":
+		return io.ReadAll(os.Stdin)
+	case strings.HasPrefix(d, "@"):
+		return os.ReadFile(d[1:])
+	}
+	return []byte(d), nil
+}
+
+// curlPaymentError explains why a request could not be paid for
+func curlPaymentError(err, signerErr error) error {
+	var priceErr *payclient.PriceError
+	switch {
+	case errors.As(err, &priceErr):
+		r := priceErr.Requirements
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "%s Payment required: %s USDC to %s\n", tui.WarningIcon(),
+			solana.FormatTokenAmount(priceErr.Amount, policy.USDCDecimals), vendorNamer()(r.PayTo))
+		if r.Description != "" {
+			fmt.Fprintln(os.Stderr, tui.Muted("  "+r.Description))
+		}
+		if priceErr.Max == 0 {
+			fmt.Fprintln(os.Stderr, tui.Muted("  Pass --max-price "+solana.FormatTokenAmount(priceErr.Amount, policy.USDCDecimals)+" to pay it"))
+		}
+		fmt.Fprintln(os.Stderr)
+	case errors.Is(err, payclient.ErrNoSigner) && signerErr != nil:
+		return fmt.Errorf("%w: %v", payclient.ErrNoSigner, signerErr)
+	}
+	return err
+}
+
+// printPaymentReceipt shows what was paid and the settlement result
+func printPaymentReceipt(w io.Writer, p *payclient.Payment) {
+	amount := solana.FormatTokenAmount(p.Amount, policy.USDCDecimals)
+	fmt.Fprintf(w, "%s Paid %s USDC to %s\n", tui.SuccessIcon(), amount, vendorNamer()(p.Requirements.PayTo))
+	if p.Requirements.Resource != "" {
+		fmt.Fprintf(w, "  %s %s\n", tui.Muted("Resource:   "), p.Requirements.Resource)
+	}
+
+	s := p.Settlement
+	switch {
+	case s == nil:
+		fmt.Fprintf(w, "%s No settlement receipt (%s header) in the response\n", tui.WarningIcon(), x402.HeaderPaymentResponse)
+	case !s.Success:
+		fmt.Fprintf(w, "%s Settlement failed: %s\n", tui.WarningIcon(), s.ErrorReason)
+	default:
+		if s.Transaction != "" {
+			fmt.Fprintf(w, "  %s %s\n", tui.Muted("Transaction:"), s.Transaction)
+			fmt.Fprintf(w, "  %s %s\n", tui.Muted("Explorer:   "), explorerTxURL(s.Transaction, config.Get().Network))
+		}
+		if s.Payer != "" {
+			fmt.Fprintf(w, "  %s %s\n", tui.Muted("Payer:      "), s.Payer)
+		}
+	}
+}
+
+// writeCurlResponse writes the status line and headers (with -i) and
+// the body to stdout or the -o file
+func writeCurlResponse(resp *http.Response) error {
+	out := io.Writer(os.Stdout)
+	if curlOutput != "" {
+		f, err := os.Create(curlOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if curlInclude {
+		fmt.Fprintf(out, "%s %s\r\n", resp.Proto, resp.Status)
+		names := make([]string, 0, len(resp.Header))
+		for name := range resp.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, v := range resp.Header[name] {
+				fmt.Fprintf(out, "%s: %s\r\n", name, v)
+			}
+		}
+		fmt.Fprint(out, "\r\n")
+	}
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	return nil
+}
+
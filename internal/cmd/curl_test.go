@@ -0,0 +1,182 @@
+// ============================================================
+// Curl Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// newPaidAPI serves a vendor charging price USDC atomic units per
+// request, settling payments like a facilitator: the payer's
+// signature must verify and the fee payer co-signs. paid counts
+// settled payments.
+func newPaidAPI(t *testing.T, price string) (server *httptest.Server, payTo string, paid *int) {
+	t.Helper()
+	vendor, _ := wallet.Generate()
+	facilitator, _ := wallet.Generate()
+	paid = new(int)
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		required := x402.PaymentRequired{
+			X402Version: x402.Version,
+			Accepts: []x402.PaymentRequirements{{
+				Scheme:            x402.SchemeExact,
+				Network:           x402.NetworkSolanaDevnet,
+				MaxAmountRequired: price,
+				Resource:          "http://" + r.Host + r.URL.Path,
+				PayTo:             vendor.PublicKeyBase58(),
+				MaxTimeoutSeconds: 60,
+				Asset:             config.GetUSDCMint(),
+				Extra:             map[string]interface{}{"feePayer": facilitator.PublicKeyBase58()},
+			}},
+		}
+
+		var payload x402.PaymentPayload
+		if err := x402.DecodeHeader(r.Header.Get(x402.HeaderPayment), &payload); err != nil {
+			w.WriteHeader(http.StatusPaymentRequired)
+			json.NewEncoder(w).Encode(required)
+			return
+		}
+		tx, err := solana.TransactionFromBase64(payload.Payload.Transaction)
+		if err == nil {
+			err = tx.VerifySignatures()
+		}
+		if err == nil {
+			feePayer := solana.MustPublicKey(facilitator.PublicKeyBase58())
+			err = tx.AddSignature(feePayer, facilitator.Sign(tx.Message.Serialize()))
+		}
+		if err != nil || len(tx.MissingSigners()) > 0 {
+			required.Error = "invalid payment"
+			w.WriteHeader(http.StatusPaymentRequired)
+			json.NewEncoder(w).Encode(required)
+			return
+		}
+
+		*paid++
+		receipt, _ := x402.EncodeHeader(x402.SettlementResponse{Success: true, Transaction: tx.Signature(), Network: payload.Network})
+		w.Header().Set(x402.HeaderPaymentResponse, receipt)
+		w.Write([]byte("paid content"))
+	}))
+	t.Cleanup(server.Close)
+	return server, vendor.PublicKeyBase58(), paid
+}
+
+// setupCurl points the config at a fake RPC, writes a payer keypair
+// and resets the curl flags when the test ends
+func setupCurl(t *testing.T) {
+	t.Helper()
+	useTempConfig(t)
+
+	_, rpc := newFakeRPC(t, map[string]interface{}{
+		"getLatestBlockhash": map[string]interface{}{
+			"value": map[string]interface{}{"blockhash": "4uQeVj5tqViQh7yWWGStvkEG1Zmhx6uasJtWCJziofM", "lastValidBlockHeight": 100},
+		},
+	})
+	cfg := config.Get()
+	cfg.Networks = map[string]config.NetworkConfig{"test": {RPCURL: rpc.URL, Cluster: config.ClusterDevnet}}
+	cfg.Network = "test"
+
+	kp, _ := wallet.Generate()
+	keypairPath := filepath.Join(t.TempDir(), "payer.json")
+	if err := kp.SaveToFile(keypairPath); err != nil {
+		t.Fatal(err)
+	}
+
+	curlKeypair = keypairPath
+	curlOutput = filepath.Join(t.TempDir(), "body")
+	curlSilent = true
+	t.Cleanup(func() {
+		curlKeypair, curlOutput, curlMaxPrice, curlSilent = "", "", "", false
+	})
+}
+
+func TestCurl_PaysAndRecords(t *testing.T) {
+	setupCurl(t)
+	server, payTo, paid := newPaidAPI(t, "2500")
+
+	curlMaxPrice = "0.01"
+	if err := runCurl(curlCmd, []string{server.URL + "/quote"}); err != nil {
+		t.Fatalf("runCurl: %v", err)
+	}
+
+	body, _ := os.ReadFile(curlOutput)
+	if *paid != 1 || string(body) != "paid content" {
+		t.Errorf("paid %d times, body %q", *paid, body)
+	}
+
+	entries, _ := policy.NewLedger(config.GetLedgerPath()).Since(time.Now().Add(-time.Minute))
+	if len(entries) != 1 || entries[0].Vendor != payTo || entries[0].Amount != 2500 {
+		t.Errorf("ledger = %+v, want the payment to the vendor", entries)
+	}
+}
+
+func TestCurl_Refusals(t *testing.T) {
+	setupCurl(t)
+	server, _, paid := newPaidAPI(t, "2500")
+
+	// No --max-price: nothing is paid
+	if err := runCurl(curlCmd, []string{server.URL}); err == nil {
+		t.Error("paid without --max-price")
+	}
+
+	// Over --max-price
+	curlMaxPrice = "0.002"
+	if err := runCurl(curlCmd, []string{server.URL}); err == nil {
+		t.Error("paid over --max-price")
+	}
+
+	// Over the spending policy
+	if err := (&policy.Policy{MaxPerRequest: "0.001"}).Save(config.GetPolicyPath()); err != nil {
+		t.Fatal(err)
+	}
+	curlMaxPrice = "1"
+	if err := runCurl(curlCmd, []string{server.URL}); !errors.Is(err, policy.ErrViolation) {
+		t.Errorf("runCurl = %v, want spending policy violation", err)
+	}
+
+	if *paid != 0 {
+		t.Errorf("paid %d times", *paid)
+	}
+}
+
+func TestNewCurlRequest(t *testing.T) {
+	curlHeaders = []string{"Content-Type: application/json", "X-Trace:  abc "}
+	curlData = []string{`{"a":1}`}
+	t.Cleanup(func() { curlHeaders, curlData = nil, nil })
+
+	req, err := newCurlRequest(context.Background(), "example.com/api")
+	if err != nil {
+		t.Fatalf("newCurlRequest: %v", err)
+	}
+	if req.Method != http.MethodPost || req.URL.String() != "http://example.com/api" {
+		t.Errorf("request = %s %s", req.Method, req.URL)
+	}
+	if req.Header.Get("Content-Type") != "application/json" || req.Header.Get("X-Trace") != "abc" {
+		t.Errorf("headers = %v", req.Header)
+	}
+	if req.GetBody == nil {
+		t.Error("body cannot be replayed after a 402")
+	}
+
+	curlHeaders = []string{"no colon"}
+	if _, err := newCurlRequest(context.Background(), "http://example.com"); err == nil {
+		t.Error("accepted a malformed header")
+	}
+}
+
@@ -0,0 +1,273 @@
+// ============================================================
+// Facilitator Command - Query the x402 facilitator
+// ============================================================
+//
+// Usage:
+//   machpay facilitator verify <payment> --for <url|file> [--json]
+//   machpay facilitator supported [--json]
+//
+// The facilitator is the service that verifies and settles x402
+// payments for vendors. Its URL is taken per network from config
+// (facilitators: {network: url}), MACHPAY_FACILITATOR_URL or --url.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+var facilitatorURL string
+
+var facilitatorCmd = &cobra.Command{
+	Use:   "facilitator",
+	Short: "Verify payments with the x402 facilitator",
+	Long: `Query the x402 facilitator that verifies and settles payments.
+
+The facilitator for each network can be set in ~/.machpay/config.yaml:
+
+  facilitators:
+    devnet: http://localhost:9000
+
+MACHPAY_FACILITATOR_URL or --url override it.
+
+Examples:
+  machpay facilitator supported
+  machpay facilitator verify "X-PAYMENT: eyJ4NDAyVmVyc2lvbiI6MSwi..." --for https://api.example.com/v1/quote`,
+}
+
+// ============================================================
+// facilitator verify
+// ============================================================
+
+var (
+	facilitatorVerifyFor  string
+	facilitatorVerifyJSON bool
+)
+
+var facilitatorVerifyCmd = &cobra.Command{
+	Use:   "verify <payment>",
+	Short: "Ask the facilitator whether a payment is valid",
+	Long: `Ask the facilitator whether an X-PAYMENT value pays for a resource.
+
+The payment is the X-PAYMENT header value, base64 as sent, optionally
+with the "X-PAYMENT:" prefix. --for gives the requirements it must
+meet: the paid URL, fetched once without paying, or a saved 402 body.
+
+The facilitator of the payment's network is asked, so a devnet
+payment is checked by the devnet facilitator whatever the active
+network. A payment that has already settled is reported as invalid
+by most facilitators, since its transaction can only land once.
+
+Exits with an error when the payment is invalid.
+
+Examples:
+  machpay facilitator verify eyJ4NDAyVmVyc2lvbiI6MSwi... --for https://api.example.com/v1/quote
+  machpay facilitator verify "X-PAYMENT: eyJ4NDAy..." --for 402.json --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFacilitatorVerify,
+}
+
+// ============================================================
+// facilitator supported
+// ============================================================
+
+var facilitatorSupportedJSON bool
+
+var facilitatorSupportedCmd = &cobra.Command{
+	Use:   "supported",
+	Short: "List the schemes and networks the facilitator settles",
+	Args:  cobra.NoArgs,
+	RunE:  runFacilitatorSupported,
+}
+
+func init() {
+	facilitatorCmd.PersistentFlags().StringVar(&facilitatorURL, "url", "", "Facilitator URL (default: from config for the network)")
+
+	facilitatorVerifyCmd.Flags().StringVar(&facilitatorVerifyFor, "for", "", "URL (or saved 402 body) whose requirements the payment must meet (required)")
+	facilitatorVerifyCmd.Flags().BoolVar(&facilitatorVerifyJSON, "json", false, "Output as JSON")
+	facilitatorVerifyCmd.MarkFlagRequired("for")
+
+	facilitatorSupportedCmd.Flags().BoolVar(&facilitatorSupportedJSON, "json", false, "Output as JSON")
+
+	facilitatorCmd.AddCommand(facilitatorVerifyCmd)
+	facilitatorCmd.AddCommand(facilitatorSupportedCmd)
+	rootCmd.AddCommand(facilitatorCmd)
+}
+
+// facilitatorVerifyResult is the --json output of facilitator verify
+type facilitatorVerifyResult struct {
+	Facilitator  string                    `json:"facilitator"`
+	Payment      *x402.PaymentPayload      `json:"payment"`
+	Requirements *x402.PaymentRequirements `json:"requirements"`
+	Result       *x402.VerifyResponse      `json:"result"`
+}
+
+func runFacilitatorVerify(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	value := strings.TrimSpace(args[0])
+	if name, rest, ok := strings.Cut(value, ":"); ok && strings.EqualFold(strings.TrimSpace(name), x402.HeaderPayment) {
+		value = strings.TrimSpace(rest)
+	}
+	payload, err := x402.DecodePaymentHeader(value)
+	if err != nil {
+		return fmt.Errorf("payment: %w (run 'machpay x402 inspect' for details)", err)
+	}
+
+	in := newInspector(ctx)
+	item, err := in.loadRequirements(facilitatorVerifyFor)
+	if err != nil {
+		return fmt.Errorf("--for: %w", err)
+	}
+	if item.PaymentRequired == nil {
+		return fmt.Errorf("--for: %s did not return valid x402 payment requirements", facilitatorVerifyFor)
+	}
+	offer, err := selectPaidOffer(payload, item.PaymentRequired)
+	if err != nil {
+		return err
+	}
+
+	f := newFacilitatorClient(payload.Network)
+	if !facilitatorVerifyJSON {
+		fmt.Println(tui.Muted("Asking " + f.URL + " ..."))
+	}
+	resp, err := f.Verify(ctx, payload, offer)
+	if err != nil {
+		return err
+	}
+
+	if facilitatorVerifyJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(facilitatorVerifyResult{Facilitator: f.URL, Payment: payload, Requirements: offer, Result: resp}); err != nil {
+			return err
+		}
+		return resp.Err()
+	}
+
+	fmt.Println()
+	if resp.IsValid {
+		tui.PrintSuccess("Payment is valid")
+	} else {
+		tui.PrintError("Payment is invalid")
+	}
+	tui.PrintKeyValue("Network", payload.Network)
+	if raw, err := offer.Amount(); err == nil {
+		tui.PrintKeyValue("Amount", formatUSDC(raw))
+	}
+	tui.PrintKeyValue("Pay to", vendorNamer()(offer.PayTo))
+	if resp.Payer != "" {
+		tui.PrintKeyValue("Payer", resp.Payer)
+	}
+	if resp.InvalidReason != "" {
+		tui.PrintKeyValue("Reason", resp.InvalidReason)
+	}
+	tui.PrintKeyValue("Resource", offer.Resource)
+	fmt.Println()
+	return resp.Err()
+}
+
+func runFacilitatorSupported(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	network := x402.NetworkForCluster(config.GetCluster())
+	f := newFacilitatorClient(network)
+	resp, err := f.Supported(ctx)
+	if err != nil {
+		return err
+	}
+
+	if facilitatorSupportedJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(tui.Bold("Facilitator " + f.URL))
+	fmt.Println()
+	if len(resp.Kinds) == 0 {
+		fmt.Println(tui.Muted("  Supports no payment kinds"))
+		fmt.Println()
+		return nil
+	}
+	fmt.Printf("  %-8s %-8s %-16s %s\n", "VERSION", "SCHEME", "NETWORK", "FEE PAYER")
+	for _, k := range resp.Kinds {
+		line := fmt.Sprintf("  %-8d %-8s %-16s %s", k.X402Version, k.Scheme, k.Network, k.FeePayer())
+		if k.Network == network {
+			line += tui.Muted("  (active network)")
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+	if resp.Find(x402.SchemeExact, network) == nil {
+		tui.PrintWarning(fmt.Sprintf("%s payments on %s are not supported", x402.SchemeExact, network))
+	}
+	return nil
+}
+
+// selectPaidOffer returns the offer a payment answers: the one with
+// its scheme and network, preferring one the payment meets
+func selectPaidOffer(payload *x402.PaymentPayload, required *x402.PaymentRequired) (*x402.PaymentRequirements, error) {
+	var match *x402.PaymentRequirements
+	for i := range required.Accepts {
+		offer := &required.Accepts[i]
+		if offer.Scheme != payload.Scheme || offer.Network != payload.Network {
+			continue
+		}
+		if _, err := x402.VerifyExact(payload, offer); err == nil {
+			return offer, nil
+		}
+		if match == nil {
+			match = offer
+		}
+	}
+	if match == nil {
+		return nil, errors.New("the requirements have no offer for " + payload.Scheme + " on " + payload.Network)
+	}
+	return match, nil
+}
+
+// newFacilitatorClient returns a client for the facilitator of an x402
+// network: --url, MACHPAY_FACILITATOR_URL for the active network, or
+// the configured facilitator of the matching MachPay network
+func newFacilitatorClient(network string) *x402.Facilitator {
+	url := facilitatorURL
+	if url == "" {
+		url = facilitatorURLForNetwork(network)
+	}
+	f := x402.NewFacilitator(url)
+	f.Header = http.Header{"User-Agent": {"machpay-cli/" + versionInfo.Version}}
+	return f
+}
+
+// facilitatorURLForNetwork maps an x402 network to a MachPay network
+// and returns its facilitator. The active network wins when it runs on
+// the same cluster, so custom networks keep their facilitator.
+func facilitatorURLForNetwork(network string) string {
+	if network == x402.NetworkForCluster(config.GetCluster()) {
+		return config.GetFacilitatorURL()
+	}
+	if network == x402.NetworkSolana {
+		return config.FacilitatorURLFor(config.ClusterMainnet)
+	}
+	return config.FacilitatorURLFor(config.ClusterDevnet)
+}
+
@@ -0,0 +1,123 @@
+// ============================================================
+// Facilitator Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// newFakeFacilitator is a local facilitator that verifies payments
+// with x402.VerifyExact and settles exact payments on solana-devnet
+func newFakeFacilitator(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/supported" {
+			json.NewEncoder(w).Encode(x402.SupportedResponse{Kinds: []x402.SupportedKind{
+				{X402Version: x402.Version, Scheme: x402.SchemeExact, Network: x402.NetworkSolanaDevnet},
+			}})
+			return
+		}
+
+		var req x402.FacilitatorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		payment, err := x402.VerifyExact(&req.PaymentPayload, &req.PaymentRequirements)
+		switch r.URL.Path {
+		case "/verify":
+			if err != nil {
+				json.NewEncoder(w).Encode(x402.VerifyResponse{InvalidReason: err.Error()})
+				return
+			}
+			json.NewEncoder(w).Encode(x402.VerifyResponse{IsValid: true, Payer: payment.Payer})
+		case "/settle":
+			if err != nil {
+				json.NewEncoder(w).Encode(x402.SettlementResponse{ErrorReason: err.Error()})
+				return
+			}
+			json.NewEncoder(w).Encode(x402.SettlementResponse{
+				Success:     true,
+				Transaction: "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW",
+				Network:     req.PaymentRequirements.Network,
+				Payer:       payment.Payer,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// setupFacilitator points the test network at a fake facilitator
+func setupFacilitator(t *testing.T) *httptest.Server {
+	t.Helper()
+	setupInspect(t)
+	facilitator := newFakeFacilitator(t)
+	config.Get().Facilitators = map[string]string{"test": facilitator.URL}
+	t.Cleanup(func() { facilitatorURL, facilitatorVerifyFor, facilitatorVerifyJSON = "", "", false })
+	return facilitator
+}
+
+func TestFacilitatorVerify(t *testing.T) {
+	setupFacilitator(t)
+	server, _, _ := newPaidAPI(t, "2500")
+	other, _, _ := newPaidAPI(t, "2500")
+	header := payHeader(t, server.URL+"/quote")
+
+	facilitatorVerifyFor = server.URL + "/quote"
+	if err := runFacilitatorVerify(facilitatorVerifyCmd, []string{"X-PAYMENT: " + header}); err != nil {
+		t.Errorf("runFacilitatorVerify = %v, want a valid payment", err)
+	}
+
+	facilitatorVerifyFor = other.URL + "/quote"
+	if err := runFacilitatorVerify(facilitatorVerifyCmd, []string{header}); !errors.Is(err, x402.ErrInvalidPayment) {
+		t.Errorf("runFacilitatorVerify(other vendor) = %v, want ErrInvalidPayment", err)
+	}
+
+	if err := runFacilitatorVerify(facilitatorVerifyCmd, []string{"bm90IGEgcGF5bWVudA=="}); err == nil {
+		t.Error("runFacilitatorVerify(garbage) succeeded")
+	}
+}
+
+func TestFacilitatorSupported(t *testing.T) {
+	setupFacilitator(t)
+	if err := runFacilitatorSupported(facilitatorSupportedCmd, nil); err != nil {
+		t.Errorf("runFacilitatorSupported = %v", err)
+	}
+
+	facilitatorURL = "http://127.0.0.1:1"
+	if err := runFacilitatorSupported(facilitatorSupportedCmd, nil); err == nil {
+		t.Error("runFacilitatorSupported(unreachable --url) succeeded")
+	}
+}
+
+func TestFacilitatorURLForNetwork(t *testing.T) {
+	useTempConfig(t)
+	cfg := config.Get()
+	cfg.Networks = map[string]config.NetworkConfig{"private": {RPCURL: "https://rpc.example.com", Cluster: config.ClusterDevnet}}
+	cfg.Network = "private"
+	cfg.Facilitators = map[string]string{"private": "http://private", "mainnet": "http://mainnet"}
+
+	for network, want := range map[string]string{
+		x402.NetworkSolanaDevnet: "http://private", // active network, same cluster
+		x402.NetworkSolana:       "http://mainnet",
+	} {
+		if got := facilitatorURLForNetwork(network); got != want {
+			t.Errorf("facilitatorURLForNetwork(%s) = %s, want %s", network, got, want)
+		}
+	}
+}
+
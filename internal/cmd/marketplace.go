@@ -0,0 +1,403 @@
+// ============================================================
+// Marketplace Command - Find paid APIs from the terminal
+// ============================================================
+//
+// Usage:
+//   machpay marketplace search [query] [--category ai|data|finance|compute|other]
+//                              [--max-price USDC] [--json]
+//   machpay marketplace show <service> [--json]
+//   machpay marketplace quote <service> <path> [-X METHOD] [--json]
+//
+// Services come from the console API, called with the token stored by
+// 'machpay login'. Quotes ask the service itself: the path is
+// requested once and its 402 payment requirements are shown, without
+// paying.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/auth"
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/console"
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// marketplaceTimeout bounds each marketplace command
+const marketplaceTimeout = 30 * time.Second
+
+var marketplaceCmd = &cobra.Command{
+	Use:   "marketplace",
+	Short: "Search the API marketplace",
+	Long: `Find paid APIs on the MachPay marketplace.
+
+Examples:
+  machpay marketplace search weather
+  machpay marketplace search --category ai --max-price 0.01 --json
+  machpay marketplace show weather-api
+  machpay marketplace quote weather-api /v1/forecast`,
+}
+
+// ============================================================
+// marketplace search
+// ============================================================
+
+var (
+	marketplaceSearchCategory string
+	marketplaceSearchMaxPrice string
+	marketplaceSearchJSON     bool
+)
+
+var marketplaceSearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search services by name, description and category",
+	Long: `Search the marketplace. Without a query, every service (in the
+category, under the price) is listed.
+
+Categories are the ones vendors choose from in 'machpay setup':
+` + categoryHelp(),
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMarketplaceSearch,
+}
+
+func init() {
+	marketplaceSearchCmd.Flags().StringVar(&marketplaceSearchCategory, "category", "", "Only services in this category ("+strings.Join(console.CategoryIDs(), ", ")+")")
+	marketplaceSearchCmd.Flags().StringVar(&marketplaceSearchMaxPrice, "max-price", "", "Only services costing at most this much USDC per request")
+	marketplaceSearchCmd.Flags().BoolVar(&marketplaceSearchJSON, "json", false, "Output as JSON")
+
+	marketplaceShowCmd.Flags().BoolVar(&marketplaceShowJSON, "json", false, "Output as JSON")
+
+	marketplaceQuoteCmd.Flags().StringVarP(&marketplaceQuoteMethod, "request", "X", http.MethodGet, "HTTP method to price")
+	marketplaceQuoteCmd.Flags().BoolVar(&marketplaceQuoteJSON, "json", false, "Output as JSON")
+
+	marketplaceCmd.AddCommand(marketplaceSearchCmd, marketplaceShowCmd, marketplaceQuoteCmd)
+	rootCmd.AddCommand(marketplaceCmd)
+}
+
+func runMarketplaceSearch(cmd *cobra.Command, args []string) error {
+	q := console.SearchQuery{Category: marketplaceSearchCategory, MaxPrice: marketplaceSearchMaxPrice}
+	if len(args) > 0 {
+		q.Query = args[0]
+	}
+	if q.Category != "" && !console.ValidCategory(q.Category) {
+		return fmt.Errorf("--category: unknown category %q (use %s)", q.Category, strings.Join(console.CategoryIDs(), ", "))
+	}
+	if q.MaxPrice != "" {
+		if _, err := solana.ParseTokenAmount(q.MaxPrice, policy.USDCDecimals); err != nil {
+			return fmt.Errorf("--max-price: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), marketplaceTimeout)
+	defer cancel()
+	services, err := newConsoleClient().Search(ctx, q)
+	if err != nil {
+		return err
+	}
+	if services == nil {
+		services = []console.Service{}
+	}
+
+	if marketplaceSearchJSON {
+		return printJSON(map[string]interface{}{"services": services})
+	}
+
+	fmt.Println()
+	if len(services) == 0 {
+		fmt.Println(tui.Muted("No services found"))
+		fmt.Println()
+		return nil
+	}
+	fmt.Printf("  %-24s  %-8s  %14s  %s\n", tui.Bold("SERVICE"), tui.Bold("CATEGORY"), tui.Bold("USDC/REQUEST"), tui.Bold("DESCRIPTION"))
+	for _, s := range services {
+		fmt.Printf("  %-24s  %-8s  %14s  %s\n", s.ID, s.Category, s.PricePerRequest, tui.Muted(truncate(s.Description, 50)))
+	}
+	fmt.Println()
+	fmt.Println(tui.Muted("  Details: machpay marketplace show <service>"))
+	fmt.Println()
+	return nil
+}
+
+// ============================================================
+// marketplace show
+// ============================================================
+
+var marketplaceShowJSON bool
+
+var marketplaceShowCmd = &cobra.Command{
+	Use:   "show <service>",
+	Short: "Show a service and its priced endpoints",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMarketplaceShow,
+}
+
+func runMarketplaceShow(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), marketplaceTimeout)
+	defer cancel()
+	s, err := newConsoleClient().Service(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if marketplaceShowJSON {
+		return printJSON(s)
+	}
+
+	fmt.Println()
+	fmt.Println(tui.Bold(s.Name))
+	if s.Description != "" {
+		fmt.Println(tui.Muted(s.Description))
+	}
+	fmt.Println()
+	tui.PrintKeyValue("Service", s.ID)
+	tui.PrintKeyValue("Category", categoryLabel(s.Category))
+	tui.PrintKeyValue("URL", s.URL)
+	tui.PrintKeyValue("Price", s.PricePerRequest+" USDC per request")
+	if s.Network != "" {
+		tui.PrintKeyValue("Network", s.Network)
+	}
+	if s.PayTo != "" {
+		tui.PrintKeyValue("Pay to", vendorNamer()(s.PayTo))
+	}
+
+	if len(s.Endpoints) > 0 {
+		fmt.Println()
+		fmt.Printf("  %-7s  %-32s  %10s  %s\n", tui.Bold("METHOD"), tui.Bold("PATH"), tui.Bold("USDC"), tui.Bold("DESCRIPTION"))
+		for _, e := range s.Endpoints {
+			method := e.Method
+			if method == "" {
+				method = "*"
+			}
+			fmt.Printf("  %-7s  %-32s  %10s  %s\n", method, e.Path, endpointPrice(s, e), tui.Muted(e.Description))
+		}
+	}
+	fmt.Println()
+	fmt.Println(tui.Muted("  Price check: machpay marketplace quote " + s.ID + " <path>"))
+	fmt.Println()
+	return nil
+}
+
+// ============================================================
+// marketplace quote
+// ============================================================
+
+var (
+	marketplaceQuoteMethod string
+	marketplaceQuoteJSON   bool
+)
+
+var marketplaceQuoteCmd = &cobra.Command{
+	Use:   "quote <service> <path>",
+	Short: "Ask a service what a request costs, without paying",
+	Long: `Request a path of a marketplace service once and show the price in
+its 402 Payment Required response, next to the listed price. Nothing
+is paid.
+
+Examples:
+  machpay marketplace quote weather-api /v1/forecast
+  machpay marketplace quote llm-api /v1/complete -X POST --json`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMarketplaceQuote,
+}
+
+// serviceQuote is what a request costs, as its server answered
+type serviceQuote struct {
+	Service         string `json:"service,omitempty"`
+	URL             string `json:"url"`
+	Method          string `json:"method"`
+	PaymentRequired bool   `json:"payment_required"`
+	Status          int    `json:"status,omitempty"` // when free
+	Listed          string `json:"listed_price_usdc,omitempty"`
+	Price           string `json:"price_usdc,omitempty"`
+	PayTo           string `json:"pay_to,omitempty"`
+	Vendor          string `json:"vendor,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Unpayable       string `json:"unpayable,omitempty"` // why the active wallet cannot pay
+
+	amount uint64
+}
+
+func runMarketplaceQuote(cmd *cobra.Command, args []string) error {
+	method := strings.ToUpper(marketplaceQuoteMethod)
+	ctx, cancel := context.WithTimeout(context.Background(), marketplaceTimeout)
+	defer cancel()
+
+	s, err := newConsoleClient().Service(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	target, err := s.Resolve(args[1])
+	if err != nil {
+		return err
+	}
+
+	q, err := quotePrice(ctx, &payclient.Client{
+		Network:  x402.NetworkForCluster(config.GetCluster()),
+		USDCMint: config.GetUSDCMint(),
+	}, method, target)
+	if err != nil {
+		return err
+	}
+	q.Service = s.ID
+	q.Listed = listedPrice(s, method, args[1])
+
+	if marketplaceQuoteJSON {
+		return printJSON(q)
+	}
+
+	fmt.Println()
+	tui.PrintKeyValue("Service", s.ID)
+	tui.PrintKeyValue("Request", q.Method+" "+q.URL)
+	switch {
+	case !q.PaymentRequired:
+		tui.PrintKeyValue("Price", fmt.Sprintf("free (%d %s)", q.Status, http.StatusText(q.Status)))
+	case q.Unpayable != "":
+		tui.PrintKeyValue("Listed", q.Listed+" USDC")
+		fmt.Println()
+		tui.PrintWarning("Payment required, but not in a form this wallet can pay")
+		fmt.Println(tui.Muted("  " + q.Unpayable))
+	default:
+		tui.PrintKeyValue("Price", q.Price+" USDC")
+		tui.PrintKeyValue("Pay to", q.Vendor)
+		if q.Description != "" {
+			tui.PrintKeyValue("For", q.Description)
+		}
+		if q.Listed != "" && !sameUSDC(q.Listed, q.amount) {
+			fmt.Println()
+			tui.PrintWarning("The marketplace lists " + q.Listed + " USDC for this request")
+		}
+		fmt.Println()
+		fmt.Println(tui.Muted("  Pay for it: machpay curl --max-price " + q.Price + " -X " + q.Method + " " + q.URL))
+	}
+	fmt.Println()
+	return nil
+}
+
+// quotePrice requests url once and reads the price from its 402 with
+// a client that pays nothing
+func quotePrice(ctx context.Context, client *payclient.Client, method, url string) (*serviceQuote, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "machpay-cli/"+versionInfo.Version)
+
+	quoter := *client
+	quoter.MaxPrice, quoter.Approve, quoter.Sessions = 0, nil, nil
+	resp, _, err := quoter.Do(req)
+
+	q := &serviceQuote{URL: url, Method: method}
+	var priceErr *payclient.PriceError
+	switch {
+	case err == nil:
+		resp.Body.Close()
+		q.Status = resp.StatusCode
+	case errors.As(err, &priceErr):
+		r := priceErr.Requirements
+		q.PaymentRequired = true
+		q.amount = priceErr.Amount
+		q.Price = solana.FormatTokenAmount(priceErr.Amount, policy.USDCDecimals)
+		q.PayTo = r.PayTo
+		q.Vendor = vendorNamer()(r.PayTo)
+		q.Description = r.Description
+	case errors.Is(err, payclient.ErrNoAcceptableOffer):
+		q.PaymentRequired = true
+		q.Unpayable = err.Error()
+	default:
+		return nil, err
+	}
+	return q, nil
+}
+
+// ============================================================
+// Helpers
+// ============================================================
+
+// newConsoleClient returns a console API client with the stored login
+func newConsoleClient() *console.Client {
+	c := console.New(config.GetAPIURL(), auth.GetToken())
+	c.UserAgent = "machpay-cli/" + versionInfo.Version
+	return c
+}
+
+// listedPrice is the marketplace price of a request: its endpoint's,
+// or the service's
+func listedPrice(s *console.Service, method, path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	path, _, _ = strings.Cut(path, "?")
+	for _, e := range s.Endpoints {
+		if e.Method != "" && !strings.EqualFold(e.Method, method) {
+			continue
+		}
+		prefix := strings.TrimSuffix(e.Path, "*")
+		if e.Path == path || (prefix != e.Path && strings.HasPrefix(path, prefix)) {
+			return endpointPrice(s, e)
+		}
+	}
+	return s.PricePerRequest
+}
+
+func endpointPrice(s *console.Service, e console.Endpoint) string {
+	if e.Price != "" {
+		return e.Price
+	}
+	return s.PricePerRequest
+}
+
+// sameUSDC reports whether a listed USDC price equals an amount
+func sameUSDC(listed string, amount uint64) bool {
+	units, err := solana.ParseTokenAmount(listed, policy.USDCDecimals)
+	return err == nil && units == amount
+}
+
+func categoryLabel(id string) string {
+	for _, c := range console.Categories {
+		if c.ID == id {
+			return c.Label
+		}
+	}
+	return id
+}
+
+func categoryHelp() string {
+	var b strings.Builder
+	for _, c := range console.Categories {
+		fmt.Fprintf(&b, "  %-9s %s\n", c.ID, c.Label)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// truncate shortens s to n characters
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
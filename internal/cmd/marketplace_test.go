@@ -0,0 +1,150 @@
+// ============================================================
+// Marketplace Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/console"
+)
+
+// setupMarketplace points the console API at a fake listing a vendor
+// that charges 0.0025 USDC
+func setupMarketplace(t *testing.T) {
+	t.Helper()
+	useTempConfig(t)
+	config.Get().Auth.AccessToken = "console-token"
+	config.Get().Auth.UserID = fakeConsoleUser
+	vendor, _, _ := newPaidAPI(t, "2500")
+	t.Setenv("MACHPAY_API_URL", newFakeConsole(t, "console-token", vendor.URL).URL)
+}
+
+// captureStdout runs fn and returns what it printed
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	err = fn()
+	w.Close()
+	return <-out, err
+}
+
+func TestMarketplaceSearch(t *testing.T) {
+	setupMarketplace(t)
+	t.Cleanup(func() { marketplaceSearchCategory, marketplaceSearchMaxPrice, marketplaceSearchJSON = "", "", false })
+
+	marketplaceSearchJSON = true
+	marketplaceSearchCategory = "data"
+	out, err := captureStdout(t, func() error { return runMarketplaceSearch(marketplaceSearchCmd, []string{"weather"}) })
+	var result struct {
+		Services []console.Service `json:"services"`
+	}
+	if err != nil || json.Unmarshal([]byte(out), &result) != nil || len(result.Services) != 1 || result.Services[0].ID != "weather" {
+		t.Errorf("search --json = %q, %v", out, err)
+	}
+
+	// No match is an empty list, not null
+	marketplaceSearchCategory = "finance"
+	out, _ = captureStdout(t, func() error { return runMarketplaceSearch(marketplaceSearchCmd, []string{"weather"}) })
+	if !strings.Contains(out, `"services": []`) {
+		t.Errorf("empty search --json = %q", out)
+	}
+
+	marketplaceSearchJSON = false
+	marketplaceSearchCategory = ""
+	out, err = captureStdout(t, func() error { return runMarketplaceSearch(marketplaceSearchCmd, nil) })
+	if err != nil || !strings.Contains(out, "weather") || !strings.Contains(out, "0.0025") {
+		t.Errorf("search table = %q, %v", out, err)
+	}
+
+	for _, flags := range [][2]string{{"sports", ""}, {"", "free"}} {
+		marketplaceSearchCategory, marketplaceSearchMaxPrice = flags[0], flags[1]
+		if err := runMarketplaceSearch(marketplaceSearchCmd, nil); err == nil {
+			t.Errorf("search --category %q --max-price %q succeeded", flags[0], flags[1])
+		}
+	}
+}
+
+func TestMarketplaceShow(t *testing.T) {
+	setupMarketplace(t)
+	t.Cleanup(func() { marketplaceShowJSON = false })
+
+	out, err := captureStdout(t, func() error { return runMarketplaceShow(marketplaceShowCmd, []string{"weather"}) })
+	if err != nil || !strings.Contains(out, "Weather API") || !strings.Contains(out, "/quote") || !strings.Contains(out, "Data") {
+		t.Errorf("show = %q, %v", out, err)
+	}
+
+	if err := runMarketplaceShow(marketplaceShowCmd, []string{"tides"}); !errors.Is(err, console.ErrNotFound) {
+		t.Errorf("show unknown service = %v, want ErrNotFound", err)
+	}
+
+	// Without a login the console refuses
+	config.Get().Auth.AccessToken = ""
+	if err := runMarketplaceShow(marketplaceShowCmd, []string{"weather"}); !errors.Is(err, console.ErrUnauthorized) {
+		t.Errorf("show without login = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestMarketplaceQuote(t *testing.T) {
+	setupMarketplace(t)
+	t.Cleanup(func() { marketplaceQuoteJSON = false })
+
+	marketplaceQuoteJSON = true
+	out, err := captureStdout(t, func() error { return runMarketplaceQuote(marketplaceQuoteCmd, []string{"weather", "/quote"}) })
+	var q serviceQuote
+	if err != nil || json.Unmarshal([]byte(out), &q) != nil {
+		t.Fatalf("quote --json = %q, %v", out, err)
+	}
+	if !q.PaymentRequired || q.Price != "0.0025" || q.Listed != "0.0025" || q.Service != "weather" || !strings.HasSuffix(q.URL, "/quote") {
+		t.Errorf("quote = %+v", q)
+	}
+	if entries, _ := spendGuard().Spent(); len(entries) != 0 {
+		t.Errorf("quoting spent %+v", entries)
+	}
+}
+
+func TestListedPrice(t *testing.T) {
+	s := &console.Service{PricePerRequest: "0.001", Endpoints: []console.Endpoint{
+		{Method: "POST", Path: "/v1/complete", Price: "0.01"},
+		{Path: "/v1/search/*", Price: "0.002"},
+		{Path: "/v1/status"},
+	}}
+	for _, tc := range []struct{ method, path, want string }{
+		{"POST", "/v1/complete", "0.01"},
+		{"GET", "/v1/complete", "0.001"},
+		{"GET", "v1/search/news?q=sol", "0.002"},
+		{"GET", "/v1/status", "0.001"},
+		{"GET", "/other", "0.001"},
+	} {
+		if got := listedPrice(s, tc.method, tc.path); got != tc.want {
+			t.Errorf("listedPrice(%s %s) = %s, want %s", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestVendorCategoriesMatchMarketplace(t *testing.T) {
+	want := []string{"ai", "data", "finance", "compute", "other"}
+	if got := console.CategoryIDs(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("categories = %v, want %v", got, want)
+	}
+}
+
@@ -0,0 +1,755 @@
+// ============================================================
+// MCP Command - MachPay tools for AI assistants
+// ============================================================
+//
+// Usage:
+//   machpay mcp serve --max-price USDC [--auto-approve USDC]
+//                     [--wallet <name>] [--keypair <file>] [--cache]
+//                     [--session-deposit USDC] [--sign-requests]
+//
+// Serves the Model Context Protocol on stdin/stdout, so MCP hosts
+// (desktop assistants, IDEs, agent frameworks) can use these tools:
+//
+//   get_balance         SOL and USDC of the paying wallet
+//   search_marketplace  APIs listed on the MachPay marketplace
+//   quote_price         what a URL costs, without paying
+//   paid_fetch          GET or POST a URL, paying its x402 invoice
+//   get_spend_report    spending against the policy
+//
+// Payments are capped by --max-price, held to the spending policy and
+// need a human's approval: on the terminal when there is one, or
+// through the signing agent's prompt. Only payments up to
+// --auto-approve are made without asking.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/console"
+	"github.com/machpay-xyz/machpay-cli/internal/mcp"
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/signer"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// mcpMaxBody is the most of a response body returned to the model
+const mcpMaxBody = 64 << 10
+
+// mcpFetchTimeout bounds each quote_price and paid_fetch request
+const mcpFetchTimeout = 2 * time.Minute
+
+// mcpApprovalMu keeps approval prompts from interleaving
+var mcpApprovalMu sync.Mutex
+
+var (
+	errNoApprover  = errors.New("payment needs a human's approval but there is no terminal to ask on: run 'machpay signer start --policy prompt' to approve in the signing agent, or raise --auto-approve")
+	errNotApproved = errors.New("payment not approved")
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Let AI assistants pay for APIs over MCP",
+	Long: `Tools for AI assistants that speak the Model Context Protocol.
+
+Examples:
+  machpay mcp serve --max-price 0.05
+  machpay mcp serve --max-price 0.05 --auto-approve 0.005`,
+}
+
+// ============================================================
+// mcp serve
+// ============================================================
+
+var (
+	mcpServeMaxPrice    string
+	mcpServeAutoApprove string
+	mcpServeWallet      string
+	mcpServeKeypair     string
+	mcpServeCache       bool
+	mcpServeDeposit     string
+	mcpServeSign        bool
+)
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve MachPay tools over MCP on stdin/stdout",
+	Long: `Serve MachPay tools to an MCP host over stdin/stdout.
+
+Tools:
+  get_balance         SOL and USDC of the paying wallet
+  search_marketplace  APIs listed on the MachPay marketplace
+  quote_price         what a URL costs, without paying
+  paid_fetch          GET or POST a URL, paying its x402 invoice
+  get_spend_report    spending against the policy
+
+Add it to the host's MCP configuration, for example:
+
+  {
+    "mcpServers": {
+      "machpay": {
+        "command": "machpay",
+        "args": ["mcp", "serve", "--max-price", "0.05"]
+      }
+    }
+  }
+
+No payment costs more than --max-price. Payments above --auto-approve
+(default 0: every payment) need a human's approval, asked on the
+terminal the server runs in or, when the host gives it none, by the
+signing agent started with 'machpay signer start --policy prompt'.
+Without either, such payments are declined. All payments are held to
+the spending policy ('machpay policy') and recorded in the ledger.
+
+With --cache a paid GET response is kept and an identical paid_fetch
+is answered from it instead of paying again ('machpay cache').
+
+With --session-deposit, vendors that offer prepaid sessions get one
+deposit (approved like any payment) and later requests to them are
+charged to the session instead of paid one by one. Sessions are closed
+and the unused credit reclaimed when the server stops.
+
+With --sign-requests every request, the marketplace's included, is
+signed with the key registered by 'machpay agent register'.
+
+The marketplace is searched with the login from 'machpay login'.`,
+	Args: cobra.NoArgs,
+	RunE: runMCPServe,
+}
+
+func init() {
+	mcpServeCmd.Flags().StringVar(&mcpServeMaxPrice, "max-price", "", "Most USDC to pay for a single request (required)")
+	mcpServeCmd.Flags().StringVar(&mcpServeAutoApprove, "auto-approve", "0", "Pay up to this much USDC per request without asking")
+	mcpServeCmd.Flags().StringVar(&mcpServeWallet, "wallet", "", "Registered wallet to pay with (default: signing agent or active wallet)")
+	mcpServeCmd.Flags().StringVar(&mcpServeKeypair, "keypair", "", "Keypair file to pay with")
+	mcpServeCmd.Flags().BoolVar(&mcpServeCache, "cache", false, "Reuse paid GET responses ('machpay cache')")
+	mcpServeCmd.Flags().StringVar(&mcpServeDeposit, "session-deposit", "", "Prepay vendors that offer sessions this much USDC at a time")
+	mcpServeCmd.Flags().BoolVar(&mcpServeSign, "sign-requests", false, "Sign requests with the agent key ('machpay agent register')")
+	mcpServeCmd.MarkFlagRequired("max-price")
+
+	mcpCmd.AddCommand(mcpServeCmd)
+	rootCmd.AddCommand(mcpCmd)
+}
+
+func runMCPServe(cmd *cobra.Command, args []string) error {
+	// Stdout carries the protocol; anything else printed goes to stderr
+	out := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = out }()
+
+	tools, err := newMCPTools()
+	if err != nil {
+		return err
+	}
+	defer tools.close()
+	tools.approve, tools.approver = mcpApprover(tools.client.Signer)
+
+	fmt.Fprintf(os.Stderr, "%s MachPay MCP server on stdio: wallet %s on %s, max %s per request, approval by %s\n",
+		tui.InfoIcon(), wallet.SignerAddress(tools.client.Signer), tools.client.Network, formatUSDC(tools.maxPrice), tools.approver)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+			os.Stdin.Close()
+		case <-ctx.Done():
+		}
+	}()
+
+	err = tools.server().Serve(ctx, os.Stdin, out)
+	if ctx.Err() != nil {
+		// Interrupted: stdin was closed under the server
+		return nil
+	}
+	return err
+}
+
+// mcpTools backs the MCP tools with the wallet, config and login
+type mcpTools struct {
+	client      *payclient.Client
+	console     *console.Client
+	maxPrice    uint64
+	autoApprove uint64
+
+	// approve asks a human about a payment above autoApprove
+	approve  func(ctx context.Context, q *mcpQuote) error
+	approver string
+
+	mu        sync.Mutex
+	payments  int
+	spent     uint64
+	cacheHits int
+	saved     uint64
+}
+
+// mcpQuote is a payment waiting for approval
+type mcpQuote struct {
+	Method      string
+	URL         string
+	Amount      uint64
+	PayTo       string
+	Description string
+}
+
+// newMCPTools builds the tools from the flags and active config
+func newMCPTools() (*mcpTools, error) {
+	maxPrice, err := solana.ParseTokenAmount(mcpServeMaxPrice, policy.USDCDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("--max-price: %w", err)
+	}
+	if maxPrice == 0 {
+		return nil, errors.New("--max-price must be more than 0")
+	}
+	autoApprove, err := solana.ParseTokenAmount(mcpServeAutoApprove, policy.USDCDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("--auto-approve: %w", err)
+	}
+	if autoApprove > maxPrice {
+		return nil, errors.New("--auto-approve cannot be more than --max-price")
+	}
+
+	cache, err := payCache(mcpServeCache)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := sessionPool(mcpServeDeposit, maxPrice)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := requestSigner(mcpServeSign, mcpServeWallet, mcpServeKeypair)
+	if err != nil {
+		return nil, err
+	}
+	s, err := loadSigner(mcpServeWallet, mcpServeKeypair)
+	if err != nil {
+		return nil, err
+	}
+	consoleClient := newConsoleClient()
+	consoleClient.HTTP = signedClient(&http.Client{Timeout: console.DefaultTimeout}, identity)
+
+	return &mcpTools{
+		client: &payclient.Client{
+			HTTP:     signedClient(&http.Client{Timeout: mcpFetchTimeout}, identity),
+			Signer:   s,
+			RPC:      solana.NewClient(config.GetRPCURL()),
+			Network:  x402.NetworkForCluster(config.GetCluster()),
+			USDCMint: config.GetUSDCMint(),
+			MaxPrice: maxPrice,
+			Cache:    cache,
+			Sessions: sessions,
+		},
+		console:     consoleClient,
+		maxPrice:    maxPrice,
+		autoApprove: autoApprove,
+	}, nil
+}
+
+func (t *mcpTools) close() {
+	closeSessions(os.Stderr, t.client.Sessions)
+	if closer, ok := t.client.Signer.(interface{ Close() error }); ok {
+		closer.Close()
+	}
+}
+
+// server returns the MCP server offering the tools
+func (t *mcpTools) server() *mcp.Server {
+	s := mcp.NewServer("machpay", versionInfo.Version)
+	s.Instructions = fmt.Sprintf("Pays for HTTP APIs with x402 from the user's MachPay wallet (USDC on %s). "+
+		"Find APIs with search_marketplace, check prices with quote_price, then call them with paid_fetch. "+
+		"No request may cost more than %s; payments above %s wait for the user's approval.",
+		t.client.Network, formatUSDC(t.maxPrice), formatUSDC(t.autoApprove))
+	s.Logf = func(format string, args ...interface{}) {
+		fmt.Fprintln(os.Stderr, tui.Muted(time.Now().Format("15:04:05")+"  "+fmt.Sprintf(format, args...)))
+	}
+
+	s.AddTool(mcp.Tool{
+		Name:        "get_balance",
+		Description: "Get the SOL and USDC balance of the wallet that pays for API calls.",
+		Handler:     t.getBalance,
+	})
+	s.AddTool(mcp.Tool{
+		Name:        "search_marketplace",
+		Description: "Search the MachPay marketplace for paid APIs. Returns services with their base URL and price per request in USDC.",
+		InputSchema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "query": {"type": "string", "description": "Words to search for"},
+    "category": {"type": "string", "enum": ` + string(mustMarshal(console.CategoryIDs())) + `},
+    "max_price": {"type": "string", "description": "Most USDC per request, e.g. \"0.01\""}
+  }
+}`),
+		Handler: t.searchMarketplace,
+	})
+	s.AddTool(mcp.Tool{
+		Name:        "quote_price",
+		Description: "Find out what a request costs without paying: give a url, or a marketplace service ID and a path.",
+		InputSchema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "url": {"type": "string", "description": "URL to price"},
+    "service": {"type": "string", "description": "Marketplace service ID, instead of url"},
+    "path": {"type": "string", "description": "Path on the service, with service"},
+    "method": {"type": "string", "enum": ["GET", "POST"], "default": "GET"}
+  }
+}`),
+		Handler: t.quotePrice,
+	})
+	s.AddTool(mcp.Tool{
+		Name: "paid_fetch",
+		Description: "Send an HTTP GET or POST and pay the x402 invoice if the server asks for payment, within the price limit. " +
+			"Returns the status, content type and body (text, up to 64 KB) and what was paid.",
+		InputSchema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "url": {"type": "string"},
+    "method": {"type": "string", "enum": ["GET", "POST"], "default": "GET"},
+    "body": {"type": "string", "description": "Request body, for POST"},
+    "content_type": {"type": "string", "default": "application/json"},
+    "headers": {"type": "object", "additionalProperties": {"type": "string"}},
+    "max_price": {"type": "string", "description": "Most USDC to pay, up to the server's limit"}
+  },
+  "required": ["url"]
+}`),
+		Handler: t.paidFetch,
+	})
+	s.AddTool(mcp.Tool{
+		Name:        "get_spend_report",
+		Description: "Report USDC spent in the last 24 hours by vendor, the spending policy limits and this session's payments.",
+		Handler:     t.getSpendReport,
+	})
+	return s
+}
+
+// ============================================================
+// Tools
+// ============================================================
+
+type mcpBalance struct {
+	Address string `json:"address"`
+	Network string `json:"network"`
+	SOL     string `json:"sol"`
+	USDC    string `json:"usdc"`
+}
+
+func (t *mcpTools) getBalance(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+	address := wallet.SignerAddress(t.client.Signer)
+	lamports, err := t.client.RPC.GetBalance(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("get balance: %w", err)
+	}
+
+	usdc := "0"
+	ata, _, err := wallet.FindAssociatedTokenAddress(address, t.client.USDCMint, wallet.TokenProgramID)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := t.client.RPC.GetTokenAccountBalance(ctx, ata)
+	var rpcErr *solana.RPCError
+	switch {
+	case errors.As(err, &rpcErr) && strings.Contains(rpcErr.Message, "could not find account"):
+		// No USDC account yet
+	case err != nil:
+		return nil, fmt.Errorf("get USDC balance: %w", err)
+	default:
+		usdc = balance.UIAmountString
+	}
+
+	return mcp.JSON(mcpBalance{Address: address, Network: t.client.Network, SOL: solana.FormatSOL(lamports), USDC: usdc})
+}
+
+func (t *mcpTools) searchMarketplace(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+	var in struct {
+		Query    string `json:"query"`
+		Category string `json:"category"`
+		MaxPrice string `json:"max_price"`
+	}
+	if err := mcp.Bind(args, &in); err != nil {
+		return nil, err
+	}
+	services, err := t.console.Search(ctx, console.SearchQuery{Query: in.Query, Category: in.Category, MaxPrice: in.MaxPrice})
+	if err != nil {
+		return nil, err
+	}
+	if services == nil {
+		services = []console.Service{}
+	}
+	return mcp.JSON(map[string]interface{}{"services": services})
+}
+
+type mcpPrice struct {
+	serviceQuote
+	WithinMaxPrice bool `json:"within_max_price"`
+	NeedsApproval  bool `json:"needs_approval"`
+}
+
+func (t *mcpTools) quotePrice(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+	var in struct {
+		URL     string `json:"url"`
+		Service string `json:"service"`
+		Path    string `json:"path"`
+		Method  string `json:"method"`
+	}
+	if err := mcp.Bind(args, &in); err != nil {
+		return nil, err
+	}
+	method, err := mcpMethod(in.Method)
+	if err != nil {
+		return nil, err
+	}
+	target, err := t.resolveURL(ctx, in.URL, in.Service, in.Path)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, mcp.InvalidParams("url %q is not an http(s) URL", target)
+	}
+
+	q, err := quotePrice(ctx, t.client, method, target)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.JSON(mcpPrice{
+		serviceQuote:   *q,
+		WithinMaxPrice: q.amount <= t.maxPrice,
+		NeedsApproval:  q.amount > t.autoApprove,
+	})
+}
+
+type mcpFetchResult struct {
+	Status      int            `json:"status"`
+	ContentType string         `json:"content_type,omitempty"`
+	Body        string         `json:"body"`
+	Truncated   bool           `json:"truncated,omitempty"`
+	Payment     *mcpPaymentOut `json:"payment,omitempty"`
+	Cached      bool           `json:"cached,omitempty"`     // served without paying
+	Saved       string         `json:"saved_usdc,omitempty"` // what paying would have cost
+}
+
+type mcpPaymentOut struct {
+	Amount      string `json:"amount_usdc"`
+	PayTo       string `json:"pay_to"`
+	Settled     bool   `json:"settled"`
+	Deposit     bool   `json:"session_deposit,omitempty"` // prepays later requests to the vendor
+	Transaction string `json:"transaction,omitempty"`
+	Explorer    string `json:"explorer,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+func (t *mcpTools) paidFetch(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+	var in struct {
+		URL         string            `json:"url"`
+		Method      string            `json:"method"`
+		Body        string            `json:"body"`
+		ContentType string            `json:"content_type"`
+		Headers     map[string]string `json:"headers"`
+		MaxPrice    string            `json:"max_price"`
+	}
+	if err := mcp.Bind(args, &in); err != nil {
+		return nil, err
+	}
+	method, err := mcpMethod(in.Method)
+	if err != nil {
+		return nil, err
+	}
+	if in.URL == "" {
+		return nil, mcp.InvalidParams("url is required")
+	}
+	maxPrice := t.maxPrice
+	if in.MaxPrice != "" {
+		if maxPrice, err = solana.ParseTokenAmount(in.MaxPrice, policy.USDCDecimals); err != nil {
+			return nil, mcp.InvalidParams("max_price: %v", err)
+		}
+		if maxPrice > t.maxPrice {
+			return nil, fmt.Errorf("max_price %s is above this server's limit of %s", formatUSDC(maxPrice), formatUSDC(t.maxPrice))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mcpFetchTimeout)
+	defer cancel()
+	var body io.Reader
+	if in.Body != "" {
+		if method != http.MethodPost {
+			return nil, mcp.InvalidParams("body is only sent with POST")
+		}
+		body = strings.NewReader(in.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, in.URL, body)
+	if err != nil || (req.URL.Scheme != "http" && req.URL.Scheme != "https") {
+		return nil, mcp.InvalidParams("url %q is not an http(s) URL", in.URL)
+	}
+	req.Header.Set("User-Agent", "machpay-cli/"+versionInfo.Version)
+	if body != nil {
+		contentType := in.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	for name, value := range in.Headers {
+		if strings.EqualFold(name, x402.HeaderPayment) {
+			return nil, mcp.InvalidParams("the %s header is set by the server", x402.HeaderPayment)
+		}
+		req.Header.Set(name, value)
+	}
+
+	client := *t.client
+	client.MaxPrice = maxPrice
+	client.Approve = func(req *http.Request, offer x402.PaymentRequirements, amount uint64) error {
+		if amount <= t.autoApprove {
+			return nil
+		}
+		return t.approve(req.Context(), &mcpQuote{Method: req.Method, URL: req.URL.String(),
+			Amount: amount, PayTo: offer.PayTo, Description: offer.Description})
+	}
+
+	resp, payment, err := client.Do(req)
+	if err != nil {
+		return nil, mcpPaymentError(err)
+	}
+	defer resp.Body.Close()
+
+	out := mcpFetchResult{Status: resp.StatusCode, ContentType: resp.Header.Get("Content-Type")}
+	if payment != nil {
+		out.Payment = t.recordPayment(req, payment)
+	}
+	if saved, ok := payclient.CacheHit(resp); ok {
+		t.mu.Lock()
+		t.cacheHits++
+		t.saved += saved
+		t.mu.Unlock()
+		out.Cached, out.Saved = true, solana.FormatTokenAmount(saved, policy.USDCDecimals)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, mcpMaxBody+1))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if len(data) > mcpMaxBody {
+		data, out.Truncated = data[:mcpMaxBody], true
+	}
+	text := data
+	if out.Truncated && len(text) > utf8.UTFMax {
+		// The cut may have split the last character
+		text = text[:len(text)-utf8.UTFMax]
+	}
+	if utf8.Valid(text) {
+		out.Body = strings.ToValidUTF8(string(data), "")
+	} else {
+		out.Body = fmt.Sprintf("(binary body of %d bytes not shown)", len(data))
+	}
+	return mcp.JSON(out)
+}
+
+// recordPayment logs a payment and adds it to the session
+func (t *mcpTools) recordPayment(req *http.Request, p *payclient.Payment) *mcpPaymentOut {
+	t.mu.Lock()
+	t.payments++
+	t.spent += p.Amount
+	t.mu.Unlock()
+
+	out := &mcpPaymentOut{
+		Amount:  solana.FormatTokenAmount(p.Amount, policy.USDCDecimals),
+		PayTo:   p.Requirements.PayTo,
+		Deposit: p.Session != nil,
+	}
+	switch s := p.Settlement; {
+	case s == nil:
+		out.Error = "no settlement receipt in the response"
+	case !s.Success:
+		out.Error = "settlement failed: " + s.ErrorReason
+	default:
+		out.Settled = true
+		out.Transaction = s.Transaction
+		if s.Transaction != "" {
+			out.Explorer = explorerTxURL(s.Transaction, config.Get().Network)
+		}
+	}
+	fmt.Fprintln(os.Stderr, tui.Muted(time.Now().Format("15:04:05")+"  ")+tui.SuccessIcon()+fmt.Sprintf(" Paid %s USDC to %s for %s %s",
+		out.Amount, vendorNamer()(out.PayTo), req.Method, req.URL))
+	return out
+}
+
+type mcpSpendReport struct {
+	policyReport
+	MaxPrice    string `json:"max_price_per_request"`
+	AutoApprove string `json:"auto_approve_up_to"`
+	Session     struct {
+		Payments  int    `json:"payments"`
+		Spent     string `json:"spent"`
+		CacheHits int    `json:"cache_hits"`
+		Saved     string `json:"saved_by_cache"`
+	} `json:"session"`
+}
+
+func (t *mcpTools) getSpendReport(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+	pol, err := policy.Load(config.GetPolicyPath())
+	if err != nil {
+		return nil, err
+	}
+	spent, err := spendGuard().Spent()
+	if err != nil {
+		return nil, err
+	}
+	total, byVendor := policy.Totals(spent)
+
+	report := mcpSpendReport{
+		policyReport: policyReport{
+			Policy:  pol,
+			Spent:   solana.FormatTokenAmount(total, policy.USDCDecimals),
+			Vendors: make(map[string]string, len(byVendor)),
+		},
+		MaxPrice:    solana.FormatTokenAmount(t.maxPrice, policy.USDCDecimals),
+		AutoApprove: solana.FormatTokenAmount(t.autoApprove, policy.USDCDecimals),
+	}
+	name := vendorNamer()
+	for vendor, amount := range byVendor {
+		report.Vendors[name(vendor)] = solana.FormatTokenAmount(amount, policy.USDCDecimals)
+	}
+	t.mu.Lock()
+	report.Session.Payments = t.payments
+	report.Session.Spent = solana.FormatTokenAmount(t.spent, policy.USDCDecimals)
+	report.Session.CacheHits = t.cacheHits
+	report.Session.Saved = solana.FormatTokenAmount(t.saved, policy.USDCDecimals)
+	t.mu.Unlock()
+	return mcp.JSON(report)
+}
+
+// ============================================================
+// Helpers
+// ============================================================
+
+// resolveURL returns the URL to call: given directly, or a path on a
+// marketplace service
+func (t *mcpTools) resolveURL(ctx context.Context, rawURL, service, path string) (string, error) {
+	switch {
+	case rawURL != "" && service != "":
+		return "", mcp.InvalidParams("give url or service, not both")
+	case rawURL != "":
+		return rawURL, nil
+	case service == "":
+		return "", mcp.InvalidParams("url or service is required")
+	}
+	s, err := t.console.Service(ctx, service)
+	if err != nil {
+		return "", err
+	}
+	return s.Resolve(path)
+}
+
+// mcpMethod checks a tool's method argument
+func mcpMethod(method string) (string, error) {
+	switch m := strings.ToUpper(method); m {
+	case "":
+		return http.MethodGet, nil
+	case http.MethodGet, http.MethodPost:
+		return m, nil
+	}
+	return "", mcp.InvalidParams("method must be GET or POST")
+}
+
+// mcpPaymentError explains a declined payment to the model
+func mcpPaymentError(err error) error {
+	var priceErr *payclient.PriceError
+	switch {
+	case errors.As(err, &priceErr):
+		return fmt.Errorf("not paid: %w (pass a higher max_price only if the user agreed to it)", err)
+	case errors.Is(err, policy.ErrViolation):
+		return fmt.Errorf("not paid, refused by the user's spending policy: %w", err)
+	case errors.Is(err, errNotApproved), errors.Is(err, errNoApprover):
+		return fmt.Errorf("not paid: %w", err)
+	}
+	return err
+}
+
+func mustMarshal(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// ============================================================
+// Approval
+// ============================================================
+
+// mcpApprover picks who approves payments: the terminal if there is
+// one, else the signing agent if it prompts for every signature
+func mcpApprover(s wallet.Signer) (func(ctx context.Context, q *mcpQuote) error, string) {
+	if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+		tty.Close()
+		return approvePaymentOnTTY, "terminal"
+	}
+	if client, ok := s.(*signer.Client); ok {
+		if status, err := client.Status(); err == nil && status.Policy == signer.PolicyPrompt {
+			return func(context.Context, *mcpQuote) error { return nil }, "signing agent"
+		}
+	}
+	return func(context.Context, *mcpQuote) error { return errNoApprover }, "nobody (payments above --auto-approve are declined)"
+}
+
+// approvePaymentOnTTY asks on the controlling terminal, as stdin and
+// stdout belong to the MCP host
+func approvePaymentOnTTY(ctx context.Context, q *mcpQuote) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return errNoApprover
+	}
+	defer tty.Close()
+	mcpApprovalMu.Lock()
+	defer mcpApprovalMu.Unlock()
+
+	fmt.Fprintln(tty)
+	fmt.Fprintln(tty, tui.Bold("Payment requested by the AI assistant"))
+	fmt.Fprintf(tty, "  %s %s %s\n", tui.Muted("Request:"), q.Method, q.URL)
+	fmt.Fprintf(tty, "  %s %s\n", tui.Muted("Amount: "), formatUSDC(q.Amount))
+	fmt.Fprintf(tty, "  %s %s\n", tui.Muted("Pay to: "), vendorNamer()(q.PayTo))
+	if q.Description != "" {
+		fmt.Fprintf(tty, "  %s %s\n", tui.Muted("For:    "), q.Description)
+	}
+	fmt.Fprint(tty, "Pay? [y/N]: ")
+
+	answer := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(tty).ReadString('\n')
+		answer <- strings.ToLower(strings.TrimSpace(line))
+	}()
+	select {
+	case a := <-answer:
+		if a == "y" || a == "yes" {
+			return nil
+		}
+		return errNotApproved
+	case <-ctx.Done():
+		fmt.Fprintln(tty, tui.Muted("(cancelled)"))
+		return ctx.Err()
+	}
+}
+
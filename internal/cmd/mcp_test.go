@@ -0,0 +1,258 @@
+// ============================================================
+// MCP Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/console"
+	"github.com/machpay-xyz/machpay-cli/internal/mcp"
+	"github.com/machpay-xyz/machpay-cli/internal/mcp/mcptest"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// fakeConsoleUser is the account newFakeConsole registers agents to
+const fakeConsoleUser = "usr_1"
+
+// newFakeConsole serves the marketplace with one service at
+// serviceURL and agent registration, and checks the bearer token
+func newFakeConsole(t *testing.T, token, serviceURL string) *httptest.Server {
+	t.Helper()
+	weather := console.Service{
+		ID:              "weather",
+		Name:            "Weather API",
+		Category:        "data",
+		URL:             serviceURL,
+		PricePerRequest: "0.0025",
+		Endpoints:       []console.Endpoint{{Method: "GET", Path: "/quote", Description: "Current weather"}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid token"})
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/marketplace/services":
+			var found []console.Service
+			q := r.URL.Query()
+			if strings.Contains("weather", q.Get("q")) && (q.Get("category") == "" || q.Get("category") == weather.Category) {
+				s := weather
+				s.Endpoints = nil
+				found = append(found, s)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"services": found})
+		case "/v1/marketplace/services/weather":
+			json.NewEncoder(w).Encode(weather)
+		case "/v1/agents":
+			var reg console.AgentRegistration
+			json.NewDecoder(r.Body).Decode(&reg)
+			key, _ := wallet.ParsePublicKey(reg.PublicKey)
+			sig, _ := wallet.Base58Decode(reg.Signature)
+			if reg.UserID != fakeConsoleUser || len(key) == 0 ||
+				!ed25519.Verify(key, console.RegistrationMessage(reg.UserID, reg.PublicKey, reg.Name, reg.SignedAt), sig) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "signature does not match the key"})
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(console.Agent{ID: "agt_1", PublicKey: reg.PublicKey, Name: reg.Name, CreatedAt: reg.SignedAt})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "no such service"})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// setupMCP builds the MCP tools for a fake RPC, console and vendor
+// charging 0.0025 USDC; approve stands in for the human
+func setupMCP(t *testing.T, approve func(q *mcpQuote) error) (tools *mcpTools, paid *int) {
+	t.Helper()
+	useTempConfig(t)
+	_, rpc := newFakeRPC(t, map[string]interface{}{
+		"getLatestBlockhash": map[string]interface{}{
+			"value": map[string]interface{}{"blockhash": "4uQeVj5tqViQh7yWWGStvkEG1Zmhx6uasJtWCJziofM", "lastValidBlockHeight": 100},
+		},
+		"getBalance":             map[string]interface{}{"value": 5000000},
+		"getTokenAccountBalance": map[string]interface{}{"value": map[string]interface{}{"amount": "2500000", "decimals": 6, "uiAmountString": "2.5"}},
+	})
+	cfg := config.Get()
+	cfg.Networks = map[string]config.NetworkConfig{"test": {RPCURL: rpc.URL, Cluster: config.ClusterDevnet}}
+	cfg.Network = "test"
+	cfg.Auth.AccessToken = "console-token"
+
+	vendor, _, paid := newPaidAPI(t, "2500")
+	t.Setenv("MACHPAY_API_URL", newFakeConsole(t, "console-token", vendor.URL).URL)
+
+	kp, _ := wallet.Generate()
+	mcpServeKeypair = filepath.Join(t.TempDir(), "payer.json")
+	if err := kp.SaveToFile(mcpServeKeypair); err != nil {
+		t.Fatal(err)
+	}
+	mcpServeMaxPrice, mcpServeAutoApprove = "0.01", "0.001"
+	t.Cleanup(func() { mcpServeKeypair, mcpServeMaxPrice, mcpServeAutoApprove = "", "", "0" })
+
+	tools, err := newMCPTools()
+	if err != nil {
+		t.Fatalf("newMCPTools: %v", err)
+	}
+	tools.approve = func(ctx context.Context, q *mcpQuote) error { return approve(q) }
+	return tools, paid
+}
+
+// toolJSON calls a tool that must succeed and decodes its JSON text
+func toolJSON(t *testing.T, c *mcptest.Client, name string, args, out interface{}) {
+	t.Helper()
+	result, rpcErr := c.CallTool(name, args)
+	if rpcErr != nil || result.IsError || len(result.Content) != 1 {
+		t.Fatalf("%s = %+v, %v", name, result, rpcErr)
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), out); err != nil {
+		t.Fatalf("%s: %q: %v", name, result.Content[0].Text, err)
+	}
+}
+
+// toolError calls a tool that must fail and returns its message
+func toolError(t *testing.T, c *mcptest.Client, name string, args interface{}) string {
+	t.Helper()
+	result, rpcErr := c.CallTool(name, args)
+	if rpcErr != nil {
+		return rpcErr.Message
+	}
+	if !result.IsError {
+		t.Errorf("%s(%v) succeeded: %s", name, args, result.Content[0].Text)
+		return ""
+	}
+	return result.Content[0].Text
+}
+
+func TestMCPServe_Tools(t *testing.T) {
+	var asked []*mcpQuote
+	tools, _ := setupMCP(t, func(q *mcpQuote) error {
+		asked = append(asked, q)
+		return nil
+	})
+	c := mcptest.Start(t, tools.server())
+	c.Initialize()
+
+	var list struct {
+		Tools []mcp.Tool `json:"tools"`
+	}
+	if err := json.Unmarshal(c.Call("tools/list", nil).Result, &list); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range list.Tools {
+		names = append(names, tool.Name)
+	}
+	if got := strings.Join(names, ","); got != "get_balance,search_marketplace,quote_price,paid_fetch,get_spend_report" {
+		t.Errorf("tools = %s", got)
+	}
+
+	var balance mcpBalance
+	toolJSON(t, c, "get_balance", nil, &balance)
+	if balance.USDC != "2.5" || balance.SOL != "0.005" || balance.Address != wallet.SignerAddress(tools.client.Signer) {
+		t.Errorf("balance = %+v", balance)
+	}
+
+	var search struct {
+		Services []console.Service `json:"services"`
+	}
+	toolJSON(t, c, "search_marketplace", map[string]string{"query": "weather", "category": "data"}, &search)
+	if len(search.Services) != 1 || search.Services[0].ID != "weather" {
+		t.Errorf("search = %+v", search)
+	}
+	if msg := toolError(t, c, "search_marketplace", map[string]string{"category": "sports"}); !strings.Contains(msg, "unknown category") {
+		t.Errorf("bad category: %s", msg)
+	}
+
+	var price mcpPrice
+	toolJSON(t, c, "quote_price", map[string]string{"service": "weather", "path": "/quote"}, &price)
+	if !price.PaymentRequired || price.Price != "0.0025" || !price.WithinMaxPrice || !price.NeedsApproval || !strings.HasSuffix(price.URL, "/quote") {
+		t.Errorf("quote = %+v", price)
+	}
+	if len(asked) != 0 {
+		t.Errorf("quoting asked for approval")
+	}
+
+	var fetched mcpFetchResult
+	toolJSON(t, c, "paid_fetch", map[string]string{"url": price.URL}, &fetched)
+	if fetched.Status != http.StatusOK || fetched.Body != "paid content" || fetched.Payment == nil || fetched.Payment.Amount != "0.0025" || !fetched.Payment.Settled {
+		t.Errorf("paid_fetch = %+v", fetched)
+	}
+	if len(asked) != 1 || asked[0].Amount != 2500 || asked[0].URL != price.URL {
+		t.Errorf("approval asked for %+v", asked)
+	}
+
+	var report mcpSpendReport
+	toolJSON(t, c, "get_spend_report", nil, &report)
+	if report.Spent != "0.0025" || report.Session.Payments != 1 || report.Session.Spent != "0.0025" || report.MaxPrice != "0.01" {
+		t.Errorf("spend report = %+v", report)
+	}
+}
+
+func TestMCPServe_PaymentGuards(t *testing.T) {
+	tools, paid := setupMCP(t, func(q *mcpQuote) error { return errNotApproved })
+	c := mcptest.Start(t, tools.server())
+	c.Initialize()
+
+	var price mcpPrice
+	toolJSON(t, c, "quote_price", map[string]string{"service": "weather", "path": "quote"}, &price)
+
+	for _, tc := range []struct {
+		args map[string]string
+		want string
+	}{
+		{map[string]string{"url": price.URL}, "not approved"},
+		{map[string]string{"url": price.URL, "max_price": "0.002"}, "exceeds the maximum"},
+		{map[string]string{"url": price.URL, "max_price": "1"}, "above this server's limit"},
+		{map[string]string{"url": price.URL, "method": "DELETE"}, "GET or POST"},
+		{map[string]string{"url": "file:///etc/passwd"}, "not an http(s) URL"},
+		{map[string]string{"url": price.URL, "body": "{}"}, "only sent with POST"},
+	} {
+		if msg := toolError(t, c, "paid_fetch", tc.args); !strings.Contains(msg, tc.want) {
+			t.Errorf("paid_fetch(%v) = %q, want %q", tc.args, msg, tc.want)
+		}
+	}
+	if *paid != 0 {
+		t.Fatalf("paid %d times", *paid)
+	}
+
+	// Within --auto-approve nobody is asked
+	tools.autoApprove = 2500
+	var fetched mcpFetchResult
+	toolJSON(t, c, "paid_fetch", map[string]string{"url": price.URL, "method": "post", "body": `{"city":"Lisbon"}`}, &fetched)
+	if *paid != 1 || fetched.Payment == nil {
+		t.Errorf("auto-approved payment: paid %d times, %+v", *paid, fetched)
+	}
+}
+
+func TestNewMCPTools_Flags(t *testing.T) {
+	useTempConfig(t)
+	t.Cleanup(func() { mcpServeMaxPrice, mcpServeAutoApprove = "", "0" })
+
+	for _, tc := range []struct{ maxPrice, autoApprove string }{
+		{"0", "0"},
+		{"abc", "0"},
+		{"0.01", "x"},
+		{"0.01", "0.02"},
+	} {
+		mcpServeMaxPrice, mcpServeAutoApprove = tc.maxPrice, tc.autoApprove
+		if _, err := newMCPTools(); err == nil {
+			t.Errorf("newMCPTools(%q, %q) succeeded", tc.maxPrice, tc.autoApprove)
+		}
+	}
+}
+
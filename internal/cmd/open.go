@@ -43,7 +43,9 @@ Examples:
   machpay open              # Opens console home
   machpay open marketplace  # Opens marketplace
   machpay open funding      # Opens funding page
-  machpay open --web        # Force browser (not desktop app)`,
+  machpay open --web        # Force browser (not desktop app)
+
+To search the marketplace from the terminal, use 'machpay marketplace'.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runOpen,
 }
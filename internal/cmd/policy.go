@@ -0,0 +1,407 @@
+// ============================================================
+// Policy Command - Spending limits and the spend ledger
+// ============================================================
+//
+// Usage:
+//   machpay policy show [--json]
+//   machpay policy set [--max-per-request 1] [--max-per-day 25]
+//                      [--max-per-vendor 10] [--vendor-limit @v=50]
+//                      [--allow @v] [--deny <address>] [--unlist @v]
+//   machpay policy test <vendor> <amount>
+//
+// The policy lives in ~/.machpay/policy.yaml and every payment
+// signed through the CLI is recorded in ~/.machpay/ledger.jsonl.
+// Signing refuses payments that would break the policy.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+)
+
+// noLimit clears a limit in 'policy set'
+const noLimit = "none"
+
+var (
+	policyShowJSON         bool
+	policySetMaxPerRequest string
+	policySetMaxPerDay     string
+	policySetMaxPerVendor  string
+	policySetVendorLimits  []string
+	policySetAllow         []string
+	policySetDeny          []string
+	policySetUnlist        []string
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Manage spending limits for payments",
+	Long: `Limit what the CLI will sign for.
+
+Every USDC payment signed through the CLI (machpay tx sign, the
+signing agent, and anything built on them) is checked against the
+policy and the local spend ledger first, and refused when it would
+break a rule:
+
+  max_per_request  Largest single payment
+  max_per_day      Total across all vendors in the last 24 hours
+  max_per_vendor   Total per vendor in the last 24 hours
+  vendor_limits    Per-vendor overrides of max_per_vendor
+  allow            If set, only these vendors may be paid
+  deny             These vendors are never paid
+
+Limits are in USDC. Vendors are wallet addresses or @labels from
+the address book. While any rule is set, transactions that move the
+wallet's funds in ways the limits cannot count (SOL transfers, token
+approvals, other tokens, calls that pass the wallet to programs other
+than System, Token, Associated Token, Memo and Compute Budget) are
+refused too.
+
+Examples:
+  machpay policy set --max-per-request 1 --max-per-day 25
+  machpay policy set --vendor-limit @openai-proxy=50 --allow @openai-proxy
+  machpay policy test @openai-proxy 2.5
+  machpay policy show`,
+}
+
+var policyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the policy and spending in the last 24 hours",
+	Args:  cobra.NoArgs,
+	RunE:  runPolicyShow,
+}
+
+var policySetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Change spending limits and vendor lists",
+	Long: `Change spending limits and vendor lists.
+
+Only the flags given are changed. Pass "none" to remove a limit,
+e.g. --max-per-day none or --vendor-limit @openai-proxy=none.`,
+	Args: cobra.NoArgs,
+	RunE: runPolicySet,
+}
+
+var policyTestCmd = &cobra.Command{
+	Use:   "test <vendor> <amount>",
+	Short: "Check whether a payment would be allowed now",
+	Long: `Check whether a USDC payment would be allowed now, without
+signing or recording anything. Exits non-zero when it would be
+refused.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runPolicyTest,
+}
+
+func init() {
+	policyShowCmd.Flags().BoolVar(&policyShowJSON, "json", false, "Output as JSON")
+
+	policySetCmd.Flags().StringVar(&policySetMaxPerRequest, "max-per-request", "", "Largest single payment in USDC (none to clear)")
+	policySetCmd.Flags().StringVar(&policySetMaxPerDay, "max-per-day", "", "USDC across all vendors per 24 hours (none to clear)")
+	policySetCmd.Flags().StringVar(&policySetMaxPerVendor, "max-per-vendor", "", "USDC per vendor per 24 hours (none to clear)")
+	policySetCmd.Flags().StringArrayVar(&policySetVendorLimits, "vendor-limit", nil, "VENDOR=USDC per 24 hours for one vendor (repeatable)")
+	policySetCmd.Flags().StringArrayVar(&policySetAllow, "allow", nil, "Add a vendor to the allow list (repeatable)")
+	policySetCmd.Flags().StringArrayVar(&policySetDeny, "deny", nil, "Add a vendor to the deny list (repeatable)")
+	policySetCmd.Flags().StringArrayVar(&policySetUnlist, "unlist", nil, "Remove a vendor from the allow and deny lists (repeatable)")
+
+	policyCmd.AddCommand(policyShowCmd)
+	policyCmd.AddCommand(policySetCmd)
+	policyCmd.AddCommand(policyTestCmd)
+	rootCmd.AddCommand(policyCmd)
+}
+
+// spendGuard enforces the spending policy for the active network
+func spendGuard() *policy.Guard {
+	return policy.NewGuard(config.GetPolicyPath(), policy.NewLedger(config.GetLedgerPath()),
+		config.GetUSDCMint(), addressLabels)
+}
+
+// vendorNamer names addresses as @label when the address book has them
+func vendorNamer() func(string) string {
+	labels := addressLabels()
+	return func(addr string) string {
+		if label, ok := labels[addr]; ok {
+			return "@" + label
+		}
+		return addr
+	}
+}
+
+// formatUSDC formats raw USDC units
+func formatUSDC(amount uint64) string {
+	return solana.FormatTokenAmount(amount, policy.USDCDecimals) + " USDC"
+}
+
+// ============================================================
+// policy show
+// ============================================================
+
+type policyReport struct {
+	Policy  *policy.Policy    `json:"policy"`
+	Spent   string            `json:"spent_24h"`
+	Vendors map[string]string `json:"spent_24h_by_vendor"`
+}
+
+func runPolicyShow(cmd *cobra.Command, args []string) error {
+	pol, err := policy.Load(config.GetPolicyPath())
+	if err != nil {
+		return err
+	}
+	spent, err := spendGuard().Spent()
+	if err != nil {
+		return err
+	}
+	total, byVendor := policy.Totals(spent)
+
+	if policyShowJSON {
+		report := policyReport{
+			Policy:  pol,
+			Spent:   solana.FormatTokenAmount(total, policy.USDCDecimals),
+			Vendors: make(map[string]string, len(byVendor)),
+		}
+		for vendor, amount := range byVendor {
+			report.Vendors[vendor] = solana.FormatTokenAmount(amount, policy.USDCDecimals)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	name := vendorNamer()
+	limit := func(amount string) string {
+		if amount == "" {
+			return tui.Muted(noLimit)
+		}
+		return amount + " USDC"
+	}
+
+	fmt.Println()
+	fmt.Println(tui.Bold("Spending Policy"))
+	fmt.Println()
+	if pol.Empty() {
+		fmt.Println(tui.Muted("  No limits set: every payment is allowed"))
+		fmt.Println(tui.Muted("  Run 'machpay policy set --max-per-day 25' to add one"))
+	} else {
+		tui.PrintKeyValue("Per request", limit(pol.MaxPerRequest))
+		tui.PrintKeyValue("Per day", limit(pol.MaxPerDay))
+		tui.PrintKeyValue("Per vendor", limit(pol.MaxPerVendor))
+	}
+
+	if len(pol.VendorLimits) > 0 {
+		fmt.Println()
+		fmt.Println(tui.Bold("Vendor limits"))
+		for _, vendor := range sortedKeys(pol.VendorLimits) {
+			fmt.Printf("  %-46s %s\n", name(vendor), limit(pol.VendorLimits[vendor]))
+		}
+	}
+	for _, list := range []struct {
+		title   string
+		vendors []string
+	}{{"Allow", pol.Allow}, {"Deny", pol.Deny}} {
+		if len(list.vendors) == 0 {
+			continue
+		}
+		fmt.Println()
+		fmt.Println(tui.Bold(list.title))
+		for _, vendor := range list.vendors {
+			fmt.Printf("  %s\n", name(vendor))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(tui.Bold("Spent in the last 24 hours"))
+	if len(byVendor) == 0 {
+		fmt.Println(tui.Muted("  Nothing"))
+	}
+	vendors := make([]string, 0, len(byVendor))
+	for vendor := range byVendor {
+		vendors = append(vendors, vendor)
+	}
+	sort.Slice(vendors, func(i, j int) bool { return byVendor[vendors[i]] > byVendor[vendors[j]] })
+	for _, vendor := range vendors {
+		fmt.Printf("  %-46s %s\n", name(vendor), formatUSDC(byVendor[vendor]))
+	}
+	if len(byVendor) > 0 {
+		fmt.Printf("  %s %s\n", tui.Bold(fmt.Sprintf("%-46s", "Total")), tui.Bold(formatUSDC(total)))
+	}
+	fmt.Println()
+	fmt.Println(tui.Muted("  Policy: " + config.GetPolicyPath()))
+	fmt.Println(tui.Muted("  Ledger: " + config.GetLedgerPath()))
+	fmt.Println()
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ============================================================
+// policy set
+// ============================================================
+
+func runPolicySet(cmd *cobra.Command, args []string) error {
+	path := config.GetPolicyPath()
+	pol, err := policy.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if err := applyPolicyChanges(pol, cmd); err != nil {
+		return err
+	}
+	if err := pol.Save(path); err != nil {
+		return err
+	}
+
+	tui.PrintSuccess("Spending policy updated")
+	fmt.Println(tui.Muted("  Run 'machpay policy show' to review it"))
+	return nil
+}
+
+// applyPolicyChanges applies the 'policy set' flags that were given
+func applyPolicyChanges(pol *policy.Policy, cmd *cobra.Command) error {
+	for _, f := range []struct {
+		flag  string
+		value string
+		field *string
+	}{
+		{"max-per-request", policySetMaxPerRequest, &pol.MaxPerRequest},
+		{"max-per-day", policySetMaxPerDay, &pol.MaxPerDay},
+		{"max-per-vendor", policySetMaxPerVendor, &pol.MaxPerVendor},
+	} {
+		if !cmd.Flags().Changed(f.flag) {
+			continue
+		}
+		amount, err := normalizeLimit(f.value)
+		if err != nil {
+			return fmt.Errorf("--%s: %w", f.flag, err)
+		}
+		*f.field = amount
+	}
+
+	for _, spec := range policySetVendorLimits {
+		vendorArg, amountArg, ok := strings.Cut(spec, "=")
+		if !ok {
+			return fmt.Errorf("--vendor-limit %q: use VENDOR=AMOUNT", spec)
+		}
+		vendor, err := resolveAddress(vendorArg)
+		if err != nil {
+			return fmt.Errorf("--vendor-limit %q: %w", spec, err)
+		}
+		amount, err := normalizeLimit(amountArg)
+		if err != nil {
+			return fmt.Errorf("--vendor-limit %q: %w", spec, err)
+		}
+		if amount == "" {
+			delete(pol.VendorLimits, vendor)
+			continue
+		}
+		if pol.VendorLimits == nil {
+			pol.VendorLimits = make(map[string]string)
+		}
+		pol.VendorLimits[vendor] = amount
+	}
+
+	for _, arg := range policySetUnlist {
+		vendor, err := resolveAddress(arg)
+		if err != nil {
+			return fmt.Errorf("--unlist %q: %w", arg, err)
+		}
+		pol.Allow = without(pol.Allow, vendor)
+		pol.Deny = without(pol.Deny, vendor)
+	}
+	for _, arg := range policySetAllow {
+		vendor, err := resolveAddress(arg)
+		if err != nil {
+			return fmt.Errorf("--allow %q: %w", arg, err)
+		}
+		pol.Deny = without(pol.Deny, vendor)
+		pol.Allow = append(without(pol.Allow, vendor), vendor)
+	}
+	for _, arg := range policySetDeny {
+		vendor, err := resolveAddress(arg)
+		if err != nil {
+			return fmt.Errorf("--deny %q: %w", arg, err)
+		}
+		pol.Allow = without(pol.Allow, vendor)
+		pol.Deny = append(without(pol.Deny, vendor), vendor)
+	}
+	return nil
+}
+
+// normalizeLimit validates a USDC limit; "none" clears it
+func normalizeLimit(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, noLimit) || value == "" {
+		return "", nil
+	}
+	amount, err := solana.ParseTokenAmount(value, policy.USDCDecimals)
+	if err != nil {
+		return "", err
+	}
+	return solana.FormatTokenAmount(amount, policy.USDCDecimals), nil
+}
+
+func without(list []string, s string) []string {
+	out := list[:0]
+	for _, x := range list {
+		if x != s {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// ============================================================
+// policy test
+// ============================================================
+
+func runPolicyTest(cmd *cobra.Command, args []string) error {
+	vendor, err := resolveAddress(args[0])
+	if err != nil {
+		return err
+	}
+	amount, err := solana.ParseTokenAmount(strings.TrimSpace(args[1]), policy.USDCDecimals)
+	if err != nil {
+		return err
+	}
+
+	pol, err := policy.Load(config.GetPolicyPath())
+	if err != nil {
+		return err
+	}
+	spent, err := spendGuard().Spent()
+	if err != nil {
+		return err
+	}
+
+	label := vendorNamer()
+	if err := pol.Check([]policy.Payment{{Vendor: vendor, Amount: amount}}, spent, label); err != nil {
+		tui.PrintError(err.Error())
+		return fmt.Errorf("payment would be refused")
+	}
+
+	total, byVendor := policy.Totals(spent)
+	tui.PrintSuccess(fmt.Sprintf("Payment of %s to %s would be allowed", formatUSDC(amount), label(vendor)))
+	tui.PrintKeyValue("Vendor 24h", formatUSDC(byVendor[vendor]))
+	tui.PrintKeyValue("Total 24h", formatUSDC(total))
+	return nil
+}
+
@@ -0,0 +1,92 @@
+// ============================================================
+// Policy Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// setPolicyFlags sets 'policy set' flags as if given on the command
+// line and resets them when the test ends
+func setPolicyFlags(t *testing.T, flags map[string][]string) {
+	t.Helper()
+	t.Cleanup(func() {
+		policySetCmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if sv, ok := f.Value.(pflag.SliceValue); ok {
+				sv.Replace(nil)
+			} else {
+				f.Value.Set(f.DefValue)
+			}
+			f.Changed = false
+		})
+	})
+	for name, values := range flags {
+		for _, v := range values {
+			if err := policySetCmd.Flags().Set(name, v); err != nil {
+				t.Fatalf("--%s %s: %v", name, v, err)
+			}
+		}
+	}
+}
+
+func TestApplyPolicyChanges(t *testing.T) {
+	useTempConfig(t)
+
+	kp, _ := wallet.Generate()
+	vendor := kp.PublicKeyBase58()
+	if _, err := addressBook().Add("vendor", vendor, "", false); err != nil {
+		t.Fatal(err)
+	}
+	other, _ := wallet.Generate()
+
+	pol := &policy.Policy{
+		MaxPerRequest: "5",
+		MaxPerDay:     "100",
+		Deny:          []string{vendor},
+	}
+	setPolicyFlags(t, map[string][]string{
+		"max-per-day":    {"none"},
+		"max-per-vendor": {"10.50"},
+		"vendor-limit":   {"@vendor=50", other.PublicKeyBase58() + "=1"},
+		"allow":          {"@vendor"},
+	})
+
+	if err := applyPolicyChanges(pol, policySetCmd); err != nil {
+		t.Fatalf("applyPolicyChanges: %v", err)
+	}
+	if pol.MaxPerRequest != "5" || pol.MaxPerDay != "" || pol.MaxPerVendor != "10.5" {
+		t.Errorf("limits = %q %q %q", pol.MaxPerRequest, pol.MaxPerDay, pol.MaxPerVendor)
+	}
+	if pol.VendorLimits[vendor] != "50" || pol.VendorLimits[other.PublicKeyBase58()] != "1" {
+		t.Errorf("VendorLimits = %v", pol.VendorLimits)
+	}
+	// Allowing a denied vendor moves it between the lists
+	if len(pol.Allow) != 1 || pol.Allow[0] != vendor || len(pol.Deny) != 0 {
+		t.Errorf("Allow = %v, Deny = %v", pol.Allow, pol.Deny)
+	}
+}
+
+func TestApplyPolicyChanges_Invalid(t *testing.T) {
+	useTempConfig(t)
+
+	for _, flags := range []map[string][]string{
+		{"max-per-request": {"abc"}},
+		{"vendor-limit": {"50"}},
+		{"deny": {"@missing"}},
+	} {
+		t.Run("", func(t *testing.T) {
+			setPolicyFlags(t, flags)
+			if err := applyPolicyChanges(&policy.Policy{}, policySetCmd); err == nil {
+				t.Errorf("applyPolicyChanges(%v) succeeded", flags)
+			}
+		})
+	}
+}
+
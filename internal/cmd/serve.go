@@ -101,6 +101,10 @@ func runServe(cmd *cobra.Command, args []string) error {
 	// 3. Create process manager
 	pm := gateway.NewProcessManager(dl.BinaryPath(), servePort, upstream)
 	pm.SetDebug(serveDebug)
+	if client := dialAgent(); client != nil {
+		client.Close()
+		pm.SetSignerSocket(config.GetSignerSocket())
+	}
 
 	// 4. Handle detach mode
 	if serveDetach {
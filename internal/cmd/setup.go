@@ -6,8 +6,8 @@
 //
 // Guides users through initial configuration:
 // - Role selection (Agent or Vendor)
-// - Network selection (Devnet or Mainnet)
-// - Wallet generation or import
+// - Network selection (Devnet, Mainnet or the Solana CLI's endpoint)
+// - Wallet generation or import (Solana CLI keypair: setup_solanacli.go)
 // - Role-specific configuration
 //
 // ============================================================
@@ -15,9 +15,10 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -25,6 +26,7 @@ import (
 
 	"github.com/machpay-xyz/machpay-cli/internal/auth"
 	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/console"
 	"github.com/machpay-xyz/machpay-cli/internal/tui"
 	"github.com/machpay-xyz/machpay-cli/internal/wallet"
 )
@@ -42,8 +44,17 @@ This wizard will guide you through:
   - Setting up your wallet
   - Generating API keys or configuring your service
 
+Existing wallets are never overwritten: new keys are added to the
+wallet registry under a new name (see 'machpay wallet list').
+
 Non-interactive mode for CI/CD:
-  MACHPAY_ROLE=agent MACHPAY_NETWORK=devnet machpay setup --non-interactive`,
+  MACHPAY_ROLE=agent MACHPAY_NETWORK=devnet machpay setup --non-interactive
+
+  MACHPAY_WALLET_PATH  Import a key file: Solana CLI keypairs are linked;
+                       base58, hex and recovery phrase files are copied
+  MACHPAY_WALLET_NAME  Wallet name to use or create (default: "default")
+  MACHPAY_NETWORK      devnet, mainnet, or solana-cli to use the RPC endpoint
+                       from ~/.config/solana/cli/config.yml`,
 	RunE: runSetup,
 }
 
@@ -118,7 +129,7 @@ func runInteractiveSetup() error {
 
 	// Step 2: Network selection
 	tui.PrintSection()
-	network, err := tui.Select("Select network:", []tui.SelectOption{
+	networkOptions := []tui.SelectOption{
 		{
 			Label:       "Devnet",
 			Description: "Testing network - free tokens, no real money",
@@ -129,13 +140,37 @@ func runInteractiveSetup() error {
 			Description: "Production network - real USDC transactions",
 			Value:       "mainnet",
 		},
-	})
+	}
+
+	// Offer the Solana CLI's endpoint first when one is configured
+	var cliNetwork string
+	var cliCustom *config.NetworkConfig
+	if cli := detectSolanaCLI(); cli != nil && cli.JSONRPCURL != "" {
+		var err error
+		cliNetwork, cliCustom, err = solanaCLINetwork(context.Background(), cli)
+		if err != nil {
+			tui.PrintWarning(fmt.Sprintf("Ignoring Solana CLI config: %v", err))
+		} else {
+			networkOptions = append([]tui.SelectOption{{
+				Label:       fmt.Sprintf("Solana CLI config (%s)", solanaCLICluster(cliNetwork, cliCustom)),
+				Description: cli.JSONRPCURL,
+				Value:       config.SolanaCLINetwork,
+			}}, networkOptions...)
+		}
+	}
+
+	network, err := tui.Select("Select network:", networkOptions)
 	if err != nil {
 		return err
 	}
+	cluster := network.Value
+	if network.Value == config.SolanaCLINetwork {
+		network.Value = cliNetwork
+		cluster = solanaCLICluster(cliNetwork, cliCustom)
+	}
 
 	// Warning for mainnet
-	if network.Value == "mainnet" {
+	if cluster == config.ClusterMainnet {
 		tui.PrintSection()
 		fmt.Println(tui.Warning("⚠️  MAINNET WARNING"))
 		fmt.Println()
@@ -151,6 +186,9 @@ func runInteractiveSetup() error {
 			network.Value = "devnet"
 		}
 	}
+	if network.Value == cliNetwork {
+		applyNetwork(cliNetwork, cliCustom)
+	}
 
 	// Step 3: Branch by role
 	tui.PrintSection()
@@ -173,7 +211,7 @@ func setupAgent(network string) error {
 	fmt.Println()
 
 	// 1. Wallet setup
-	kp, err := promptWallet()
+	info, err := promptWallet("agent")
 	if err != nil {
 		return err
 	}
@@ -182,8 +220,7 @@ func setupAgent(network string) error {
 	cfg := config.Get()
 	cfg.Role = "agent"
 	cfg.Network = network
-	cfg.Wallet.KeypairPath = filepath.Join(config.GetDir(), "wallet.json")
-	cfg.Wallet.PublicKey = kp.PublicKeyBase58()
+	activateWallet(info)
 
 	if err := config.Save(); err != nil {
 		return fmt.Errorf("save config: %w", err)
@@ -191,7 +228,7 @@ func setupAgent(network string) error {
 
 	// 3. Show success
 	tui.PrintSection()
-	printAgentSuccess(kp.PublicKeyBase58(), network)
+	printAgentSuccess(info.PublicKey, network)
 
 	return nil
 }
@@ -234,13 +271,12 @@ func setupVendor(network string) error {
 		return err
 	}
 
-	category, err := tui.Select("Category:", []tui.SelectOption{
-		{Label: "AI/ML", Value: "ai"},
-		{Label: "Data", Value: "data"},
-		{Label: "Finance", Value: "finance"},
-		{Label: "Compute", Value: "compute"},
-		{Label: "Other", Value: "other"},
-	})
+	// The marketplace's categories, so buyers can filter on them
+	categories := make([]tui.SelectOption, len(console.Categories))
+	for i, c := range console.Categories {
+		categories[i] = tui.SelectOption{Label: c.Label, Value: c.ID}
+	}
+	category, err := tui.Select("Category:", categories)
 	if err != nil {
 		return err
 	}
@@ -263,7 +299,7 @@ func setupVendor(network string) error {
 	tui.PrintSection()
 
 	// 3. Wallet setup
-	kp, err := promptWallet()
+	info, err := promptWallet("vendor")
 	if err != nil {
 		return err
 	}
@@ -272,8 +308,7 @@ func setupVendor(network string) error {
 	cfg := config.Get()
 	cfg.Role = "vendor"
 	cfg.Network = network
-	cfg.Wallet.KeypairPath = filepath.Join(config.GetDir(), "wallet.json")
-	cfg.Wallet.PublicKey = kp.PublicKeyBase58()
+	activateWallet(info)
 	cfg.Vendor.UpstreamURL = upstreamURL
 	cfg.Vendor.PricePerRequest = price
 
@@ -321,25 +356,85 @@ func printVendorSuccess(name, category, upstream, price, network string) {
 // Wallet Prompt
 // ============================================================
 
-func promptWallet() (*wallet.Keypair, error) {
-	choice, err := tui.Select("Wallet setup:", []tui.SelectOption{
-		{Label: "Generate new wallet", Description: "Recommended for new users", Value: "generate"},
-		{Label: "Import existing keypair", Description: "Use existing Solana wallet", Value: "import"},
-	})
+// promptWallet lets the user keep the active wallet, generate a new
+// one or import a keypair. New wallets are added to the registry
+// under a fresh name, so an existing key is never overwritten.
+func promptWallet(role string) (*wallet.WalletInfo, error) {
+	reg, err := walletRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	var options []tui.SelectOption
+	current, _ := reg.Get(config.Get().Wallet.Name)
+	if current != nil {
+		options = append(options, tui.SelectOption{
+			Label:       fmt.Sprintf("Keep current wallet (%s)", current.Name),
+			Description: current.PublicKey,
+			Value:       "keep",
+		})
+	}
+	cli := detectSolanaCLI()
+	if kp, _ := solanaCLIKeypair(cli); kp != nil && (current == nil || current.PublicKey != kp.PublicKeyBase58()) {
+		options = append(options, tui.SelectOption{
+			Label:       "Use Solana CLI keypair",
+			Description: fmt.Sprintf("%s (%s)", kp.PublicKeyBase58(), cli.KeypairPath),
+			Value:       "solana-cli",
+		})
+	}
+	options = append(options,
+		tui.SelectOption{Label: "Generate new wallet", Description: "Recommended for new users", Value: "generate"},
+		tui.SelectOption{Label: "Import existing keypair", Description: "Use existing Solana wallet", Value: "import"},
+	)
+
+	choice, err := tui.Select("Wallet setup:", options)
 	if err != nil {
 		return nil, err
 	}
 
 	switch choice.Value {
+	case "keep":
+		return current, nil
+	case "solana-cli":
+		return useSolanaCLIWallet(reg, cli)
 	case "generate":
-		return generateNewWallet()
+		return generateNewWallet(reg, role)
 	case "import":
-		return importExistingWallet()
+		return importExistingWallet(reg, role, cli)
 	}
 	return nil, fmt.Errorf("invalid choice")
 }
 
-func generateNewWallet() (*wallet.Keypair, error) {
+// promptWalletName asks for a registry name that is not yet taken
+func promptWalletName(reg *wallet.Registry, base string) (string, error) {
+	suggested, err := reg.UniqueName(base)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		name, err := tui.TextInputOptional("Wallet name", suggested)
+		if err != nil {
+			return "", err
+		}
+		if err := wallet.ValidateName(name); err != nil {
+			tui.PrintError(err.Error())
+			continue
+		}
+		if existing, _ := reg.Get(name); existing != nil {
+			tui.PrintError(fmt.Sprintf("Wallet %s already exists", name))
+			continue
+		}
+		return name, nil
+	}
+}
+
+func generateNewWallet(reg *wallet.Registry, role string) (*wallet.WalletInfo, error) {
+	name, err := promptWalletName(reg, role)
+	if err != nil {
+		return nil, err
+	}
+
 	fmt.Println()
 	fmt.Println(tui.Muted("  Generating new wallet..."))
 
@@ -348,56 +443,69 @@ func generateNewWallet() (*wallet.Keypair, error) {
 		return nil, fmt.Errorf("generate wallet: %w", err)
 	}
 
-	walletPath := filepath.Join(config.GetDir(), "wallet.json")
-	if err := kp.SaveToFile(walletPath); err != nil {
+	info, err := reg.Add(name, kp, wallet.SourceGenerated)
+	if err != nil {
 		return nil, fmt.Errorf("save wallet: %w", err)
 	}
 
 	fmt.Println()
 	tui.PrintSuccess("Generated new wallet")
 	fmt.Println()
-	tui.PrintKeyValue("Address", kp.PublicKeyBase58())
-	tui.PrintKeyValue("Saved to", walletPath)
+	tui.PrintKeyValue("Name", info.Name)
+	tui.PrintKeyValue("Address", info.PublicKey)
+	tui.PrintKeyValue("Saved to", info.Path)
 	fmt.Println()
 	fmt.Println(tui.Warning("⚠️  BACKUP THIS FILE! It contains your private key."))
+	fmt.Println(tui.Muted("  Run 'machpay wallet backup' to split it into recovery shares"))
 
-	return kp, nil
+	return info, nil
 }
 
-func importExistingWallet() (*wallet.Keypair, error) {
-	path, err := tui.TextInput("Path to keypair file", "~/.config/solana/id.json", func(s string) error {
-		// Expand ~ to home directory
-		expandedPath := s
-		if strings.HasPrefix(s, "~") {
-			home, _ := os.UserHomeDir()
-			expandedPath = filepath.Join(home, s[1:])
-		}
-		if _, err := os.Stat(expandedPath); os.IsNotExist(err) {
+func importExistingWallet(reg *wallet.Registry, role string, cli *config.SolanaCLIConfig) (*wallet.WalletInfo, error) {
+	suggested := "~/.config/solana/id.json"
+	if cli != nil && cli.KeypairPath != "" {
+		suggested = cli.KeypairPath
+	}
+
+	var kp *wallet.Keypair
+	var format wallet.KeyFormat
+	path, err := tui.TextInput("Path to key file", suggested, func(s string) error {
+		var err error
+		kp, format, err = wallet.LoadKeyFile(s)
+		if errors.Is(err, os.ErrNotExist) {
 			return fmt.Errorf("file not found: %s", s)
 		}
-		return nil
+		return err
 	})
 	if err != nil {
 		return nil, err
 	}
+	fmt.Println(tui.Muted(fmt.Sprintf("  Detected %s: %s", strings.ToLower(format.Description()), kp.PublicKeyBase58())))
 
-	// Load keypair
-	kp, err := wallet.LoadFromFile(path)
+	// Already registered under another name
+	if existing, err := reg.FindByPublicKey(kp.PublicKeyBase58()); err == nil {
+		fmt.Println()
+		tui.PrintInfo(fmt.Sprintf("Wallet already registered as %s", tui.Bold(existing.Name)))
+		return existing, nil
+	}
+
+	name, err := promptWalletName(reg, role)
 	if err != nil {
-		return nil, fmt.Errorf("load keypair: %w", err)
+		return nil, err
 	}
 
-	// Copy to MachPay directory
-	destPath := filepath.Join(config.GetDir(), "wallet.json")
-	if err := kp.SaveToFile(destPath); err != nil {
+	// Copy to MachPay wallet registry
+	info, err := registerImported(reg, name, path, kp, format, false)
+	if err != nil {
 		return nil, fmt.Errorf("save keypair: %w", err)
 	}
 
 	fmt.Println()
 	tui.PrintSuccess("Imported wallet")
-	tui.PrintKeyValue("Address", kp.PublicKeyBase58())
+	tui.PrintKeyValue("Name", info.Name)
+	tui.PrintKeyValue("Address", info.PublicKey)
 
-	return kp, nil
+	return info, nil
 }
 
 // ============================================================
@@ -408,6 +516,7 @@ func runNonInteractiveSetup() error {
 	role := os.Getenv("MACHPAY_ROLE")
 	network := os.Getenv("MACHPAY_NETWORK")
 	walletPath := os.Getenv("MACHPAY_WALLET_PATH")
+	walletName := os.Getenv("MACHPAY_WALLET_NAME")
 
 	if role == "" {
 		return fmt.Errorf("MACHPAY_ROLE environment variable required (agent or vendor)")
@@ -418,6 +527,18 @@ func runNonInteractiveSetup() error {
 	if network == "" {
 		network = "devnet"
 	}
+	if network == config.SolanaCLINetwork {
+		cli := detectSolanaCLI()
+		if cli == nil {
+			return fmt.Errorf("MACHPAY_NETWORK=%s but no Solana CLI config at %s", network, solanaCLIConfigPath())
+		}
+		resolved, custom, err := solanaCLINetwork(context.Background(), cli)
+		if err != nil {
+			return err
+		}
+		network = resolved
+		applyNetwork(network, custom)
+	}
 
 	fmt.Printf("Configuring as %s on %s...\n", tui.Primary(role), tui.Primary(network))
 
@@ -426,28 +547,11 @@ func runNonInteractiveSetup() error {
 	cfg.Network = network
 
 	// Handle wallet
-	if walletPath != "" {
-		kp, err := wallet.LoadFromFile(walletPath)
-		if err != nil {
-			return fmt.Errorf("load wallet: %w", err)
-		}
-		cfg.Wallet.KeypairPath = walletPath
-		cfg.Wallet.PublicKey = kp.PublicKeyBase58()
-		fmt.Printf("Wallet: %s\n", tui.Primary(kp.PublicKeyBase58()))
-	} else {
-		// Generate new wallet
-		kp, err := wallet.Generate()
-		if err != nil {
-			return fmt.Errorf("generate wallet: %w", err)
-		}
-		walletPath := filepath.Join(config.GetDir(), "wallet.json")
-		if err := kp.SaveToFile(walletPath); err != nil {
-			return fmt.Errorf("save wallet: %w", err)
-		}
-		cfg.Wallet.KeypairPath = walletPath
-		cfg.Wallet.PublicKey = kp.PublicKeyBase58()
-		fmt.Printf("Generated wallet: %s\n", tui.Primary(kp.PublicKeyBase58()))
+	info, err := nonInteractiveWallet(walletPath, walletName)
+	if err != nil {
+		return err
 	}
+	activateWallet(info)
 
 	// Vendor-specific config
 	if role == "vendor" {
@@ -472,6 +576,58 @@ func runNonInteractiveSetup() error {
 	return nil
 }
 
+// nonInteractiveWallet resolves the wallet for CI/CD setup. An
+// existing wallet with the requested name (default "default") is
+// reused rather than regenerated, so re-running setup is safe.
+func nonInteractiveWallet(walletPath, walletName string) (*wallet.WalletInfo, error) {
+	reg, err := walletRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	if walletName == "" {
+		walletName = "default"
+	}
+
+	if walletPath != "" {
+		kp, format, err := wallet.LoadKeyFile(walletPath)
+		if err != nil {
+			return nil, fmt.Errorf("load wallet: %w", err)
+		}
+		if existing, err := reg.FindByPublicKey(kp.PublicKeyBase58()); err == nil {
+			fmt.Printf("Wallet: %s (%s)\n", tui.Primary(existing.PublicKey), existing.Name)
+			return existing, nil
+		}
+		name, err := reg.UniqueName(walletName)
+		if err != nil {
+			return nil, err
+		}
+		info, err := registerImported(reg, name, walletPath, kp, format, true)
+		if err != nil {
+			return nil, fmt.Errorf("register wallet: %w", err)
+		}
+		fmt.Printf("Wallet: %s (%s)\n", tui.Primary(info.PublicKey), info.Name)
+		return info, nil
+	}
+
+	if existing, err := reg.Get(walletName); err == nil {
+		fmt.Printf("Using existing wallet: %s (%s)\n", tui.Primary(existing.PublicKey), existing.Name)
+		return existing, nil
+	}
+
+	// Generate new wallet
+	kp, err := wallet.Generate()
+	if err != nil {
+		return nil, fmt.Errorf("generate wallet: %w", err)
+	}
+	info, err := reg.Add(walletName, kp, wallet.SourceGenerated)
+	if err != nil {
+		return nil, fmt.Errorf("save wallet: %w", err)
+	}
+	fmt.Printf("Generated wallet: %s (%s)\n", tui.Primary(info.PublicKey), info.Name)
+	return info, nil
+}
+
 // ============================================================
 // Validators
 // ============================================================
@@ -0,0 +1,173 @@
+// ============================================================
+// Setup - Solana CLI config import
+// ============================================================
+//
+// When ~/.config/solana/cli/config.yml exists, setup offers its
+// RPC endpoint as the network and its keypair as the wallet. The
+// keypair can be linked in place (it keeps tracking the file) or
+// copied into the MachPay wallet registry.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// solanaCLIConfigPath is where setup looks for the Solana CLI config
+var solanaCLIConfigPath = config.SolanaCLIConfigPath
+
+// detectSolanaCLI returns the Solana CLI config, or nil if there is none
+func detectSolanaCLI() *config.SolanaCLIConfig {
+	path := solanaCLIConfigPath()
+	if path == "" {
+		return nil
+	}
+	cli, err := config.LoadSolanaCLIConfig(path)
+	if err != nil {
+		return nil
+	}
+	return cli
+}
+
+// solanaCLINetwork maps the Solana CLI RPC endpoint to a MachPay
+// network. Well-known hosts are matched by name; anything else is
+// identified by asking the endpoint for its genesis hash, since a
+// private RPC provider may serve any cluster. A local validator that
+// is not running is assumed to be a plain localnet.
+func solanaCLINetwork(ctx context.Context, cli *config.SolanaCLIConfig) (string, *config.NetworkConfig, error) {
+	if cli.JSONRPCURL == "" {
+		return "", nil, fmt.Errorf("%s has no json_rpc_url", cli.Path)
+	}
+
+	cluster, ok := config.ClusterForRPCURL(cli.JSONRPCURL)
+	if !ok {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		hash, err := solana.NewClient(cli.JSONRPCURL).GetGenesisHash(ctx)
+		switch {
+		case err == nil:
+			cluster = clusterForGenesis(hash)
+		case isLoopbackURL(cli.JSONRPCURL):
+			cluster = config.ClusterLocalnet
+		default:
+			return "", nil, fmt.Errorf("identify cluster of %s: %w", cli.JSONRPCURL, err)
+		}
+	}
+
+	network, custom := config.NetworkForRPCURL(cli.JSONRPCURL, cluster)
+	if custom != nil {
+		custom.Source = cli.Path
+	}
+	return network, custom, nil
+}
+
+// clusterForGenesis names the public cluster with the given genesis
+// hash; any other chain is a local or private validator
+func clusterForGenesis(hash string) string {
+	switch hash {
+	case solana.GenesisMainnet:
+		return config.ClusterMainnet
+	case solana.GenesisDevnet:
+		return config.ClusterDevnet
+	case solana.GenesisTestnet:
+		return config.ClusterTestnet
+	}
+	return config.ClusterLocalnet
+}
+
+func isLoopbackURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if u.Hostname() == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
+
+// solanaCLICluster is the cluster of a network from solanaCLINetwork
+func solanaCLICluster(network string, custom *config.NetworkConfig) string {
+	if custom != nil {
+		return custom.Cluster
+	}
+	return config.ClusterOf(network)
+}
+
+// applyNetwork records a custom network definition, if any
+func applyNetwork(network string, custom *config.NetworkConfig) {
+	if custom == nil {
+		return
+	}
+	cfg := config.Get()
+	if cfg.Networks == nil {
+		cfg.Networks = make(map[string]config.NetworkConfig)
+	}
+	cfg.Networks[network] = *custom
+}
+
+// solanaCLIKeypair loads the Solana CLI keypair, or returns nil if it
+// is missing or unreadable
+func solanaCLIKeypair(cli *config.SolanaCLIConfig) (*wallet.Keypair, wallet.KeyFormat) {
+	if cli == nil || cli.KeypairPath == "" {
+		return nil, ""
+	}
+	kp, format, err := wallet.LoadKeyFile(cli.KeypairPath)
+	if err != nil {
+		return nil, ""
+	}
+	return kp, format
+}
+
+// useSolanaCLIWallet registers the Solana CLI keypair, linked or
+// copied as the user chooses
+func useSolanaCLIWallet(reg *wallet.Registry, cli *config.SolanaCLIConfig) (*wallet.WalletInfo, error) {
+	kp, format := solanaCLIKeypair(cli)
+	if kp == nil {
+		return nil, fmt.Errorf("cannot read Solana CLI keypair %s", cli.KeypairPath)
+	}
+
+	if existing, err := reg.FindByPublicKey(kp.PublicKeyBase58()); err == nil {
+		fmt.Println()
+		tui.PrintInfo(fmt.Sprintf("Wallet already registered as %s", tui.Bold(existing.Name)))
+		return existing, nil
+	}
+
+	mode, err := tui.Select("How should MachPay use this keypair?", []tui.SelectOption{
+		{Label: "Link to the file", Description: "Keep using " + cli.KeypairPath + " in place", Value: "link"},
+		{Label: "Copy into MachPay", Description: "Independent copy under ~/.machpay/wallets", Value: "copy"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := promptWalletName(reg, "solana-cli")
+	if err != nil {
+		return nil, err
+	}
+	info, err := registerImported(reg, name, cli.KeypairPath, kp, format, mode.Value == "link")
+	if err != nil {
+		return nil, fmt.Errorf("register wallet: %w", err)
+	}
+
+	fmt.Println()
+	tui.PrintSuccess("Using Solana CLI wallet")
+	tui.PrintKeyValue("Name", info.Name)
+	tui.PrintKeyValue("Address", info.PublicKey)
+	tui.PrintKeyValue("Keypair", info.Path)
+
+	return info, nil
+}
+
@@ -0,0 +1,139 @@
+// ============================================================
+// Setup Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// writeSolanaCLIConfig points setup at a Solana CLI config using
+// rpcURL and a fresh keypair
+func writeSolanaCLIConfig(t *testing.T, rpcURL string) (*wallet.Keypair, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	kp, _ := wallet.Generate()
+	keypairPath := filepath.Join(dir, "id.json")
+	if err := kp.SaveToFile(keypairPath); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "config.yml")
+	content := "json_rpc_url: " + rpcURL + "\nkeypair_path: " + keypairPath + "\ncommitment: confirmed\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := solanaCLIConfigPath
+	solanaCLIConfigPath = func() string { return path }
+	t.Cleanup(func() { solanaCLIConfigPath = orig })
+	return kp, keypairPath
+}
+
+func TestSolanaCLINetwork(t *testing.T) {
+	useTempConfig(t)
+
+	// Public endpoint: the built-in network, no RPC call needed
+	writeSolanaCLIConfig(t, "https://api.devnet.solana.com")
+	network, custom, err := solanaCLINetwork(context.Background(), detectSolanaCLI())
+	if err != nil || network != "devnet" || custom != nil {
+		t.Errorf("devnet: got %q, %+v, %v", network, custom, err)
+	}
+
+	// Private endpoint: identified by its genesis hash
+	rpc, server := newFakeRPC(t, map[string]interface{}{"getGenesisHash": solana.GenesisMainnet})
+	writeSolanaCLIConfig(t, server.URL)
+	network, custom, err = solanaCLINetwork(context.Background(), detectSolanaCLI())
+	if err != nil {
+		t.Fatalf("solanaCLINetwork failed: %v", err)
+	}
+	if !rpc.called("getGenesisHash") {
+		t.Error("private endpoint should be identified by genesis hash")
+	}
+	if network != config.SolanaCLINetwork || custom == nil || custom.Cluster != config.ClusterMainnet || custom.RPCURL != server.URL {
+		t.Fatalf("got %q, %+v", network, custom)
+	}
+
+	applyNetwork(network, custom)
+	config.Get().Network = network
+	if config.GetRPCURL() != server.URL || config.GetCluster() != config.ClusterMainnet {
+		t.Errorf("custom network not applied: %s on %s", config.GetRPCURL(), config.GetCluster())
+	}
+}
+
+func TestClusterForGenesis(t *testing.T) {
+	tests := map[string]string{
+		solana.GenesisMainnet: config.ClusterMainnet,
+		solana.GenesisDevnet:  config.ClusterDevnet,
+		solana.GenesisTestnet: config.ClusterTestnet,
+		"LocalGenesis111":     config.ClusterLocalnet,
+	}
+	for hash, want := range tests {
+		if got := clusterForGenesis(hash); got != want {
+			t.Errorf("clusterForGenesis(%s) = %s, want %s", hash, got, want)
+		}
+	}
+}
+
+func TestNonInteractiveSetup_SolanaCLINetwork(t *testing.T) {
+	useTempConfig(t)
+	_, server := newFakeRPC(t, map[string]interface{}{"getGenesisHash": "LocalGenesis111"})
+	kp, keypairPath := writeSolanaCLIConfig(t, server.URL)
+
+	t.Setenv("MACHPAY_ROLE", "agent")
+	t.Setenv("MACHPAY_NETWORK", config.SolanaCLINetwork)
+	t.Setenv("MACHPAY_WALLET_PATH", keypairPath)
+	if err := runNonInteractiveSetup(); err != nil {
+		t.Fatalf("runNonInteractiveSetup failed: %v", err)
+	}
+
+	cfg := config.Get()
+	if cfg.Network != config.SolanaCLINetwork || config.GetRPCURL() != server.URL || config.GetCluster() != config.ClusterLocalnet {
+		t.Errorf("network = %s (%s on %s)", cfg.Network, config.GetRPCURL(), config.GetCluster())
+	}
+	if cfg.Wallet.PublicKey != kp.PublicKeyBase58() || cfg.Wallet.KeypairPath != keypairPath {
+		t.Errorf("wallet = %+v, want linked Solana CLI keypair", cfg.Wallet)
+	}
+}
+
+func TestExplorerTxURL_Clusters(t *testing.T) {
+	useTempConfig(t)
+	config.Get().Networks = map[string]config.NetworkConfig{
+		"private": {RPCURL: "https://rpc.example.com", Cluster: config.ClusterMainnet},
+	}
+
+	tests := map[string]string{
+		"mainnet": "https://explorer.solana.com/tx/sig",
+		"private": "https://explorer.solana.com/tx/sig",
+		"devnet":  "https://explorer.solana.com/tx/sig?cluster=devnet",
+		"testnet": "https://explorer.solana.com/tx/sig?cluster=testnet",
+	}
+	for network, want := range tests {
+		if got := explorerTxURL("sig", network); got != want {
+			t.Errorf("explorerTxURL(%s) = %s, want %s", network, got, want)
+		}
+	}
+}
+
+func TestSolanaCLINetwork_StoppedLocalValidator(t *testing.T) {
+	useTempConfig(t)
+	writeSolanaCLIConfig(t, "http://127.0.0.1:1")
+
+	network, custom, err := solanaCLINetwork(context.Background(), detectSolanaCLI())
+	if err != nil {
+		t.Fatalf("solanaCLINetwork failed: %v", err)
+	}
+	if network != config.SolanaCLINetwork || custom.Cluster != config.ClusterLocalnet {
+		t.Errorf("got %q, %+v; want localnet", network, custom)
+	}
+}
+
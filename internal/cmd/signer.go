@@ -0,0 +1,320 @@
+// ============================================================
+// Signer Command - Local signing agent
+// ============================================================
+//
+// Usage:
+//   machpay signer start [--wallet <name>] [--policy prompt|auto|deny]
+//                        [--idle-timeout 15m]
+//   machpay signer status
+//   machpay signer lock
+//   machpay signer stop
+//
+// The agent holds the wallet key in memory behind a Unix socket
+// (~/.machpay/signer.sock). 'machpay tx sign' and the gateway use
+// it when it is running, so they never read the keypair file. The
+// agent refuses payments over the spending policy (machpay policy).
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/signer"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+var (
+	signerStartWallet  string
+	signerStartKeypair string
+	signerStartPolicy  string
+	signerStartIdle    time.Duration
+)
+
+var signerCmd = &cobra.Command{
+	Use:   "signer",
+	Short: "Run a local signing agent",
+	Long: `Run an ssh-agent style signing daemon.
+
+The agent loads the wallet key once and serves signatures over a
+Unix socket, so other commands and the gateway can sign without
+reading the keypair file. Set MACHPAY_SIGNER_SOCK to use a socket
+other than ~/.machpay/signer.sock.`,
+}
+
+var signerStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the signing agent in the foreground",
+	Long: `Start the signing agent in the foreground.
+
+Approval policies:
+  prompt  Ask in this terminal before every signature (default)
+  auto    Sign every request from processes that can reach the socket
+  deny    Serve the public key only
+
+Payments are checked against the spending policy before approval
+and refused when they would exceed it (see 'machpay policy').
+
+After --idle-timeout without a signature the key is wiped from
+memory; start the agent again to continue.
+
+Examples:
+  machpay signer start
+  machpay signer start --wallet payouts --policy auto --idle-timeout 1h`,
+	Args: cobra.NoArgs,
+	RunE: runSignerStart,
+}
+
+var signerStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show signing agent status",
+	Args:  cobra.NoArgs,
+	RunE:  runSignerStatus,
+}
+
+var signerLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Wipe the key from the running agent",
+	Args:  cobra.NoArgs,
+	RunE:  runSignerLock,
+}
+
+var signerStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the signing agent",
+	Args:  cobra.NoArgs,
+	RunE:  runSignerStop,
+}
+
+func init() {
+	signerStartCmd.Flags().StringVar(&signerStartWallet, "wallet", "", "Registered wallet to load (default: active wallet)")
+	signerStartCmd.Flags().StringVar(&signerStartKeypair, "keypair", "", "Keypair file to load")
+	signerStartCmd.Flags().StringVar(&signerStartPolicy, "policy", signer.PolicyPrompt, "Approval policy: prompt, auto or deny")
+	signerStartCmd.Flags().DurationVar(&signerStartIdle, "idle-timeout", 15*time.Minute, "Wipe the key after this long without signing (0 = never)")
+
+	signerCmd.AddCommand(signerStartCmd)
+	signerCmd.AddCommand(signerStatusCmd)
+	signerCmd.AddCommand(signerLockCmd)
+	signerCmd.AddCommand(signerStopCmd)
+	rootCmd.AddCommand(signerCmd)
+}
+
+// ============================================================
+// Signer Resolution
+// ============================================================
+
+// loadSigner returns the signer for commands that need signatures.
+// An explicit --keypair or --wallet always uses the file. Otherwise a
+// running agent is preferred when it holds the active wallet's key.
+// Either way payments are held to the spending policy: the agent
+// enforces it itself, file signers are wrapped in the guard.
+func loadSigner(walletName, keypairPath string) (wallet.Signer, error) {
+	if walletName == "" && keypairPath == "" {
+		if client := dialAgent(); client != nil {
+			active := config.Get().Wallet.PublicKey
+			if active == "" || active == wallet.SignerAddress(client) {
+				return client, nil
+			}
+			client.Close()
+			fmt.Println(tui.Muted("  Signing agent holds a different key; using the active wallet file"))
+		}
+	}
+
+	kp, err := loadSigningKeypair(walletName, keypairPath)
+	if err != nil {
+		return nil, err
+	}
+	return spendGuard().Signer(wallet.NewKeypairSigner(kp)), nil
+}
+
+// loadSigningKeypair loads a keypair by path, registry name, or the
+// active wallet, in that order of preference
+func loadSigningKeypair(walletName, keypairPath string) (*wallet.Keypair, error) {
+	if keypairPath != "" {
+		return wallet.LoadFromFile(keypairPath)
+	}
+
+	reg := wallet.NewRegistry(config.GetWalletsDir())
+	if walletName != "" {
+		return reg.Load(walletName)
+	}
+
+	cfg := config.Get()
+	if cfg.Wallet.KeypairPath == "" {
+		return nil, fmt.Errorf("no wallet configured: pass --wallet or --keypair")
+	}
+	return wallet.LoadFromFile(cfg.Wallet.KeypairPath)
+}
+
+// dialAgent connects to the signing agent, or returns nil if none is running
+func dialAgent() *signer.Client {
+	client, err := signer.Dial(config.GetSignerSocket())
+	if err != nil {
+		return nil
+	}
+	return client
+}
+
+// requireAgent connects to the signing agent or explains how to start one
+func requireAgent() (*signer.Client, error) {
+	client, err := signer.Dial(config.GetSignerSocket())
+	if err != nil {
+		tui.PrintError("Signing agent is not running")
+		fmt.Println(tui.Muted("  Start it with 'machpay signer start'"))
+		return nil, err
+	}
+	return client, nil
+}
+
+// ============================================================
+// Subcommands
+// ============================================================
+
+func runSignerStart(cmd *cobra.Command, args []string) error {
+	kp, err := loadSigningKeypair(signerStartWallet, signerStartKeypair)
+	if err != nil {
+		return err
+	}
+
+	agent, err := signer.NewAgent(kp, signer.Options{
+		Policy:      signerStartPolicy,
+		IdleTimeout: signerStartIdle,
+		Approve:     approveSignRequest,
+		Spend:       spendGuard(),
+		Logf: func(format string, args ...interface{}) {
+			fmt.Println(tui.Muted(time.Now().Format("15:04:05") + "  " + fmt.Sprintf(format, args...)))
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	socket := config.GetSignerSocket()
+	listener, err := signer.Listen(socket)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	tui.PrintSuccess("Signing agent started")
+	tui.PrintKeyValue("Address", kp.PublicKeyBase58())
+	tui.PrintKeyValue("Socket", socket)
+	tui.PrintKeyValue("Policy", signerStartPolicy)
+	if signerStartIdle > 0 {
+		tui.PrintKeyValue("Idle lock", signerStartIdle.String())
+	} else {
+		tui.PrintKeyValue("Idle lock", "disabled")
+	}
+	fmt.Println()
+	fmt.Println(tui.Muted("Press Ctrl+C to stop"))
+	fmt.Println()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	if err := agent.Serve(ctx, listener); err != nil {
+		return err
+	}
+
+	tui.PrintSuccess("Signing agent stopped")
+	return nil
+}
+
+// approveSignRequest asks in the agent's terminal whether to sign
+func approveSignRequest(req *signer.SignRequest) bool {
+	fmt.Println()
+	fmt.Println(tui.Bold("Signature requested"))
+	if len(req.Instructions) > 0 {
+		for i, ix := range req.Instructions {
+			fmt.Printf("  %d. %s\n", i+1, ix)
+		}
+	} else {
+		fmt.Printf("  %d-byte message (not a Solana transaction)\n", len(req.Message))
+	}
+	if req.Description != "" {
+		fmt.Println(tui.Muted("  Client says: " + req.Description))
+	}
+
+	confirmed, err := tui.Confirm("Sign?", false)
+	return err == nil && confirmed
+}
+
+func runSignerStatus(cmd *cobra.Command, args []string) error {
+	client, err := requireAgent()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	status, err := client.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	if status.Locked {
+		fmt.Println(tui.Warning("● Locked"))
+	} else {
+		tui.PrintSuccess("Unlocked")
+	}
+	tui.PrintKeyValue("Address", status.PublicKey)
+	tui.PrintKeyValue("Socket", config.GetSignerSocket())
+	tui.PrintKeyValue("Policy", status.Policy)
+	if status.IdleTimeout != "" {
+		tui.PrintKeyValue("Idle lock", status.IdleTimeout)
+	}
+	tui.PrintKeyValue("Signatures", fmt.Sprintf("%d (%d denied)", status.Signatures, status.Denied))
+	tui.PrintKeyValue("Started", status.StartedAt)
+	fmt.Println()
+
+	return nil
+}
+
+func runSignerLock(cmd *cobra.Command, args []string) error {
+	client, err := requireAgent()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Lock(); err != nil && !errors.Is(err, signer.ErrLocked) {
+		return err
+	}
+
+	tui.PrintSuccess("Signing agent locked")
+	return nil
+}
+
+func runSignerStop(cmd *cobra.Command, args []string) error {
+	client, err := requireAgent()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Stop(); err != nil {
+		return err
+	}
+
+	tui.PrintSuccess("Signing agent stopped")
+	return nil
+}
+
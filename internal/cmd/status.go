@@ -64,6 +64,7 @@ type StatusOutput struct {
 		Path    string `json:"config_path"`
 	} `json:"config"`
 	Wallet struct {
+		Name        string `json:"name,omitempty"`
 		Address     string `json:"address,omitempty"`
 		KeypairPath string `json:"keypair_path,omitempty"`
 	} `json:"wallet,omitempty"`
@@ -154,6 +155,7 @@ func gatherStatus() *StatusOutput {
 
 	// Wallet
 	if cfg.Wallet.PublicKey != "" {
+		status.Wallet.Name = cfg.Wallet.Name
 		status.Wallet.Address = cfg.Wallet.PublicKey
 		status.Wallet.KeypairPath = cfg.Wallet.KeypairPath
 	}
@@ -212,6 +214,9 @@ func outputHuman(status *StatusOutput) error {
 	// Wallet (if configured)
 	if status.Wallet.Address != "" {
 		fmt.Println(tui.Bold("Wallet"))
+		if status.Wallet.Name != "" {
+			fmt.Printf("  Name:    %s\n", status.Wallet.Name)
+		}
 		fmt.Printf("  Address: %s\n", tui.Primary(truncateAddress(status.Wallet.Address)))
 		// TODO: Add balance fetching
 		fmt.Println()
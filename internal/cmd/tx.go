@@ -0,0 +1,477 @@
+// ============================================================
+// Tx Command - Offline signing workflow
+// ============================================================
+//
+// Usage:
+//   machpay tx build --to <addr> --amount <n> [--token usdc|sol]
+//                    [--nonce-account <addr>] [-o unsigned.json]
+//   machpay tx sign <file> [--wallet <name>|--keypair <path>]
+//                   (uses the signing agent when it is running)
+//   machpay tx broadcast <file>
+//
+// Build and broadcast run on an online host. Sign never touches
+// the network, so it can run on an air-gapped machine holding
+// the payout key. Durable nonces keep a signed transaction valid
+// while it is carried between machines.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// usdcDecimals is the number of decimals of the USDC mint
+const usdcDecimals = 6
+
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Build, sign and broadcast transactions offline",
+	Long: `Three-step offline signing for keys that never touch the internet.
+
+  1. machpay tx build      (online)  writes an unsigned transaction file
+  2. machpay tx sign       (offline) signs it with a local keypair
+  3. machpay tx broadcast  (online)  submits the signed file
+
+Use --nonce-account with build so the transaction does not expire
+while it is carried between machines. Without it, the blockhash is
+only valid for about a minute.
+
+Examples:
+  machpay tx build --to <addr> --amount 25 --nonce-account <nonce> -o payout.json
+  machpay tx sign payout.json --wallet payouts
+  machpay tx broadcast payout.signed.json`,
+}
+
+var (
+	txBuildTo             string
+	txBuildAmount         string
+	txBuildToken          string
+	txBuildFrom           string
+	txBuildFeePayer       string
+	txBuildNonceAccount   string
+	txBuildNonceAuthority string
+	txBuildOutput         string
+
+	txSignWallet  string
+	txSignKeypair string
+	txSignOutput  string
+	txSignYes     bool
+
+	txBroadcastNoWait bool
+)
+
+var txBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build an unsigned transfer (online)",
+	Long: `Build an unsigned SOL or USDC transfer and write it to a file.
+
+Only public keys are needed; the signing key stays offline. The
+sender defaults to the active wallet's address.`,
+	Args: cobra.NoArgs,
+	RunE: runTxBuild,
+}
+
+var txSignCmd = &cobra.Command{
+	Use:   "sign <file>",
+	Short: "Sign a transaction file (offline)",
+	Long: `Review and sign a transaction file. Makes no network calls.
+
+The instructions shown are decoded from the encoded transaction
+itself, not from the file's summary fields.
+
+Without --wallet or --keypair, a running signing agent ('machpay
+signer start') that holds the active wallet's key signs instead of
+the keypair file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTxSign,
+}
+
+var txBroadcastCmd = &cobra.Command{
+	Use:   "broadcast <file>",
+	Short: "Submit a signed transaction file (online)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTxBroadcast,
+}
+
+func init() {
+	txBuildCmd.Flags().StringVar(&txBuildTo, "to", "", "Recipient address or @label (required)")
+	txBuildCmd.Flags().StringVar(&txBuildAmount, "amount", "", "Amount to send, e.g. 25 or 0.5 (required)")
+	txBuildCmd.Flags().StringVar(&txBuildToken, "token", "usdc", "Token to send: usdc or sol")
+	txBuildCmd.Flags().StringVar(&txBuildFrom, "from", "", "Sender address (default: active wallet)")
+	txBuildCmd.Flags().StringVar(&txBuildFeePayer, "fee-payer", "", "Fee payer address (default: sender)")
+	txBuildCmd.Flags().StringVar(&txBuildNonceAccount, "nonce-account", "", "Durable nonce account to use instead of a recent blockhash")
+	txBuildCmd.Flags().StringVar(&txBuildNonceAuthority, "nonce-authority", "", "Nonce authority (default: sender)")
+	txBuildCmd.Flags().StringVarP(&txBuildOutput, "output", "o", "unsigned-tx.json", "Output file")
+	txBuildCmd.MarkFlagRequired("to")
+	txBuildCmd.MarkFlagRequired("amount")
+
+	txSignCmd.Flags().StringVar(&txSignWallet, "wallet", "", "Registered wallet to sign with (default: active wallet)")
+	txSignCmd.Flags().StringVar(&txSignKeypair, "keypair", "", "Keypair file to sign with")
+	txSignCmd.Flags().StringVarP(&txSignOutput, "output", "o", "", "Output file (default: <file>.signed.json)")
+	txSignCmd.Flags().BoolVarP(&txSignYes, "yes", "y", false, "Sign without confirmation")
+
+	txBroadcastCmd.Flags().BoolVar(&txBroadcastNoWait, "no-wait", false, "Do not wait for confirmation")
+
+	txCmd.AddCommand(txBuildCmd)
+	txCmd.AddCommand(txSignCmd)
+	txCmd.AddCommand(txBroadcastCmd)
+	rootCmd.AddCommand(txCmd)
+}
+
+// ============================================================
+// tx build
+// ============================================================
+
+// transferParams describes a transfer to build
+type transferParams struct {
+	From           string
+	To             string
+	FeePayer       string
+	Amount         string
+	Token          string // "usdc" or "sol"
+	Mint           string
+	NonceAccount   string
+	NonceAuthority string
+}
+
+func runTxBuild(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+
+	params := transferParams{
+		From:           txBuildFrom,
+		To:             txBuildTo,
+		FeePayer:       txBuildFeePayer,
+		Amount:         txBuildAmount,
+		Token:          strings.ToLower(txBuildToken),
+		Mint:           config.GetUSDCMint(),
+		NonceAccount:   txBuildNonceAccount,
+		NonceAuthority: txBuildNonceAuthority,
+	}
+	for _, addr := range []*string{&params.From, &params.To, &params.FeePayer, &params.NonceAccount, &params.NonceAuthority} {
+		resolved, err := resolveAddress(*addr)
+		if err != nil {
+			return err
+		}
+		*addr = resolved
+	}
+	if params.From == "" {
+		params.From = cfg.Wallet.PublicKey
+	}
+	if params.From == "" {
+		return fmt.Errorf("no sender: pass --from or configure a wallet")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rpc := solana.NewClient(config.GetRPCURL())
+	txFile, err := buildTransfer(ctx, rpc, cfg.Network, params)
+	if err != nil {
+		return err
+	}
+
+	if err := txFile.Save(txBuildOutput); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	tui.PrintSuccess("Unsigned transaction written")
+	tui.PrintKeyValue("File", txBuildOutput)
+	tui.PrintKeyValue("Transfer", txFile.Description)
+	tui.PrintKeyValue("Signers", strings.Join(txFile.Signers, ", "))
+	if txFile.NonceAccount != "" {
+		tui.PrintKeyValue("Expires", "never (durable nonce "+truncateAddress(txFile.NonceAccount)+")")
+	} else {
+		tui.PrintKeyValue("Expires", fmt.Sprintf("block height %d (~1 minute)", txFile.LastValidBlockHeight))
+		fmt.Println(tui.Muted("  Use --nonce-account for air-gapped signing"))
+	}
+	fmt.Println()
+	fmt.Printf("  Next: %s\n", tui.Primary("machpay tx sign "+txBuildOutput))
+	fmt.Println()
+
+	return nil
+}
+
+// buildTransfer creates the unsigned transaction file for a transfer
+func buildTransfer(ctx context.Context, rpc *solana.Client, network string, p transferParams) (*solana.TxFile, error) {
+	from, err := solana.ParsePublicKey(p.From)
+	if err != nil {
+		return nil, fmt.Errorf("sender: %w", err)
+	}
+	to, err := solana.ParsePublicKey(p.To)
+	if err != nil {
+		return nil, fmt.Errorf("recipient: %w", err)
+	}
+	feePayer := from
+	if p.FeePayer != "" {
+		if feePayer, err = solana.ParsePublicKey(p.FeePayer); err != nil {
+			return nil, fmt.Errorf("fee payer: %w", err)
+		}
+	}
+
+	var instructions []solana.Instruction
+	var blockhash solana.Hash
+	var lastValid uint64
+
+	// Durable nonce: the advance instruction must come first
+	if p.NonceAccount != "" {
+		nonceAccount, err := solana.ParsePublicKey(p.NonceAccount)
+		if err != nil {
+			return nil, fmt.Errorf("nonce account: %w", err)
+		}
+		authority := from
+		if p.NonceAuthority != "" {
+			if authority, err = solana.ParsePublicKey(p.NonceAuthority); err != nil {
+				return nil, fmt.Errorf("nonce authority: %w", err)
+			}
+		}
+
+		nonce, err := rpc.GetNonceAccount(ctx, p.NonceAccount)
+		if err != nil {
+			return nil, fmt.Errorf("fetch nonce: %w", err)
+		}
+		if nonce.Authority != authority {
+			return nil, fmt.Errorf("nonce authority is %s, not %s", nonce.Authority, authority)
+		}
+
+		blockhash = nonce.Nonce
+		instructions = append(instructions, solana.AdvanceNonceAccount(nonceAccount, authority))
+	} else {
+		if blockhash, lastValid, err = rpc.GetLatestBlockhash(ctx); err != nil {
+			return nil, fmt.Errorf("fetch blockhash: %w", err)
+		}
+	}
+
+	var description string
+	switch p.Token {
+	case "sol":
+		lamports, err := solana.ParseTokenAmount(p.Amount, 9)
+		if err != nil || lamports == 0 {
+			return nil, fmt.Errorf("invalid amount %q", p.Amount)
+		}
+		instructions = append(instructions, solana.SystemTransfer(from, to, lamports))
+		description = fmt.Sprintf("%s SOL to %s", solana.FormatSOL(lamports), to)
+
+	case "usdc":
+		amount, err := solana.ParseTokenAmount(p.Amount, usdcDecimals)
+		if err != nil || amount == 0 {
+			return nil, fmt.Errorf("invalid amount %q", p.Amount)
+		}
+		mint, err := solana.ParsePublicKey(p.Mint)
+		if err != nil {
+			return nil, fmt.Errorf("mint: %w", err)
+		}
+		source, err := solana.AssociatedTokenAddress(from, mint, solana.TokenProgramID)
+		if err != nil {
+			return nil, err
+		}
+		destination, err := solana.AssociatedTokenAddress(to, mint, solana.TokenProgramID)
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions,
+			solana.CreateAssociatedTokenAccountIdempotent(feePayer, destination, to, mint, solana.TokenProgramID),
+			solana.TokenTransferChecked(solana.TokenProgramID, source, mint, destination, from, amount, usdcDecimals),
+		)
+		description = fmt.Sprintf("%s USDC to %s", solana.FormatTokenAmount(amount, usdcDecimals), to)
+
+	default:
+		return nil, fmt.Errorf("unknown token %q (use usdc or sol)", p.Token)
+	}
+
+	msg, err := solana.NewMessage(feePayer, instructions, blockhash)
+	if err != nil {
+		return nil, err
+	}
+
+	return solana.NewTxFile(network, description, solana.NewTransaction(msg), lastValid), nil
+}
+
+// ============================================================
+// tx sign
+// ============================================================
+
+func runTxSign(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	txFile, tx, err := solana.LoadTxFile(path)
+	if err != nil {
+		return err
+	}
+
+	s, err := loadSigner(txSignWallet, txSignKeypair)
+	if err != nil {
+		return err
+	}
+
+	printTxReview(txFile, tx)
+
+	if !txSignYes {
+		confirmed, err := tui.Confirm(fmt.Sprintf("Sign as %s?", wallet.SignerAddress(s)), false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println(tui.Muted("Not signed."))
+			return nil
+		}
+	}
+
+	if err := signTxFile(txFile, tx, s); err != nil {
+		return err
+	}
+
+	output := txSignOutput
+	if output == "" {
+		output = signedFileName(path)
+	}
+	if err := txFile.Save(output); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	tui.PrintSuccess("Transaction signed")
+	tui.PrintKeyValue("File", output)
+	if missing := tx.MissingSigners(); len(missing) > 0 {
+		tui.PrintWarning(fmt.Sprintf("Still needs %d signature(s)", len(missing)))
+		for _, key := range missing {
+			fmt.Printf("    %s\n", key)
+		}
+	} else {
+		fmt.Printf("  Next: %s\n", tui.Primary("machpay tx broadcast "+output))
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// signTxFile adds s's signature to the transaction and file
+func signTxFile(txFile *solana.TxFile, tx *solana.Transaction, s wallet.Signer) error {
+	var key solana.PublicKey
+	copy(key[:], s.PublicKey())
+
+	sig, err := s.Sign(tx.Message.Serialize())
+	if err != nil {
+		return fmt.Errorf("sign: %w", err)
+	}
+	if err := tx.AddSignature(key, sig); err != nil {
+		return err
+	}
+	txFile.SetTransaction(tx)
+	return nil
+}
+
+func printTxReview(txFile *solana.TxFile, tx *solana.Transaction) {
+	fmt.Println()
+	fmt.Println(tui.Bold("Transaction Review"))
+	fmt.Println()
+	tui.PrintKeyValue("Network", txFile.Network)
+	tui.PrintKeyValue("Fee payer", txFile.FeePayer)
+	if txFile.NonceAccount != "" {
+		tui.PrintKeyValue("Nonce", txFile.NonceAccount)
+	} else {
+		tui.PrintKeyValue("Blockhash", txFile.Blockhash)
+		fmt.Println(tui.Warning("  ⚠ Uses a recent blockhash: broadcast within ~1 minute of building"))
+	}
+
+	fmt.Println()
+	fmt.Println(tui.Bold("Instructions"))
+	for i, ix := range solana.DecodeInstructions(&tx.Message) {
+		fmt.Printf("  %d. %s\n", i+1, ix.Description)
+	}
+}
+
+// signedFileName derives "x.signed.json" from "x.json"
+func signedFileName(path string) string {
+	base := strings.TrimSuffix(path, ".json")
+	base = strings.TrimSuffix(base, ".unsigned")
+	if strings.HasSuffix(base, "unsigned-tx") {
+		return strings.TrimSuffix(base, "unsigned-tx") + "signed-tx.json"
+	}
+	return base + ".signed.json"
+}
+
+// ============================================================
+// tx broadcast
+// ============================================================
+
+func runTxBroadcast(cmd *cobra.Command, args []string) error {
+	txFile, tx, err := solana.LoadTxFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	if txFile.Network != "" && txFile.Network != config.Get().Network {
+		return fmt.Errorf("transaction was built for %s but the active network is %s",
+			txFile.Network, config.Get().Network)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	rpc := solana.NewClient(config.GetRPCURL())
+	signature, err := broadcastTx(ctx, rpc, tx, !txBroadcastNoWait)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	if txBroadcastNoWait {
+		tui.PrintSuccess("Transaction submitted")
+	} else {
+		tui.PrintSuccess("Transaction confirmed")
+	}
+	tui.PrintKeyValue("Signature", signature)
+	tui.PrintKeyValue("Explorer", explorerTxURL(signature, txFile.Network))
+	fmt.Println()
+
+	return nil
+}
+
+// broadcastTx submits a fully signed transaction and optionally waits
+// for confirmation
+func broadcastTx(ctx context.Context, rpc *solana.Client, tx *solana.Transaction, wait bool) (string, error) {
+	if missing := tx.MissingSigners(); len(missing) > 0 {
+		return "", fmt.Errorf("transaction is missing %d signature(s), first: %s", len(missing), missing[0])
+	}
+
+	signature, err := rpc.SendTransaction(ctx, tx)
+	if err != nil {
+		return "", fmt.Errorf("send transaction: %w", err)
+	}
+
+	if wait {
+		fmt.Println(tui.Muted("  Waiting for confirmation..."))
+		if _, err := rpc.WaitForConfirmation(ctx, signature, airdropPollInterval); err != nil {
+			return signature, fmt.Errorf("confirm %s: %w", signature, err)
+		}
+	}
+
+	return signature, nil
+}
+
+// explorerTxURL returns the Solana Explorer link for a transaction
+func explorerTxURL(signature, network string) string {
+	url := "https://explorer.solana.com/tx/" + signature
+	switch cluster := config.ClusterOf(network); cluster {
+	case config.ClusterMainnet:
+	case config.ClusterLocalnet:
+		url += "?cluster=custom"
+	default:
+		url += "?cluster=" + cluster
+	}
+	return url
+}
+
@@ -0,0 +1,224 @@
+// ============================================================
+// Tx Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/signer"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+const (
+	testRecipient = "B8UwBUUnKwCyKuGMbFKWaG7exYdDk2ozZrPg72NyVbfj"
+	testBlockhash = "EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N"
+	testDevnetUSD = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+)
+
+// nonceAccountInfo encodes an initialized nonce account for getAccountInfo
+func nonceAccountInfo(authority solana.PublicKey, nonce solana.Hash) map[string]interface{} {
+	data := make([]byte, 80)
+	binary.LittleEndian.PutUint32(data[4:8], 1)
+	copy(data[8:40], authority[:])
+	copy(data[40:72], nonce[:])
+	return map[string]interface{}{
+		"value": map[string]interface{}{
+			"data":  []string{base64.StdEncoding.EncodeToString(data), "base64"},
+			"owner": solana.SystemProgramID.String(),
+		},
+	}
+}
+
+func TestTxBuildSignBroadcast(t *testing.T) {
+	airdropPollInterval = 10 * time.Millisecond
+
+	kp, _ := wallet.Generate()
+	f, server := newFakeRPC(t, map[string]interface{}{
+		"getLatestBlockhash": map[string]interface{}{
+			"value": map[string]interface{}{"blockhash": testBlockhash, "lastValidBlockHeight": 1234},
+		},
+		"sendTransaction": "txsig",
+		"getSignatureStatuses": map[string]interface{}{
+			"value": []interface{}{map[string]interface{}{"slot": 1, "err": nil, "confirmationStatus": "confirmed"}},
+		},
+	})
+	rpc := solana.NewClient(server.URL)
+
+	txFile, err := buildTransfer(context.Background(), rpc, "devnet", transferParams{
+		From:   kp.PublicKeyBase58(),
+		To:     testRecipient,
+		Amount: "2.5",
+		Token:  "usdc",
+		Mint:   testDevnetUSD,
+	})
+	if err != nil {
+		t.Fatalf("buildTransfer failed: %v", err)
+	}
+	if txFile.LastValidBlockHeight != 1234 || txFile.Blockhash != testBlockhash {
+		t.Errorf("blockhash = %s@%d", txFile.Blockhash, txFile.LastValidBlockHeight)
+	}
+	if len(txFile.Instructions) != 2 || !strings.Contains(txFile.Instructions[1], "transfer 2.5") {
+		t.Errorf("Instructions = %v", txFile.Instructions)
+	}
+
+	// Round trip through disk, as between the online and offline hosts
+	path := filepath.Join(t.TempDir(), "unsigned-tx.json")
+	if err := txFile.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, tx, err := solana.LoadTxFile(path)
+	if err != nil {
+		t.Fatalf("LoadTxFile failed: %v", err)
+	}
+
+	if _, err := broadcastTx(context.Background(), rpc, tx, false); err == nil {
+		t.Error("broadcast of unsigned transaction should fail")
+	}
+	if f.called("sendTransaction") {
+		t.Error("unsigned transaction should not be sent")
+	}
+
+	if err := signTxFile(loaded, tx, wallet.NewKeypairSigner(kp)); err != nil {
+		t.Fatalf("signTxFile failed: %v", err)
+	}
+	signedPath := signedFileName(path)
+	if err := loaded.Save(signedPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	_, signed, err := solana.LoadTxFile(signedPath)
+	if err != nil {
+		t.Fatalf("LoadTxFile(signed) failed: %v", err)
+	}
+
+	sig, err := broadcastTx(context.Background(), rpc, signed, true)
+	if err != nil {
+		t.Fatalf("broadcastTx failed: %v", err)
+	}
+	if sig != "txsig" {
+		t.Errorf("signature = %q, want txsig", sig)
+	}
+	if !f.called("getSignatureStatuses") {
+		t.Error("expected confirmation polling")
+	}
+}
+
+func TestTxBuild_DurableNonce(t *testing.T) {
+	kp, _ := wallet.Generate()
+	authority, _ := solana.ParsePublicKey(kp.PublicKeyBase58())
+	nonceValue, _ := solana.ParseHash(testBlockhash)
+	nonceAccount := "SysvarC1ock11111111111111111111111111111111"
+
+	f, server := newFakeRPC(t, map[string]interface{}{
+		"getAccountInfo": nonceAccountInfo(authority, nonceValue),
+	})
+	rpc := solana.NewClient(server.URL)
+
+	txFile, err := buildTransfer(context.Background(), rpc, "devnet", transferParams{
+		From:         kp.PublicKeyBase58(),
+		To:           testRecipient,
+		Amount:       "0.5",
+		Token:        "sol",
+		NonceAccount: nonceAccount,
+	})
+	if err != nil {
+		t.Fatalf("buildTransfer failed: %v", err)
+	}
+	if f.called("getLatestBlockhash") {
+		t.Error("durable nonce build should not fetch a blockhash")
+	}
+	if txFile.NonceAccount != nonceAccount || txFile.Blockhash != testBlockhash {
+		t.Errorf("nonce = %s, blockhash = %s", txFile.NonceAccount, txFile.Blockhash)
+	}
+	if !strings.HasPrefix(txFile.Instructions[0], "System: advance nonce") {
+		t.Errorf("first instruction = %q", txFile.Instructions[0])
+	}
+
+	// A nonce owned by someone else is refused
+	_, err = buildTransfer(context.Background(), rpc, "devnet", transferParams{
+		From:           kp.PublicKeyBase58(),
+		To:             testRecipient,
+		Amount:         "0.5",
+		Token:          "sol",
+		NonceAccount:   nonceAccount,
+		NonceAuthority: testRecipient,
+	})
+	if err == nil || !strings.Contains(err.Error(), "nonce authority") {
+		t.Errorf("err = %v, want nonce authority mismatch", err)
+	}
+}
+
+func TestSignedFileName(t *testing.T) {
+	tests := map[string]string{
+		"unsigned-tx.json":     "signed-tx.json",
+		"out/unsigned-tx.json": "out/signed-tx.json",
+		"payout.json":          "payout.signed.json",
+		"payout.unsigned.json": "payout.signed.json",
+	}
+	for in, want := range tests {
+		if got := signedFileName(in); got != want {
+			t.Errorf("signedFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLoadSigner_PrefersAgent(t *testing.T) {
+	useTempConfig(t)
+	t.Setenv("MACHPAY_SIGNER_SOCK", filepath.Join(t.TempDir(), "s.sock"))
+
+	// Active wallet on disk
+	reg := wallet.NewRegistry(config.GetWalletsDir())
+	fileKey, _ := wallet.Generate()
+	info, err := reg.Add("default", fileKey, wallet.SourceGenerated)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	activateWallet(info)
+
+	// No agent: the file is used
+	s, err := loadSigner("", "")
+	if err != nil {
+		t.Fatalf("loadSigner failed: %v", err)
+	}
+	if _, ok := s.(*signer.Client); ok || wallet.SignerAddress(s) != fileKey.PublicKeyBase58() {
+		t.Errorf("signer = %T %s, want file signer without agent", s, wallet.SignerAddress(s))
+	}
+
+	// Agent holding the active key takes over
+	agent, _ := signer.NewAgent(fileKey, signer.Options{Policy: signer.PolicyAuto})
+	l, err := signer.Listen(config.GetSignerSocket())
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go agent.Serve(context.Background(), l)
+	t.Cleanup(agent.Stop)
+
+	s, err = loadSigner("", "")
+	if err != nil {
+		t.Fatalf("loadSigner failed: %v", err)
+	}
+	client, ok := s.(*signer.Client)
+	if !ok {
+		t.Fatalf("signer = %T, want agent client", s)
+	}
+	client.Close()
+
+	// An explicit --keypair bypasses the agent
+	s, err = loadSigner("", info.Path)
+	if err != nil {
+		t.Fatalf("loadSigner failed: %v", err)
+	}
+	if _, ok := s.(*signer.Client); ok || wallet.SignerAddress(s) != fileKey.PublicKeyBase58() {
+		t.Errorf("signer = %T %s, want file signer with --keypair", s, wallet.SignerAddress(s))
+	}
+}
+
@@ -0,0 +1,122 @@
+// ============================================================
+// Wallet Command - Wallet utilities
+// ============================================================
+//
+// Usage: machpay wallet <subcommand>
+//
+// Subcommands:
+//   ata       Derive an associated token account (offline)
+//   airdrop   Fund the wallet on devnet
+//   list, add, use, rename, remove   Named wallets (wallet_manage.go)
+//   backup, restore                  Shamir recovery shares (wallet_backup.go)
+//   vanity                           Custom address search (wallet_vanity.go)
+//   history                          SOL and USDC transfers (wallet_history.go)
+//   fund                             Solana Pay request and QR code (wallet_fund.go)
+//
+// ============================================================
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+var walletCmd = &cobra.Command{
+	Use:   "wallet",
+	Short: "Manage your wallet",
+	Long: `Wallet utilities for your MachPay keypair.
+
+Examples:
+  machpay wallet ata                 # USDC token account for your wallet
+  machpay wallet ata --owner <addr>  # Token account for another owner
+  machpay wallet airdrop --sol 2     # Devnet SOL
+  machpay wallet list                # Registered wallets
+  machpay wallet use payouts         # Switch the active wallet
+  machpay wallet backup              # Split the key into recovery shares
+  machpay wallet history --since 7d  # Recent transfers
+  machpay wallet fund --qr           # Solana Pay QR code to fund the wallet`,
+}
+
+// ============================================================
+// wallet ata
+// ============================================================
+
+var (
+	walletATAOwner     string
+	walletATAMint      string
+	walletATAToken2022 bool
+)
+
+var walletATACmd = &cobra.Command{
+	Use:   "ata",
+	Short: "Derive an associated token account address",
+	Long: `Derive the associated token account (ATA) for an owner and mint.
+
+Runs fully offline. Defaults to your configured wallet and the USDC
+mint for the active network, which gives your USDC deposit address.
+
+Examples:
+  machpay wallet ata
+  machpay wallet ata --owner 7o36UsWR1JQLpZ9PE2gn9L4SQ69CNNiWAXd4Jt7rqz9Z
+  machpay wallet ata --mint EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v`,
+	Args: cobra.NoArgs,
+	RunE: runWalletATA,
+}
+
+func init() {
+	walletATACmd.Flags().StringVar(&walletATAOwner, "owner", "", "Owner address or @label (default: configured wallet)")
+	walletATACmd.Flags().StringVar(&walletATAMint, "mint", "", "Token mint (default: USDC for the active network)")
+	walletATACmd.Flags().BoolVar(&walletATAToken2022, "token-2022", false, "Derive under the Token-2022 program")
+
+	walletCmd.AddCommand(walletATACmd)
+	rootCmd.AddCommand(walletCmd)
+}
+
+func runWalletATA(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+
+	owner, err := resolveAddress(walletATAOwner)
+	if err != nil {
+		return err
+	}
+	if owner == "" {
+		owner = cfg.Wallet.PublicKey
+	}
+	if owner == "" {
+		tui.PrintError("No wallet configured")
+		fmt.Println(tui.Muted("  Run 'machpay setup' or pass --owner"))
+		return fmt.Errorf("no owner address")
+	}
+
+	mint := walletATAMint
+	if mint == "" {
+		mint = config.GetUSDCMint()
+	}
+
+	tokenProgram := wallet.TokenProgramID
+	if walletATAToken2022 {
+		tokenProgram = wallet.Token2022ProgramID
+	}
+
+	ata, bump, err := wallet.FindAssociatedTokenAddress(owner, mint, tokenProgram)
+	if err != nil {
+		return fmt.Errorf("derive token account: %w", err)
+	}
+
+	fmt.Println()
+	tui.PrintKeyValue("Owner", owner)
+	tui.PrintKeyValue("Mint", mint)
+	tui.PrintKeyValue("Program", tokenProgram)
+	tui.PrintKeyValue("ATA", ata)
+	tui.PrintKeyValue("Bump", fmt.Sprintf("%d", bump))
+	fmt.Println()
+
+	return nil
+}
+
@@ -0,0 +1,201 @@
+// ============================================================
+// Wallet Airdrop - Devnet SOL and USDC funding
+// ============================================================
+//
+// Usage: machpay wallet airdrop [--sol N] [--usdc N]
+//
+// Requests SOL via the RPC requestAirdrop method, waits for
+// confirmation and shows the new balance. If a USDC faucet is
+// configured (faucet.usdc_url), also requests devnet USDC.
+//
+// Refuses to run on mainnet.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+const (
+	// maxAirdropSOL is the largest airdrop devnet will usually grant
+	maxAirdropSOL = 5.0
+
+	// circleFaucetURL is shown when no USDC faucet is configured
+	circleFaucetURL = "https://faucet.circle.com"
+)
+
+var (
+	walletAirdropSOL  float64
+	walletAirdropUSDC float64
+
+	// airdropPollInterval is how often confirmation is polled
+	airdropPollInterval = 2 * time.Second
+
+	// airdropTimeout bounds the wait for each confirmation
+	airdropTimeout = 60 * time.Second
+)
+
+var walletAirdropCmd = &cobra.Command{
+	Use:   "airdrop",
+	Short: "Fund your wallet on devnet",
+	Long: `Request devnet SOL (and optionally USDC) for your wallet.
+
+SOL comes from the RPC node's airdrop. USDC requires a faucet
+endpoint in your config:
+
+  faucet:
+    usdc_url: https://faucet.example.com/usdc
+
+This command refuses to run when the active network is mainnet.
+
+Examples:
+  machpay wallet airdrop             # 1 SOL
+  machpay wallet airdrop --sol 2
+  machpay wallet airdrop --usdc 10   # Also request 10 devnet USDC`,
+	Args: cobra.NoArgs,
+	RunE: runWalletAirdrop,
+}
+
+func init() {
+	walletAirdropCmd.Flags().Float64Var(&walletAirdropSOL, "sol", 1, "Amount of SOL to request")
+	walletAirdropCmd.Flags().Float64Var(&walletAirdropUSDC, "usdc", 0, "Amount of devnet USDC to request from the faucet")
+
+	walletCmd.AddCommand(walletAirdropCmd)
+}
+
+func runWalletAirdrop(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+
+	if config.GetCluster() == config.ClusterMainnet {
+		tui.PrintError("Airdrops are not available on mainnet")
+		fmt.Println(tui.Muted("  Switch to devnet with 'machpay setup'"))
+		return fmt.Errorf("refusing to airdrop on mainnet")
+	}
+
+	if cfg.Wallet.PublicKey == "" {
+		tui.PrintError("No wallet configured")
+		fmt.Println(tui.Muted("  Run 'machpay setup' first"))
+		return fmt.Errorf("no wallet configured")
+	}
+
+	if walletAirdropSOL < 0 || walletAirdropSOL > maxAirdropSOL {
+		return fmt.Errorf("--sol must be between 0 and %g", maxAirdropSOL)
+	}
+	if walletAirdropUSDC < 0 {
+		return fmt.Errorf("--usdc must not be negative")
+	}
+
+	// Handle Ctrl+C
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	rpc := solana.NewClient(config.GetRPCURL())
+
+	var faucet *solana.FaucetClient
+	if cfg.Faucet.USDCURL != "" {
+		faucet = solana.NewFaucetClient(cfg.Faucet.USDCURL)
+	}
+
+	return airdrop(ctx, rpc, faucet, cfg.Wallet.PublicKey, walletAirdropSOL, walletAirdropUSDC)
+}
+
+// airdrop funds address with SOL and, if a faucet is available, USDC
+func airdrop(ctx context.Context, rpc *solana.Client, faucet *solana.FaucetClient, address string, sol, usdc float64) error {
+	fmt.Println()
+
+	if sol > 0 {
+		fmt.Printf("Requesting %s SOL for %s...\n", tui.Primary(fmt.Sprintf("%g", sol)), truncateAddress(address))
+
+		signature, err := rpc.RequestAirdrop(ctx, address, solana.SOLToLamports(sol))
+		if err != nil {
+			return fmt.Errorf("request airdrop: %w", err)
+		}
+		tui.PrintKeyValue("Signature", signature)
+
+		if err := waitForAirdrop(ctx, rpc, signature); err != nil {
+			return err
+		}
+
+		lamports, err := rpc.GetBalance(ctx, address)
+		if err != nil {
+			return fmt.Errorf("get balance: %w", err)
+		}
+
+		fmt.Println()
+		tui.PrintSuccess("Airdrop confirmed")
+		tui.PrintKeyValue("Balance", solana.FormatSOL(lamports)+" SOL")
+	}
+
+	if usdc > 0 {
+		fmt.Println()
+		if faucet == nil {
+			tui.PrintWarning("No USDC faucet configured")
+			fmt.Printf("  Get devnet USDC at %s\n", tui.Primary(circleFaucetURL))
+			fmt.Println(tui.Muted("  Or set faucet.usdc_url in your config"))
+			return nil
+		}
+
+		fmt.Printf("Requesting %s USDC from faucet...\n", tui.Primary(fmt.Sprintf("%g", usdc)))
+
+		signature, err := faucet.Request(ctx, address, usdc)
+		if err != nil {
+			return fmt.Errorf("request USDC: %w", err)
+		}
+		tui.PrintKeyValue("Signature", signature)
+
+		if err := waitForAirdrop(ctx, rpc, signature); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		tui.PrintSuccess("USDC received")
+
+		ata, _, err := wallet.FindAssociatedTokenAddress(address, config.GetUSDCMint(), wallet.TokenProgramID)
+		if err == nil {
+			if balance, err := rpc.GetTokenAccountBalance(ctx, ata); err == nil {
+				tui.PrintKeyValue("USDC", balance.UIAmountString)
+			}
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
+
+func waitForAirdrop(ctx context.Context, rpc *solana.Client, signature string) error {
+	fmt.Println(tui.Muted("  Waiting for confirmation..."))
+
+	ctx, cancel := context.WithTimeout(ctx, airdropTimeout)
+	defer cancel()
+
+	if _, err := rpc.WaitForConfirmation(ctx, signature, airdropPollInterval); err != nil {
+		return fmt.Errorf("confirm %s: %w", truncateAddress(signature), err)
+	}
+	return nil
+}
+
@@ -0,0 +1,339 @@
+// ============================================================
+// Wallet Backup - Shamir secret-sharing backup and restore
+// ============================================================
+//
+// Usage:
+//   machpay wallet backup [--shares 5] [--threshold 3] [--format words|base58]
+//   machpay wallet restore [--share <share>]... [--wallet <name>]
+//                          [--output <path>] [--name <name>]
+//
+// Backup splits a wallet's seed into shares; any threshold of them
+// restore it. Restore checks the recovered address against the active
+// wallet (or --wallet), then matches it to a registered wallet before
+// writing anything, or adds an unknown key to the registry.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+var (
+	walletBackupShares    int
+	walletBackupThreshold int
+	walletBackupFormat    string
+	walletBackupWallet    string
+
+	walletRestoreShares []string
+	walletRestoreWallet string
+	walletRestoreOutput string
+	walletRestoreName   string
+)
+
+var walletBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Split the wallet key into recovery shares",
+	Long: `Split the wallet's private key into Shamir secret shares.
+
+Any --threshold of the --shares shares restore the key; fewer reveal
+nothing about it. Store each share in a different place (paper in a
+safe, a password manager, a trusted person).
+
+Shares are printed as 30 English words or as base58. Both carry a
+checksum and the share number, so typos are caught at restore time.
+
+Examples:
+  machpay wallet backup
+  machpay wallet backup --shares 3 --threshold 2 --format base58`,
+	Args: cobra.NoArgs,
+	RunE: runWalletBackup,
+}
+
+var walletRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Recover the wallet key from backup shares",
+	Long: `Recombine backup shares and restore the wallet key.
+
+The recovered address is checked against the active wallet, and a
+mismatch is reported, before it is matched to the registered wallet it
+belongs to (with --wallet, it must match that wallet instead). If that wallet's keypair file is missing
+it is rewritten; if it is present the shares are only verified. Use
+--output to write elsewhere.
+
+A key that is not registered, such as one restored on a new machine,
+is added to the wallet registry under --name (asked for when
+omitted), unless --output writes it to a file instead.
+
+Examples:
+  machpay wallet restore
+  machpay wallet restore --wallet trading
+  machpay wallet restore --name recovered
+  machpay wallet restore --share "<words>" --share "<words>" --output restored.json`,
+	Args: cobra.NoArgs,
+	RunE: runWalletRestore,
+}
+
+func init() {
+	walletBackupCmd.Flags().IntVar(&walletBackupShares, "shares", 5, "Number of shares to create")
+	walletBackupCmd.Flags().IntVar(&walletBackupThreshold, "threshold", 3, "Shares needed to restore")
+	walletBackupCmd.Flags().StringVar(&walletBackupFormat, "format", "words", "Share format: words or base58")
+	walletBackupCmd.Flags().StringVar(&walletBackupWallet, "wallet", "", "Registered wallet to back up (default: active wallet)")
+
+	walletRestoreCmd.Flags().StringArrayVar(&walletRestoreShares, "share", nil, "Share to combine (repeat; prompts when omitted)")
+	walletRestoreCmd.Flags().StringVar(&walletRestoreWallet, "wallet", "", "Registered wallet the shares belong to (default: found by address)")
+	walletRestoreCmd.Flags().StringVarP(&walletRestoreOutput, "output", "o", "", "Write the restored keypair to this file")
+	walletRestoreCmd.Flags().StringVar(&walletRestoreName, "name", "", "Name to register an unknown key under")
+
+	walletCmd.AddCommand(walletBackupCmd)
+	walletCmd.AddCommand(walletRestoreCmd)
+}
+
+func runWalletBackup(cmd *cobra.Command, args []string) error {
+	if walletBackupFormat != "words" && walletBackupFormat != "base58" {
+		return fmt.Errorf("unknown format %q (use words or base58)", walletBackupFormat)
+	}
+
+	kp, err := loadSigningKeypair(walletBackupWallet, "")
+	if err != nil {
+		return err
+	}
+
+	shares, err := splitAndVerify(kp, walletBackupShares, walletBackupThreshold)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println(tui.Bold("Wallet Backup"))
+	fmt.Println()
+	tui.PrintKeyValue("Address", kp.PublicKeyBase58())
+	tui.PrintKeyValue("Shares", fmt.Sprintf("%d (any %d restore the key)", len(shares), walletBackupThreshold))
+	fmt.Println()
+	fmt.Println(tui.Warning("⚠️  Each share is secret. Store them separately; never together."))
+
+	for _, s := range shares {
+		fmt.Println()
+		fmt.Println(tui.Bold(fmt.Sprintf("Share %d of %d", s.Index, len(shares))))
+		if walletBackupFormat == "base58" {
+			fmt.Println("  " + s.Base58())
+		} else {
+			printShareWords(s.Mnemonic())
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(tui.Muted("  Check your copies with 'machpay wallet restore'"))
+	fmt.Println()
+
+	return nil
+}
+
+// splitAndVerify splits kp and checks that the first threshold
+// shares recombine to the same key before any are shown
+func splitAndVerify(kp *wallet.Keypair, n, threshold int) ([]*wallet.Share, error) {
+	shares, err := wallet.SplitSeed(kp, n, threshold)
+	if err != nil {
+		return nil, err
+	}
+	check, err := wallet.CombineShares(shares[:threshold])
+	if err != nil || check.PublicKeyBase58() != kp.PublicKeyBase58() {
+		return nil, fmt.Errorf("backup self-check failed; no shares were produced")
+	}
+	return shares, nil
+}
+
+// printShareWords prints a mnemonic as numbered rows of six words
+func printShareWords(mnemonic string) {
+	words := strings.Fields(mnemonic)
+	for i := 0; i < len(words); i += 6 {
+		fmt.Print("  ")
+		for j := i; j < i+6 && j < len(words); j++ {
+			fmt.Printf("%2d. %-10s", j+1, words[j])
+		}
+		fmt.Println()
+	}
+}
+
+func runWalletRestore(cmd *cobra.Command, args []string) error {
+	reg, err := walletRegistry()
+	if err != nil {
+		return err
+	}
+	shares, err := collectShares(walletRestoreShares)
+	if err != nil {
+		return err
+	}
+
+	kp, err := wallet.CombineShares(shares)
+	if err != nil {
+		return err
+	}
+
+	// Without --wallet the shares are expected to be the active
+	// wallet's; other keys are still looked up in the registry
+	name := walletRestoreWallet
+	active := config.Get().Wallet
+	fmt.Println()
+	if name == "" && active.PublicKey != "" {
+		if active.PublicKey == kp.PublicKeyBase58() {
+			name = active.Name
+		} else {
+			tui.PrintWarning(fmt.Sprintf("The restored key does not match the active wallet (%s)", active.PublicKey))
+		}
+	}
+
+	info, err := restoredWallet(reg, kp, name)
+	if err != nil {
+		tui.PrintError(err.Error())
+		return fmt.Errorf("restore aborted")
+	}
+
+	if info != nil && info.PublicKey == active.PublicKey {
+		tui.PrintSuccess(fmt.Sprintf("Shares match the active wallet %q", info.Name))
+	} else if info != nil {
+		tui.PrintSuccess(fmt.Sprintf("Shares match wallet %q", info.Name))
+	} else {
+		tui.PrintWarning("The restored key is not a registered wallet")
+	}
+	tui.PrintKeyValue("Address", kp.PublicKeyBase58())
+
+	if info == nil && walletRestoreOutput == "" {
+		return registerRestored(reg, kp, walletRestoreName)
+	}
+
+	output := walletRestoreOutput
+	if output == "" {
+		if _, err := os.Stat(info.Path); os.IsNotExist(err) {
+			output = info.Path
+		}
+	}
+	if output == "" {
+		fmt.Println(tui.Muted("  Keypair file already present; nothing written"))
+		fmt.Println(tui.Muted("  Use --output <path> to write a copy"))
+		return nil
+	}
+
+	if _, err := os.Stat(output); err == nil {
+		return fmt.Errorf("%s already exists", output)
+	}
+	if err := kp.SaveToFile(output); err != nil {
+		return err
+	}
+	tui.PrintKeyValue("Saved to", output)
+	fmt.Println()
+
+	return nil
+}
+
+// restoredWallet returns the registered wallet a recovered key belongs
+// to: the named one, which must have its address, or the one found by
+// address. It returns nil for a key that is not registered.
+func restoredWallet(reg *wallet.Registry, kp *wallet.Keypair, name string) (*wallet.WalletInfo, error) {
+	if name != "" {
+		info, err := reg.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		if info.PublicKey != kp.PublicKeyBase58() {
+			return nil, fmt.Errorf("restored address %s does not match wallet %q (%s)",
+				kp.PublicKeyBase58(), info.Name, info.PublicKey)
+		}
+		return info, nil
+	}
+
+	info, err := reg.FindByPublicKey(kp.PublicKeyBase58())
+	if errors.Is(err, wallet.ErrWalletNotFound) {
+		return nil, nil
+	}
+	return info, err
+}
+
+// registerRestored adds a restored key to the registry under name,
+// asking for one when it is empty
+func registerRestored(reg *wallet.Registry, kp *wallet.Keypair, name string) error {
+	if name == "" {
+		add, err := tui.Confirm("Add it to your wallets?", true)
+		if err != nil {
+			return err
+		}
+		if !add {
+			fmt.Println(tui.Muted("  Nothing written; use --output <path> to save the keypair"))
+			return nil
+		}
+		suggested, err := reg.UniqueName("restored")
+		if err != nil {
+			return err
+		}
+		if name, err = tui.TextInputOptional("Wallet name", suggested); err != nil {
+			return err
+		}
+	}
+
+	info, err := reg.Add(name, kp, wallet.SourceImported)
+	if err != nil {
+		return err
+	}
+	tui.PrintSuccess(fmt.Sprintf("Added wallet %q", info.Name))
+	tui.PrintKeyValue("Saved to", info.Path)
+	fmt.Println(tui.Muted("  Make it active with 'machpay wallet use " + info.Name + "'"))
+	fmt.Println()
+	return nil
+}
+
+// collectShares parses shares from flags, or prompts until the
+// threshold recorded in the first share is reached
+func collectShares(texts []string) ([]*wallet.Share, error) {
+	var shares []*wallet.Share
+	for i, text := range texts {
+		s, err := wallet.ParseShare(text)
+		if err != nil {
+			return nil, fmt.Errorf("share %d: %w", i+1, err)
+		}
+		shares = append(shares, s)
+	}
+	if len(texts) > 0 {
+		return shares, nil
+	}
+
+	for {
+		question := "Enter a share (words or base58)"
+		if len(shares) > 0 {
+			question = fmt.Sprintf("Enter share %d of %d", len(shares)+1, shares[0].Threshold)
+		}
+
+		var parsed *wallet.Share
+		if _, err := tui.TextInput(question, "", func(input string) error {
+			s, err := wallet.ParseShare(input)
+			if err != nil {
+				return err
+			}
+			for _, prev := range shares {
+				if prev.Index == s.Index {
+					return fmt.Errorf("share %d was already entered", s.Index)
+				}
+			}
+			parsed = s
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		shares = append(shares, parsed)
+		tui.PrintSuccess(fmt.Sprintf("Share %d accepted", parsed.Index))
+		if len(shares) >= int(shares[0].Threshold) {
+			return shares, nil
+		}
+	}
+}
+
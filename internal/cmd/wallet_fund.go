@@ -0,0 +1,217 @@
+// ============================================================
+// Wallet Fund - Solana Pay funding requests
+// ============================================================
+//
+// Usage: machpay wallet fund [--amount N] [--qr] [--wait]
+//
+// Prints a Solana Pay transfer request for USDC into the active
+// wallet, optionally as a terminal QR code for a phone wallet to
+// scan. Each request carries a fresh reference key; --wait polls
+// for a transaction with that key and confirms the payment.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/qr"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+)
+
+var (
+	walletFundAmount  string
+	walletFundQR      bool
+	walletFundWait    bool
+	walletFundLabel   string
+	walletFundMessage string
+	walletFundTimeout time.Duration
+
+	// fundPollInterval is how often --wait checks for the payment
+	fundPollInterval = 2 * time.Second
+)
+
+var walletFundCmd = &cobra.Command{
+	Use:   "fund",
+	Short: "Request USDC with a Solana Pay link or QR code",
+	Long: `Create a Solana Pay request for USDC into your wallet.
+
+Any Solana Pay wallet (Phantom, Solflare, ...) can open the link or
+scan the QR code. Without --amount the payer chooses the amount.
+
+--wait watches for the payment using the request's reference key
+and prints the confirmation once it lands.
+
+Examples:
+  machpay wallet fund --amount 25 --qr
+  machpay wallet fund --amount 10 --qr --wait
+  machpay wallet fund --label "Agent budget"`,
+	Args: cobra.NoArgs,
+	RunE: runWalletFund,
+}
+
+func init() {
+	walletFundCmd.Flags().StringVar(&walletFundAmount, "amount", "", "USDC amount to request (default: payer chooses)")
+	walletFundCmd.Flags().BoolVar(&walletFundQR, "qr", false, "Show the request as a QR code")
+	walletFundCmd.Flags().BoolVar(&walletFundWait, "wait", false, "Wait for the payment and confirm it")
+	walletFundCmd.Flags().StringVar(&walletFundLabel, "label", "MachPay", "Requester name shown by the paying wallet")
+	walletFundCmd.Flags().StringVar(&walletFundMessage, "message", "", "Payment description shown by the paying wallet")
+	walletFundCmd.Flags().DurationVar(&walletFundTimeout, "timeout", 10*time.Minute, "How long --wait waits for the payment")
+
+	walletCmd.AddCommand(walletFundCmd)
+}
+
+func runWalletFund(cmd *cobra.Command, args []string) error {
+	address := config.Get().Wallet.PublicKey
+	if address == "" {
+		tui.PrintError("No wallet configured")
+		fmt.Println(tui.Muted("  Run 'machpay setup' first"))
+		return fmt.Errorf("no wallet configured")
+	}
+
+	req, err := newFundingRequest(address, config.GetUSDCMint(), walletFundAmount, walletFundLabel, walletFundMessage)
+	if err != nil {
+		return err
+	}
+	link := req.URL()
+
+	fmt.Println()
+	if walletFundQR {
+		code, err := qr.Encode([]byte(link), qr.M)
+		if err != nil {
+			return fmt.Errorf("encode QR code: %w", err)
+		}
+		fmt.Print(code.Terminal())
+		fmt.Println()
+	}
+
+	amount := "any amount of"
+	if req.Amount != "" {
+		amount = req.Amount
+	}
+	tui.PrintInfo(fmt.Sprintf("Requesting %s USDC on %s", tui.Primary(amount), config.Get().Network))
+	tui.PrintKeyValue("Recipient", address)
+	tui.PrintKeyValue("Reference", req.References[0])
+	tui.PrintKeyValue("Link", link)
+	fmt.Println()
+
+	if !walletFundWait {
+		if !walletFundQR {
+			fmt.Println(tui.Muted("  Add --qr to show a scannable code, --wait to watch for the payment"))
+			fmt.Println()
+		}
+		return nil
+	}
+
+	// Handle Ctrl+C
+	ctx, cancel := context.WithTimeout(context.Background(), walletFundTimeout)
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	fmt.Println(tui.Muted("  Waiting for payment (Ctrl+C to stop)..."))
+	rpc := solana.NewClient(config.GetRPCURL())
+	signature, received, err := waitForFunding(ctx, rpc, req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	tui.PrintSuccess(fmt.Sprintf("Received %s USDC", received.AmountString()))
+	tui.PrintKeyValue("From", received.Counterparty)
+	tui.PrintKeyValue("Signature", signature)
+	tui.PrintKeyValue("Explorer", explorerTxURL(signature, config.Get().Network))
+	fmt.Println()
+	return nil
+}
+
+// newFundingRequest builds a USDC transfer request to address with a
+// fresh reference key
+func newFundingRequest(address, usdcMint, amount, label, message string) (*solana.TransferRequest, error) {
+	if amount != "" {
+		raw, err := solana.ParseTokenAmount(amount, usdcDecimals)
+		if err != nil {
+			return nil, fmt.Errorf("--amount: %w", err)
+		}
+		if raw == 0 {
+			return nil, fmt.Errorf("--amount must be greater than zero")
+		}
+		amount = solana.FormatTokenAmount(raw, usdcDecimals)
+	}
+
+	reference, err := solana.NewReference()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &solana.TransferRequest{
+		Recipient:  address,
+		Amount:     amount,
+		SPLToken:   usdcMint,
+		References: []string{reference},
+		Label:      label,
+		Message:    message,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// waitForFunding polls for a transaction carrying the request's
+// reference and validates that it pays the request
+func waitForFunding(ctx context.Context, rpc *solana.Client, req *solana.TransferRequest) (string, *solana.Transfer, error) {
+	ticker := time.NewTicker(fundPollInterval)
+	defer ticker.Stop()
+
+	for {
+		sig, err := rpc.FindReference(ctx, req.References[0])
+		switch {
+		case err == nil:
+			tx, err := rpc.GetTransaction(ctx, sig.Signature)
+			if err != nil {
+				return "", nil, fmt.Errorf("get transaction %s: %w", sig.Signature, err)
+			}
+			// The node can list the signature before serving the transaction
+			if tx != nil {
+				received, err := solana.ValidateTransfer(sig.Signature, tx, req)
+				if err != nil {
+					return "", nil, err
+				}
+				return sig.Signature, received, nil
+			}
+		case !errors.Is(err, solana.ErrReferenceNotFound) && ctx.Err() == nil:
+			return "", nil, fmt.Errorf("find payment: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", nil, fmt.Errorf("no payment received within %s", walletFundTimeout)
+			}
+			return "", nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
@@ -0,0 +1,364 @@
+// ============================================================
+// Wallet History - Incoming and outgoing transfers
+// ============================================================
+//
+// Usage:
+//   machpay wallet history [--limit 25] [--before <sig>]
+//                          [--since <time>] [--until <time>]
+//                          [--json | --csv]
+//
+// Merges the signatures of the wallet and its USDC token account
+// (incoming token transfers only reference the token account),
+// fetches each transaction and decodes SOL and USDC transfers.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+var (
+	walletHistoryLimit   int
+	walletHistoryBefore  string
+	walletHistorySince   string
+	walletHistoryUntil   string
+	walletHistoryJSON    bool
+	walletHistoryCSV     bool
+	walletHistoryAddress string
+)
+
+// Paging limits for history scans
+const (
+	historyPageSize = 100
+	historyMaxScan  = 1000
+)
+
+var walletHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show SOL and USDC transfers for the wallet",
+	Long: `List transfers into and out of the wallet, newest first.
+
+--since and --until accept a date (2025-01-31), an RFC 3339 time, or
+a duration back from now (24h, 7d). Use --before with the cursor
+printed at the end of a page to see older transfers.
+
+Examples:
+  machpay wallet history
+  machpay wallet history --since 7d --csv > week.csv
+  machpay wallet history --address <addr> --json`,
+	Args: cobra.NoArgs,
+	RunE: runWalletHistory,
+}
+
+func init() {
+	walletHistoryCmd.Flags().IntVar(&walletHistoryLimit, "limit", 25, "Maximum transfers to show")
+	walletHistoryCmd.Flags().StringVar(&walletHistoryBefore, "before", "", "Only show transfers older than this signature")
+	walletHistoryCmd.Flags().StringVar(&walletHistorySince, "since", "", "Only show transfers at or after this time")
+	walletHistoryCmd.Flags().StringVar(&walletHistoryUntil, "until", "", "Only show transfers before this time")
+	walletHistoryCmd.Flags().BoolVar(&walletHistoryJSON, "json", false, "Output as JSON")
+	walletHistoryCmd.Flags().BoolVar(&walletHistoryCSV, "csv", false, "Output as CSV")
+	walletHistoryCmd.Flags().StringVar(&walletHistoryAddress, "address", "", "Address or @label to inspect (default: active wallet)")
+
+	walletCmd.AddCommand(walletHistoryCmd)
+}
+
+// historyOptions bounds a history query
+type historyOptions struct {
+	Limit  int
+	Before string
+	Since  time.Time // zero = unbounded
+	Until  time.Time // zero = unbounded
+}
+
+// historyPage is one page of decoded transfers
+type historyPage struct {
+	Address   string            `json:"address"`
+	Transfers []solana.Transfer `json:"transfers"`
+	Next      string            `json:"next_before,omitempty"` // pass as --before for the next page
+	Labels    map[string]string `json:"labels,omitempty"`      // address book labels of counterparties
+}
+
+func runWalletHistory(cmd *cobra.Command, args []string) error {
+	if walletHistoryJSON && walletHistoryCSV {
+		return fmt.Errorf("--json and --csv are mutually exclusive")
+	}
+
+	address, err := resolveAddress(walletHistoryAddress)
+	if err != nil {
+		return err
+	}
+	if address == "" {
+		address = config.Get().Wallet.PublicKey
+	}
+	if address == "" {
+		return fmt.Errorf("no wallet configured: run 'machpay setup' or pass --address")
+	}
+	if _, err := wallet.ParsePublicKey(address); err != nil {
+		return fmt.Errorf("invalid address: %w", err)
+	}
+
+	now := time.Now()
+	opts := historyOptions{Limit: walletHistoryLimit, Before: walletHistoryBefore}
+	if opts.Since, err = parseHistoryTime(walletHistorySince, now); err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	if opts.Until, err = parseHistoryTime(walletHistoryUntil, now); err != nil {
+		return fmt.Errorf("--until: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	rpc := solana.NewClient(config.GetRPCURL())
+	page, err := fetchHistory(ctx, rpc, address, config.GetUSDCMint(), opts)
+	if err != nil {
+		return err
+	}
+	labelCounterparties(page, addressLabels())
+
+	switch {
+	case walletHistoryJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(page)
+	case walletHistoryCSV:
+		return writeHistoryCSV(page)
+	}
+
+	printHistoryTable(page)
+	return nil
+}
+
+// fetchHistory collects up to opts.Limit transfers for address,
+// newest first
+func fetchHistory(ctx context.Context, rpc *solana.Client, address, usdcMint string, opts historyOptions) (*historyPage, error) {
+	tokens := map[string]string{usdcMint: "USDC"}
+
+	streams := []*signatureStream{{rpc: rpc, address: address, before: opts.Before}}
+	if ata, _, err := wallet.FindAssociatedTokenAddress(address, usdcMint, wallet.TokenProgramID); err == nil {
+		streams = append(streams, &signatureStream{rpc: rpc, address: ata, before: opts.Before})
+	}
+
+	page := &historyPage{Address: address, Transfers: []solana.Transfer{}}
+	seen := make(map[string]bool)
+	scanned := 0
+	last := ""
+
+	for {
+		sig, err := nextSignature(ctx, streams)
+		if err != nil {
+			return nil, err
+		}
+		if sig == nil {
+			return page, nil
+		}
+		if seen[sig.Signature] {
+			continue
+		}
+		seen[sig.Signature] = true
+
+		if scanned >= historyMaxScan || (opts.Limit > 0 && len(page.Transfers) >= opts.Limit) {
+			page.Next = last
+			return page, nil
+		}
+		scanned++
+		last = sig.Signature
+
+		// Signatures are newest first: skip until --until, stop at --since
+		blockTime := sig.Time()
+		if !opts.Until.IsZero() && !blockTime.IsZero() && !blockTime.Before(opts.Until) {
+			continue
+		}
+		if !opts.Since.IsZero() && !blockTime.IsZero() && blockTime.Before(opts.Since) {
+			return page, nil
+		}
+		if sig.Err != nil {
+			continue
+		}
+
+		tx, err := rpc.GetTransaction(ctx, sig.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("get transaction %s: %w", sig.Signature, err)
+		}
+		page.Transfers = append(page.Transfers, solana.DecodeTransfers(sig.Signature, tx, address, tokens)...)
+	}
+}
+
+// labelCounterparties records the labels of saved counterparties
+func labelCounterparties(page *historyPage, labels map[string]string) {
+	for _, t := range page.Transfers {
+		if label, ok := labels[t.Counterparty]; ok {
+			if page.Labels == nil {
+				page.Labels = make(map[string]string)
+			}
+			page.Labels[t.Counterparty] = label
+		}
+	}
+}
+
+// signatureStream pages lazily through one address's signatures
+type signatureStream struct {
+	rpc     *solana.Client
+	address string
+	before  string
+	buf     []solana.SignatureInfo
+	done    bool
+}
+
+func (s *signatureStream) peek(ctx context.Context) (*solana.SignatureInfo, error) {
+	if len(s.buf) == 0 && !s.done {
+		sigs, err := s.rpc.GetSignaturesForAddress(ctx, s.address, solana.SignaturesOptions{
+			Limit:  historyPageSize,
+			Before: s.before,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get signatures for %s: %w", s.address, err)
+		}
+		s.buf = sigs
+		if len(sigs) < historyPageSize {
+			s.done = true
+		}
+		if len(sigs) > 0 {
+			s.before = sigs[len(sigs)-1].Signature
+		}
+	}
+	if len(s.buf) == 0 {
+		return nil, nil
+	}
+	return &s.buf[0], nil
+}
+
+// nextSignature pops the newest signature across all streams
+func nextSignature(ctx context.Context, streams []*signatureStream) (*solana.SignatureInfo, error) {
+	var best *signatureStream
+	var bestSig *solana.SignatureInfo
+	for _, s := range streams {
+		sig, err := s.peek(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if sig != nil && (bestSig == nil || sig.Slot > bestSig.Slot) {
+			best, bestSig = s, sig
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	best.buf = best.buf[1:]
+	return bestSig, nil
+}
+
+// parseHistoryTime parses a date, RFC 3339 time, or a duration back
+// from now ("24h", "7d"). Empty input returns the zero time.
+func parseHistoryTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use 2025-01-31, RFC 3339, or 24h/7d)", s)
+}
+
+// ============================================================
+// Output
+// ============================================================
+
+func signedAmount(t *solana.Transfer) string {
+	switch t.Direction {
+	case solana.DirectionIn:
+		return "+" + t.AmountString()
+	case solana.DirectionOut:
+		return "-" + t.AmountString()
+	}
+	return t.AmountString()
+}
+
+func formatHistoryTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func printHistoryTable(page *historyPage) {
+	fmt.Println()
+	if len(page.Transfers) == 0 {
+		fmt.Println(tui.Muted("No transfers found"))
+		fmt.Println()
+		return
+	}
+
+	fmt.Printf("  %-16s  %16s  %-6s  %-14s  %s\n",
+		tui.Bold("TIME"), tui.Bold("AMOUNT"), tui.Bold("ASSET"), tui.Bold("COUNTERPARTY"), tui.Bold("SIGNATURE"))
+
+	for i := range page.Transfers {
+		t := &page.Transfers[i]
+		amount := fmt.Sprintf("%16s", signedAmount(t))
+		switch t.Direction {
+		case solana.DirectionIn:
+			amount = tui.Success(amount)
+		case solana.DirectionOut:
+			amount = tui.Warning(amount)
+		}
+		fmt.Printf("  %-16s  %s  %-6s  %-14s  %s\n",
+			formatHistoryTime(t.Time), amount, t.Asset, counterpartyName(page, t.Counterparty), tui.Muted(truncateAddress(t.Signature)))
+	}
+
+	fmt.Println()
+	if page.Next != "" {
+		fmt.Println(tui.Muted("  More: machpay wallet history --before " + page.Next))
+		fmt.Println()
+	}
+}
+
+// counterpartyName is the counterparty's @label, or its short address
+func counterpartyName(page *historyPage, address string) string {
+	if label, ok := page.Labels[address]; ok {
+		return "@" + label
+	}
+	return truncateAddress(address)
+}
+
+func writeHistoryCSV(page *historyPage) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"time", "direction", "asset", "amount", "counterparty", "signature", "counterparty_label"})
+	for i := range page.Transfers {
+		t := &page.Transfers[i]
+		ts := ""
+		if !t.Time.IsZero() {
+			ts = t.Time.Format(time.RFC3339)
+		}
+		w.Write([]string{ts, t.Direction, t.Asset, signedAmount(t), t.Counterparty, t.Signature, page.Labels[t.Counterparty]})
+	}
+	w.Flush()
+	return w.Error()
+}
+
@@ -0,0 +1,333 @@
+// ============================================================
+// Wallet Management - Named wallets and the active selector
+// ============================================================
+//
+// Usage:
+//   machpay wallet list
+//   machpay wallet add <name> [--import <path>] [--link]
+//   machpay wallet use <name>
+//   machpay wallet rename <old> <new>
+//   machpay wallet remove <name> [--yes]
+//
+// Wallets live in ~/.machpay/wallets. The config's wallet.name
+// selects the active one.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+var (
+	walletAddImport string
+	walletAddLink   bool
+	walletRemoveYes bool
+)
+
+var walletListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered wallets",
+	Args:  cobra.NoArgs,
+	RunE:  runWalletList,
+}
+
+var walletAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Generate or import a named wallet",
+	Long: `Add a wallet to the registry.
+
+Without flags a new keypair is generated. With --import the keypair
+is copied into ~/.machpay/wallets; add --link to reference the file
+in place instead of copying it.
+
+Examples:
+  machpay wallet add payouts
+  machpay wallet add laptop --import ~/.config/solana/id.json
+  machpay wallet add laptop --import ~/.config/solana/id.json --link`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWalletAdd,
+}
+
+var walletUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Set the active wallet",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWalletUse,
+}
+
+var walletRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a wallet",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runWalletRename,
+}
+
+var walletRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a wallet from the registry",
+	Long: `Remove a wallet from the registry.
+
+The keypair file is deleted for generated and imported wallets.
+Linked wallets are only unregistered; their file is left in place.
+The active wallet cannot be removed; switch with 'machpay wallet use'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWalletRemove,
+}
+
+func init() {
+	walletAddCmd.Flags().StringVar(&walletAddImport, "import", "", "Import a key file (Solana CLI JSON, base58, hex or recovery phrase)")
+	walletAddCmd.Flags().BoolVar(&walletAddLink, "link", false, "Reference the imported file instead of copying it")
+	walletRemoveCmd.Flags().BoolVarP(&walletRemoveYes, "yes", "y", false, "Skip confirmation")
+
+	walletCmd.AddCommand(walletListCmd)
+	walletCmd.AddCommand(walletAddCmd)
+	walletCmd.AddCommand(walletUseCmd)
+	walletCmd.AddCommand(walletRenameCmd)
+	walletCmd.AddCommand(walletRemoveCmd)
+}
+
+// ============================================================
+// Registry Helpers
+// ============================================================
+
+// walletRegistry returns the registry and migrates a pre-registry
+// wallet (config.wallet.keypair_path without a name) into it
+func walletRegistry() (*wallet.Registry, error) {
+	reg := wallet.NewRegistry(config.GetWalletsDir())
+
+	cfg := config.Get()
+	if cfg.Wallet.Name != "" || cfg.Wallet.KeypairPath == "" {
+		return reg, nil
+	}
+
+	// Legacy single wallet: link it in place so nothing is copied or lost
+	info, err := reg.FindByPublicKey(cfg.Wallet.PublicKey)
+	if err != nil {
+		name, err := reg.UniqueName("default")
+		if err != nil {
+			return nil, err
+		}
+		info, err = reg.Link(name, cfg.Wallet.KeypairPath)
+		if err != nil {
+			return nil, fmt.Errorf("register existing wallet: %w", err)
+		}
+	}
+
+	activateWallet(info)
+	if err := config.Save(); err != nil {
+		return nil, fmt.Errorf("save config: %w", err)
+	}
+	return reg, nil
+}
+
+// registerImported adds an imported keypair to the registry. With
+// link set, Solana CLI files are referenced in place; other formats
+// are always stored as a converted copy so that keypair_path only
+// ever points at a Solana CLI keypair.
+func registerImported(reg *wallet.Registry, name, path string, kp *wallet.Keypair, format wallet.KeyFormat, link bool) (*wallet.WalletInfo, error) {
+	if link && format == wallet.FormatJSON {
+		return reg.Link(name, path)
+	}
+	if link {
+		tui.PrintInfo(fmt.Sprintf("%s is a %s, not a Solana CLI keypair; storing a converted copy",
+			path, strings.ToLower(format.Description())))
+	}
+	return reg.Add(name, kp, wallet.SourceImported)
+}
+
+// activateWallet points the config at a registered wallet
+func activateWallet(info *wallet.WalletInfo) {
+	cfg := config.Get()
+	cfg.Wallet.Name = info.Name
+	cfg.Wallet.KeypairPath = info.Path
+	cfg.Wallet.PublicKey = info.PublicKey
+}
+
+// ============================================================
+// Subcommands
+// ============================================================
+
+func runWalletList(cmd *cobra.Command, args []string) error {
+	reg, err := walletRegistry()
+	if err != nil {
+		return err
+	}
+
+	wallets, err := reg.List()
+	if err != nil {
+		return err
+	}
+
+	if len(wallets) == 0 {
+		fmt.Println(tui.Muted("No wallets registered"))
+		fmt.Println(tui.Muted("  Run 'machpay wallet add <name>' to create one"))
+		return nil
+	}
+
+	active := config.Get().Wallet.Name
+
+	fmt.Println()
+	for _, w := range wallets {
+		marker := "  "
+		name := fmt.Sprintf("%-20s", w.Name)
+		if w.Name == active {
+			marker = tui.Success("●") + " "
+			name = tui.Bold(name)
+		}
+		fmt.Printf("%s%s %s  %s\n", marker, name, tui.Primary(w.PublicKey), tui.Muted(w.Source))
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func runWalletAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	reg, err := walletRegistry()
+	if err != nil {
+		return err
+	}
+
+	var info *wallet.WalletInfo
+	switch {
+	case walletAddImport != "":
+		kp, format, loadErr := wallet.LoadKeyFile(walletAddImport)
+		if loadErr != nil {
+			return fmt.Errorf("load keypair: %w", loadErr)
+		}
+		info, err = registerImported(reg, name, walletAddImport, kp, format, walletAddLink)
+	case walletAddLink:
+		return fmt.Errorf("--link requires --import <path>")
+	default:
+		kp, genErr := wallet.Generate()
+		if genErr != nil {
+			return fmt.Errorf("generate wallet: %w", genErr)
+		}
+		info, err = reg.Add(name, kp, wallet.SourceGenerated)
+	}
+	if err != nil {
+		return err
+	}
+
+	tui.PrintSuccess(fmt.Sprintf("Added wallet %s", tui.Bold(info.Name)))
+	tui.PrintKeyValue("Address", info.PublicKey)
+	tui.PrintKeyValue("Keypair", info.Path)
+
+	// First wallet becomes active automatically
+	if config.Get().Wallet.Name == "" {
+		activateWallet(info)
+		if err := config.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+		fmt.Println(tui.Muted("  Set as active wallet"))
+	} else {
+		fmt.Println(tui.Muted(fmt.Sprintf("  Run 'machpay wallet use %s' to make it active", info.Name)))
+	}
+
+	if info.Source == wallet.SourceGenerated {
+		fmt.Println()
+		fmt.Println(tui.Warning("⚠️  BACKUP THIS FILE! It contains your private key."))
+		fmt.Println(tui.Muted("  Run 'machpay wallet backup' to split it into recovery shares"))
+	}
+
+	return nil
+}
+
+func runWalletUse(cmd *cobra.Command, args []string) error {
+	reg, err := walletRegistry()
+	if err != nil {
+		return err
+	}
+
+	info, err := reg.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	activateWallet(info)
+	if err := config.Save(); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+
+	tui.PrintSuccess(fmt.Sprintf("Active wallet: %s", tui.Bold(info.Name)))
+	tui.PrintKeyValue("Address", info.PublicKey)
+	return nil
+}
+
+func runWalletRename(cmd *cobra.Command, args []string) error {
+	oldName, newName := args[0], args[1]
+
+	reg, err := walletRegistry()
+	if err != nil {
+		return err
+	}
+
+	info, err := reg.Rename(oldName, newName)
+	if err != nil {
+		return err
+	}
+
+	if config.Get().Wallet.Name == oldName {
+		activateWallet(info)
+		if err := config.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+	}
+
+	tui.PrintSuccess(fmt.Sprintf("Renamed %s to %s", oldName, tui.Bold(newName)))
+	return nil
+}
+
+func runWalletRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	reg, err := walletRegistry()
+	if err != nil {
+		return err
+	}
+
+	info, err := reg.Get(name)
+	if err != nil {
+		return err
+	}
+
+	if config.Get().Wallet.Name == name {
+		tui.PrintError(fmt.Sprintf("%s is the active wallet", name))
+		fmt.Println(tui.Muted("  Switch first with 'machpay wallet use <other>'"))
+		return fmt.Errorf("cannot remove active wallet")
+	}
+
+	if !walletRemoveYes {
+		question := fmt.Sprintf("Delete wallet %s (%s)? The private key cannot be recovered.",
+			name, truncateAddress(info.PublicKey))
+		if info.Linked() {
+			question = fmt.Sprintf("Unregister linked wallet %s? %s is kept.", name, info.Path)
+		}
+		confirmed, err := tui.Confirm(question, false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println(tui.Muted("Cancelled."))
+			return nil
+		}
+	}
+
+	if err := reg.Remove(name); err != nil {
+		return err
+	}
+
+	tui.PrintSuccess(fmt.Sprintf("Removed wallet %s", name))
+	return nil
+}
+
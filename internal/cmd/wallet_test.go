@@ -0,0 +1,661 @@
+// ============================================================
+// Wallet Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// fakeRPC is a minimal Solana JSON-RPC stand-in that records calls
+type fakeRPC struct {
+	mu      sync.Mutex
+	calls   []string
+	results map[string]interface{}
+}
+
+func newFakeRPC(t *testing.T, results map[string]interface{}) (*fakeRPC, *httptest.Server) {
+	t.Helper()
+	f := &fakeRPC{results: results}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		f.mu.Lock()
+		f.calls = append(f.calls, req.Method)
+		result, ok := f.results[req.Method]
+		f.mu.Unlock()
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if ok {
+			resp["result"] = result
+		} else {
+			resp["error"] = map[string]interface{}{"code": -32601, "message": "Method not found"}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeRPC) called(method string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.calls {
+		if c == method {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAirdrop(t *testing.T) {
+	airdropPollInterval = 10 * time.Millisecond
+
+	f, server := newFakeRPC(t, map[string]interface{}{
+		"requestAirdrop": "airdropsig",
+		"getSignatureStatuses": map[string]interface{}{
+			"value": []interface{}{map[string]interface{}{"slot": 1, "err": nil, "confirmationStatus": "confirmed"}},
+		},
+		"getBalance": map[string]interface{}{"value": 2000000000},
+	})
+
+	rpc := solana.NewClient(server.URL)
+	err := airdrop(context.Background(), rpc, nil, "B8UwBUUnKwCyKuGMbFKWaG7exYdDk2ozZrPg72NyVbfj", 2, 0)
+	if err != nil {
+		t.Fatalf("airdrop failed: %v", err)
+	}
+
+	for _, method := range []string{"requestAirdrop", "getSignatureStatuses", "getBalance"} {
+		if !f.called(method) {
+			t.Errorf("expected RPC call %s", method)
+		}
+	}
+}
+
+func TestAirdrop_USDCFaucet(t *testing.T) {
+	airdropPollInterval = 10 * time.Millisecond
+
+	f, server := newFakeRPC(t, map[string]interface{}{
+		"getSignatureStatuses": map[string]interface{}{
+			"value": []interface{}{map[string]interface{}{"slot": 1, "err": nil, "confirmationStatus": "finalized"}},
+		},
+		"getTokenAccountBalance": map[string]interface{}{
+			"value": map[string]interface{}{"amount": "10000000", "decimals": 6, "uiAmountString": "10"},
+		},
+	})
+
+	faucetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"signature": "faucetsig"})
+	}))
+	defer faucetServer.Close()
+
+	rpc := solana.NewClient(server.URL)
+	faucet := solana.NewFaucetClient(faucetServer.URL)
+	err := airdrop(context.Background(), rpc, faucet, "B8UwBUUnKwCyKuGMbFKWaG7exYdDk2ozZrPg72NyVbfj", 0, 10)
+	if err != nil {
+		t.Fatalf("airdrop failed: %v", err)
+	}
+
+	if f.called("requestAirdrop") {
+		t.Error("SOL airdrop should be skipped when --sol is 0")
+	}
+	if !f.called("getTokenAccountBalance") {
+		t.Error("expected USDC balance lookup")
+	}
+}
+
+func TestWalletAirdrop_RefusesMainnet(t *testing.T) {
+	cfg := config.Get()
+	prevNetwork, prevKey := cfg.Network, cfg.Wallet.PublicKey
+	defer func() {
+		cfg.Network, cfg.Wallet.PublicKey = prevNetwork, prevKey
+	}()
+
+	cfg.Network = "mainnet"
+	cfg.Wallet.PublicKey = "B8UwBUUnKwCyKuGMbFKWaG7exYdDk2ozZrPg72NyVbfj"
+
+	if err := runWalletAirdrop(walletAirdropCmd, nil); err == nil {
+		t.Error("airdrop should refuse to run on mainnet")
+	}
+}
+
+// useTempConfig points the config package at a fresh temp directory
+func useTempConfig(t *testing.T) {
+	t.Helper()
+	if err := config.Init(filepath.Join(t.TempDir(), "config.yaml")); err != nil {
+		t.Fatalf("config.Init failed: %v", err)
+	}
+}
+
+func TestWalletRegistry_MigratesLegacyWallet(t *testing.T) {
+	useTempConfig(t)
+
+	legacy := filepath.Join(config.GetDir(), "wallet.json")
+	kp, _ := wallet.Generate()
+	if err := kp.SaveToFile(legacy); err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+
+	cfg := config.Get()
+	cfg.Wallet.KeypairPath = legacy
+	cfg.Wallet.PublicKey = kp.PublicKeyBase58()
+
+	reg, err := walletRegistry()
+	if err != nil {
+		t.Fatalf("walletRegistry failed: %v", err)
+	}
+
+	if cfg.Wallet.Name != "default" {
+		t.Errorf("Active wallet = %q, want default", cfg.Wallet.Name)
+	}
+	info, err := reg.Get("default")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !info.Linked() || info.Path != legacy {
+		t.Errorf("Legacy wallet should be linked in place, got %+v", info)
+	}
+}
+
+func TestNonInteractiveWallet_ReusesExisting(t *testing.T) {
+	useTempConfig(t)
+
+	first, err := nonInteractiveWallet("", "")
+	if err != nil {
+		t.Fatalf("nonInteractiveWallet failed: %v", err)
+	}
+	second, err := nonInteractiveWallet("", "")
+	if err != nil {
+		t.Fatalf("nonInteractiveWallet failed: %v", err)
+	}
+
+	if first.PublicKey != second.PublicKey {
+		t.Error("Re-running setup must not replace the existing wallet")
+	}
+}
+
+func TestWalletRemove_RefusesActive(t *testing.T) {
+	useTempConfig(t)
+
+	info, err := nonInteractiveWallet("", "main")
+	if err != nil {
+		t.Fatalf("nonInteractiveWallet failed: %v", err)
+	}
+	activateWallet(info)
+
+	walletRemoveYes = true
+	defer func() { walletRemoveYes = false }()
+
+	if err := runWalletRemove(walletRemoveCmd, []string{"main"}); err == nil {
+		t.Error("Removing the active wallet should fail")
+	}
+}
+
+func TestWalletBackupRestore(t *testing.T) {
+	kp, _ := wallet.Generate()
+
+	shares, err := splitAndVerify(kp, 3, 2)
+	if err != nil {
+		t.Fatalf("splitAndVerify failed: %v", err)
+	}
+
+	parsed, err := collectShares([]string{shares[2].Mnemonic(), shares[0].Base58()})
+	if err != nil {
+		t.Fatalf("collectShares failed: %v", err)
+	}
+	restored, err := wallet.CombineShares(parsed)
+	if err != nil {
+		t.Fatalf("CombineShares failed: %v", err)
+	}
+
+	if restored.PublicKeyBase58() != kp.PublicKeyBase58() {
+		t.Errorf("restored %s, want %s", restored.PublicKeyBase58(), kp.PublicKeyBase58())
+	}
+
+	if _, err := collectShares([]string{"not a share"}); err == nil {
+		t.Error("expected error for invalid share")
+	}
+}
+
+func TestWalletRestore_NonActiveWallet(t *testing.T) {
+	useTempConfig(t)
+	main, err := nonInteractiveWallet("", "main")
+	if err != nil {
+		t.Fatalf("nonInteractiveWallet failed: %v", err)
+	}
+	activateWallet(main)
+	reg, _ := walletRegistry()
+	trading, _ := wallet.Generate()
+	info, err := reg.Add("trading", trading, wallet.SourceGenerated)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	t.Cleanup(func() {
+		walletRestoreShares, walletRestoreWallet, walletRestoreName, walletRestoreOutput = nil, "", "", ""
+	})
+
+	restoreFrom := func(kp *wallet.Keypair) {
+		shares, err := splitAndVerify(kp, 3, 2)
+		if err != nil {
+			t.Fatalf("splitAndVerify failed: %v", err)
+		}
+		walletRestoreShares = []string{shares[0].Mnemonic(), shares[1].Mnemonic()}
+	}
+
+	// The lost keypair file of a non-active wallet is rewritten
+	restoreFrom(trading)
+	os.Remove(info.Path)
+	walletRestoreWallet = "trading"
+	if err := runWalletRestore(walletRestoreCmd, nil); err != nil {
+		t.Fatalf("restore --wallet trading: %v", err)
+	}
+	if kp, err := wallet.LoadFromFile(info.Path); err != nil || kp.PublicKeyBase58() != trading.PublicKeyBase58() {
+		t.Errorf("restored keypair = %v, %v", kp, err)
+	}
+
+	// Found by address without --wallet, even with --output, after
+	// reporting that it is not the active wallet
+	walletRestoreWallet, walletRestoreOutput = "", filepath.Join(t.TempDir(), "copy.json")
+	out, err := captureStdout(t, func() error { return runWalletRestore(walletRestoreCmd, nil) })
+	if err != nil || !strings.Contains(out, "does not match the active wallet") || !strings.Contains(out, `wallet "trading"`) {
+		t.Errorf("restore --output = %q, %v", out, err)
+	}
+
+	// Shares of the active wallet are reported as such
+	mainKey, _ := wallet.LoadFromFile(main.Path)
+	restoreFrom(mainKey)
+	walletRestoreOutput = ""
+	out, err = captureStdout(t, func() error { return runWalletRestore(walletRestoreCmd, nil) })
+	if err != nil || !strings.Contains(out, `match the active wallet "main"`) || strings.Contains(out, "does not match") {
+		t.Errorf("restore of the active wallet = %q, %v", out, err)
+	}
+	restoreFrom(trading)
+
+	walletRestoreWallet, walletRestoreOutput = "main", ""
+	if err := runWalletRestore(walletRestoreCmd, nil); err == nil {
+		t.Error("restore accepted shares of another wallet for --wallet main")
+	}
+
+	// An unknown key is added to the registry
+	recovered, _ := wallet.Generate()
+	restoreFrom(recovered)
+	walletRestoreWallet, walletRestoreName = "", "recovered"
+	if err := runWalletRestore(walletRestoreCmd, nil); err != nil {
+		t.Fatalf("restore --name: %v", err)
+	}
+	if got, err := reg.Get("recovered"); err != nil || got.PublicKey != recovered.PublicKeyBase58() {
+		t.Errorf("registered %+v, %v", got, err)
+	}
+	if config.Get().Wallet.Name != "main" {
+		t.Errorf("active wallet changed to %q", config.Get().Wallet.Name)
+	}
+}
+
+func TestWalletVanity_InvalidName(t *testing.T) {
+	useTempConfig(t)
+	workers := walletVanityWorkers
+	walletVanityPrefix, walletVanityName, walletVanityWorkers = "a", "my vanity", 1
+	t.Cleanup(func() { walletVanityPrefix, walletVanityName, walletVanityWorkers = "", "", workers })
+
+	// Refused before the search rather than after finding the key
+	out, err := captureStdout(t, func() error { return runWalletVanity(walletVanityCmd, nil) })
+	if !errors.Is(err, wallet.ErrInvalidName) || strings.Contains(out, "Found") {
+		t.Errorf("wallet vanity --name 'my vanity' = %v, output %q", err, out)
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := map[float64]string{
+		950:    "950",
+		12300:  "12.3k",
+		4.5e6:  "4.5M",
+		1.2e9:  "1.2B",
+		3.4e12: "3.4T",
+	}
+	for n, want := range tests {
+		if got := formatCount(n); got != want {
+			t.Errorf("formatCount(%v) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	tests := []struct {
+		difficulty, rate float64
+		want             string
+	}{
+		{58, 0, "estimating..."},
+		{58, 1000, "<1s"},
+		{195112, 1000, "~3m15s"},
+		{1e15, 1000, "~31710 years"},
+	}
+	for _, tt := range tests {
+		if got := formatETA(tt.difficulty, tt.rate); got != tt.want {
+			t.Errorf("formatETA(%v, %v) = %q, want %q", tt.difficulty, tt.rate, got, tt.want)
+		}
+	}
+}
+
+
+// historyFixtureRPC replays the recorded responses in
+// internal/solana/testdata/history, honouring limit and before
+func historyFixtureRPC(t *testing.T) (*fakeRPC, *httptest.Server) {
+	t.Helper()
+	dir := filepath.Join("..", "solana", "testdata", "history")
+	f := &fakeRPC{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var key string
+		json.Unmarshal(req.Params[0], &key)
+
+		f.mu.Lock()
+		f.calls = append(f.calls, req.Method+" "+key)
+		f.mu.Unlock()
+
+		var file string
+		switch req.Method {
+		case "getSignaturesForAddress":
+			file = filepath.Join(dir, "signatures", key+".json")
+		case "getTransaction":
+			file = filepath.Join(dir, "tx", key+".json")
+		}
+		var recorded struct {
+			Result json.RawMessage `json:"result"`
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			recorded.Result = json.RawMessage("null")
+			if req.Method == "getSignaturesForAddress" {
+				recorded.Result = json.RawMessage("[]")
+			}
+		} else if err := json.Unmarshal(data, &recorded); err != nil {
+			t.Errorf("fixture %s: %v", file, err)
+		}
+
+		result := recorded.Result
+		if req.Method == "getSignaturesForAddress" {
+			var opts solana.SignaturesOptions
+			json.Unmarshal(req.Params[1], &opts)
+			var sigs []json.RawMessage
+			json.Unmarshal(result, &sigs)
+			var page []json.RawMessage
+			started := opts.Before == ""
+			for _, s := range sigs {
+				var info solana.SignatureInfo
+				json.Unmarshal(s, &info)
+				if !started {
+					started = info.Signature == opts.Before
+					continue
+				}
+				if len(page) < opts.Limit {
+					page = append(page, s)
+				}
+			}
+			result, _ = json.Marshal(page)
+			if page == nil {
+				result = json.RawMessage("[]")
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func TestFetchHistory(t *testing.T) {
+	const (
+		owner    = "9fH94rHgVWxXh3YTA8jYNKU6Z8xs7r76SZUyzyfmwTQb"
+		usdcMint = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+		usdcIn   = "36Quqf4bFmSEFhNmyLDmQdMtB3drdeFKuXx6VruqqamNsDrxfQQ6hvuWCKSRezZ1yCBptkLaWysNmQH3yPSVmGmN"
+		solOut   = "4GRQMTrkvRhtwYyNNmRz8NYkV6fwktZgyy1E8ynNNUc9uzGd2iG98S5pxtThWumosyFLFaYMfCEMAzEzUx7m9hpe"
+		failed   = "UeB3GbYTj5GWqppYj7mQygh2JaF5wp2EQzehZxwZwG1i8AC3aQLRNmvinyh638y4deqM7NMut7obFaWfenBKNkp"
+		usdcOut  = "4RsKg7iw2nkTheTWAWazKQrE7GkYH4FnLCKw6cAXkS769bn1r4jy5vPRE4sNYDqaCUgUWFvCVgXkMUdfQuYxNvLd"
+		solIn    = "4uDgcTYNaeNicBQjAVJhPGtm4k5jxEz6qdYqb7nrJ1gooLoVfdFzpJgkxDFVeEnWvdi9RMmpEkaZ33oy53arvVRg"
+	)
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	signatures := func(page *historyPage) []string {
+		var out []string
+		for _, tr := range page.Transfers {
+			out = append(out, tr.Signature+" "+tr.Asset+" "+tr.Direction)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		opts     historyOptions
+		want     []string
+		wantNext string
+	}{
+		{
+			name: "all",
+			want: []string{
+				usdcIn + " USDC in", solOut + " SOL out",
+				usdcOut + " SOL out", usdcOut + " USDC out", solIn + " SOL in",
+			},
+		},
+		{
+			name:     "limit",
+			opts:     historyOptions{Limit: 2},
+			want:     []string{usdcIn + " USDC in", solOut + " SOL out"},
+			wantNext: solOut,
+		},
+		{
+			name:     "before cursor",
+			opts:     historyOptions{Limit: 2, Before: solOut},
+			want:     []string{usdcOut + " SOL out", usdcOut + " USDC out"},
+			wantNext: usdcOut,
+		},
+		{
+			name: "since",
+			opts: historyOptions{Since: day(3)},
+			want: []string{usdcIn + " USDC in", solOut + " SOL out", usdcOut + " SOL out", usdcOut + " USDC out"},
+		},
+		{
+			name: "until",
+			opts: historyOptions{Until: day(5)},
+			want: []string{usdcOut + " SOL out", usdcOut + " USDC out", solIn + " SOL in"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpc, server := historyFixtureRPC(t)
+			page, err := fetchHistory(context.Background(), solana.NewClient(server.URL), owner, usdcMint, tt.opts)
+			if err != nil {
+				t.Fatalf("fetchHistory failed: %v", err)
+			}
+			got := signatures(page)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("transfers:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if page.Next != tt.wantNext {
+				t.Errorf("next = %q, want %q", page.Next, tt.wantNext)
+			}
+
+			// Failed signatures are never fetched; shared ones only once
+			fetched := 0
+			for _, c := range rpc.calls {
+				if c == "getTransaction "+failed {
+					t.Error("fetched a failed transaction")
+				}
+				if c == "getTransaction "+usdcOut {
+					fetched++
+				}
+			}
+			if fetched > 1 {
+				t.Errorf("fetched %s %d times", usdcOut, fetched)
+			}
+		})
+	}
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"2025-01-02T03:04:05Z", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"24h", now.Add(-24 * time.Hour)},
+		{"7d", now.AddDate(0, 0, -7)},
+	}
+	for _, tt := range tests {
+		got, err := parseHistoryTime(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseHistoryTime(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	if got, err := parseHistoryTime("2025-01-31", now); err != nil || got.Day() != 31 || got.Hour() != 0 {
+		t.Errorf("date = %v, %v", got, err)
+	}
+	for _, bad := range []string{"yesterday", "-3d", "2025-13-01"} {
+		if _, err := parseHistoryTime(bad, now); err == nil {
+			t.Errorf("parseHistoryTime(%q) should fail", bad)
+		}
+	}
+}
+
+func TestNonInteractiveWallet_ImportFormats(t *testing.T) {
+	useTempConfig(t)
+	dir := t.TempDir()
+
+	cliKey, _ := wallet.Generate()
+	cliPath := filepath.Join(dir, "id.json")
+	if err := cliKey.SaveToFile(cliPath); err != nil {
+		t.Fatal(err)
+	}
+	linked, err := nonInteractiveWallet(cliPath, "cli")
+	if err != nil {
+		t.Fatalf("nonInteractiveWallet failed: %v", err)
+	}
+	if linked.Source != wallet.SourceLinked || linked.Path != cliPath {
+		t.Errorf("Solana CLI keypair should be linked, got %s at %s", linked.Source, linked.Path)
+	}
+
+	// Phantom exports a base58 secret key: copied, not linked
+	phantomKey, _ := wallet.Generate()
+	phantomPath := filepath.Join(dir, "phantom.txt")
+	os.WriteFile(phantomPath, []byte(wallet.Base58Encode(phantomKey.PrivateKey)+"\n"), 0600)
+	copied, err := nonInteractiveWallet(phantomPath, "phantom")
+	if err != nil {
+		t.Fatalf("nonInteractiveWallet failed: %v", err)
+	}
+	if copied.Source != wallet.SourceImported || copied.PublicKey != phantomKey.PublicKeyBase58() {
+		t.Errorf("base58 key should be copied, got %+v", copied)
+	}
+	if _, format, err := wallet.LoadKeyFile(copied.Path); err != nil || format != wallet.FormatJSON {
+		t.Errorf("copy should be a Solana CLI keypair, got %s (%v)", format, err)
+	}
+
+	// A key whose public half was altered is rejected
+	other, _ := wallet.Generate()
+	forged := append(append([]byte{}, phantomKey.PrivateKey.Seed()...), other.PublicKey...)
+	forgedPath := filepath.Join(dir, "forged.txt")
+	os.WriteFile(forgedPath, []byte(wallet.Base58Encode(forged)), 0600)
+	if _, err := nonInteractiveWallet(forgedPath, "forged"); !errors.Is(err, wallet.ErrPublicKeyMismatch) {
+		t.Errorf("Expected ErrPublicKeyMismatch, got %v", err)
+	}
+}
+
+func TestNewFundingRequest(t *testing.T) {
+	const (
+		owner    = "9fH94rHgVWxXh3YTA8jYNKU6Z8xs7r76SZUyzyfmwTQb"
+		usdcMint = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+	)
+
+	req, err := newFundingRequest(owner, usdcMint, "10.50", "MachPay", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Amount != "10.5" || req.SPLToken != usdcMint || len(req.References) != 1 {
+		t.Errorf("request = %+v", req)
+	}
+	parsed, err := solana.ParseTransferRequest(req.URL())
+	if err != nil || parsed.References[0] != req.References[0] {
+		t.Errorf("URL does not round trip: %v", err)
+	}
+
+	for _, amount := range []string{"0", "1.0000001", "abc"} {
+		if _, err := newFundingRequest(owner, usdcMint, amount, "", ""); err == nil {
+			t.Errorf("amount %q accepted", amount)
+		}
+	}
+}
+
+func TestWaitForFunding(t *testing.T) {
+	fundPollInterval = 10 * time.Millisecond
+	defer func() { fundPollInterval = 2 * time.Second }()
+
+	const (
+		owner    = "9fH94rHgVWxXh3YTA8jYNKU6Z8xs7r76SZUyzyfmwTQb"
+		alice    = "7pxrn6LqK3rmvca2j1b2oV5jUGMNQaofjxLKiwYm15YN"
+		usdcMint = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+		usdcIn   = "36Quqf4bFmSEFhNmyLDmQdMtB3drdeFKuXx6VruqqamNsDrxfQQ6hvuWCKSRezZ1yCBptkLaWysNmQH3yPSVmGmN"
+	)
+	data, err := os.ReadFile(filepath.Join("..", "solana", "testdata", "history", "tx", usdcIn+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recorded struct {
+		Result json.RawMessage `json:"result"`
+	}
+	json.Unmarshal(data, &recorded)
+
+	// Alice's token account stands in for the reference key
+	req := &solana.TransferRequest{
+		Recipient:  owner,
+		Amount:     "12.5",
+		SPLToken:   usdcMint,
+		References: []string{"41CNgeRv1uDX9iPPWGxEstdqBVSkVoZ3yvYfXhUu7eBz"},
+	}
+
+	_, server := newFakeRPC(t, map[string]interface{}{
+		"getSignaturesForAddress": []map[string]interface{}{{"signature": usdcIn, "slot": 1, "err": nil}},
+		"getTransaction":          recorded.Result,
+	})
+	signature, received, err := waitForFunding(context.Background(), solana.NewClient(server.URL), req)
+	if err != nil {
+		t.Fatalf("waitForFunding: %v", err)
+	}
+	if signature != usdcIn || received.AmountString() != "12.5" || received.Counterparty != alice {
+		t.Errorf("got %s %+v", signature, received)
+	}
+
+	// Nothing arrives before the deadline
+	_, empty := newFakeRPC(t, map[string]interface{}{"getSignaturesForAddress": []interface{}{}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := waitForFunding(ctx, solana.NewClient(empty.URL), req); err == nil || !strings.Contains(err.Error(), "no payment received") {
+		t.Errorf("error = %v, want timeout", err)
+	}
+}
+
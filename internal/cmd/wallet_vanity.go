@@ -0,0 +1,243 @@
+// ============================================================
+// Wallet Vanity - Search for a recognisable address
+// ============================================================
+//
+// Usage: machpay wallet vanity --prefix <p> [--suffix <s>] [--ignore-case]
+//
+// Runs one search worker per CPU, shows attempts per second and
+// an ETA, and registers the winning keypair as a named wallet.
+// Ctrl+C cancels the search.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+var (
+	walletVanityPrefix     string
+	walletVanitySuffix     string
+	walletVanityIgnoreCase bool
+	walletVanityWorkers    int
+	walletVanityName       string
+	walletVanityOutput     string
+)
+
+// vanityProgressInterval is how often the progress line refreshes
+var vanityProgressInterval = 500 * time.Millisecond
+
+// maxVanityBase bounds the default wallet name before UniqueName
+// numbers it
+const maxVanityBase = 29
+
+var walletVanityCmd = &cobra.Command{
+	Use:   "vanity",
+	Short: "Generate a wallet with a custom address prefix or suffix",
+	Long: `Generate keypairs until the address matches a pattern.
+
+Each character makes the search about 58 times longer (29 times with
+--ignore-case for letters). Four or five characters take seconds to
+minutes on a laptop; seven or more can take days.
+
+The pattern may only use base58 characters, which exclude 0, O, I
+and l.
+
+Examples:
+  machpay wallet vanity --prefix pay
+  machpay wallet vanity --prefix mach --ignore-case --name payouts
+  machpay wallet vanity --suffix Pay --output ~/keys/vanity.json`,
+	Args: cobra.NoArgs,
+	RunE: runWalletVanity,
+}
+
+func init() {
+	walletVanityCmd.Flags().StringVar(&walletVanityPrefix, "prefix", "", "Address prefix")
+	walletVanityCmd.Flags().StringVar(&walletVanitySuffix, "suffix", "", "Address suffix")
+	walletVanityCmd.Flags().BoolVar(&walletVanityIgnoreCase, "ignore-case", false, "Match letters in either case")
+	walletVanityCmd.Flags().IntVar(&walletVanityWorkers, "workers", runtime.NumCPU(), "Number of search workers")
+	walletVanityCmd.Flags().StringVar(&walletVanityName, "name", "", "Wallet name to register (default: vanity-<pattern>)")
+	walletVanityCmd.Flags().StringVarP(&walletVanityOutput, "output", "o", "", "Write the keypair to this file instead of registering it")
+
+	walletCmd.AddCommand(walletVanityCmd)
+}
+
+func runWalletVanity(cmd *cobra.Command, args []string) error {
+	pattern := wallet.VanityPattern{
+		Prefix:     walletVanityPrefix,
+		Suffix:     walletVanitySuffix,
+		IgnoreCase: walletVanityIgnoreCase,
+	}
+	if err := pattern.Validate(); err != nil {
+		return err
+	}
+
+	reg, err := walletRegistry()
+	if err != nil {
+		return err
+	}
+	name := walletVanityName
+	if walletVanityOutput == "" {
+		if name == "" {
+			// Leave room in the 32 characters for UniqueName's "-2"
+			base := "vanity-" + strings.ToLower(walletVanityPrefix+walletVanitySuffix)
+			if len(base) > maxVanityBase {
+				base = base[:maxVanityBase]
+			}
+			if name, err = reg.UniqueName(base); err != nil {
+				return err
+			}
+		}
+		// Fail before the search, not after it
+		if err := wallet.ValidateName(name); err != nil {
+			return fmt.Errorf("%w: %q", err, name)
+		}
+		if _, err := reg.Get(name); err == nil {
+			return fmt.Errorf("%w: %s", wallet.ErrWalletExists, name)
+		}
+	} else if _, err := os.Stat(walletVanityOutput); err == nil {
+		return fmt.Errorf("%s already exists", walletVanityOutput)
+	}
+
+	fmt.Println()
+	tui.PrintKeyValue("Pattern", describeVanityPattern(pattern))
+	tui.PrintKeyValue("Difficulty", fmt.Sprintf("~%s attempts", formatCount(pattern.Difficulty())))
+	tui.PrintKeyValue("Workers", fmt.Sprintf("%d", walletVanityWorkers))
+	fmt.Println(tui.Muted("  Press Ctrl+C to cancel"))
+	fmt.Println()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var attempts atomic.Uint64
+	start := time.Now()
+	done := make(chan struct{})
+	go reportVanityProgress(pattern, &attempts, start, done)
+
+	kp, err := wallet.SearchVanity(ctx, pattern, walletVanityWorkers, &attempts)
+	close(done)
+	fmt.Print("\r\033[K")
+
+	elapsed := time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Println(tui.Muted(fmt.Sprintf("Cancelled after %s attempts.", formatCount(float64(attempts.Load())))))
+			return nil
+		}
+		return err
+	}
+
+	tui.PrintSuccess(fmt.Sprintf("Found after %s attempts in %s",
+		formatCount(float64(attempts.Load())), elapsed.Round(time.Second)))
+	tui.PrintKeyValue("Address", kp.PublicKeyBase58())
+
+	if walletVanityOutput != "" {
+		if err := kp.SaveToFile(walletVanityOutput); err != nil {
+			return err
+		}
+		tui.PrintKeyValue("Saved to", walletVanityOutput)
+	} else {
+		info, err := reg.Add(name, kp, wallet.SourceGenerated)
+		if err != nil {
+			return err
+		}
+		tui.PrintKeyValue("Wallet", info.Name)
+		tui.PrintKeyValue("Saved to", info.Path)
+		fmt.Println(tui.Muted(fmt.Sprintf("  Run 'machpay wallet use %s' to make it active", info.Name)))
+	}
+
+	fmt.Println()
+	fmt.Println(tui.Warning("⚠️  BACKUP THIS FILE! It contains your private key."))
+	return nil
+}
+
+// reportVanityProgress redraws the progress line until done is closed
+func reportVanityProgress(p wallet.VanityPattern, attempts *atomic.Uint64, start time.Time, done <-chan struct{}) {
+	ticker := time.NewTicker(vanityProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			n := float64(attempts.Load())
+			rate := n / time.Since(start).Seconds()
+			fmt.Printf("\r\033[K  %s attempts/s · %s tried · ETA %s",
+				formatCount(rate), formatCount(n), formatETA(p.Difficulty(), rate))
+		}
+	}
+}
+
+func describeVanityPattern(p wallet.VanityPattern) string {
+	var parts []string
+	if p.Prefix != "" {
+		parts = append(parts, "starts with "+p.Prefix)
+	}
+	if p.Suffix != "" {
+		parts = append(parts, "ends with "+p.Suffix)
+	}
+	desc := strings.Join(parts, ", ")
+	if p.IgnoreCase {
+		desc += " (any case)"
+	}
+	return desc
+}
+
+// formatCount abbreviates large counts: 950, 12.3k, 4.5M, 1.2B
+func formatCount(n float64) string {
+	switch {
+	case n >= 1e12:
+		return fmt.Sprintf("%.1fT", n/1e12)
+	case n >= 1e9:
+		return fmt.Sprintf("%.1fB", n/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", n/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fk", n/1e3)
+	}
+	return fmt.Sprintf("%.0f", n)
+}
+
+// formatETA estimates the expected time to a match. Each attempt is
+// independent, so the expectation does not shrink as attempts accrue.
+func formatETA(difficulty, rate float64) string {
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return "estimating..."
+	}
+	seconds := difficulty / rate
+	switch {
+	case seconds < 1:
+		return "<1s"
+	case seconds > 365*24*3600:
+		return fmt.Sprintf("~%.0f years", seconds/(365*24*3600))
+	}
+	return "~" + (time.Duration(seconds) * time.Second).Round(time.Second).String()
+}
+
@@ -0,0 +1,664 @@
+// ============================================================
+// x402 Command - Debug x402 payments
+// ============================================================
+//
+// Usage:
+//   machpay x402 inspect <url>            402 requirements, without paying
+//   machpay x402 inspect <header value>   X-PAYMENT or X-PAYMENT-RESPONSE
+//   machpay x402 inspect <file.har>       every x402 exchange in a capture
+//                        [--for <url|file>] [--offline] [--json]
+//
+// Headers are decoded and pretty-printed, payment transactions are
+// checked (signatures, amount, recipient, USDC mint, blockhash) and
+// common mistakes are flagged. HAR captures are in x402_har.go.
+//
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/tui"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+var x402Cmd = &cobra.Command{
+	Use:   "x402",
+	Short: "Debug x402 payments",
+	Long: `Tools for debugging x402 (HTTP 402) payments.
+
+Examples:
+  machpay x402 inspect https://api.example.com/v1/quote
+  machpay x402 inspect eyJ4NDAyVmVyc2lvbiI6MSwic2NoZW1lIjoiZXhhY3Qi...
+  machpay x402 inspect session.har`,
+}
+
+// ============================================================
+// x402 inspect
+// ============================================================
+
+var (
+	x402InspectFor     string
+	x402InspectMethod  string
+	x402InspectHeaders []string
+	x402InspectOffline bool
+	x402InspectJSON    bool
+)
+
+var x402InspectCmd = &cobra.Command{
+	Use:   "inspect <url|header|file.har>",
+	Short: "Decode and check x402 requirements, payments and receipts",
+	Long: `Decode and check x402 messages.
+
+The argument can be:
+
+  a URL          fetched once; its 402 payment requirements are shown
+                 without paying
+  a header value an X-PAYMENT or X-PAYMENT-RESPONSE value (optionally
+                 with the "X-PAYMENT:" prefix), base64 as sent
+  a HAR file     a browser or proxy capture; every 402, payment and
+                 receipt in it is checked, payments against the 402
+                 that preceded them
+
+Payments are checked for valid signatures, the USDC mint of the active
+network and an unexpired blockhash (skipped with --offline). Pass
+--for with the paid URL, or a saved 402 body, to also check the amount,
+recipient and network against the vendor's requirements.
+
+Exits with an error when a problem is found.
+
+Examples:
+  machpay x402 inspect https://api.example.com/v1/quote
+  machpay x402 inspect "X-PAYMENT: eyJ4NDAyVmVyc2lvbiI6MSwi..." --for https://api.example.com/v1/quote
+  machpay x402 inspect eyJzdWNjZXNzIjp0cnVlLCJ0cmFuc2FjdGlvbiI6...
+  machpay x402 inspect capture.har --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runX402Inspect,
+}
+
+func init() {
+	x402InspectCmd.Flags().StringVar(&x402InspectFor, "for", "", "URL (or saved 402 body) whose requirements a payment must meet")
+	x402InspectCmd.Flags().StringVarP(&x402InspectMethod, "request", "X", http.MethodGet, "HTTP method when fetching a URL")
+	x402InspectCmd.Flags().StringArrayVarP(&x402InspectHeaders, "header", "H", nil, "Header 'Name: value' when fetching a URL (repeatable)")
+	x402InspectCmd.Flags().BoolVar(&x402InspectOffline, "offline", false, "Skip checks that need the network (blockhash, settlement status)")
+	x402InspectCmd.Flags().BoolVar(&x402InspectJSON, "json", false, "Output as JSON")
+
+	x402Cmd.AddCommand(x402InspectCmd)
+	rootCmd.AddCommand(x402Cmd)
+}
+
+// Finding levels
+const (
+	levelOK      = "ok"
+	levelInfo    = "info"
+	levelWarning = "warning"
+	levelError   = "error"
+)
+
+// finding is one check result
+type finding struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// inspectedTx is the decoded payment transaction
+type inspectedTx struct {
+	FeePayer     string           `json:"feePayer"`
+	Blockhash    string           `json:"blockhash"`
+	Instructions []string         `json:"instructions"`
+	Signatures   []signatureState `json:"signatures"`
+}
+
+type signatureState struct {
+	Signer string `json:"signer"`
+	Signed bool   `json:"signed"`
+}
+
+// inspectItem is one decoded message and what was found about it
+type inspectItem struct {
+	Source          string                   `json:"source"`
+	Kind            string                   `json:"kind"` // paymentRequired, payment or settlement
+	Status          int                      `json:"status,omitempty"`
+	PaymentRequired *x402.PaymentRequired    `json:"paymentRequired,omitempty"`
+	Payment         *x402.PaymentPayload     `json:"payment,omitempty"`
+	Transaction     *inspectedTx             `json:"transaction,omitempty"`
+	Settlement      *x402.SettlementResponse `json:"settlement,omitempty"`
+	Findings        []finding                `json:"findings"`
+}
+
+func (it *inspectItem) add(level, format string, args ...interface{}) {
+	it.Findings = append(it.Findings, finding{Level: level, Message: fmt.Sprintf(format, args...)})
+}
+
+// inspector checks messages against the active network
+type inspector struct {
+	ctx      context.Context
+	rpc      *solana.Client
+	network  string // x402 network of the active config
+	usdcMint string
+	offline  bool
+	label    func(string) string
+	items    []*inspectItem
+}
+
+func newInspector(ctx context.Context) *inspector {
+	return &inspector{
+		ctx:      ctx,
+		rpc:      solana.NewClient(config.GetRPCURL()),
+		network:  x402.NetworkForCluster(config.GetCluster()),
+		usdcMint: config.GetUSDCMint(),
+		offline:  x402InspectOffline,
+		label:    vendorNamer(),
+	}
+}
+
+func runX402Inspect(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	in := newInspector(ctx)
+
+	var forRequired *x402.PaymentRequired
+	if x402InspectFor != "" {
+		item, err := in.loadRequirements(x402InspectFor)
+		if err != nil {
+			return fmt.Errorf("--for: %w", err)
+		}
+		forRequired = item.PaymentRequired
+		if forRequired == nil {
+			return fmt.Errorf("--for: %s did not return x402 payment requirements", x402InspectFor)
+		}
+	}
+
+	input := strings.TrimSpace(args[0])
+	var err error
+	switch {
+	case strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://"):
+		_, err = in.loadRequirements(input)
+	case fileExists(input):
+		err = in.inspectHAR(input)
+	default:
+		err = in.inspectHeader(input, forRequired)
+	}
+	if err != nil {
+		return err
+	}
+
+	if x402InspectJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(in.items); err != nil {
+			return err
+		}
+	} else {
+		for _, it := range in.items {
+			printInspectItem(it)
+		}
+	}
+
+	problems := 0
+	for _, it := range in.items {
+		for _, f := range it.Findings {
+			if f.Level == levelError {
+				problems++
+			}
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("found %d problem(s)", problems)
+	}
+	return nil
+}
+
+// ============================================================
+// Inputs
+// ============================================================
+
+// loadRequirements fetches a URL, or reads a saved 402 body, and
+// inspects the payment requirements
+func (in *inspector) loadRequirements(source string) (*inspectItem, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		body, err := os.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		it := &inspectItem{Source: source, Kind: "paymentRequired"}
+		in.checkRequired(it, body)
+		in.items = append(in.items, it)
+		return it, nil
+	}
+
+	req, err := http.NewRequestWithContext(in.ctx, strings.ToUpper(x402InspectMethod), source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "machpay-cli/"+versionInfo.Version)
+	for _, h := range x402InspectHeaders {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q (use 'Name: value')", h)
+		}
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	it := &inspectItem{Source: req.Method + " " + source, Kind: "paymentRequired", Status: resp.StatusCode}
+	in.items = append(in.items, it)
+	if resp.StatusCode != http.StatusPaymentRequired {
+		it.add(levelInfo, "%s: no payment required", resp.Status)
+		if value := resp.Header.Get(x402.HeaderPaymentResponse); value != "" {
+			in.addSettlement(it.Source, value)
+		}
+		return it, nil
+	}
+	in.checkRequired(it, body)
+	return it, nil
+}
+
+// inspectHeader decodes a header value, with or without its name
+func (in *inspector) inspectHeader(input string, required *x402.PaymentRequired) error {
+	kind := ""
+	if name, value, ok := strings.Cut(input, ":"); ok {
+		switch strings.ToUpper(strings.TrimSpace(name)) {
+		case x402.HeaderPayment:
+			kind, input = "payment", strings.TrimSpace(value)
+		case x402.HeaderPaymentResponse:
+			kind, input = "settlement", strings.TrimSpace(value)
+		}
+	}
+
+	if kind == "" {
+		var fields map[string]json.RawMessage
+		if _, err := decodeLenient(input, &fields); err != nil {
+			return fmt.Errorf("not a URL, HAR file or x402 header value: %w", err)
+		}
+		switch {
+		case fields["payload"] != nil:
+			kind = "payment"
+		case fields["success"] != nil:
+			kind = "settlement"
+		default:
+			return errors.New("header decodes to JSON that is neither an X-PAYMENT nor an X-PAYMENT-RESPONSE")
+		}
+	}
+
+	if kind == "settlement" {
+		in.addSettlement(x402.HeaderPaymentResponse+" header", input)
+		return nil
+	}
+	in.addPayment(x402.HeaderPayment+" header", input, required, time.Time{}, time.Time{})
+	return nil
+}
+
+// decodeLenient decodes a header value that may not be standard
+// base64, reporting the encoding it was in
+func decodeLenient(value string, v interface{}) (string, error) {
+	encodings := []struct {
+		name string
+		enc  *base64.Encoding
+	}{
+		{"standard", base64.StdEncoding},
+		{"unpadded", base64.RawStdEncoding},
+		{"URL-safe", base64.URLEncoding},
+		{"unpadded URL-safe", base64.RawURLEncoding},
+	}
+	for _, e := range encodings {
+		data, err := e.enc.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return e.name, fmt.Errorf("base64 does not decode to JSON: %w", err)
+		}
+		return e.name, nil
+	}
+	return "", errors.New("not base64")
+}
+
+// ============================================================
+// Checks
+// ============================================================
+
+// checkRequired decodes a 402 body and checks each offer
+func (in *inspector) checkRequired(it *inspectItem, body []byte) {
+	pr, err := x402.ParsePaymentRequired(body)
+	if err != nil {
+		it.add(levelError, "%v", err)
+		var loose x402.PaymentRequired
+		if json.Unmarshal(body, &loose) != nil {
+			return
+		}
+		pr = &loose
+	}
+	it.PaymentRequired = pr
+	if pr.Error != "" {
+		it.add(levelInfo, "server says: %s", pr.Error)
+	}
+
+	onNetwork := 0
+	for i := range pr.Accepts {
+		o := &pr.Accepts[i]
+		n := i + 1
+		if o.Scheme != x402.SchemeExact || !x402.IsSolanaNetwork(o.Network) {
+			it.add(levelInfo, "offer %d (%s on %s) cannot be paid by machpay", n, o.Scheme, o.Network)
+			continue
+		}
+		if err := o.Validate(); err != nil {
+			it.add(levelError, "offer %d: %v", n, err)
+			continue
+		}
+		if o.Network != in.network {
+			it.add(levelWarning, "offer %d is on %s; machpay is on %s", n, o.Network, in.network)
+			continue
+		}
+		onNetwork++
+		if o.Asset != in.usdcMint {
+			it.add(levelError, "offer %d asks for asset %s, which is not USDC on %s (%s)", n, o.Asset, o.Network, in.usdcMint)
+		}
+		if o.FeePayer() == "" {
+			it.add(levelWarning, "offer %d has no extra.feePayer; the payer pays the network fee", n)
+		}
+	}
+	if onNetwork == 0 && len(pr.Accepts) > 0 {
+		it.add(levelError, "wrong network: no offer on %s", in.network)
+	}
+
+	client := &payclient.Client{Network: in.network, USDCMint: in.usdcMint, MaxPrice: math.MaxUint64}
+	if offer, amount, err := client.Select(pr.Accepts); err == nil {
+		it.add(levelOK, "payable: %s to %s", formatUSDC(amount), in.label(offer.PayTo))
+	}
+}
+
+// addPayment decodes and checks an X-PAYMENT value. required, when
+// known, is the 402 the payment answers; requiredAt and sentAt are
+// when the 402 was received and the payment sent.
+func (in *inspector) addPayment(source, value string, required *x402.PaymentRequired, requiredAt, sentAt time.Time) {
+	it := &inspectItem{Source: source, Kind: "payment"}
+	in.items = append(in.items, it)
+
+	var payload x402.PaymentPayload
+	if err := x402.DecodeHeader(value, &payload); err != nil {
+		it.add(levelError, "%v", err)
+		encoding, err := decodeLenient(value, &payload)
+		if err != nil {
+			return
+		}
+		if encoding != "standard" {
+			it.add(levelError, "header is %s base64; x402 requires standard, padded base64", encoding)
+		}
+	}
+	it.Payment = &payload
+	if err := payload.Validate(); err != nil {
+		it.add(levelError, "%v", err)
+	}
+
+	tx, err := solana.TransactionFromBase64(payload.Payload.Transaction)
+	if err != nil {
+		it.add(levelError, "transaction does not decode: %v", err)
+		return
+	}
+	it.Transaction = describeInspectedTx(tx)
+	if err := tx.VerifySignatures(); err != nil {
+		it.add(levelError, "%v", err)
+	} else {
+		it.add(levelOK, "signatures verify")
+	}
+
+	onNetwork := payload.Network == in.network
+	if !onNetwork {
+		it.add(levelWarning, "payment is on %s; machpay is on %s, so mint and blockhash checks are skipped", payload.Network, in.network)
+	}
+	for _, ix := range solana.DecodeInstructions(&tx.Message) {
+		isToken := ix.Program == solana.TokenProgramID || ix.Program == solana.Token2022ProgramID
+		if !isToken || len(ix.Data) != 10 || ix.Data[0] != 12 || len(ix.Accounts) < 4 {
+			continue
+		}
+		amount := binary.LittleEndian.Uint64(ix.Data[1:9])
+		if onNetwork && ix.Accounts[1].String() != in.usdcMint {
+			it.add(levelError, "transfers mint %s, not USDC on %s (%s)", ix.Accounts[1], in.network, in.usdcMint)
+		} else if onNetwork {
+			it.add(levelOK, "transfers %s", formatUSDC(amount))
+		}
+	}
+
+	if required == nil {
+		it.add(levelInfo, "pass --for <url> to check the amount and recipient against the vendor's requirements")
+	} else {
+		in.matchRequirements(it, &payload, required, requiredAt, sentAt)
+	}
+
+	if onNetwork && !in.offline {
+		valid, err := in.rpc.IsBlockhashValid(in.ctx, tx.Message.RecentBlockhash)
+		switch {
+		case err != nil:
+			it.add(levelWarning, "could not check the blockhash: %v", err)
+		case !valid:
+			it.add(levelError, "expired: the blockhash is no longer valid, so the payment cannot settle; pay again with a fresh transaction")
+		default:
+			it.add(levelOK, "blockhash still valid")
+		}
+	}
+}
+
+// matchRequirements checks a payment against the offers it answers
+func (in *inspector) matchRequirements(it *inspectItem, payload *x402.PaymentPayload, required *x402.PaymentRequired, requiredAt, sentAt time.Time) {
+	var matchErr error
+	for i := range required.Accepts {
+		offer := &required.Accepts[i]
+		if offer.Scheme != payload.Scheme || offer.Network != payload.Network {
+			continue
+		}
+		payment, err := x402.VerifyExact(payload, offer)
+		if err != nil {
+			if matchErr == nil {
+				matchErr = err
+			}
+			continue
+		}
+		it.add(levelOK, "meets the requirements: %s from %s to %s", formatUSDC(payment.Amount), payment.Payer, in.label(offer.PayTo))
+		if !requiredAt.IsZero() && !sentAt.IsZero() && offer.MaxTimeoutSeconds > 0 {
+			if took := sentAt.Sub(requiredAt); took > time.Duration(offer.MaxTimeoutSeconds)*time.Second {
+				it.add(levelError, "expired: sent %s after the 402, past maxTimeoutSeconds (%ds)", took.Round(time.Second), offer.MaxTimeoutSeconds)
+			}
+		}
+		return
+	}
+
+	if matchErr == nil {
+		networks := make([]string, 0, len(required.Accepts))
+		for _, o := range required.Accepts {
+			networks = append(networks, o.Scheme+" on "+o.Network)
+		}
+		it.add(levelError, "wrong network: paid %s on %s, the vendor accepts %s", payload.Scheme, payload.Network, strings.Join(networks, ", "))
+		return
+	}
+	it.add(levelError, "does not meet the requirements: %v", matchErr)
+}
+
+// addSettlement decodes and checks an X-PAYMENT-RESPONSE value
+func (in *inspector) addSettlement(source, value string) {
+	it := &inspectItem{Source: source, Kind: "settlement"}
+	in.items = append(in.items, it)
+
+	var s x402.SettlementResponse
+	if err := x402.DecodeHeader(value, &s); err != nil {
+		it.add(levelError, "%v", err)
+		if _, err := decodeLenient(value, &s); err != nil {
+			return
+		}
+	}
+	it.Settlement = &s
+	if err := s.Validate(); err != nil {
+		it.add(levelError, "%v", err)
+	}
+	if !s.Success {
+		it.add(levelError, "settlement failed: %s", s.ErrorReason)
+		return
+	}
+	if s.Network != in.network {
+		it.add(levelWarning, "settled on %s; machpay is on %s, so the transaction is not looked up", s.Network, in.network)
+		return
+	}
+	if in.offline || s.Transaction == "" {
+		return
+	}
+
+	statuses, err := in.rpc.GetSignatureStatuses(in.ctx, []string{s.Transaction})
+	switch {
+	case err != nil:
+		it.add(levelWarning, "could not look up the transaction: %v", err)
+	case len(statuses) == 0 || statuses[0] == nil:
+		it.add(levelError, "transaction %s not found on %s", truncateAddress(s.Transaction), s.Network)
+	case statuses[0].Failed():
+		it.add(levelError, "transaction failed on-chain: %s", statuses[0].Err)
+	default:
+		it.add(levelOK, "transaction %s on-chain", statuses[0].ConfirmationStatus)
+	}
+}
+
+func describeInspectedTx(tx *solana.Transaction) *inspectedTx {
+	d := &inspectedTx{
+		FeePayer:  tx.Message.AccountKeys[0].String(),
+		Blockhash: tx.Message.RecentBlockhash.String(),
+	}
+	for _, ix := range solana.DecodeInstructions(&tx.Message) {
+		d.Instructions = append(d.Instructions, ix.Description)
+	}
+	for i, key := range tx.Message.Signers() {
+		d.Signatures = append(d.Signatures, signatureState{Signer: key.String(), Signed: tx.Signatures[i] != [solana.SignatureLength]byte{}})
+	}
+	return d
+}
+
+// ============================================================
+// Output
+// ============================================================
+
+func printInspectItem(it *inspectItem) {
+	fmt.Println()
+	title := it.Source
+	if it.Status != 0 {
+		title += fmt.Sprintf(" → %d", it.Status)
+	}
+	fmt.Println(tui.Bold(title))
+
+	switch {
+	case it.PaymentRequired != nil:
+		pr := it.PaymentRequired
+		tui.PrintKeyValue("Version", fmt.Sprint(pr.X402Version))
+		if pr.Error != "" {
+			tui.PrintKeyValue("Error", pr.Error)
+		}
+		for i := range pr.Accepts {
+			fmt.Println(tui.Muted(fmt.Sprintf("  Offer %d of %d", i+1, len(pr.Accepts))))
+			printOffer(&pr.Accepts[i])
+		}
+
+	case it.Payment != nil:
+		p := it.Payment
+		tui.PrintKeyValue("Version", fmt.Sprint(p.X402Version))
+		tui.PrintKeyValue("Scheme", p.Scheme)
+		tui.PrintKeyValue("Network", p.Network)
+		if tx := it.Transaction; tx != nil {
+			tui.PrintKeyValue("Fee payer", tx.FeePayer)
+			tui.PrintKeyValue("Blockhash", tx.Blockhash)
+			fmt.Println(tui.Muted("  Instructions"))
+			for i, ix := range tx.Instructions {
+				fmt.Printf("    %d. %s\n", i+1, ix)
+			}
+			fmt.Println(tui.Muted("  Signatures"))
+			for _, s := range tx.Signatures {
+				state := tui.Warning("missing")
+				switch {
+				case s.Signed:
+					state = tui.Success("signed")
+				case s.Signer == tx.FeePayer:
+					state = tui.Muted("missing (added by the facilitator)")
+				}
+				fmt.Printf("    %s  %s\n", s.Signer, state)
+			}
+		}
+
+	case it.Settlement != nil:
+		s := it.Settlement
+		tui.PrintKeyValue("Success", fmt.Sprint(s.Success))
+		if s.ErrorReason != "" {
+			tui.PrintKeyValue("Reason", s.ErrorReason)
+		}
+		if s.Transaction != "" {
+			tui.PrintKeyValue("Tx", s.Transaction)
+			tui.PrintKeyValue("Explorer", explorerTxURL(s.Transaction, config.Get().Network))
+		}
+		if s.Network != "" {
+			tui.PrintKeyValue("Network", s.Network)
+		}
+		if s.Payer != "" {
+			tui.PrintKeyValue("Payer", s.Payer)
+		}
+	}
+
+	for _, f := range it.Findings {
+		icon := tui.InfoIcon()
+		switch f.Level {
+		case levelOK:
+			icon = tui.SuccessIcon()
+		case levelWarning:
+			icon = tui.WarningIcon()
+		case levelError:
+			icon = tui.ErrorIcon()
+		}
+		fmt.Printf("  %s %s\n", icon, f.Message)
+	}
+}
+
+func printOffer(o *x402.PaymentRequirements) {
+	tui.PrintKeyValue("Scheme", o.Scheme)
+	tui.PrintKeyValue("Network", o.Network)
+	amount := o.MaxAmountRequired + " (atomic units)"
+	if raw, err := o.Amount(); err == nil && x402.IsSolanaNetwork(o.Network) {
+		amount = fmt.Sprintf("%s (%s USDC at 6 decimals)", o.MaxAmountRequired, solana.FormatTokenAmount(raw, policy.USDCDecimals))
+	}
+	tui.PrintKeyValue("Amount", amount)
+	tui.PrintKeyValue("Asset", o.Asset)
+	tui.PrintKeyValue("Pay to", vendorNamer()(o.PayTo))
+	if fp := o.FeePayer(); fp != "" {
+		tui.PrintKeyValue("Fee payer", fp)
+	}
+	tui.PrintKeyValue("Resource", o.Resource)
+	if o.Description != "" {
+		tui.PrintKeyValue("About", o.Description)
+	}
+	tui.PrintKeyValue("Timeout", fmt.Sprintf("%ds", o.MaxTimeoutSeconds))
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
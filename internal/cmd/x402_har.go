@@ -0,0 +1,123 @@
+// ============================================================
+// x402 HAR - Inspect x402 exchanges in HTTP archives
+// ============================================================
+//
+// Browsers and debugging proxies export HAR (HTTP Archive) files.
+// Each entry is checked for:
+//
+//   402 response          payment requirements
+//   X-PAYMENT request     payment, against the latest 402 for the
+//                         same URL and within its maxTimeoutSeconds
+//   X-PAYMENT-RESPONSE    settlement receipt
+//
+// ============================================================
+
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// harFile is the subset of HAR 1.2 that is inspected
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Request         struct {
+		Method  string      `json:"method"`
+		URL     string      `json:"url"`
+		Headers []harHeader `json:"headers"`
+	} `json:"request"`
+	Response struct {
+		Status  int         `json:"status"`
+		Headers []harHeader `json:"headers"`
+		Content struct {
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func harHeaderValue(headers []harHeader, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// inspectHAR checks every x402 exchange in a HAR file
+func (in *inspector) inspectHAR(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return fmt.Errorf("%s is not a HAR file: %w", path, err)
+	}
+
+	type seen402 struct {
+		required *x402.PaymentRequired
+		at       time.Time
+	}
+	latest := map[string]seen402{}
+	before := len(in.items)
+
+	for i, e := range har.Log.Entries {
+		source := fmt.Sprintf("#%d %s %s", i+1, e.Request.Method, e.Request.URL)
+
+		if value := harHeaderValue(e.Request.Headers, x402.HeaderPayment); value != "" {
+			prior, ok := latest[e.Request.URL]
+			if !ok {
+				in.addPayment(source+" (X-PAYMENT)", value, nil, time.Time{}, time.Time{})
+			} else {
+				in.addPayment(source+" (X-PAYMENT)", value, prior.required, prior.at, e.StartedDateTime)
+			}
+		}
+
+		if e.Response.Status == http.StatusPaymentRequired {
+			body := []byte(e.Response.Content.Text)
+			if e.Response.Content.Encoding == "base64" {
+				if body, err = base64.StdEncoding.DecodeString(e.Response.Content.Text); err != nil {
+					return fmt.Errorf("entry %d: response body: %w", i+1, err)
+				}
+			}
+			it := &inspectItem{Source: source, Kind: "paymentRequired", Status: e.Response.Status}
+			in.items = append(in.items, it)
+			in.checkRequired(it, body)
+			if it.PaymentRequired != nil {
+				latest[e.Request.URL] = seen402{it.PaymentRequired, e.StartedDateTime}
+			}
+		}
+
+		if value := harHeaderValue(e.Response.Headers, x402.HeaderPaymentResponse); value != "" {
+			in.addSettlement(source+" (X-PAYMENT-RESPONSE)", value)
+		}
+	}
+
+	if len(in.items) == before {
+		return errors.New("no x402 exchanges (402 responses or X-PAYMENT headers) in " + path)
+	}
+	return nil
+}
+
@@ -0,0 +1,226 @@
+// ============================================================
+// x402 Command Tests
+// ============================================================
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// setupInspect points the config at a fake RPC on which blockhashes
+// are valid and settled transactions are confirmed
+func setupInspect(t *testing.T) *fakeRPC {
+	t.Helper()
+	useTempConfig(t)
+	f, rpc := newFakeRPC(t, map[string]interface{}{
+		"getLatestBlockhash": map[string]interface{}{
+			"value": map[string]interface{}{"blockhash": "4uQeVj5tqViQh7yWWGStvkEG1Zmhx6uasJtWCJziofM", "lastValidBlockHeight": 100},
+		},
+		"isBlockhashValid": map[string]interface{}{"value": true},
+		"getSignatureStatuses": map[string]interface{}{
+			"value": []interface{}{map[string]interface{}{"slot": 5, "err": nil, "confirmationStatus": "finalized"}},
+		},
+	})
+	cfg := config.Get()
+	cfg.Networks = map[string]config.NetworkConfig{"test": {RPCURL: rpc.URL, Cluster: config.ClusterDevnet}}
+	cfg.Network = "test"
+	t.Cleanup(func() { x402InspectFor, x402InspectJSON, x402InspectOffline = "", false, false })
+	return f
+}
+
+// payHeader fetches the vendor's 402 and returns a signed X-PAYMENT
+// value for it
+func payHeader(t *testing.T, url string) string {
+	t.Helper()
+	in := newInspector(context.Background())
+	item, err := in.loadRequirements(url)
+	if err != nil || item.PaymentRequired == nil {
+		t.Fatalf("loadRequirements: %v", err)
+	}
+
+	kp, _ := wallet.Generate()
+	client := &payclient.Client{
+		Signer:   wallet.NewKeypairSigner(kp),
+		RPC:      solana.NewClient(config.GetRPCURL()),
+		Network:  x402.NetworkSolanaDevnet,
+		USDCMint: config.GetUSDCMint(),
+		MaxPrice: math.MaxUint64,
+	}
+	offer, amount, err := client.Select(item.PaymentRequired.Accepts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := client.Pay(context.Background(), offer, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return header
+}
+
+func findingsOf(items []*inspectItem, level string) []string {
+	var out []string
+	for _, it := range items {
+		for _, f := range it.Findings {
+			if f.Level == level {
+				out = append(out, f.Message)
+			}
+		}
+	}
+	return out
+}
+
+func TestX402Inspect_URL(t *testing.T) {
+	setupInspect(t)
+	server, _, paid := newPaidAPI(t, "2500")
+
+	in := newInspector(context.Background())
+	item, err := in.loadRequirements(server.URL + "/quote")
+	if err != nil {
+		t.Fatalf("loadRequirements: %v", err)
+	}
+	if item.Status != 402 || item.PaymentRequired == nil || len(item.PaymentRequired.Accepts) != 1 {
+		t.Fatalf("item = %+v", item)
+	}
+	if ok := findingsOf(in.items, levelOK); len(ok) != 1 || !strings.Contains(ok[0], "0.0025 USDC") {
+		t.Errorf("ok findings = %v", ok)
+	}
+	if *paid != 0 {
+		t.Error("inspect paid the vendor")
+	}
+}
+
+func TestX402Inspect_Payment(t *testing.T) {
+	f := setupInspect(t)
+	server, _, _ := newPaidAPI(t, "2500")
+	other, _, _ := newPaidAPI(t, "2500")
+	header := payHeader(t, server.URL+"/quote")
+
+	// Against the vendor it was made for
+	x402InspectFor = server.URL + "/quote"
+	if err := runX402Inspect(x402InspectCmd, []string{"X-PAYMENT: " + header}); err != nil {
+		t.Errorf("runX402Inspect = %v, want a clean payment", err)
+	}
+
+	// Against another vendor
+	in := newInspector(context.Background())
+	item, _ := in.loadRequirements(other.URL + "/quote")
+	in.addPayment("payment", header, item.PaymentRequired, time.Time{}, time.Time{})
+	if errs := findingsOf(in.items, levelError); len(errs) != 1 || !strings.Contains(errs[0], "does not meet the requirements") {
+		t.Errorf("errors = %v, want a requirements mismatch", errs)
+	}
+
+	// Once the blockhash has expired
+	f.mu.Lock()
+	f.results["isBlockhashValid"] = map[string]interface{}{"value": false}
+	f.mu.Unlock()
+	x402InspectFor = ""
+	if err := runX402Inspect(x402InspectCmd, []string{header}); err == nil || !strings.Contains(err.Error(), "1 problem") {
+		t.Errorf("runX402Inspect = %v, want the expired blockhash", err)
+	}
+}
+
+func TestX402Inspect_Settlement(t *testing.T) {
+	setupInspect(t)
+	sig := "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW"
+
+	in := newInspector(context.Background())
+	ok, _ := x402.EncodeHeader(x402.SettlementResponse{Success: true, Transaction: sig, Network: x402.NetworkSolanaDevnet})
+	if err := in.inspectHeader(ok, nil); err != nil {
+		t.Fatal(err)
+	}
+	failed, _ := x402.EncodeHeader(x402.SettlementResponse{ErrorReason: "insufficient_funds"})
+	if err := in.inspectHeader(failed, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(in.items) != 2 || in.items[0].Kind != "settlement" {
+		t.Fatalf("items = %+v", in.items)
+	}
+	if got := findingsOf(in.items[:1], levelOK); len(got) != 1 || !strings.Contains(got[0], "finalized") {
+		t.Errorf("successful settlement findings = %v", got)
+	}
+	if got := findingsOf(in.items[1:], levelError); len(got) != 1 || !strings.Contains(got[0], "insufficient_funds") {
+		t.Errorf("failed settlement findings = %v", got)
+	}
+
+	if err := in.inspectHeader("bm90IGpzb24=", nil); err == nil {
+		t.Error("accepted a header that is not JSON")
+	}
+}
+
+func TestX402Inspect_HAR(t *testing.T) {
+	setupInspect(t)
+	x402InspectOffline = true
+	server, _, _ := newPaidAPI(t, "2500")
+	url := server.URL + "/quote"
+	header := payHeader(t, url)
+
+	in := newInspector(context.Background())
+	item, _ := in.loadRequirements(url)
+	body, _ := json.Marshal(item.PaymentRequired)
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry := func(at time.Time, status int, reqHeaders, respHeaders []harHeader, text string) harEntry {
+		var e harEntry
+		e.StartedDateTime = at
+		e.Request.Method, e.Request.URL, e.Request.Headers = "GET", url, reqHeaders
+		e.Response.Status, e.Response.Headers = status, respHeaders
+		e.Response.Content.Text = text
+		return e
+	}
+	var har harFile
+	har.Log.Entries = []harEntry{
+		entry(start, 402, nil, nil, string(body)),
+		entry(start.Add(2*time.Minute), 200, []harHeader{{Name: "x-payment", Value: header}}, nil, "paid"),
+	}
+	data, _ := json.Marshal(har)
+	path := filepath.Join(t.TempDir(), "capture.har")
+	os.WriteFile(path, data, 0600)
+
+	in = newInspector(context.Background())
+	if err := in.inspectHAR(path); err != nil {
+		t.Fatalf("inspectHAR: %v", err)
+	}
+	if len(in.items) != 2 || in.items[1].Kind != "payment" {
+		t.Fatalf("items = %+v", in.items)
+	}
+	if errs := findingsOf(in.items, levelError); len(errs) != 1 || !strings.Contains(errs[0], "maxTimeoutSeconds") {
+		t.Errorf("errors = %v, want the late payment", errs)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.har")
+	os.WriteFile(empty, []byte(`{"log":{"entries":[]}}`), 0600)
+	if err := newInspector(context.Background()).inspectHAR(empty); err == nil {
+		t.Error("inspectHAR accepted a capture without x402 traffic")
+	}
+}
+
+func TestDecodeLenient(t *testing.T) {
+	var v map[string]interface{}
+	for value, want := range map[string]string{
+		"eyJhIjoxfQ==": "standard",
+		"eyJhIjoxfQ":   "unpadded",
+	} {
+		if got, err := decodeLenient(value, &v); err != nil || got != want {
+			t.Errorf("decodeLenient(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := decodeLenient("!!", &v); err == nil {
+		t.Error("decodeLenient accepted invalid base64")
+	}
+}
+
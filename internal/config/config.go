@@ -9,20 +9,31 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
+
+	"github.com/machpay-xyz/machpay-cli/pkg/paywall"
 )
 
 // Config represents the CLI configuration
 type Config struct {
 	Version string `yaml:"version"`
 	Role    string `yaml:"role"` // "agent" or "vendor"
-	Network string `yaml:"network"` // "mainnet" or "devnet"
+	Network string `yaml:"network"` // "mainnet", "devnet" or a name in Networks
 
 	Auth    AuthConfig    `yaml:"auth"`
 	Wallet  WalletConfig  `yaml:"wallet"`
 	Vendor  VendorConfig  `yaml:"vendor,omitempty"`
 	Gateway GatewayConfig `yaml:"gateway,omitempty"`
+	Faucet  FaucetConfig  `yaml:"faucet,omitempty"`
+	Cache   CacheConfig   `yaml:"cache,omitempty"`
+	Agent   AgentConfig   `yaml:"agent,omitempty"`
+
+	// Networks defines custom networks selectable via Network
+	Networks map[string]NetworkConfig `yaml:"networks,omitempty"`
+
+	// Facilitators maps network names to x402 facilitator URLs,
+	// overriding the cluster defaults
+	Facilitators map[string]string `yaml:"facilitators,omitempty"`
 }
 
 // AuthConfig stores authentication tokens
@@ -35,15 +46,23 @@ type AuthConfig struct {
 
 // WalletConfig stores wallet/keypair info
 type WalletConfig struct {
+	Name        string `yaml:"name,omitempty"` // Active wallet in the registry
 	KeypairPath string `yaml:"keypair_path,omitempty"`
 	PublicKey   string `yaml:"public_key,omitempty"`
 }
 
 // VendorConfig stores vendor-specific settings
 type VendorConfig struct {
-	UpstreamURL     string   `yaml:"upstream_url,omitempty"`
-	PricePerRequest float64  `yaml:"price_per_request,omitempty"`
-	AllowedOrigins  []string `yaml:"allowed_origins,omitempty"`
+	UpstreamURL     string          `yaml:"upstream_url,omitempty"`
+	PricePerRequest float64         `yaml:"price_per_request,omitempty"`
+	Routes          []paywall.Route `yaml:"routes,omitempty"` // per-route prices
+	AllowedOrigins  []string        `yaml:"allowed_origins,omitempty"`
+}
+
+// Pricing returns the vendor's price list in the format shared with
+// the paywall middleware
+func (v VendorConfig) Pricing() paywall.Pricing {
+	return paywall.Pricing{PricePerRequest: v.PricePerRequest, Routes: v.Routes}
 }
 
 // GatewayConfig stores gateway settings
@@ -53,6 +72,42 @@ type GatewayConfig struct {
 	Version    string `yaml:"version,omitempty"`
 }
 
+// FaucetConfig stores devnet faucet endpoints
+type FaucetConfig struct {
+	USDCURL string `yaml:"usdc_url,omitempty"`
+}
+
+// CacheConfig stores paid-response cache settings
+type CacheConfig struct {
+	Enabled   bool   `yaml:"enabled,omitempty"`     // cache without --cache
+	MaxSizeMB int64  `yaml:"max_size_mb,omitempty"` // default 100
+	TTL       string `yaml:"ttl,omitempty"`         // longest reuse, e.g. "1h"; default 10m
+}
+
+// AgentConfig stores the agent identity registered with the console
+type AgentConfig struct {
+	ID           string `yaml:"id,omitempty"`
+	Name         string `yaml:"name,omitempty"`
+	PublicKey    string `yaml:"public_key,omitempty"`    // key bound to the account
+	SignRequests bool   `yaml:"sign_requests,omitempty"` // sign without --sign-requests
+}
+
+// Clusters a network can run against
+const (
+	ClusterMainnet  = "mainnet"
+	ClusterDevnet   = "devnet"
+	ClusterTestnet  = "testnet"
+	ClusterLocalnet = "localnet"
+)
+
+// NetworkConfig is a custom network: an RPC endpoint on a known cluster
+type NetworkConfig struct {
+	RPCURL   string `yaml:"rpc_url"`
+	Cluster  string `yaml:"cluster"`             // decides console, mint and safety checks
+	USDCMint string `yaml:"usdc_mint,omitempty"` // default: the cluster's USDC
+	Source   string `yaml:"source,omitempty"`    // e.g. the Solana CLI config it came from
+}
+
 var (
 	configDir  string
 	configPath string
@@ -79,26 +134,20 @@ func Init(customPath string) error {
 		return fmt.Errorf("create config dir: %w", err)
 	}
 
-	// Set up viper
-	viper.SetConfigFile(configPath)
-	viper.SetConfigType("yaml")
-
-	// Load config if it exists
-	if _, err := os.Stat(configPath); err == nil {
-		if err := viper.ReadInConfig(); err != nil {
-			return fmt.Errorf("read config: %w", err)
-		}
-	}
-
 	// Initialize default config
 	cfg = &Config{
 		Version: "1.0",
 		Network: "devnet",
 	}
 
-	// Unmarshal into struct
-	if err := viper.Unmarshal(cfg); err != nil {
-		return fmt.Errorf("unmarshal config: %w", err)
+	// Load config if it exists. Decode with the yaml tags Save writes;
+	// viper's decoder would ignore snake_case keys like keypair_path.
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("read config: %w", err)
 	}
 
 	return nil
@@ -147,15 +196,132 @@ func GetPath() string {
 	return configPath
 }
 
+// GetWalletsDir returns the wallet registry directory
+func GetWalletsDir() string {
+	return filepath.Join(configDir, "wallets")
+}
+
+// GetAddressBookPath returns the address book file path
+func GetAddressBookPath() string {
+	return filepath.Join(configDir, "addressbook.yaml")
+}
+
+// GetPolicyPath returns the spending policy file path
+func GetPolicyPath() string {
+	return filepath.Join(configDir, "policy.yaml")
+}
+
+// GetLedgerPath returns the spend ledger file path
+func GetLedgerPath() string {
+	return filepath.Join(configDir, "ledger.jsonl")
+}
+
+// GetCacheDir returns the paid-response cache directory
+func GetCacheDir() string {
+	return filepath.Join(configDir, "cache")
+}
+
+// GetSignerSocket returns the signing agent socket path.
+// MACHPAY_SIGNER_SOCK overrides the default.
+func GetSignerSocket() string {
+	if path := os.Getenv("MACHPAY_SIGNER_SOCK"); path != "" {
+		return path
+	}
+	return filepath.Join(configDir, "signer.sock")
+}
+
+// GetCluster returns the cluster of the configured network
+func GetCluster() string {
+	return ClusterOf(Get().Network)
+}
+
+// ClusterOf returns the cluster a network name runs against. Custom
+// networks name their cluster; unknown names are treated as devnet.
+func ClusterOf(network string) string {
+	if n, ok := Get().Networks[network]; ok {
+		return n.Cluster
+	}
+	switch network {
+	case ClusterMainnet, ClusterTestnet, ClusterLocalnet:
+		return network
+	}
+	return ClusterDevnet
+}
+
+// ClusterRPCURL returns the public RPC endpoint of a cluster
+func ClusterRPCURL(cluster string) string {
+	switch cluster {
+	case ClusterMainnet:
+		return "https://api.mainnet-beta.solana.com"
+	case ClusterTestnet:
+		return "https://api.testnet.solana.com"
+	case ClusterLocalnet:
+		return "http://127.0.0.1:8899"
+	}
+	return "https://api.devnet.solana.com"
+}
+
 // GetConsoleURL returns the MachPay console URL based on network
 func GetConsoleURL() string {
-	if cfg != nil && cfg.Network == "mainnet" {
+	if GetCluster() == ClusterMainnet {
 		return "https://console.machpay.xyz"
 	}
 	// Default to devnet console
 	return "https://console-dev.machpay.xyz"
 }
 
+// GetAPIURL returns the console API base URL for the network.
+// MACHPAY_API_URL overrides it.
+func GetAPIURL() string {
+	if url := os.Getenv("MACHPAY_API_URL"); url != "" {
+		return url
+	}
+	return GetConsoleURL() + "/api"
+}
+
+// GetRPCURL returns the Solana RPC endpoint based on network
+func GetRPCURL() string {
+	c := Get()
+	if n, ok := c.Networks[c.Network]; ok && n.RPCURL != "" {
+		return n.RPCURL
+	}
+	return ClusterRPCURL(GetCluster())
+}
+
+// GetUSDCMint returns the USDC mint address for the configured network
+func GetUSDCMint() string {
+	c := Get()
+	if n, ok := c.Networks[c.Network]; ok && n.USDCMint != "" {
+		return n.USDCMint
+	}
+	if GetCluster() == ClusterMainnet {
+		return "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	}
+	// Default to devnet USDC (Circle)
+	return "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+}
+
+// GetFacilitatorURL returns the x402 facilitator for the configured
+// network. MACHPAY_FACILITATOR_URL overrides it.
+func GetFacilitatorURL() string {
+	if url := os.Getenv("MACHPAY_FACILITATOR_URL"); url != "" {
+		return url
+	}
+	return FacilitatorURLFor(Get().Network)
+}
+
+// FacilitatorURLFor returns the x402 facilitator for a network name:
+// its entry in Facilitators, or the default for its cluster
+func FacilitatorURLFor(network string) string {
+	if url, ok := Get().Facilitators[network]; ok && url != "" {
+		return url
+	}
+	if ClusterOf(network) == ClusterMainnet {
+		return "https://facilitator.machpay.xyz"
+	}
+	return "https://facilitator-dev.machpay.xyz"
+}
+
 // Clear removes all auth credentials
 func Clear() {
 	if cfg != nil {
@@ -184,6 +184,26 @@ func TestGetPath(t *testing.T) {
 	}
 }
 
+func TestGetWalletsDir(t *testing.T) {
+	configDir = "/test/dir"
+	if got := GetWalletsDir(); got != filepath.Join("/test/dir", "wallets") {
+		t.Errorf("GetWalletsDir() = %v, want /test/dir/wallets", got)
+	}
+}
+
+func TestGetSignerSocket(t *testing.T) {
+	configDir = "/test/dir"
+	t.Setenv("MACHPAY_SIGNER_SOCK", "")
+	if got := GetSignerSocket(); got != filepath.Join("/test/dir", "signer.sock") {
+		t.Errorf("GetSignerSocket() = %v, want /test/dir/signer.sock", got)
+	}
+
+	t.Setenv("MACHPAY_SIGNER_SOCK", "/run/agent.sock")
+	if got := GetSignerSocket(); got != "/run/agent.sock" {
+		t.Errorf("GetSignerSocket() = %v, want override", got)
+	}
+}
+
 func TestGetConsoleURL(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -284,3 +304,131 @@ func TestConfigStruct(t *testing.T) {
 	}
 }
 
+func TestGetUSDCMint(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		want    string
+	}{
+		{
+			name:    "mainnet",
+			network: "mainnet",
+			want:    "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+		},
+		{
+			name:    "devnet",
+			network: "devnet",
+			want:    "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = &Config{Network: tt.network}
+			if got := GetUSDCMint(); got != tt.want {
+				t.Errorf("GetUSDCMint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInit_LoadsSavedConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := Init(path); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	Get().Wallet = WalletConfig{Name: "main", KeypairPath: "/keys/main.json", PublicKey: "addr"}
+	Get().Auth.AccessToken = "token"
+	Get().Faucet.USDCURL = "https://faucet.example.com"
+	Get().Networks = map[string]NetworkConfig{"local": {RPCURL: "http://127.0.0.1:8899", Cluster: ClusterLocalnet}}
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	cfg = nil
+	if err := Init(path); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	c := Get()
+	if c.Wallet.KeypairPath != "/keys/main.json" || c.Wallet.PublicKey != "addr" {
+		t.Errorf("Wallet = %+v, snake_case keys were not loaded", c.Wallet)
+	}
+	if c.Auth.AccessToken != "token" {
+		t.Errorf("AccessToken = %q", c.Auth.AccessToken)
+	}
+	if c.Faucet.USDCURL != "https://faucet.example.com" {
+		t.Errorf("Faucet = %+v", c.Faucet)
+	}
+	if c.Networks["local"].Cluster != ClusterLocalnet {
+		t.Errorf("Networks = %+v", c.Networks)
+	}
+}
+
+func TestCustomNetwork(t *testing.T) {
+	cfg = &Config{
+		Network: "private",
+		Networks: map[string]NetworkConfig{
+			"private": {RPCURL: "https://rpc.example.com", Cluster: ClusterMainnet},
+			"local":   {RPCURL: "http://127.0.0.1:8899", Cluster: ClusterLocalnet, USDCMint: "LocalMint111"},
+		},
+	}
+
+	if got := GetCluster(); got != ClusterMainnet {
+		t.Errorf("GetCluster() = %v, want mainnet", got)
+	}
+	if got := GetRPCURL(); got != "https://rpc.example.com" {
+		t.Errorf("GetRPCURL() = %v", got)
+	}
+	if got := GetUSDCMint(); got != "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v" {
+		t.Errorf("GetUSDCMint() = %v, want mainnet USDC", got)
+	}
+	if got := GetConsoleURL(); got != "https://console.machpay.xyz" {
+		t.Errorf("GetConsoleURL() = %v", got)
+	}
+
+	cfg.Network = "local"
+	if got := GetUSDCMint(); got != "LocalMint111" {
+		t.Errorf("GetUSDCMint() = %v, want override", got)
+	}
+
+	for network, want := range map[string]string{"mainnet": ClusterMainnet, "testnet": ClusterTestnet, "devnet": ClusterDevnet, "": ClusterDevnet, "unknown": ClusterDevnet} {
+		if got := ClusterOf(network); got != want {
+			t.Errorf("ClusterOf(%q) = %v, want %v", network, got, want)
+		}
+	}
+}
+
+func TestGetFacilitatorURL(t *testing.T) {
+	cfg = &Config{
+		Network:      "private",
+		Networks:     map[string]NetworkConfig{"private": {RPCURL: "https://rpc.example.com", Cluster: ClusterMainnet}},
+		Facilitators: map[string]string{"devnet": "http://127.0.0.1:9000"},
+	}
+
+	if got := GetFacilitatorURL(); got != "https://facilitator.machpay.xyz" {
+		t.Errorf("GetFacilitatorURL() = %v, want the mainnet default", got)
+	}
+	if got := FacilitatorURLFor("devnet"); got != "http://127.0.0.1:9000" {
+		t.Errorf("FacilitatorURLFor(devnet) = %v, want the configured URL", got)
+	}
+	if got := FacilitatorURLFor("testnet"); got != "https://facilitator-dev.machpay.xyz" {
+		t.Errorf("FacilitatorURLFor(testnet) = %v", got)
+	}
+
+	t.Setenv("MACHPAY_FACILITATOR_URL", "http://override")
+	if got := GetFacilitatorURL(); got != "http://override" {
+		t.Errorf("GetFacilitatorURL() = %v, want the environment override", got)
+	}
+}
+
+func TestGetAPIURL(t *testing.T) {
+	cfg = &Config{Network: "devnet"}
+	if got := GetAPIURL(); got != "https://console-dev.machpay.xyz/api" {
+		t.Errorf("GetAPIURL() = %v", got)
+	}
+	t.Setenv("MACHPAY_API_URL", "http://127.0.0.1:9001")
+	if got := GetAPIURL(); got != "http://127.0.0.1:9001" {
+		t.Errorf("GetAPIURL() = %v, want the environment override", got)
+	}
+}
+
@@ -0,0 +1,91 @@
+// ============================================================
+// Solana CLI Config - Detect an existing solana-cli setup
+// ============================================================
+//
+// Reads ~/.config/solana/cli/config.yml so setup can reuse its
+// keypair and RPC endpoint instead of asking for them again.
+//
+// ============================================================
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SolanaCLINetwork is the custom network name used for a Solana CLI
+// RPC endpoint that is not one of the public clusters
+const SolanaCLINetwork = "solana-cli"
+
+// SolanaCLIConfig is the subset of the Solana CLI config we use
+type SolanaCLIConfig struct {
+	Path        string `yaml:"-"`
+	JSONRPCURL  string `yaml:"json_rpc_url"`
+	KeypairPath string `yaml:"keypair_path"`
+}
+
+// SolanaCLIConfigPath returns the default Solana CLI config location
+func SolanaCLIConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "solana", "cli", "config.yml")
+}
+
+// LoadSolanaCLIConfig reads a Solana CLI config file. Relative and
+// ~ paths in it are resolved the way solana-cli resolves them.
+func LoadSolanaCLIConfig(path string) (*SolanaCLIConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &SolanaCLIConfig{Path: path}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if strings.HasPrefix(c.KeypairPath, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			c.KeypairPath = filepath.Join(home, c.KeypairPath[1:])
+		}
+	}
+	return c, nil
+}
+
+// ClusterForRPCURL recognises the public endpoint of each cluster.
+// ok is false for other (private or local) endpoints.
+func ClusterForRPCURL(rpcURL string) (cluster string, ok bool) {
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return "", false
+	}
+
+	switch host := u.Hostname(); host {
+	case "api.mainnet-beta.solana.com":
+		return ClusterMainnet, true
+	case "api.devnet.solana.com":
+		return ClusterDevnet, true
+	case "api.testnet.solana.com":
+		return ClusterTestnet, true
+	}
+	return "", false
+}
+
+// NetworkForRPCURL returns the MachPay network to use for rpcURL on
+// cluster: the built-in network when rpcURL is that network's public
+// endpoint, otherwise a custom network definition
+func NetworkForRPCURL(rpcURL, cluster string) (string, *NetworkConfig) {
+	if (cluster == ClusterMainnet || cluster == ClusterDevnet) &&
+		strings.TrimRight(rpcURL, "/") == ClusterRPCURL(cluster) {
+		return cluster, nil
+	}
+	return SolanaCLINetwork, &NetworkConfig{RPCURL: rpcURL, Cluster: cluster}
+}
+
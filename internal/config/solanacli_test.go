@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSolanaCLIConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := filepath.Join(home, ".config", "solana", "cli", "config.yml")
+	os.MkdirAll(filepath.Dir(path), 0700)
+	content := `---
+json_rpc_url: "https://api.devnet.solana.com"
+websocket_url: ""
+keypair_path: ~/.config/solana/id.json
+address_labels:
+  "11111111111111111111111111111111": System Program
+commitment: confirmed
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := SolanaCLIConfigPath(); got != path {
+		t.Errorf("SolanaCLIConfigPath() = %v, want %v", got, path)
+	}
+	c, err := LoadSolanaCLIConfig(path)
+	if err != nil {
+		t.Fatalf("LoadSolanaCLIConfig() error = %v", err)
+	}
+	if c.JSONRPCURL != "https://api.devnet.solana.com" {
+		t.Errorf("JSONRPCURL = %v", c.JSONRPCURL)
+	}
+	if want := filepath.Join(home, ".config", "solana", "id.json"); c.KeypairPath != want {
+		t.Errorf("KeypairPath = %v, want %v", c.KeypairPath, want)
+	}
+
+	if _, err := LoadSolanaCLIConfig(filepath.Join(home, "missing.yml")); !os.IsNotExist(err) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+}
+
+func TestClusterForRPCURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		cluster string
+		ok      bool
+	}{
+		{"https://api.mainnet-beta.solana.com", ClusterMainnet, true},
+		{"https://api.devnet.solana.com/", ClusterDevnet, true},
+		{"https://api.testnet.solana.com", ClusterTestnet, true},
+		{"http://localhost:8899", "", false},
+		{"https://mainnet.helius-rpc.com/?api-key=x", "", false},
+	}
+	for _, tt := range tests {
+		cluster, ok := ClusterForRPCURL(tt.url)
+		if cluster != tt.cluster || ok != tt.ok {
+			t.Errorf("ClusterForRPCURL(%q) = %q, %v; want %q, %v", tt.url, cluster, ok, tt.cluster, tt.ok)
+		}
+	}
+}
+
+func TestNetworkForRPCURL(t *testing.T) {
+	if network, custom := NetworkForRPCURL("https://api.devnet.solana.com/", ClusterDevnet); network != "devnet" || custom != nil {
+		t.Errorf("public devnet = %q, %+v; want built-in devnet", network, custom)
+	}
+
+	network, custom := NetworkForRPCURL("https://mainnet.helius-rpc.com/?api-key=x", ClusterMainnet)
+	if network != SolanaCLINetwork || custom == nil {
+		t.Fatalf("private endpoint = %q, %+v; want custom network", network, custom)
+	}
+	if custom.RPCURL != "https://mainnet.helius-rpc.com/?api-key=x" || custom.Cluster != ClusterMainnet {
+		t.Errorf("custom = %+v", custom)
+	}
+
+	if network, _ := NetworkForRPCURL("http://localhost:8899", ClusterLocalnet); network != SolanaCLINetwork {
+		t.Errorf("localnet = %q, want custom network", network)
+	}
+}
+
@@ -0,0 +1,74 @@
+// ============================================================
+// Agents - Agent identities bound to a console account
+// ============================================================
+//
+//   POST /v1/agents
+//       {"user_id", "public_key", "name", "signed_at", "signature"}
+//       Agent
+//
+// Registering binds a wallet's ed25519 key to the logged-in account.
+// The key proves itself by signing RegistrationMessage (base58
+// signature), so a token alone cannot claim someone else's key. The
+// message names the account, so a registration captured for one
+// account cannot be replayed to bind the key to another.
+// Vendors then identify the agent by the keyid of its signed
+// requests (pkg/httpsig).
+//
+// ============================================================
+
+package console
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Agent is an agent identity registered to an account
+type Agent struct {
+	ID        string    `json:"id"`
+	PublicKey string    `json:"public_key"` // base58 ed25519 key
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AgentRegistration asks to bind a key to the account
+type AgentRegistration struct {
+	UserID    string    `json:"user_id"`
+	PublicKey string    `json:"public_key"`
+	Name      string    `json:"name,omitempty"`
+	SignedAt  time.Time `json:"signed_at"`
+	Signature string    `json:"signature"` // base58, of RegistrationMessage
+}
+
+// RegistrationMessage is what the key signs to register with the
+// account userID
+func RegistrationMessage(userID, publicKey, name string, signedAt time.Time) []byte {
+	return []byte(fmt.Sprintf("MachPay agent registration\naccount: %s\nkey: %s\nname: %s\ntime: %s",
+		userID, publicKey, name, signedAt.UTC().Format(time.RFC3339)))
+}
+
+// RegisterAgent binds a key to the logged-in account. Registering a
+// key again updates its name.
+func (c *Client) RegisterAgent(ctx context.Context, reg AgentRegistration) (*Agent, error) {
+	if c.Token == "" {
+		return nil, ErrUnauthorized
+	}
+	if reg.UserID == "" || reg.PublicKey == "" || reg.Signature == "" {
+		return nil, errors.New("registration needs a user ID, a public key and its signature")
+	}
+	body, err := json.Marshal(reg)
+	if err != nil {
+		return nil, err
+	}
+	var agent Agent
+	if err := c.do(ctx, http.MethodPost, "/v1/agents", nil, bytes.NewReader(body), &agent); err != nil {
+		return nil, fmt.Errorf("register agent: %w", err)
+	}
+	return &agent, nil
+}
+
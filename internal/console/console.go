@@ -0,0 +1,153 @@
+// ============================================================
+// Console API - Client for the MachPay console's REST API
+// ============================================================
+//
+// Requests go to config.GetAPIURL() and carry the token stored by
+// 'machpay login' as a bearer token, when there is one:
+//
+//   c := console.New(config.GetAPIURL(), auth.GetToken())
+//   services, err := c.Search(ctx, console.SearchQuery{Query: "weather"})
+//
+// Errors from the API come back as *APIError; a rejected or missing
+// token matches ErrUnauthorized.
+//
+// ============================================================
+
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds each API request
+const DefaultTimeout = 15 * time.Second
+
+// maxResponseSize caps the API responses read
+const maxResponseSize = 4 << 20
+
+// Errors
+var (
+	ErrUnauthorized = errors.New("not logged in to the MachPay console: run 'machpay login'")
+	ErrNotFound     = errors.New("not found")
+)
+
+// APIError is an error response from the console API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("console API: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("console API: %s (%d)", e.Message, e.StatusCode)
+}
+
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Client calls the console API
+type Client struct {
+	BaseURL   string
+	Token     string       // bearer token; "" sends requests anonymously
+	UserAgent string       // optional
+	HTTP      *http.Client // nil uses a client with DefaultTimeout
+}
+
+// New returns a client for the API at baseURL
+func New(baseURL, token string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), Token: token}
+}
+
+// get fetches path with query and decodes the JSON response into out
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, out)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, out interface{}) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	resp, err := c.http().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("read console API response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{StatusCode: resp.StatusCode, Message: errorMessage(data)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode console API response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) http() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return &http.Client{Timeout: DefaultTimeout}
+}
+
+// errorMessage extracts the message of an error body:
+// {"error": "..."}, {"message": "..."} or plain text
+func errorMessage(data []byte) string {
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		if body.Message != "" {
+			return body.Message
+		}
+		return body.Error
+	}
+	msg := strings.TrimSpace(string(data))
+	if len(msg) > 200 || strings.HasPrefix(msg, "<") {
+		return ""
+	}
+	return msg
+}
+
@@ -0,0 +1,107 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Search(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/v1/marketplace/services" || q.Get("q") != "llm" || q.Get("category") != "ai" || q.Get("max_price") != "0.01" {
+			t.Errorf("request %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"services": []Service{{ID: "llm", Category: "ai", PricePerRequest: "0.002"}}})
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/", "tok")
+	services, err := c.Search(context.Background(), SearchQuery{Query: "llm", Category: "ai", MaxPrice: "0.01"})
+	if err != nil || len(services) != 1 || services[0].PricePerRequest != "0.002" {
+		t.Errorf("Search = %+v, %v", services, err)
+	}
+	if _, err := c.Search(context.Background(), SearchQuery{Category: "sports"}); err == nil {
+		t.Error("Search accepted an unknown category")
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"login required"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"no such service"}`))
+		}
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL, "").Search(context.Background(), SearchQuery{})
+	var apiErr *APIError
+	if !errors.Is(err, ErrUnauthorized) || !errors.As(err, &apiErr) || apiErr.Message != "login required" {
+		t.Errorf("anonymous Search = %v, want ErrUnauthorized", err)
+	}
+	if _, err := New(server.URL, "tok").Service(context.Background(), "gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Service = %v, want ErrNotFound", err)
+	}
+	if _, err := New(server.URL, "tok").Service(context.Background(), "../admin"); err == nil {
+		t.Error("Service accepted a path as ID")
+	}
+}
+
+func TestService_Resolve(t *testing.T) {
+	s := &Service{ID: "x", URL: "https://api.example.com/v1/"}
+	for path, want := range map[string]string{
+		"/quote":        "https://api.example.com/v1/quote",
+		"quote?sym=SOL": "https://api.example.com/v1/quote?sym=SOL",
+	} {
+		if got, err := s.Resolve(path); err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	if _, err := (&Service{ID: "x", URL: "ftp://example.com"}).Resolve("/"); err == nil {
+		t.Error("Resolve accepted a non-HTTP service URL")
+	}
+}
+
+func TestClient_RegisterAgent(t *testing.T) {
+	signedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reg AgentRegistration
+		json.NewDecoder(r.Body).Decode(&reg)
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/agents" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request %s %s", r.Method, r.URL)
+		}
+		if reg.UserID != "usr_1" || reg.PublicKey != "key" || reg.Name != "bot" || !reg.SignedAt.Equal(signedAt) || reg.Signature != "sig" {
+			t.Errorf("registration = %+v", reg)
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Agent{ID: "agt_1", PublicKey: reg.PublicKey, Name: reg.Name})
+	}))
+	defer server.Close()
+
+	reg := AgentRegistration{UserID: "usr_1", PublicKey: "key", Name: "bot", SignedAt: signedAt, Signature: "sig"}
+	agent, err := New(server.URL, "tok").RegisterAgent(context.Background(), reg)
+	if err != nil || agent.ID != "agt_1" || agent.PublicKey != "key" {
+		t.Errorf("RegisterAgent = %+v, %v", agent, err)
+	}
+	if _, err := New(server.URL, "").RegisterAgent(context.Background(), reg); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("anonymous RegisterAgent = %v, want ErrUnauthorized", err)
+	}
+
+	msg := string(RegistrationMessage("usr_1", "key", "bot", signedAt))
+	if msg != "MachPay agent registration\naccount: usr_1\nkey: key\nname: bot\ntime: 2026-01-02T03:04:05Z" {
+		t.Errorf("RegistrationMessage = %q", msg)
+	}
+}
+
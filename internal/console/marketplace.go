@@ -0,0 +1,141 @@
+// ============================================================
+// Marketplace - Paid APIs listed on MachPay
+// ============================================================
+//
+//   GET /v1/marketplace/services?q=&category=&max_price=
+//       {"services": [Service...]}
+//   GET /v1/marketplace/services/{id}
+//       Service, with its endpoints
+//
+// Prices are USDC decimal strings, as vendors set them.
+//
+// ============================================================
+
+package console
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Category is a marketplace category
+type Category struct {
+	ID    string
+	Label string
+}
+
+// Categories are the marketplace categories, in the order vendors
+// pick from at setup
+var Categories = []Category{
+	{ID: "ai", Label: "AI/ML"},
+	{ID: "data", Label: "Data"},
+	{ID: "finance", Label: "Finance"},
+	{ID: "compute", Label: "Compute"},
+	{ID: "other", Label: "Other"},
+}
+
+// ValidCategory reports whether id is a marketplace category
+func ValidCategory(id string) bool {
+	for _, c := range Categories {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Service is an API listed on the marketplace
+type Service struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Category        string     `json:"category"`
+	Description     string     `json:"description,omitempty"`
+	URL             string     `json:"url"`                 // base URL to call
+	Network         string     `json:"network,omitempty"`   // x402 network
+	PayTo           string     `json:"pay_to,omitempty"`    // vendor wallet
+	PricePerRequest string     `json:"price_per_request"`   // USDC
+	Endpoints       []Endpoint `json:"endpoints,omitempty"` // only from Service
+}
+
+// Endpoint is a priced route of a service
+type Endpoint struct {
+	Method      string `json:"method,omitempty"` // "" for any
+	Path        string `json:"path"`
+	Price       string `json:"price,omitempty"` // USDC; default: the service's price
+	Description string `json:"description,omitempty"`
+}
+
+// SearchQuery filters a marketplace search. Empty fields match all.
+type SearchQuery struct {
+	Query    string
+	Category string // one of Categories
+	MaxPrice string // USDC per request
+}
+
+// Search lists the services matching q
+func (c *Client) Search(ctx context.Context, q SearchQuery) ([]Service, error) {
+	if q.Category != "" && !ValidCategory(q.Category) {
+		return nil, fmt.Errorf("unknown category %q (want %s)", q.Category, strings.Join(CategoryIDs(), ", "))
+	}
+	query := url.Values{}
+	if q.Query != "" {
+		query.Set("q", q.Query)
+	}
+	if q.Category != "" {
+		query.Set("category", q.Category)
+	}
+	if q.MaxPrice != "" {
+		query.Set("max_price", q.MaxPrice)
+	}
+
+	var resp struct {
+		Services []Service `json:"services"`
+	}
+	if err := c.get(ctx, "/v1/marketplace/services", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Services, nil
+}
+
+// Service returns a service and its endpoints by ID
+func (c *Client) Service(ctx context.Context, id string) (*Service, error) {
+	if id == "" || strings.ContainsAny(id, "/?#") {
+		return nil, fmt.Errorf("invalid service ID %q", id)
+	}
+	var s Service
+	if err := c.get(ctx, "/v1/marketplace/services/"+url.PathEscape(id), nil, &s); err != nil {
+		return nil, fmt.Errorf("service %s: %w", id, err)
+	}
+	return &s, nil
+}
+
+// Resolve returns the URL of path on the service
+func (s *Service) Resolve(path string) (string, error) {
+	base, err := url.Parse(s.URL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return "", fmt.Errorf("service %s has no usable URL (%q)", s.ID, s.URL)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("path %q: %w", path, err)
+	}
+	base.Path = strings.TrimRight(base.Path, "/") + ref.Path
+	base.RawPath = ""
+	base.RawQuery = ref.RawQuery
+	return base.String(), nil
+}
+
+// CategoryIDs returns the IDs of Categories
+func CategoryIDs() []string {
+	ids := make([]string, len(Categories))
+	for i, c := range Categories {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
@@ -40,6 +40,7 @@ type ProcessManager struct {
 	port       int
 	upstream   string
 	debug      bool
+	signerSock string
 }
 
 // NewProcessManager creates a new process manager
@@ -68,6 +69,12 @@ func (pm *ProcessManager) SetUpstream(upstream string) {
 	pm.upstream = upstream
 }
 
+// SetSignerSocket points the gateway at a running signing agent so it
+// can sign without reading the wallet file
+func (pm *ProcessManager) SetSignerSocket(path string) {
+	pm.signerSock = path
+}
+
 // PIDFile returns the path to the PID file
 func (pm *ProcessManager) PIDFile() string {
 	return filepath.Join(pm.configDir, "gateway.pid")
@@ -97,6 +104,7 @@ func (pm *ProcessManager) Start() error {
 	args := pm.buildArgs()
 
 	cmd := exec.Command(pm.binaryPath, args...)
+	cmd.Env = pm.buildEnv()
 
 	// Set up logging to file
 	logFile, err := os.OpenFile(pm.LogFile(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
@@ -148,6 +156,7 @@ func (pm *ProcessManager) StartForeground(ctx context.Context, stdout, stderr io
 	args := pm.buildArgs()
 
 	cmd := exec.CommandContext(ctx, pm.binaryPath, args...)
+	cmd.Env = pm.buildEnv()
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 
@@ -183,6 +192,14 @@ func (pm *ProcessManager) buildArgs() []string {
 	return args
 }
 
+// buildEnv builds the gateway environment; nil inherits ours unchanged
+func (pm *ProcessManager) buildEnv() []string {
+	if pm.signerSock == "" {
+		return nil
+	}
+	return append(os.Environ(), "MACHPAY_SIGNER_SOCK="+pm.signerSock)
+}
+
 // ============================================================
 // Stop Methods
 // ============================================================
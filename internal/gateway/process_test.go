@@ -222,6 +222,20 @@ func TestProcessManager_SetUpstream(t *testing.T) {
 	}
 }
 
+func TestProcessManager_BuildEnv(t *testing.T) {
+	pm := NewProcessManager("/usr/bin/test", 8402, "")
+
+	if env := pm.buildEnv(); env != nil {
+		t.Errorf("buildEnv() = %v, want nil without a signer socket", env)
+	}
+
+	pm.SetSignerSocket("/tmp/signer.sock")
+	env := pm.buildEnv()
+	if len(env) == 0 || env[len(env)-1] != "MACHPAY_SIGNER_SOCK=/tmp/signer.sock" {
+		t.Errorf("buildEnv() should end with MACHPAY_SIGNER_SOCK, got %v", env)
+	}
+}
+
 func TestProcessManager_ClearLogs(t *testing.T) {
 	tmpDir := t.TempDir()
 	pm := &ProcessManager{
@@ -0,0 +1,382 @@
+// ============================================================
+// MCP - Model Context Protocol server over stdio
+// ============================================================
+//
+// A minimal MCP server exposing tools: JSON-RPC 2.0 messages, one per
+// line, on stdin and stdout. It implements the lifecycle
+// (initialize, notifications/initialized), ping, tools/list,
+// tools/call and notifications/cancelled.
+//
+//   s := mcp.NewServer("machpay", version)
+//   s.AddTool(mcp.Tool{Name: "get_balance", ..., Handler: balance})
+//   err := s.Serve(ctx, os.Stdin, os.Stdout)
+//
+// Requests are handled concurrently; a cancelled request's handler
+// sees its context cancelled. Tool failures are reported as tool
+// results with isError set, so the model can read them; malformed
+// calls get JSON-RPC errors.
+//
+// ============================================================
+
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ProtocolVersions are the MCP revisions the server speaks, newest
+// first
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Error is a JSON-RPC error. Handlers return one to fail the call
+// itself instead of reporting a tool error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// InvalidParams returns a CodeInvalidParams error
+func InvalidParams(format string, args ...interface{}) *Error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+// Tool is a tool offered to the client
+type Tool struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+
+	// Handler runs a call with its arguments ({} when none were
+	// given). An *Error fails the request; other errors become a tool
+	// result with isError set.
+	Handler func(ctx context.Context, args json.RawMessage) (*Result, error) `json:"-"`
+}
+
+// Result is the result of a tool call
+type Result struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Content is a content block of a result
+type Content struct {
+	Type string `json:"type"` // "text"
+	Text string `json:"text"`
+}
+
+// Text returns a result holding text
+func Text(text string) *Result {
+	return &Result{Content: []Content{{Type: "text", Text: text}}}
+}
+
+// JSON returns a result holding v as indented JSON text
+func JSON(v interface{}) (*Result, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return Text(string(data)), nil
+}
+
+// Bind decodes tool arguments into v, rejecting unknown arguments
+func Bind(args json.RawMessage, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return InvalidParams("invalid arguments: %v", err)
+	}
+	return nil
+}
+
+// Server serves tools over MCP
+type Server struct {
+	Name         string
+	Version      string
+	Instructions string // optional hint for the model about the tools
+
+	// Logf, if set, receives diagnostics. It must not write to the
+	// protocol's output.
+	Logf func(format string, args ...interface{})
+
+	tools []Tool
+
+	mu          sync.Mutex
+	initialized bool
+	inflight    map[string]context.CancelFunc
+
+	writeMu sync.Mutex
+	out     io.Writer
+}
+
+// NewServer returns a server without tools
+func NewServer(name, version string) *Server {
+	return &Server{Name: name, Version: version}
+}
+
+// AddTool offers a tool
+func (s *Server) AddTool(t Tool) {
+	if len(t.InputSchema) == 0 {
+		t.InputSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+	}
+	s.tools = append(s.tools, t)
+}
+
+// message is an incoming JSON-RPC request, notification or response
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is an outgoing JSON-RPC response
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Serve reads messages from in and writes responses to out until in
+// is closed, then waits for the requests in progress
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.out = out
+	s.inflight = make(map[string]context.CancelFunc)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	r := bufio.NewReader(in)
+	for {
+		line, err := r.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if msg, rpcErr := parseMessage(line); rpcErr != nil {
+				s.write(response{ID: json.RawMessage("null"), Error: rpcErr})
+			} else if msg.Method == "initialize" || len(msg.ID) == 0 {
+				// In order, so that requests sent right after them
+				// find the server initialized
+				s.handle(ctx, msg)
+			} else if msg.Method != "" {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					s.handle(ctx, msg)
+				}()
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseMessage decodes one line. Responses to requests the server
+// never sends are returned without a method and ignored.
+func parseMessage(line []byte) (*message, *Error) {
+	if line[0] == '[' {
+		return nil, &Error{Code: CodeInvalidRequest, Message: "batch requests are not supported"}
+	}
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return nil, &Error{Code: CodeParseError, Message: "parse error: " + err.Error()}
+	}
+	if msg.JSONRPC != "2.0" {
+		return nil, &Error{Code: CodeInvalidRequest, Message: `jsonrpc must be "2.0"`}
+	}
+	return &msg, nil
+}
+
+// handle runs a request or notification
+func (s *Server) handle(ctx context.Context, msg *message) {
+	if len(msg.ID) == 0 {
+		s.notification(msg)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	id := string(msg.ID)
+	s.mu.Lock()
+	s.inflight[id] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.inflight, id)
+		s.mu.Unlock()
+	}()
+
+	result, err := s.call(ctx, msg)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		// Cancelled requests get no response
+		return
+	}
+	resp := response{ID: msg.ID, Result: result}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Result, resp.Error = nil, rpcErr
+	}
+	s.write(resp)
+}
+
+// notification handles a message without an ID
+func (s *Server) notification(msg *message) {
+	switch msg.Method {
+	case "notifications/initialized":
+		s.mu.Lock()
+		s.initialized = true
+		s.mu.Unlock()
+	case "notifications/cancelled":
+		var params struct {
+			RequestID json.RawMessage `json:"requestId"`
+		}
+		if json.Unmarshal(msg.Params, &params) == nil {
+			s.mu.Lock()
+			if cancel, ok := s.inflight[string(params.RequestID)]; ok {
+				cancel()
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// call dispatches a request
+func (s *Server) call(ctx context.Context, msg *message) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		return s.initialize(msg.Params)
+	case "ping":
+		return struct{}{}, nil
+	}
+
+	s.mu.Lock()
+	initialized := s.initialized
+	s.mu.Unlock()
+	if !initialized {
+		return nil, &Error{Code: CodeInvalidRequest, Message: "server not initialized"}
+	}
+
+	switch msg.Method {
+	case "tools/list":
+		return map[string]interface{}{"tools": s.tools}, nil
+	case "tools/call":
+		return s.callTool(ctx, msg.Params)
+	}
+	return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.ProtocolVersion == "" {
+		return nil, InvalidParams("initialize needs a protocolVersion")
+	}
+
+	// Answer with the client's version if spoken, else the latest
+	version := ProtocolVersions[0]
+	for _, v := range ProtocolVersions {
+		if v == p.ProtocolVersion {
+			version = v
+		}
+	}
+	// Clients may skip notifications/initialized; being asked to
+	// initialize is enough to serve them
+	s.mu.Lock()
+	s.initialized = true
+	s.mu.Unlock()
+
+	result := map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    map[string]interface{}{"tools": map[string]interface{}{"listChanged": false}},
+		"serverInfo":      map[string]string{"name": s.Name, "version": s.Version},
+	}
+	if s.Instructions != "" {
+		result["instructions"] = s.Instructions
+	}
+	return result, nil
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.Name == "" {
+		return nil, InvalidParams("tools/call needs a tool name")
+	}
+	var tool *Tool
+	for i := range s.tools {
+		if s.tools[i].Name == p.Name {
+			tool = &s.tools[i]
+		}
+	}
+	if tool == nil {
+		return nil, InvalidParams("unknown tool: %s", p.Name)
+	}
+	if len(p.Arguments) == 0 || string(p.Arguments) == "null" {
+		p.Arguments = json.RawMessage("{}")
+	}
+
+	result, err := tool.Handler(ctx, p.Arguments)
+	var rpcErr *Error
+	switch {
+	case errors.As(err, &rpcErr):
+		return nil, rpcErr
+	case err != nil:
+		s.logf("%s: %v", p.Name, err)
+		result = Text(err.Error())
+		result.IsError = true
+	case result == nil:
+		result = Text("")
+	}
+	return result, nil
+}
+
+// write sends one message on its own line
+func (s *Server) write(resp response) {
+	resp.JSONRPC = "2.0"
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID,
+			Error: &Error{Code: CodeInternalError, Message: "encode response: " + err.Error()}})
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := s.out.Write(append(data, '\n')); err != nil {
+		s.logf("write: %v", err)
+	}
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
+
@@ -0,0 +1,185 @@
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/mcp"
+	"github.com/machpay-xyz/machpay-cli/internal/mcp/mcptest"
+)
+
+func newServer() *mcp.Server {
+	s := mcp.NewServer("test", "1.0.0")
+	s.AddTool(mcp.Tool{
+		Name:        "echo",
+		Description: "Echoes its text",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+			var in struct {
+				Text string `json:"text"`
+			}
+			if err := mcp.Bind(args, &in); err != nil {
+				return nil, err
+			}
+			return mcp.Text(in.Text), nil
+		},
+	})
+	s.AddTool(mcp.Tool{
+		Name:        "fail",
+		Description: "Always fails",
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+			return nil, errors.New("out of USDC")
+		},
+	})
+	s.AddTool(mcp.Tool{
+		Name:        "wait",
+		Description: "Waits until cancelled",
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	return s
+}
+
+func TestServer_Lifecycle(t *testing.T) {
+	c := mcptest.Start(t, newServer())
+
+	// Only ping is served before initialize
+	if resp := c.Call("tools/list", nil); resp.Error == nil || resp.Error.Code != mcp.CodeInvalidRequest {
+		t.Errorf("tools/list before initialize = %s, %v", resp.Result, resp.Error)
+	}
+	if resp := c.Call("ping", nil); resp.Error != nil || string(resp.Result) != "{}" {
+		t.Errorf("ping = %s, %v", resp.Result, resp.Error)
+	}
+
+	// An unknown version is answered with the latest
+	resp := c.Call("initialize", map[string]interface{}{
+		"protocolVersion": "2099-01-01",
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "test", "version": "1"},
+	})
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		Capabilities    struct {
+			Tools *struct{} `json:"tools"`
+		} `json:"capabilities"`
+		ServerInfo struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	if err := json.Unmarshal(resp.Result, &init); err != nil || resp.Error != nil {
+		t.Fatalf("initialize = %s, %v", resp.Result, resp.Error)
+	}
+	if init.ProtocolVersion != mcp.ProtocolVersions[0] || init.Capabilities.Tools == nil || init.ServerInfo.Name != "test" {
+		t.Errorf("initialize = %s", resp.Result)
+	}
+	c.Notify("notifications/initialized", nil)
+
+	// A supported version is echoed
+	c2 := mcptest.Start(t, newServer())
+	resp = c2.Call("initialize", map[string]interface{}{"protocolVersion": "2024-11-05", "capabilities": map[string]interface{}{}})
+	if err := json.Unmarshal(resp.Result, &init); err != nil || init.ProtocolVersion != "2024-11-05" {
+		t.Errorf("initialize 2024-11-05 = %s", resp.Result)
+	}
+
+	var list struct {
+		Tools []struct {
+			Name        string          `json:"name"`
+			Description string          `json:"description"`
+			InputSchema json.RawMessage `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	resp = c.Call("tools/list", map[string]interface{}{})
+	if err := json.Unmarshal(resp.Result, &list); err != nil || len(list.Tools) != 3 {
+		t.Fatalf("tools/list = %s, %v", resp.Result, resp.Error)
+	}
+	for _, tool := range list.Tools {
+		var schema struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(tool.InputSchema, &schema) != nil || schema.Type != "object" || tool.Description == "" {
+			t.Errorf("tool %s has input schema %s", tool.Name, tool.InputSchema)
+		}
+	}
+}
+
+func TestServer_ToolCalls(t *testing.T) {
+	c := mcptest.Start(t, newServer())
+	c.Initialize()
+
+	result, rpcErr := c.CallTool("echo", map[string]string{"text": "hi"})
+	if rpcErr != nil || result.IsError || len(result.Content) != 1 || result.Content[0].Type != "text" || result.Content[0].Text != "hi" {
+		t.Errorf("echo = %+v, %v", result, rpcErr)
+	}
+
+	// Tool failures are results the model can read
+	result, rpcErr = c.CallTool("fail", nil)
+	if rpcErr != nil || !result.IsError || result.Content[0].Text != "out of USDC" {
+		t.Errorf("fail = %+v, %v", result, rpcErr)
+	}
+
+	// Malformed calls are protocol errors
+	if _, rpcErr = c.CallTool("nope", nil); rpcErr == nil || rpcErr.Code != mcp.CodeInvalidParams {
+		t.Errorf("unknown tool: %v", rpcErr)
+	}
+	if _, rpcErr = c.CallTool("echo", map[string]interface{}{"text": "hi", "extra": 1}); rpcErr == nil || rpcErr.Code != mcp.CodeInvalidParams {
+		t.Errorf("unknown argument: %v", rpcErr)
+	}
+	if resp := c.Call("resources/list", nil); resp.Error == nil || resp.Error.Code != mcp.CodeMethodNotFound {
+		t.Errorf("resources/list = %v", resp.Error)
+	}
+}
+
+func TestServer_Malformed(t *testing.T) {
+	c := mcptest.Start(t, newServer())
+	c.Initialize()
+
+	for _, tc := range []struct {
+		line string
+		code int
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":`, mcp.CodeParseError},
+		{`{"jsonrpc":"1.0","id":1,"method":"ping"}`, mcp.CodeInvalidRequest},
+		{`[{"jsonrpc":"2.0","id":1,"method":"ping"}]`, mcp.CodeInvalidRequest},
+	} {
+		c.Send(tc.line)
+		resp := c.Recv()
+		if resp == nil || resp.Error == nil || resp.Error.Code != tc.code || string(resp.ID) != "null" {
+			t.Errorf("%s: got %+v", tc.line, resp)
+		}
+	}
+
+	// Notifications and responses get no answer: the next message is
+	// the ping's
+	c.Notify("notifications/progress", map[string]interface{}{"progressToken": 1, "progress": 1})
+	c.Send(`{"jsonrpc":"2.0","id":"from-server","result":{}}`)
+	c.Send(`{"jsonrpc":"2.0","id":"p","method":"ping"}`)
+	if resp := c.Recv(); resp == nil || string(resp.ID) != `"p"` {
+		t.Errorf("after notifications got %+v, want the ping response", resp)
+	}
+}
+
+func TestServer_Cancel(t *testing.T) {
+	c := mcptest.Start(t, newServer())
+	c.Initialize()
+
+	id := c.Request("tools/call", map[string]interface{}{"name": "wait"})
+	time.Sleep(50 * time.Millisecond)
+	c.Notify("notifications/cancelled", map[string]interface{}{"requestId": id, "reason": "user"})
+
+	// The cancelled call is never answered; the server moves on
+	resp := c.Call("ping", nil)
+	if resp.Error != nil {
+		t.Errorf("ping = %v", resp.Error)
+	}
+	c.Send(`{"jsonrpc":"2.0","id":"last","method":"ping"}`)
+	if resp := c.Recv(); resp == nil || string(resp.ID) != `"last"` {
+		t.Errorf("got %+v after cancelling, want no response to the cancelled call", resp)
+	}
+}
+
@@ -0,0 +1,221 @@
+// ============================================================
+// MCP Test - Scripted MCP client for testing servers
+// ============================================================
+//
+//   c := mcptest.Start(t, server)
+//   c.Initialize()
+//   result, rpcErr := c.CallTool("get_balance", nil)
+//   c.Send(`{"jsonrpc":"2.0","id":9,"method":"nope"}`)   // raw lines
+//   resp := c.Recv()
+//
+// The server runs on in-memory pipes for the test's duration.
+//
+// ============================================================
+
+package mcptest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/mcp"
+)
+
+// Timeout is how long Recv and Call wait for a message
+var Timeout = 10 * time.Second
+
+// Response is a message from the server
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *mcp.Error      `json:"error"`
+}
+
+// Client talks to a server like an MCP host would
+type Client struct {
+	t       testing.TB
+	w       io.Writer
+	lines   chan []byte
+	nextID  int
+	pending []*Response
+}
+
+// Start serves s over pipes until the test ends
+func Start(t testing.TB, s *mcp.Server) *Client {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &Client{t: t, w: inW, lines: make(chan []byte, 64)}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve(context.Background(), inR, outW)
+		outW.Close()
+	}()
+	go func() {
+		defer close(c.lines)
+		r := bufio.NewReader(outR)
+		for {
+			line, err := r.ReadBytes('\n')
+			if len(line) > 0 {
+				c.lines <- line
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		inW.Close()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Serve: %v", err)
+			}
+		case <-time.After(Timeout):
+			t.Error("Serve did not return after stdin closed")
+		}
+	})
+	return c
+}
+
+// Send writes a raw line
+func (c *Client) Send(line string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.w, line+"\n"); err != nil {
+		c.t.Fatalf("send: %v", err)
+	}
+}
+
+// Notify sends a notification
+func (c *Client) Notify(method string, params interface{}) {
+	c.t.Helper()
+	c.send(nil, method, params)
+}
+
+// Request sends a request and returns its ID without waiting
+func (c *Client) Request(method string, params interface{}) json.RawMessage {
+	c.t.Helper()
+	c.nextID++
+	id := json.RawMessage(mustJSON(c.t, c.nextID))
+	c.send(id, method, params)
+	return id
+}
+
+// Call sends a request and waits for its response; other messages
+// received meanwhile are kept for Recv
+func (c *Client) Call(method string, params interface{}) *Response {
+	c.t.Helper()
+	id := c.Request(method, params)
+	for i, resp := range c.pending {
+		if bytes.Equal(resp.ID, id) {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return resp
+		}
+	}
+	for {
+		resp := c.read()
+		if resp == nil {
+			c.t.Fatalf("no response to %s", method)
+		}
+		if bytes.Equal(resp.ID, id) {
+			return resp
+		}
+		c.pending = append(c.pending, resp)
+	}
+}
+
+// Recv returns the next message, or nil if none arrives in time
+func (c *Client) Recv() *Response {
+	c.t.Helper()
+	if len(c.pending) > 0 {
+		resp := c.pending[0]
+		c.pending = c.pending[1:]
+		return resp
+	}
+	return c.read()
+}
+
+// Initialize runs the initialization handshake and returns the
+// server's initialize result
+func (c *Client) Initialize() json.RawMessage {
+	c.t.Helper()
+	resp := c.Call("initialize", map[string]interface{}{
+		"protocolVersion": mcp.ProtocolVersions[0],
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "mcptest", "version": "1"},
+	})
+	if resp.Error != nil {
+		c.t.Fatalf("initialize: %v", resp.Error)
+	}
+	c.Notify("notifications/initialized", nil)
+	return resp.Result
+}
+
+// CallTool calls a tool. A JSON-RPC error is returned as such; tool
+// errors are results with IsError set.
+func (c *Client) CallTool(name string, args interface{}) (*mcp.Result, *mcp.Error) {
+	c.t.Helper()
+	params := map[string]interface{}{"name": name}
+	if args != nil {
+		params["arguments"] = args
+	}
+	resp := c.Call("tools/call", params)
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	var result mcp.Result
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		c.t.Fatalf("tools/call %s: result %s: %v", name, resp.Result, err)
+	}
+	return &result, nil
+}
+
+func (c *Client) send(id json.RawMessage, method string, params interface{}) {
+	c.t.Helper()
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if id != nil {
+		msg["id"] = id
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	c.Send(string(mustJSON(c.t, msg)))
+}
+
+func (c *Client) read() *Response {
+	c.t.Helper()
+	select {
+	case line, ok := <-c.lines:
+		if !ok {
+			return nil
+		}
+		var resp Response
+		if err := json.Unmarshal(line, &resp); err != nil {
+			c.t.Fatalf("server sent invalid JSON %q: %v", line, err)
+		}
+		if resp.JSONRPC != "2.0" {
+			c.t.Errorf("message without jsonrpc 2.0: %s", line)
+		}
+		return &resp
+	case <-time.After(Timeout):
+		return nil
+	}
+}
+
+func mustJSON(t testing.TB, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
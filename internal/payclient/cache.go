@@ -0,0 +1,544 @@
+// ============================================================
+// Pay Cache - Reuse paid GET responses instead of paying again
+// ============================================================
+//
+// Layout: ~/.machpay/cache/
+//
+//   entries/<key>.<expires>.json
+//                        stored response: status, headers, body,
+//                        price paid, stored and expiry times; the
+//                        expiry (Unix seconds) is in the name too
+//   vary/<resource>      header names the stored response varies on
+//   stats.jsonl          one line per store or hit, for the savings
+//
+// A response is found by the request method and URL and the payment
+// requirements the server sent (so a new price or payee misses),
+// then by the values of the headers it named in Vary. Authorization
+// always counts as varying.
+//
+// Only 200 responses to paid GETs are stored, for as long as the
+// vendor's Cache-Control max-age or Expires allows, capped at the
+// TTL; responses with neither live for the TTL. no-store, no-cache,
+// Vary: * and Set-Cookie responses are not stored. When the cache
+// outgrows its size cap the least recently used entries go first;
+// pruning goes by file names and stat info alone, without reading
+// the entries.
+//
+// The cache is best effort: failing to read or write it never fails
+// a request.
+//
+// ============================================================
+
+package payclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// Cache defaults
+const (
+	DefaultCacheMaxBytes = 100 << 20
+	DefaultCacheTTL      = 10 * time.Minute
+
+	// maxCacheEntry bounds a stored body, which is held in memory
+	maxCacheEntry = 16 << 20
+)
+
+// Headers set on responses served from the cache
+const (
+	CacheHeader      = "X-Machpay-Cache"       // "HIT"
+	CacheSavedHeader = "X-Machpay-Cache-Saved" // USDC atomic units not paid
+)
+
+// Cache stores paid GET responses on disk
+type Cache struct {
+	Dir      string
+	MaxBytes int64         // cap on the size of stored entries
+	TTL      time.Duration // longest a response is reused
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// CacheStats summarizes a cache and what it saved
+type CacheStats struct {
+	Entries int
+	Bytes   int64
+	Stores  int       // paid responses stored
+	Hits    int       // requests served without paying
+	Saved   uint64    // USDC atomic units
+	Since   time.Time // first recorded store or hit; zero if none
+}
+
+// cacheEntry is a stored response
+type cacheEntry struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	PayTo   string      `json:"pay_to"`
+	Amount  uint64      `json:"amount"` // USDC atomic units paid for it
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header"`
+	Body    []byte      `json:"body"`
+	Stored  time.Time   `json:"stored"`
+	Expires time.Time   `json:"expires"`
+}
+
+// cacheEvent is a stats.jsonl line
+type cacheEvent struct {
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"` // "store" or "hit"
+	URL    string    `json:"url"`
+	PayTo  string    `json:"pay_to"`
+	Amount uint64    `json:"amount"`
+}
+
+// uncachedHeaders are not stored: they describe the connection or the
+// original payment, not the content
+var uncachedHeaders = []string{
+	x402.HeaderPaymentResponse,
+	"Set-Cookie",
+	"Age",
+	"Date",
+}
+
+// NewCache returns a cache in dir. Zero maxBytes or ttl use the
+// defaults.
+func NewCache(dir string, maxBytes int64, ttl time.Duration) *Cache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultCacheMaxBytes
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Cache{Dir: dir, MaxBytes: maxBytes, TTL: ttl}
+}
+
+// CacheHit reports whether resp was served from a cache, and what
+// paying for it would have cost
+func CacheHit(resp *http.Response) (saved uint64, ok bool) {
+	if resp.Header.Get(CacheHeader) != "HIT" {
+		return 0, false
+	}
+	saved, _ = strconv.ParseUint(resp.Header.Get(CacheSavedHeader), 10, 64)
+	return saved, true
+}
+
+// lookup returns a fresh stored response to req for offer
+func (c *Cache) lookup(req *http.Request, offer x402.PaymentRequirements) (*http.Response, bool) {
+	if !cacheableRequest(req) || hasDirective(req.Header, "no-cache") {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resource := resourceKey(req, offer)
+	vary, err := os.ReadFile(c.varyPath(resource))
+	if err != nil {
+		return nil, false
+	}
+	path, ok := c.findEntry(variantKey(resource, req, strings.Fields(string(vary))))
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil || !c.clock().Before(e.Expires) {
+		os.Remove(path)
+		return nil, false
+	}
+
+	// Touch for least-recently-used eviction
+	now := c.clock()
+	os.Chtimes(path, now, now)
+	c.record(cacheEvent{Time: now, Event: "hit", URL: e.URL, PayTo: e.PayTo, Amount: e.Amount})
+
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Age", strconv.Itoa(int(now.Sub(e.Stored).Seconds())))
+	header.Set(CacheHeader, "HIT")
+	header.Set(CacheSavedHeader, strconv.FormatUint(e.Amount, 10))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}, true
+}
+
+// store saves the response to a paid request if it may be reused,
+// and returns a response to use in its place
+func (c *Cache) store(req *http.Request, offer x402.PaymentRequirements, amount uint64, resp *http.Response) *http.Response {
+	if !cacheableRequest(req) || hasDirective(req.Header, "no-store") || resp.ContentLength > maxCacheEntry {
+		return resp
+	}
+	now := c.clock()
+	lifetime, ok := freshness(resp.Header, now, c.TTL)
+	if !ok || resp.StatusCode != http.StatusOK {
+		return resp
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCacheEntry+1))
+	if err != nil || len(body) > maxCacheEntry || int64(len(body)) > c.MaxBytes {
+		// Hand back what was read followed by the rest
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	for _, name := range uncachedHeaders {
+		header.Del(name)
+	}
+	removeHopHeaders(header)
+	e := cacheEntry{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		PayTo:   offer.PayTo,
+		Amount:  amount,
+		Status:  resp.StatusCode,
+		Header:  header,
+		Body:    body,
+		Stored:  now,
+		Expires: now.Add(lifetime),
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return resp
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resource := resourceKey(req, offer)
+	vary := varyNames(resp.Header)
+	key := variantKey(resource, req, vary)
+	old, _ := c.findEntry(key)
+	path := c.entryPath(key, e.Expires)
+	if writeFileAtomic(c.varyPath(resource), []byte(strings.Join(vary, "\n"))) != nil ||
+		writeFileAtomic(path, data) != nil {
+		return resp
+	}
+	if old != "" && old != path {
+		os.Remove(old)
+	}
+	c.record(cacheEvent{Time: now, Event: "store", URL: e.URL, PayTo: e.PayTo, Amount: amount})
+	c.prune()
+	return resp
+}
+
+// prune removes expired entries, then the least recently used until
+// the cache fits in MaxBytes. Callers hold mu.
+func (c *Cache) prune() {
+	dir := filepath.Join(c.Dir, "entries")
+	files, _ := os.ReadDir(dir)
+	type stored struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var entries []stored
+	var total int64
+	now := c.clock()
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		if expires, ok := entryExpiry(file.Name()); !ok || !now.Before(expires) {
+			os.Remove(path)
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, stored{path, info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].used.Before(entries[j].used) })
+	for _, e := range entries {
+		if total <= c.MaxBytes {
+			break
+		}
+		if os.Remove(e.path) == nil {
+			total -= e.size
+		}
+	}
+}
+
+// Stats returns what is stored and what the cache has saved
+func (c *Cache) Stats() (CacheStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var stats CacheStats
+	files, _ := filepath.Glob(filepath.Join(c.Dir, "entries", "*.json"))
+	for _, path := range files {
+		if info, err := os.Stat(path); err == nil {
+			stats.Entries++
+			stats.Bytes += info.Size()
+		}
+	}
+
+	f, err := os.Open(c.statsPath())
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, fmt.Errorf("open cache stats: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e cacheEvent
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		if stats.Since.IsZero() || e.Time.Before(stats.Since) {
+			stats.Since = e.Time
+		}
+		switch e.Event {
+		case "store":
+			stats.Stores++
+		case "hit":
+			stats.Hits++
+			stats.Saved += e.Amount
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("read cache stats: %w", err)
+	}
+	return stats, nil
+}
+
+// Clear removes every stored response and returns how many there
+// were. The savings record is kept.
+func (c *Cache) Clear() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, _ := filepath.Glob(filepath.Join(c.Dir, "entries", "*.json"))
+	for _, dir := range []string{"entries", "vary"} {
+		if err := os.RemoveAll(filepath.Join(c.Dir, dir)); err != nil {
+			return 0, fmt.Errorf("clear cache: %w", err)
+		}
+	}
+	return len(files), nil
+}
+
+// record appends an event to the stats file
+func (c *Cache) record(e cacheEvent) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(c.Dir, 0700) != nil {
+		return
+	}
+	f, err := os.OpenFile(c.statsPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	f.Write(append(line, '\n'))
+	f.Close()
+}
+
+func (c *Cache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *Cache) entryPath(key string, expires time.Time) string {
+	return filepath.Join(c.Dir, "entries", fmt.Sprintf("%s.%d.json", key, expires.Unix()))
+}
+
+// findEntry returns the stored entry for key, if there is one
+func (c *Cache) findEntry(key string) (string, bool) {
+	// Keys are hex, so the pattern has no metacharacters to escape
+	matches, _ := filepath.Glob(filepath.Join(c.Dir, "entries", key+".*.json"))
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[len(matches)-1], true
+}
+
+// entryExpiry reads the expiry from an entry's file name
+func entryExpiry(name string) (time.Time, bool) {
+	parts := strings.Split(name, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	seconds, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+func (c *Cache) varyPath(key string) string {
+	return filepath.Join(c.Dir, "vary", key)
+}
+
+func (c *Cache) statsPath() string {
+	return filepath.Join(c.Dir, "stats.jsonl")
+}
+
+// cacheableRequest reports whether a request may be answered from
+// or stored in the cache
+func cacheableRequest(req *http.Request) bool {
+	return req.Method == http.MethodGet && req.Header.Get("Range") == ""
+}
+
+// resourceKey identifies what was paid for: method, URL and the
+// payment requirements accepted
+func resourceKey(req *http.Request, offer x402.PaymentRequirements) string {
+	requirements, _ := json.Marshal(offer)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s", req.Method, req.URL.String(), requirements)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// variantKey narrows a resource to the request's values of the
+// headers the response varies on
+func variantKey(resource string, req *http.Request, vary []string) string {
+	h := sha256.New()
+	h.Write([]byte(resource))
+	for _, name := range vary {
+		fmt.Fprintf(h, "\n%s: %s", name, strings.Join(req.Header.Values(name), ", "))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// varyNames returns the sorted header names a response varies on,
+// always including Authorization
+func varyNames(header http.Header) []string {
+	names := map[string]bool{"Authorization": true}
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names[http.CanonicalHeaderKey(name)] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// freshness returns how long a response may be reused, at most ttl,
+// or false if it must not be stored
+func freshness(header http.Header, now time.Time, ttl time.Duration) (time.Duration, bool) {
+	if hasDirective(header, "no-store") || hasDirective(header, "no-cache") ||
+		header.Get("Set-Cookie") != "" || strings.Contains(header.Get("Vary"), "*") {
+		return 0, false
+	}
+
+	lifetime := ttl
+	if maxAge, ok := directive(header, "max-age"); ok {
+		seconds, err := strconv.ParseInt(maxAge, 10, 64)
+		if err != nil || seconds <= 0 {
+			return 0, false
+		}
+		lifetime = time.Duration(seconds) * time.Second
+		if age, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && age > 0 {
+			lifetime -= time.Duration(age) * time.Second
+		}
+	} else if value := header.Get("Expires"); value != "" {
+		expires, err := http.ParseTime(value)
+		if err != nil {
+			return 0, false
+		}
+		date := now
+		if d, err := http.ParseTime(header.Get("Date")); err == nil {
+			date = d
+		}
+		lifetime = expires.Sub(date)
+	}
+
+	if lifetime <= 0 {
+		return 0, false
+	}
+	if lifetime > ttl {
+		lifetime = ttl
+	}
+	return lifetime, true
+}
+
+// hasDirective reports whether Cache-Control has a directive
+func hasDirective(header http.Header, name string) bool {
+	_, ok := directive(header, name)
+	return ok
+}
+
+// directive returns the value of a Cache-Control directive
+func directive(header http.Header, name string) (string, bool) {
+	for _, value := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(value, ",") {
+			key, val, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if strings.EqualFold(key, name) {
+				return strings.Trim(val, `"`), true
+			}
+		}
+	}
+	return "", false
+}
+
+// writeFileAtomic writes data to path via a temporary file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readCloser reads from one reader and closes another
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
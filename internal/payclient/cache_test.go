@@ -0,0 +1,211 @@
+package payclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getVia fetches url through client and returns the body and what a
+// cache hit saved
+func getVia(t *testing.T, client *Client, url string, header http.Header) (body string, saved uint64, hit bool) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, _, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	saved, hit = CacheHit(resp)
+	return string(data), saved, hit
+}
+
+func TestCache_ReusesPaidGET(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	vendor.header = http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept"}}
+	client, _ := newTestClient(t, 2000)
+	client.Cache = NewCache(t.TempDir(), 0, 0)
+
+	if body, _, hit := getVia(t, client, vendor.URL+"/quote", nil); hit || body != `{"quote":42}` {
+		t.Fatalf("first GET = %q, hit %v", body, hit)
+	}
+	body, saved, hit := getVia(t, client, vendor.URL+"/quote", nil)
+	if !hit || saved != 1500 || body != `{"quote":42}` || vendor.settled != 1 {
+		t.Fatalf("second GET = %q, hit %v saved %d; vendor settled %d", body, hit, saved, vendor.settled)
+	}
+
+	// Another variant, resource or price is paid for
+	getVia(t, client, vendor.URL+"/quote", http.Header{"Accept": {"text/csv"}})
+	getVia(t, client, vendor.URL+"/other", nil)
+	vendor.price = 1800
+	getVia(t, client, vendor.URL+"/quote", nil)
+	if vendor.settled != 4 {
+		t.Errorf("vendor settled %d, want 4", vendor.settled)
+	}
+
+	// Unless asked not to
+	if _, _, hit := getVia(t, client, vendor.URL+"/quote", http.Header{"Cache-Control": {"no-cache"}}); hit {
+		t.Error("no-cache request served from cache")
+	}
+
+	stats, err := client.Cache.Stats()
+	if err != nil || stats.Hits != 1 || stats.Saved != 1500 || stats.Stores != 5 || stats.Entries != 4 || stats.Since.IsZero() {
+		t.Errorf("Stats = %+v, %v", stats, err)
+	}
+
+	if n, err := client.Cache.Clear(); err != nil || n != 4 {
+		t.Errorf("Clear = %d, %v", n, err)
+	}
+	if _, _, hit := getVia(t, client, vendor.URL+"/other", nil); hit {
+		t.Error("cleared response served from cache")
+	}
+	if stats, _ := client.Cache.Stats(); stats.Saved != 1500 {
+		t.Errorf("Clear lost the savings: %+v", stats)
+	}
+}
+
+func TestCache_Expiry(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	vendor.header = http.Header{"Cache-Control": {"private, max-age=30"}}
+	client, _ := newTestClient(t, 2000)
+	client.Cache = NewCache(t.TempDir(), 0, time.Hour)
+	now := time.Now()
+	client.Cache.now = func() time.Time { return now }
+
+	getVia(t, client, vendor.URL, nil)
+	now = now.Add(20 * time.Second)
+	if _, _, hit := getVia(t, client, vendor.URL, nil); !hit {
+		t.Error("fresh response not reused")
+	}
+	now = now.Add(20 * time.Second)
+	if _, _, hit := getVia(t, client, vendor.URL, nil); hit {
+		t.Error("stale response reused")
+	}
+	if vendor.settled != 2 {
+		t.Errorf("vendor settled %d, want 2", vendor.settled)
+	}
+}
+
+func TestCache_NotStored(t *testing.T) {
+	for _, header := range []http.Header{
+		{"Cache-Control": {"no-store"}},
+		{"Cache-Control": {"no-cache"}},
+		{"Cache-Control": {"max-age=0"}},
+		{"Vary": {"*"}},
+		{"Set-Cookie": {"session=1"}},
+	} {
+		vendor := newFakeVendor(t, 1500)
+		vendor.header = header
+		client, _ := newTestClient(t, 2000)
+		client.Cache = NewCache(t.TempDir(), 0, 0)
+
+		getVia(t, client, vendor.URL, nil)
+		if _, _, hit := getVia(t, client, vendor.URL, nil); hit || vendor.settled != 2 {
+			t.Errorf("%v: response reused", header)
+		}
+	}
+
+	// Nor are POSTs
+	vendor := newFakeVendor(t, 1500)
+	client, _ := newTestClient(t, 2000)
+	client.Cache = NewCache(t.TempDir(), 0, 0)
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodPost, vendor.URL, strings.NewReader("{}"))
+		resp, _, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if vendor.settled != 2 {
+		t.Errorf("POST reused: vendor settled %d", vendor.settled)
+	}
+}
+
+func TestCache_SizeCap(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	client, _ := newTestClient(t, 2000)
+	dir := t.TempDir()
+	client.Cache = NewCache(dir, 0, 0)
+
+	getVia(t, client, vendor.URL+"/a", nil)
+	files, _ := filepath.Glob(filepath.Join(dir, "entries", "*.json"))
+	info, _ := os.Stat(files[0])
+	client.Cache.MaxBytes = info.Size()*2 + info.Size()/2
+
+	getVia(t, client, vendor.URL+"/b", nil)
+	getVia(t, client, vendor.URL+"/a", nil) // most recently used
+	getVia(t, client, vendor.URL+"/c", nil)
+
+	if stats, _ := client.Cache.Stats(); stats.Entries != 2 || stats.Bytes > client.Cache.MaxBytes {
+		t.Errorf("Stats = %+v, want 2 entries within %d bytes", stats, client.Cache.MaxBytes)
+	}
+	if _, _, hit := getVia(t, client, vendor.URL+"/a", nil); !hit {
+		t.Error("recently used entry evicted")
+	}
+	if _, _, hit := getVia(t, client, vendor.URL+"/b", nil); hit {
+		t.Error("least recently used entry kept")
+	}
+}
+
+func TestCache_PruneByName(t *testing.T) {
+	dir := t.TempDir()
+	c := NewCache(dir, 0, 0)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	// Pruning goes by the expiry in the name, not the contents
+	names := map[string]bool{
+		fmt.Sprintf("aa.%d.json", now.Add(-time.Second).Unix()): false,
+		fmt.Sprintf("bb.%d.json", now.Add(time.Hour).Unix()):    true,
+		"cc.json": false,
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "entries"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name := range names {
+		if err := os.WriteFile(filepath.Join(dir, "entries", name), []byte("not json"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.prune()
+	for name, kept := range names {
+		if _, err := os.Stat(filepath.Join(dir, "entries", name)); (err == nil) != kept {
+			t.Errorf("%s: kept %v, want %v", name, err == nil, kept)
+		}
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ttl := 10 * time.Minute
+	for _, tc := range []struct {
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{http.Header{}, ttl, true},
+		{http.Header{"Cache-Control": {"public, max-age=60"}}, time.Minute, true},
+		{http.Header{"Cache-Control": {"max-age=60"}, "Age": {"15"}}, 45 * time.Second, true},
+		{http.Header{"Cache-Control": {"max-age=86400"}}, ttl, true},
+		{http.Header{"Expires": {now.Add(2 * time.Minute).Format(http.TimeFormat)}, "Date": {now.Format(http.TimeFormat)}}, 2 * time.Minute, true},
+		{http.Header{"Expires": {"0"}}, 0, false},
+		{http.Header{"Cache-Control": {"max-age=abc"}}, 0, false},
+		{http.Header{"Cache-Control": {"No-Store"}}, 0, false},
+	} {
+		got, ok := freshness(tc.header, now, ttl)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("freshness(%v) = %v, %v; want %v, %v", tc.header, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
@@ -0,0 +1,393 @@
+// ============================================================
+// Pay Client - HTTP client that pays x402 invoices
+// ============================================================
+//
+// Do sends a request; when the server answers 402 Payment Required
+// it picks the cheapest offer it can pay (Solana "exact" scheme, the
+// configured network and USDC mint, at most MaxPrice), builds and
+// signs the USDC transfer, and retries with the X-PAYMENT header.
+//
+// The payment transaction is:
+//
+//   1. Compute budget: unit limit
+//   2. Compute budget: unit price
+//   3. ATA: create payTo's USDC account if missing (payer pays rent)
+//   4. Token: transferChecked amount from payer to payTo
+//
+// with the facilitator (extra.feePayer) as fee payer when given.
+// Signing goes through a wallet.Signer, so the spending policy and
+// signing agent apply as for any other signature.
+//
+// With Sessions, vendors that offer prepaid sessions get one deposit
+// and later requests carry the session token (see session.go).
+//
+// With a Cache, a GET whose response was already paid for under the
+// same requirements is answered from disk instead (see cache.go).
+//
+// ============================================================
+
+package payclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// Payment transaction parameters
+const (
+	usdcDecimals     = 6
+	computeUnitLimit = 60_000
+	computeUnitPrice = 1 // micro-lamports per unit
+
+	maxRequirementsSize = 1 << 20
+)
+
+// Errors
+var (
+	ErrNoAcceptableOffer = errors.New("no payment option this wallet can pay")
+	ErrPaymentRejected   = errors.New("payment rejected by the server")
+	ErrBodyNotReplayable = errors.New("request body cannot be resent after a 402")
+	ErrNoSigner          = errors.New("payment required but no wallet to pay with")
+)
+
+// describedSigner is implemented by signers that show the approver a
+// description, like the signing agent client
+type describedSigner interface {
+	SignWithDescription(message []byte, description string) ([]byte, error)
+}
+
+// PriceError reports an offer above the price ceiling
+type PriceError struct {
+	Requirements x402.PaymentRequirements
+	Amount       uint64
+	Max          uint64
+}
+
+func (e *PriceError) Error() string {
+	if e.Max == 0 {
+		return fmt.Sprintf("payment of %s USDC required; no maximum price set",
+			solana.FormatTokenAmount(e.Amount, usdcDecimals))
+	}
+	return fmt.Sprintf("price of %s USDC exceeds the maximum of %s USDC",
+		solana.FormatTokenAmount(e.Amount, usdcDecimals), solana.FormatTokenAmount(e.Max, usdcDecimals))
+}
+
+// DeclinedError is returned when a server asked for payment and the
+// request could not be paid for. Required is the server's 402 body.
+type DeclinedError struct {
+	Required *x402.PaymentRequired
+	Err      error
+}
+
+func (e *DeclinedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DeclinedError) Unwrap() error {
+	return e.Err
+}
+
+// Client pays for HTTP requests
+type Client struct {
+	HTTP     *http.Client // nil uses http.DefaultClient
+	Signer   wallet.Signer
+	RPC      *solana.Client // for the payment's recent blockhash
+	Network  string         // x402 network to pay on, e.g. "solana-devnet"
+	USDCMint string         // the only asset paid with
+	MaxPrice uint64         // per request, in USDC atomic units; 0 refuses every payment
+
+	// Approve, if set, is asked before each payment; an error declines it
+	Approve func(req *http.Request, offer x402.PaymentRequirements, amount uint64) error
+
+	// Cache, if set, answers repeated paid GETs without paying again
+	Cache *Cache
+
+	// Sessions, if set, pays vendors that offer prepaid sessions from
+	// a deposit instead of per request (see session.go)
+	Sessions *SessionPool
+}
+
+// Payment describes a payment made for a request
+type Payment struct {
+	Requirements x402.PaymentRequirements
+	Amount       uint64                   // USDC atomic units
+	Settlement   *x402.SettlementResponse // nil if the server sent no receipt
+	Session      *Session                 // set when the payment was a session deposit
+}
+
+// Do sends req, paying once if the server asks for payment. A request
+// with a body must have GetBody set (http.NewRequest does this for
+// bytes and strings readers). The returned Payment is nil when no
+// payment was needed, including when the response came from Cache
+// (see CacheHit) or was charged to an open session.
+func (c *Client) Do(req *http.Request) (*http.Response, *Payment, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return nil, nil, ErrBodyNotReplayable
+	}
+
+	if c.Sessions != nil {
+		if s := c.Sessions.get(origin(req)); s != nil && s.Token.expired() {
+			// Expired: reclaim the rest and pay as usual
+			c.Sessions.retire(req.Context(), s)
+		} else if s != nil && s.Price <= c.MaxPrice {
+			resp, _, err := s.Do(req)
+			if !errors.Is(err, ErrSessionEnded) && !errors.Is(err, ErrSessionClosed) {
+				return resp, nil, err
+			}
+			// Used up: reclaim the rest and pay as usual
+			c.Sessions.retire(req.Context(), s)
+		}
+	}
+
+	resp, err := c.http().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusPaymentRequired {
+		return resp, nil, nil
+	}
+
+	required, err := readPaymentRequired(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	offer, amount, err := c.Select(required.Accepts)
+	if err != nil {
+		return nil, nil, &DeclinedError{Required: required, Err: err}
+	}
+	if c.Cache != nil {
+		if cached, ok := c.Cache.lookup(req, offer); ok {
+			return cached, nil, nil
+		}
+	}
+	if c.Sessions != nil && c.Sessions.Deposit >= amount {
+		if endpoint, ok := sessionURL(req, offer); ok {
+			return c.doInSession(req, endpoint, amount)
+		}
+	}
+	if c.Approve != nil {
+		if err := c.Approve(req, offer, amount); err != nil {
+			return nil, nil, &DeclinedError{Required: required, Err: err}
+		}
+	}
+
+	header, err := c.Pay(req.Context(), offer, amount)
+	if err != nil {
+		return nil, nil, &DeclinedError{Required: required, Err: err}
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, nil, err
+		}
+	}
+	retry.Header.Set(x402.HeaderPayment, header)
+
+	resp, err = c.http().Do(retry)
+	if err != nil {
+		return nil, nil, fmt.Errorf("send payment: %w", err)
+	}
+	if resp.StatusCode == http.StatusPaymentRequired {
+		reason := "payment not accepted"
+		if rejected, err := readPaymentRequired(resp); err == nil {
+			required = rejected
+			if rejected.Error != "" {
+				reason = rejected.Error
+			}
+		}
+		return nil, nil, &DeclinedError{Required: required, Err: fmt.Errorf("%w: %s", ErrPaymentRejected, reason)}
+	}
+
+	payment := &Payment{Requirements: offer, Amount: amount}
+	if value := resp.Header.Get(x402.HeaderPaymentResponse); value != "" {
+		if settlement, err := x402.DecodeSettlementHeader(value); err == nil {
+			payment.Settlement = settlement
+		}
+	}
+	if c.Cache != nil && (payment.Settlement == nil || payment.Settlement.Success) {
+		resp = c.Cache.store(req, offer, amount, resp)
+	}
+	return resp, payment, nil
+}
+
+// doInSession opens a session at endpoint, or joins one another
+// request opened meanwhile, and sends req in it. The Payment returned
+// is the deposit, if this request made it.
+func (c *Client) doInSession(req *http.Request, endpoint string, price uint64) (*http.Response, *Payment, error) {
+	c.Sessions.openMu.Lock()
+	s := c.Sessions.get(origin(req))
+	if s != nil && s.Token.expired() {
+		c.Sessions.retire(req.Context(), s)
+		s = nil
+	}
+	var payment *Payment
+	if s == nil {
+		var err error
+		s, payment, err = c.OpenSession(req.Context(), endpoint, c.Sessions.Deposit)
+		if err != nil {
+			c.Sessions.openMu.Unlock()
+			return nil, payment, err
+		}
+		s.Price = price
+		c.Sessions.add(s)
+	}
+	c.Sessions.openMu.Unlock()
+
+	resp, _, err := s.Do(req)
+	if err != nil {
+		return nil, payment, err
+	}
+	return resp, payment, nil
+}
+
+// Select picks the cheapest offer this client can pay
+func (c *Client) Select(offers []x402.PaymentRequirements) (x402.PaymentRequirements, uint64, error) {
+	var best *x402.PaymentRequirements
+	var bestAmount uint64
+	for i := range offers {
+		o := &offers[i]
+		if o.Validate() != nil || o.Network != c.Network || o.Asset != c.USDCMint {
+			continue
+		}
+		amount, _ := o.Amount()
+		if best == nil || amount < bestAmount {
+			best, bestAmount = o, amount
+		}
+	}
+
+	if best == nil {
+		return x402.PaymentRequirements{}, 0, fmt.Errorf("%w: need scheme %q on %s in USDC (%s); offered %s",
+			ErrNoAcceptableOffer, x402.SchemeExact, c.Network, c.USDCMint, describeOffers(offers))
+	}
+	if bestAmount > c.MaxPrice {
+		return x402.PaymentRequirements{}, 0, &PriceError{Requirements: *best, Amount: bestAmount, Max: c.MaxPrice}
+	}
+	return *best, bestAmount, nil
+}
+
+// Pay builds and signs the payment for an offer and returns the
+// X-PAYMENT header value
+func (c *Client) Pay(ctx context.Context, offer x402.PaymentRequirements, amount uint64) (string, error) {
+	tx, err := c.BuildPayment(ctx, offer, amount)
+	if err != nil {
+		return "", err
+	}
+	return x402.EncodeHeader(x402.PaymentPayload{
+		X402Version: x402.Version,
+		Scheme:      offer.Scheme,
+		Network:     offer.Network,
+		Payload:     x402.ExactPayload{Transaction: tx.ToBase64()},
+	})
+}
+
+// BuildPayment returns the payment transaction signed by the payer.
+// The fee payer's signature is left for the facilitator.
+func (c *Client) BuildPayment(ctx context.Context, offer x402.PaymentRequirements, amount uint64) (*solana.Transaction, error) {
+	if c.Signer == nil {
+		return nil, ErrNoSigner
+	}
+	var payer solana.PublicKey
+	copy(payer[:], c.Signer.PublicKey())
+
+	feePayer := payer
+	if fp := offer.FeePayer(); fp != "" {
+		key, err := solana.ParsePublicKey(fp)
+		if err != nil {
+			return nil, fmt.Errorf("fee payer: %w", err)
+		}
+		feePayer = key
+	}
+	mint, err := solana.ParsePublicKey(offer.Asset)
+	if err != nil {
+		return nil, fmt.Errorf("asset: %w", err)
+	}
+	payTo, err := solana.ParsePublicKey(offer.PayTo)
+	if err != nil {
+		return nil, fmt.Errorf("payTo: %w", err)
+	}
+
+	source, err := solana.AssociatedTokenAddress(payer, mint, solana.TokenProgramID)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := solana.AssociatedTokenAddress(payTo, mint, solana.TokenProgramID)
+	if err != nil {
+		return nil, err
+	}
+
+	blockhash, _, err := c.RPC.GetLatestBlockhash(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get blockhash: %w", err)
+	}
+
+	msg, err := solana.NewMessage(feePayer, []solana.Instruction{
+		solana.SetComputeUnitLimit(computeUnitLimit),
+		solana.SetComputeUnitPrice(computeUnitPrice),
+		solana.CreateAssociatedTokenAccountIdempotent(payer, destination, payTo, mint, solana.TokenProgramID),
+		solana.TokenTransferChecked(solana.TokenProgramID, source, mint, destination, payer, amount, usdcDecimals),
+	}, blockhash)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := solana.NewTransaction(msg)
+	var sig []byte
+	if ds, ok := c.Signer.(describedSigner); ok {
+		sig, err = ds.SignWithDescription(msg.Serialize(), fmt.Sprintf("x402 payment of %s USDC for %s",
+			solana.FormatTokenAmount(amount, usdcDecimals), offer.Resource))
+	} else {
+		sig, err = c.Signer.Sign(msg.Serialize())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sign payment: %w", err)
+	}
+	if err := tx.AddSignature(payer, sig); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (c *Client) http() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return http.DefaultClient
+}
+
+// readPaymentRequired consumes a 402 response
+func readPaymentRequired(resp *http.Response) (*x402.PaymentRequired, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRequirementsSize))
+	if err != nil {
+		return nil, fmt.Errorf("read 402 response: %w", err)
+	}
+	required, err := x402.ParsePaymentRequired(bytes.TrimSpace(body))
+	if err != nil {
+		return nil, fmt.Errorf("402 response without usable x402 requirements: %w", err)
+	}
+	return required, nil
+}
+
+func describeOffers(offers []x402.PaymentRequirements) string {
+	if len(offers) == 0 {
+		return "nothing"
+	}
+	s := ""
+	for i, o := range offers {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s on %s in %s", o.Scheme, o.Network, o.Asset)
+	}
+	return s
+}
+
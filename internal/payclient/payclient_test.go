@@ -0,0 +1,257 @@
+package payclient
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+const testMint = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+
+// fakeRPC answers getLatestBlockhash
+func fakeRPC(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": map[string]interface{}{
+				"value": map[string]interface{}{
+					"blockhash":            "4uQeVj5tqViQh7yWWGStvkEG1Zmhx6uasJtWCJziofM",
+					"lastValidBlockHeight": 100,
+				},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fakeVendor charges price for every request. Payments are settled
+// by a fake facilitator that checks the transfer and co-signs as fee
+// payer. settled counts accepted payments.
+type fakeVendor struct {
+	*httptest.Server
+	payTo       *wallet.Keypair
+	facilitator *wallet.Keypair
+	price       uint64
+	settled     int
+	lastBody    string
+	header      http.Header // added to paid responses
+}
+
+func newFakeVendor(t *testing.T, price uint64) *fakeVendor {
+	t.Helper()
+	payTo, _ := wallet.Generate()
+	facilitator, _ := wallet.Generate()
+	v := &fakeVendor{payTo: payTo, facilitator: facilitator, price: price}
+
+	v.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		required := v.requirements(r)
+
+		header := r.Header.Get(x402.HeaderPayment)
+		if header == "" {
+			v.paymentRequired(w, "X-PAYMENT header is required", required)
+			return
+		}
+		settlement, err := v.settle(header, required)
+		if err != nil {
+			v.paymentRequired(w, err.Error(), required)
+			return
+		}
+
+		v.settled++
+		v.lastBody = string(body)
+		value, _ := x402.EncodeHeader(settlement)
+		w.Header().Set(x402.HeaderPaymentResponse, value)
+		for name, values := range v.header {
+			w.Header()[name] = values
+		}
+		w.Write([]byte(`{"quote":42}`))
+	}))
+	t.Cleanup(v.Close)
+	return v
+}
+
+func (v *fakeVendor) requirements(r *http.Request) x402.PaymentRequirements {
+	return x402.PaymentRequirements{
+		Scheme:            x402.SchemeExact,
+		Network:           x402.NetworkSolanaDevnet,
+		MaxAmountRequired: strconv.FormatUint(v.price, 10),
+		Resource:          "http://" + r.Host + r.URL.Path,
+		Description:       "Stock quote",
+		PayTo:             v.payTo.PublicKeyBase58(),
+		MaxTimeoutSeconds: 60,
+		Asset:             testMint,
+		Extra:             map[string]interface{}{"feePayer": v.facilitator.PublicKeyBase58()},
+	}
+}
+
+func (v *fakeVendor) paymentRequired(w http.ResponseWriter, msg string, r x402.PaymentRequirements) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPaymentRequired)
+	json.NewEncoder(w).Encode(x402.PaymentRequired{X402Version: x402.Version, Error: msg, Accepts: []x402.PaymentRequirements{r}})
+}
+
+// settle plays the facilitator: verify the payment, co-sign, submit
+func (v *fakeVendor) settle(header string, required x402.PaymentRequirements) (*x402.SettlementResponse, error) {
+	payload, err := x402.DecodePaymentHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := x402.VerifyExact(payload, &required)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, _ := solana.TransactionFromBase64(payload.Payload.Transaction)
+	feePayer := solana.MustPublicKey(v.facilitator.PublicKeyBase58())
+	if err := tx.AddSignature(feePayer, v.facilitator.Sign(tx.Message.Serialize())); err != nil {
+		return nil, err
+	}
+	if missing := tx.MissingSigners(); len(missing) > 0 {
+		return nil, errors.New("transaction not fully signed")
+	}
+	return &x402.SettlementResponse{Success: true, Transaction: tx.Signature(), Network: payload.Network, Payer: payment.Payer}, nil
+}
+
+func newTestClient(t *testing.T, maxPrice uint64) (*Client, *wallet.Keypair) {
+	t.Helper()
+	kp, _ := wallet.Generate()
+	return &Client{
+		Signer:   wallet.NewKeypairSigner(kp),
+		RPC:      solana.NewClient(fakeRPC(t).URL),
+		Network:  x402.NetworkSolanaDevnet,
+		USDCMint: testMint,
+		MaxPrice: maxPrice,
+	}, kp
+}
+
+func TestClient_PaysOn402(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	client, kp := newTestClient(t, 2000)
+
+	req, _ := http.NewRequest(http.MethodPost, vendor.URL+"/quote", strings.NewReader(`{"symbol":"SOL"}`))
+	resp, payment, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != `{"quote":42}` {
+		t.Errorf("response = %d %s", resp.StatusCode, body)
+	}
+	if vendor.settled != 1 || vendor.lastBody != `{"symbol":"SOL"}` {
+		t.Errorf("vendor settled %d, body %q", vendor.settled, vendor.lastBody)
+	}
+	if payment == nil || payment.Amount != 1500 || payment.Settlement == nil ||
+		!payment.Settlement.Success || payment.Settlement.Payer != kp.PublicKeyBase58() {
+		t.Errorf("payment = %+v", payment)
+	}
+}
+
+func TestClient_FreeRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("free"))
+	}))
+	defer server.Close()
+
+	client := &Client{Network: x402.NetworkSolanaDevnet, USDCMint: testMint}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, payment, err := client.Do(req)
+	if err != nil || payment != nil {
+		t.Fatalf("Do = %v, %v; want no payment", payment, err)
+	}
+	resp.Body.Close()
+}
+
+func TestClient_PriceCeiling(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	client, _ := newTestClient(t, 1000)
+
+	req, _ := http.NewRequest(http.MethodGet, vendor.URL, nil)
+	_, _, err := client.Do(req)
+	var priceErr *PriceError
+	if !errors.As(err, &priceErr) || priceErr.Amount != 1500 || priceErr.Max != 1000 {
+		t.Fatalf("Do = %v, want PriceError", err)
+	}
+	if vendor.settled != 0 {
+		t.Error("paid over the ceiling")
+	}
+}
+
+func TestClient_SignerRefusal(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	client, _ := newTestClient(t, 2000)
+	refused := errors.New("refused")
+	client.Signer = refusingSigner{client.Signer, refused}
+
+	req, _ := http.NewRequest(http.MethodGet, vendor.URL, nil)
+	if _, _, err := client.Do(req); !errors.Is(err, refused) {
+		t.Fatalf("Do = %v, want the signer's refusal", err)
+	}
+}
+
+func TestClient_Approve(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	client, _ := newTestClient(t, 2000)
+	declined := errors.New("over budget")
+	var asked uint64
+	client.Approve = func(req *http.Request, offer x402.PaymentRequirements, amount uint64) error {
+		asked = amount
+		return declined
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, vendor.URL, nil)
+	_, _, err := client.Do(req)
+	var de *DeclinedError
+	if !errors.Is(err, declined) || !errors.As(err, &de) || asked != 1500 {
+		t.Fatalf("Do = %v (asked %d), want the approver's refusal", err, asked)
+	}
+	if vendor.settled != 0 {
+		t.Error("paid without approval")
+	}
+}
+
+type refusingSigner struct {
+	wallet.Signer
+	err error
+}
+
+func (s refusingSigner) Sign([]byte) ([]byte, error) { return nil, s.err }
+
+func TestSelect(t *testing.T) {
+	client := &Client{Network: x402.NetworkSolanaDevnet, USDCMint: testMint, MaxPrice: 500}
+	payTo := "B8UwBUUnKwCyKuGMbFKWaG7exYdDk2ozZrPg72NyVbfj"
+	offer := func(scheme, network, asset, amount string) x402.PaymentRequirements {
+		return x402.PaymentRequirements{Scheme: scheme, Network: network, Asset: asset, MaxAmountRequired: amount,
+			PayTo: payTo, Resource: "https://api.example.com/quote", MaxTimeoutSeconds: 60}
+	}
+
+	got, amount, err := client.Select([]x402.PaymentRequirements{
+		offer("exact", "base", "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", "1"),
+		offer("exact", x402.NetworkSolanaDevnet, testMint, "400"),
+		offer("exact", x402.NetworkSolanaDevnet, testMint, "300"),
+		offer("upto", x402.NetworkSolanaDevnet, testMint, "100"),
+	})
+	if err != nil || amount != 300 || got.MaxAmountRequired != "300" {
+		t.Errorf("Select = %+v, %d, %v; want the 300 offer", got, amount, err)
+	}
+
+	_, _, err = client.Select([]x402.PaymentRequirements{offer("exact", x402.NetworkSolana, testMint, "1")})
+	if !errors.Is(err, ErrNoAcceptableOffer) {
+		t.Errorf("Select(mainnet offer) = %v, want ErrNoAcceptableOffer", err)
+	}
+}
+
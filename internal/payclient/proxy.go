@@ -0,0 +1,322 @@
+// ============================================================
+// Pay Proxy - HTTP proxy that pays x402 invoices
+// ============================================================
+//
+// Proxy is an http.Handler that passes requests through a Client, so
+// any HTTP client can pay for APIs without knowing about x402:
+//
+//   Forward:  HTTP_PROXY=http://127.0.0.1:8403
+//             GET http://api.example.com/quote  → api.example.com
+//   Reverse:  Upstream https://api.example.com
+//             GET http://127.0.0.1:8403/quote   → api.example.com/quote
+//   Tunnel:   CONNECT api.example.com:443       → passed through
+//
+// Tunnelled (HTTPS_PROXY) traffic is encrypted end to end, so 402s
+// inside it reach the client unpaid; use reverse mode for HTTPS APIs.
+//
+// When a payment cannot be made the client gets the server's 402
+// with its error replaced by the reason.
+//
+// Web pages must not spend through the proxy: requests carrying Origin
+// or Sec-Fetch-Site (sent by browsers) are refused, and reverse mode
+// only answers Hosts naming the proxy itself, so a domain rebound to
+// 127.0.0.1 cannot reach the upstream.
+//
+// ============================================================
+
+package payclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+)
+
+// maxProxyBody bounds request bodies, which are held in memory so
+// they can be resent with the payment
+const maxProxyBody = 32 << 20
+
+// ErrorHeader carries the reason a proxied request was not paid for
+const ErrorHeader = "X-Machpay-Proxy-Error"
+
+// hopHeaders apply to a single connection and are not forwarded
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Proxy forwards requests, paying for them with Client
+type Proxy struct {
+	Client   *Client
+	Upstream *url.URL // target for origin-form requests; nil allows forward proxying only
+	Addr     string   // listen address origin-form requests must name as Host; "" allows localhost and IP literals on any port
+
+	// OnPayment is called after each paid request; when nil payments
+	// are reported through Logf
+	OnPayment func(r *http.Request, p *Payment)
+
+	// Logf reports requests and payments; nil disables logging
+	Logf func(format string, args ...interface{})
+
+	mu       sync.Mutex
+	paid     int
+	spent    uint64
+	declined int
+	cached   int
+	saved    uint64
+}
+
+// ProxyStats summarizes the payments made by a Proxy
+type ProxyStats struct {
+	Paid     int    // requests paid for
+	Spent    uint64 // USDC atomic units
+	Declined int    // 402s passed back to the client unpaid
+	Cached   int    // paid responses reused from the client's cache
+	Saved    uint64 // USDC atomic units the cache saved
+}
+
+// Stats returns the payments made so far
+func (p *Proxy) Stats() ProxyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return ProxyStats{Paid: p.paid, Spent: p.spent, Declined: p.declined, Cached: p.cached, Saved: p.saved}
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+
+	if r.Header.Get("Origin") != "" || r.Header.Get("Sec-Fetch-Site") != "" {
+		p.logf("%s %s: refused browser request", r.Method, r.URL)
+		http.Error(w, "machpay proxy: browser requests are refused", http.StatusForbidden)
+		return
+	}
+
+	target, err := p.target(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxProxyBody+1))
+	if err != nil {
+		http.Error(w, "read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxProxyBody {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
+	}
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), reader)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	out.Header = r.Header.Clone()
+	removeHopHeaders(out.Header)
+	out.Host = target.Host
+
+	resp, payment, err := p.Client.Do(out)
+	if err != nil {
+		p.fail(w, out, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if payment != nil {
+		p.record(out, payment)
+	}
+	if saved, ok := CacheHit(resp); ok {
+		p.mu.Lock()
+		p.cached++
+		p.saved += saved
+		p.mu.Unlock()
+		p.logf("%s %s: served from cache, saved %s USDC", out.Method, out.URL, solana.FormatTokenAmount(saved, usdcDecimals))
+	}
+
+	removeHopHeaders(resp.Header)
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(flushWriter{w}, resp.Body)
+}
+
+// target resolves where a request goes: absolute-form requests are
+// forward-proxied, origin-form ones go to the upstream
+func (p *Proxy) target(r *http.Request) (*url.URL, error) {
+	if r.URL.IsAbs() {
+		if r.URL.Scheme != "http" && r.URL.Scheme != "https" {
+			return nil, fmt.Errorf("unsupported scheme %q", r.URL.Scheme)
+		}
+		return r.URL, nil
+	}
+	if p.Upstream == nil {
+		return nil, errors.New("no upstream configured: use this address as HTTP_PROXY, or start the proxy with --upstream")
+	}
+	if !p.ownHost(r.Host) {
+		return nil, fmt.Errorf("Host %q does not name this proxy", r.Host)
+	}
+
+	target := *p.Upstream
+	target.Path = strings.TrimSuffix(p.Upstream.Path, "/") + r.URL.Path
+	target.RawPath = ""
+	target.RawQuery = r.URL.RawQuery
+	return &target, nil
+}
+
+// ownHost reports whether host, a request's Host header, names the
+// proxy: its listen address, localhost or an IP literal, on its port.
+// Any other name is a domain resolving here, as in DNS rebinding.
+func (p *Proxy) ownHost(host string) bool {
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = strings.Trim(host, "[]"), "80"
+	}
+	if p.Addr != "" {
+		listenHost, listenPort, err := net.SplitHostPort(p.Addr)
+		if err != nil || port != listenPort {
+			return false
+		}
+		if strings.EqualFold(name, listenHost) {
+			return true
+		}
+	}
+	return strings.EqualFold(name, "localhost") || net.ParseIP(name) != nil
+}
+
+// fail answers a request that could not be completed. Declined
+// payments return the server's 402 so the client sees the price.
+func (p *Proxy) fail(w http.ResponseWriter, r *http.Request, err error) {
+	var declined *DeclinedError
+	if !errors.As(err, &declined) {
+		p.logf("%s %s: %v", r.Method, r.URL, err)
+		http.Error(w, "machpay proxy: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	p.mu.Lock()
+	p.declined++
+	p.mu.Unlock()
+	p.logf("%s %s: not paid: %v", r.Method, r.URL, err)
+
+	required := *declined.Required
+	required.Error = err.Error()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(ErrorHeader, err.Error())
+	w.WriteHeader(http.StatusPaymentRequired)
+	json.NewEncoder(w).Encode(required)
+}
+
+func (p *Proxy) record(r *http.Request, payment *Payment) {
+	p.mu.Lock()
+	p.paid++
+	p.spent += payment.Amount
+	p.mu.Unlock()
+
+	if p.OnPayment != nil {
+		p.OnPayment(r, payment)
+		return
+	}
+	result := "no receipt"
+	if s := payment.Settlement; s != nil {
+		switch {
+		case !s.Success:
+			result = "settlement failed: " + s.ErrorReason
+		case s.Transaction != "":
+			result = "tx " + s.Transaction
+		default:
+			result = "settled"
+		}
+	}
+	p.logf("%s %s: paid %s USDC to %s (%s)", r.Method, r.URL,
+		solana.FormatTokenAmount(payment.Amount, usdcDecimals), payment.Requirements.PayTo, result)
+}
+
+// tunnel passes a CONNECT stream through untouched
+func (p *Proxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "tunnelling not supported", http.StatusInternalServerError)
+		return
+	}
+	upstream, err := net.DialTimeout("tcp", r.Host, 10*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	p.logf("CONNECT %s: tunnelled, payments inside cannot be handled", r.Host)
+
+	conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+	go func() {
+		// Anything the client sent after the CONNECT is already buffered
+		io.Copy(upstream, buf)
+		if tcp, ok := upstream.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+	}()
+	io.Copy(conn, upstream)
+	conn.Close()
+	upstream.Close()
+}
+
+func (p *Proxy) logf(format string, args ...interface{}) {
+	if p.Logf != nil {
+		p.Logf(format, args...)
+	}
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, name := range strings.Split(h.Get("Connection"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			h.Del(name)
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+// flushWriter flushes after every write so streamed responses reach
+// the client as they arrive
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f flushWriter) Write(b []byte) (int, error) {
+	n, err := f.w.Write(b)
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
@@ -0,0 +1,169 @@
+package payclient
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+func newTestProxy(t *testing.T, client *Client, upstream string) (*Proxy, *httptest.Server) {
+	t.Helper()
+	p := &Proxy{Client: client}
+	if upstream != "" {
+		u, err := url.Parse(upstream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Upstream = u
+	}
+	server := httptest.NewServer(p)
+	t.Cleanup(server.Close)
+	return p, server
+}
+
+func TestProxy_Forward(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	client, _ := newTestClient(t, 2000)
+	_, proxy := newTestProxy(t, client, "")
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	httpClient := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	resp, err := httpClient.Post(vendor.URL+"/quote", "application/json", strings.NewReader(`{"symbol":"SOL"}`))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != `{"quote":42}` {
+		t.Errorf("response = %d %s", resp.StatusCode, body)
+	}
+	if resp.Header.Get(x402.HeaderPaymentResponse) == "" {
+		t.Error("settlement header not passed back")
+	}
+	if vendor.settled != 1 || vendor.lastBody != `{"symbol":"SOL"}` {
+		t.Errorf("vendor settled %d, body %q", vendor.settled, vendor.lastBody)
+	}
+}
+
+func TestProxy_Reverse(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	client, _ := newTestClient(t, 2000)
+	p, proxy := newTestProxy(t, client, vendor.URL+"/v1")
+
+	resp, err := http.Get(proxy.URL + "/quote?symbol=SOL")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || vendor.settled != 1 {
+		t.Errorf("status %d, settled %d", resp.StatusCode, vendor.settled)
+	}
+	if stats := p.Stats(); stats.Paid != 1 || stats.Spent != 1500 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestProxy_Declined(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	client, _ := newTestClient(t, 1000)
+	_, proxy := newTestProxy(t, client, vendor.URL)
+
+	resp, err := http.Get(proxy.URL + "/quote")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPaymentRequired {
+		t.Fatalf("status = %d, want 402", resp.StatusCode)
+	}
+	var required x402.PaymentRequired
+	if err := json.NewDecoder(resp.Body).Decode(&required); err != nil {
+		t.Fatal(err)
+	}
+	if len(required.Accepts) != 1 || !strings.Contains(required.Error, "exceeds the maximum") ||
+		resp.Header.Get(ErrorHeader) == "" {
+		t.Errorf("402 = %+v", required)
+	}
+	if vendor.settled != 0 {
+		t.Error("paid over the ceiling")
+	}
+}
+
+func TestProxy_NoUpstream(t *testing.T) {
+	client, _ := newTestClient(t, 1000)
+	_, proxy := newTestProxy(t, client, "")
+
+	resp, err := http.Get(proxy.URL + "/quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestProxy_RefusesWebPages(t *testing.T) {
+	vendor := newFakeVendor(t, 1500)
+	client, _ := newTestClient(t, 2000)
+	p, proxy := newTestProxy(t, client, vendor.URL)
+	p.Addr = strings.TrimPrefix(proxy.URL, "http://")
+	_, port, _ := net.SplitHostPort(p.Addr)
+
+	for _, tt := range []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"own address", "", "", http.StatusOK},
+		{"localhost", "Host", "localhost:" + port, http.StatusOK},
+		{"rebound domain", "Host", "attacker.example:" + port, http.StatusBadRequest},
+		{"other port", "Host", "127.0.0.1:1", http.StatusBadRequest},
+		{"origin", "Origin", "https://attacker.example", http.StatusForbidden},
+		{"fetch metadata", "Sec-Fetch-Site", "same-origin", http.StatusForbidden},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/quote", nil)
+		if tt.header == "Host" {
+			req.Host = tt.value
+		} else if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+	if vendor.settled != 2 {
+		t.Errorf("vendor settled %d, want 2", vendor.settled)
+	}
+}
+
+func TestRemoveHopHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Connection", "close, X-Hop")
+	h.Set("X-Hop", "1")
+	h.Set("Proxy-Authorization", "secret")
+	h.Set("Accept", "application/json")
+
+	removeHopHeaders(h)
+	if len(h) != 1 || h.Get("Accept") == "" {
+		t.Errorf("headers = %v", h)
+	}
+}
+
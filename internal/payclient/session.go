@@ -0,0 +1,547 @@
+// ============================================================
+// Prepaid Sessions - One deposit, many requests
+// ============================================================
+//
+// A vendor that offers sessions names its session endpoint in the
+// "session" field of its payment requirements' extra. Then:
+//
+//   Open:   POST <session> {"deposit": N}  → 402 for N
+//           POST <session> + X-PAYMENT      → {"token": T}
+//   Use:    <request> + X-Machpay-Session: T
+//           ← X-Machpay-Receipt: R (charged, spent, remaining)
+//   Close:  DELETE <session> + X-Machpay-Session: T
+//           ← {"receipt": R, "refund": M, "transaction": "..."}
+//
+// The deposit is an ordinary x402 payment. Tokens and receipts are
+// JSON claims signed by the vendor's ed25519 key, encoded as
+// base64url(claims) "." base64url(signature). The key is the one the
+// deposit's requirements name in extra "sessionKey", or else their
+// payTo: a token signed by any other key is refused, and every
+// receipt must verify against it. Expired sessions are not reused. A
+// 402 to a session request means the session is used up or no longer
+// valid. Closing refunds what the last receipt shows as remaining.
+//
+// The token is a bearer credential for the deposit: whoever holds
+// it can spend the balance.
+//
+// ============================================================
+
+package payclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// Session protocol headers and fields
+const (
+	SessionHeader        = "X-Machpay-Session" // token on requests
+	SessionReceiptHeader = "X-Machpay-Receipt" // usage receipt on responses
+	SessionExtra         = "session"           // requirements extra: session endpoint URL
+	SessionKeyExtra      = "sessionKey"        // requirements extra: key signing tokens; default payTo
+)
+
+// maxSessionResponse bounds session open and close responses
+const maxSessionResponse = 64 << 10
+
+// Session errors
+var (
+	ErrSessionEnded  = errors.New("prepaid session ended")
+	ErrBadSession    = errors.New("invalid session token")
+	ErrBadReceipt    = errors.New("invalid usage receipt")
+	ErrSessionClosed = errors.New("session already closed")
+)
+
+// SessionToken is what a vendor grants for a deposit
+type SessionToken struct {
+	ID      string    `json:"id"`
+	Vendor  string    `json:"vendor"` // key that signs the token and receipts
+	Payer   string    `json:"payer"`
+	PayTo   string    `json:"payTo"`
+	Network string    `json:"network"`
+	Asset   string    `json:"asset"`
+	Deposit uint64    `json:"deposit"` // atomic units
+	Expires time.Time `json:"expires"`
+}
+
+// UsageReceipt is a vendor's signed statement of a session's balance
+type UsageReceipt struct {
+	Session   string    `json:"session"`
+	Seq       uint64    `json:"seq"`     // increases with each charge
+	Request   string    `json:"request"` // "GET /path"; empty on close
+	Charged   uint64    `json:"charged"`
+	Spent     uint64    `json:"spent"`
+	Remaining uint64    `json:"remaining"`
+	Time      time.Time `json:"time"`
+}
+
+// SessionClosure is the outcome of closing a session
+type SessionClosure struct {
+	Session     *Session
+	Receipt     *UsageReceipt // the vendor's final receipt
+	Refund      uint64        // atomic units returned to the payer
+	Transaction string        // refund transaction, if the vendor sent one
+}
+
+// SignClaims signs v as a session token or receipt
+func SignClaims(v interface{}, sign func(message []byte) []byte) (string, error) {
+	claims, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(claims) + "." +
+		base64.RawURLEncoding.EncodeToString(sign(claims)), nil
+}
+
+// verifyClaims checks signed claims against a base58 key and decodes
+// them into v
+func verifyClaims(signed, key string, v interface{}) error {
+	claims, err := decodeClaims(signed, v)
+	if err != nil {
+		return err
+	}
+	pub, err := solana.ParsePublicKey(key)
+	if err != nil {
+		return fmt.Errorf("signing key: %w", err)
+	}
+	_, sig, _ := strings.Cut(signed, ".")
+	signature, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !ed25519.Verify(pub[:], claims, signature) {
+		return errors.New("bad signature")
+	}
+	return nil
+}
+
+func decodeClaims(signed string, v interface{}) ([]byte, error) {
+	encoded, _, ok := strings.Cut(signed, ".")
+	if !ok {
+		return nil, errors.New("not signed claims")
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	if err := json.Unmarshal(claims, v); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	return claims, nil
+}
+
+// ParseSessionToken decodes a token and checks it is signed by key,
+// the vendor key the payer trusts (see SessionKey), and not expired
+func ParseSessionToken(signed, key string) (*SessionToken, error) {
+	var t SessionToken
+	if err := verifyClaims(signed, key, &t); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadSession, err)
+	}
+	if t.Vendor != key {
+		return nil, fmt.Errorf("%w: names key %s, not %s", ErrBadSession, t.Vendor, key)
+	}
+	if t.ID == "" || t.Deposit == 0 {
+		return nil, fmt.Errorf("%w: missing id or deposit", ErrBadSession)
+	}
+	if t.expired() {
+		return nil, fmt.Errorf("%w: expired at %s", ErrBadSession, t.Expires.Format(time.RFC3339))
+	}
+	return &t, nil
+}
+
+// SessionKey returns the key that must sign the session tokens an
+// offer's vendor grants: extra "sessionKey", or else payTo
+func SessionKey(offer x402.PaymentRequirements) string {
+	if key, _ := offer.Extra[SessionKeyExtra].(string); key != "" {
+		return key
+	}
+	return offer.PayTo
+}
+
+// expired reports whether the token's expiry has passed
+func (t *SessionToken) expired() bool {
+	return !t.Expires.IsZero() && !time.Now().Before(t.Expires)
+}
+
+// ParseUsageReceipt decodes a receipt and checks its signature
+// against the session's vendor key
+func ParseUsageReceipt(signed string, token *SessionToken) (*UsageReceipt, error) {
+	var r UsageReceipt
+	if err := verifyClaims(signed, token.Vendor, &r); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadReceipt, err)
+	}
+	if r.Session != token.ID {
+		return nil, fmt.Errorf("%w: for session %s, not %s", ErrBadReceipt, r.Session, token.ID)
+	}
+	if r.Spent > token.Deposit || r.Spent+r.Remaining != token.Deposit {
+		return nil, fmt.Errorf("%w: spent %d and remaining %d do not add up to the deposit of %d",
+			ErrBadReceipt, r.Spent, r.Remaining, token.Deposit)
+	}
+	return &r, nil
+}
+
+// ============================================================
+// Session
+// ============================================================
+
+// Session is an open prepaid session with a vendor
+type Session struct {
+	Token  *SessionToken
+	URL    string // session endpoint
+	Origin string // scheme://host whose requests it pays for
+	Price  uint64 // per request when opened; receipts may not charge more
+
+	raw  string
+	http *http.Client
+
+	mu     sync.Mutex
+	last   *UsageReceipt
+	closed bool
+}
+
+// Spent returns what the session's receipts show as spent
+func (s *Session) Spent() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		return 0
+	}
+	return s.last.Spent
+}
+
+// Remaining returns the balance left by the latest receipt
+func (s *Session) Remaining() uint64 {
+	return s.Token.Deposit - s.Spent()
+}
+
+// Do sends req charged to the session. The receipt is nil when the
+// vendor did not charge for the request. A 402 ends the session with
+// ErrSessionEnded; the response is consumed.
+func (s *Session) Do(req *http.Request) (*http.Response, *UsageReceipt, error) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return nil, nil, ErrSessionClosed
+	}
+
+	out := req.Clone(req.Context())
+	if req.GetBody != nil {
+		var err error
+		if out.Body, err = req.GetBody(); err != nil {
+			return nil, nil, err
+		}
+	}
+	out.Header.Set(SessionHeader, s.raw)
+
+	resp, err := s.http.Do(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusPaymentRequired {
+		reason := "balance used up or session expired"
+		if required, err := readPaymentRequired(resp); err == nil && required.Error != "" {
+			reason = required.Error
+		}
+		return nil, nil, fmt.Errorf("%w: %s", ErrSessionEnded, reason)
+	}
+
+	value := resp.Header.Get(SessionReceiptHeader)
+	if value == "" {
+		return resp, nil, nil
+	}
+	receipt, err := ParseUsageReceipt(value, s.Token)
+	if err != nil {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last != nil && receipt.Seq > s.last.Seq && receipt.Spent < s.last.Spent {
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("%w: receipt %d shows less spent than receipt %d", ErrBadReceipt, receipt.Seq, s.last.Seq)
+	}
+	if s.last == nil || receipt.Seq > s.last.Seq {
+		s.last = receipt
+	}
+	if s.Price > 0 && receipt.Charged > s.Price {
+		resp.Body.Close()
+		return nil, receipt, fmt.Errorf("%w: charged %s USDC, more than the %s USDC offered", ErrBadReceipt,
+			solana.FormatTokenAmount(receipt.Charged, usdcDecimals), solana.FormatTokenAmount(s.Price, usdcDecimals))
+	}
+	return resp, receipt, nil
+}
+
+// Close ends the session and reclaims the unused deposit. The vendor
+// must refund at least what the latest receipt shows as remaining.
+func (s *Session) Close(ctx context.Context) (*SessionClosure, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, ErrSessionClosed
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(SessionHeader, s.raw)
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("close session: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSessionResponse))
+	if err != nil {
+		return nil, fmt.Errorf("close session: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("close session: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	var closed struct {
+		Receipt     string `json:"receipt"`
+		Refund      uint64 `json:"refund"`
+		Transaction string `json:"transaction"`
+	}
+	if err := json.Unmarshal(body, &closed); err != nil {
+		return nil, fmt.Errorf("close session: %w", err)
+	}
+	receipt, err := ParseUsageReceipt(closed.Receipt, s.Token)
+	if err != nil {
+		return nil, fmt.Errorf("close session: %w", err)
+	}
+	closure := &SessionClosure{Session: s, Receipt: receipt, Refund: closed.Refund, Transaction: closed.Transaction}
+
+	owed := s.Remaining()
+	if closed.Refund < owed {
+		return closure, fmt.Errorf("%w: refund of %s USDC is less than the %s USDC remaining on the receipts",
+			ErrBadReceipt, solana.FormatTokenAmount(closed.Refund, usdcDecimals), solana.FormatTokenAmount(owed, usdcDecimals))
+	}
+	s.mu.Lock()
+	s.last = receipt
+	s.mu.Unlock()
+	return closure, nil
+}
+
+// OpenSession deposits amount with the vendor's session endpoint and
+// returns the session. MaxPrice does not apply to deposits; Approve,
+// the signer and the spending policy do.
+func (c *Client) OpenSession(ctx context.Context, sessionURL string, deposit uint64) (*Session, *Payment, error) {
+	endpoint, err := url.Parse(sessionURL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, nil, fmt.Errorf("session endpoint %q is not an http(s) URL", sessionURL)
+	}
+	body := fmt.Sprintf(`{"deposit":%d}`, deposit)
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, sessionURL, strings.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, err
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.http().Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open session: %w", err)
+	}
+	if resp.StatusCode != http.StatusPaymentRequired {
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("open session: expected 402 for the deposit, got %s", resp.Status)
+	}
+	required, err := readPaymentRequired(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open session: %w", err)
+	}
+
+	// The deposit offer must be for exactly the amount asked
+	selector := *c
+	selector.MaxPrice = deposit
+	offer, amount, err := selector.Select(required.Accepts)
+	if err == nil && amount != deposit {
+		err = fmt.Errorf("vendor asked %s USDC for a deposit of %s USDC",
+			solana.FormatTokenAmount(amount, usdcDecimals), solana.FormatTokenAmount(deposit, usdcDecimals))
+	}
+	if err != nil {
+		return nil, nil, &DeclinedError{Required: required, Err: err}
+	}
+	if c.Approve != nil {
+		if err := c.Approve(req, offer, amount); err != nil {
+			return nil, nil, &DeclinedError{Required: required, Err: err}
+		}
+	}
+	header, err := c.Pay(ctx, offer, amount)
+	if err != nil {
+		return nil, nil, &DeclinedError{Required: required, Err: err}
+	}
+
+	if req, err = newRequest(); err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set(x402.HeaderPayment, header)
+	resp, err = c.http().Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("send deposit: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusPaymentRequired {
+		reason := "deposit not accepted"
+		if rejected, err := readPaymentRequired(resp); err == nil && rejected.Error != "" {
+			reason = rejected.Error
+		}
+		return nil, nil, &DeclinedError{Required: required, Err: fmt.Errorf("%w: %s", ErrPaymentRejected, reason)}
+	}
+
+	payment := &Payment{Requirements: offer, Amount: amount}
+	if value := resp.Header.Get(x402.HeaderPaymentResponse); value != "" {
+		if settlement, err := x402.DecodeSettlementHeader(value); err == nil {
+			payment.Settlement = settlement
+		}
+	}
+	var granted struct {
+		Token string `json:"token"`
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSessionResponse))
+	if err == nil {
+		err = json.Unmarshal(data, &granted)
+	}
+	if err != nil || resp.StatusCode/100 != 2 {
+		return nil, payment, fmt.Errorf("open session: deposit paid but no token (%s): %s", resp.Status, bytes.TrimSpace(data))
+	}
+	token, err := ParseSessionToken(granted.Token, SessionKey(offer))
+	if err != nil {
+		return nil, payment, fmt.Errorf("open session: deposit paid but %w", err)
+	}
+	if payer := wallet.SignerAddress(c.Signer); token.Payer != payer || token.Deposit != deposit || token.PayTo != offer.PayTo {
+		return nil, payment, fmt.Errorf("open session: deposit paid but %w: issued to %s for %d to %s",
+			ErrBadSession, token.Payer, token.Deposit, token.PayTo)
+	}
+
+	s := &Session{
+		Token:  token,
+		URL:    sessionURL,
+		Origin: endpoint.Scheme + "://" + endpoint.Host,
+		raw:    granted.Token,
+		http:   c.http(),
+	}
+	payment.Session = s
+	return s, payment, nil
+}
+
+// ============================================================
+// Session Pool
+// ============================================================
+
+// SessionPool holds a client's open sessions, one per vendor origin
+type SessionPool struct {
+	Deposit uint64 // USDC atomic units put into each new session
+
+	openMu sync.Mutex // serializes opening, so a vendor gets one session
+	mu     sync.Mutex
+	open   map[string]*Session
+	ended  []*Session
+}
+
+// NewSessionPool returns a pool that deposits deposit per session
+func NewSessionPool(deposit uint64) *SessionPool {
+	return &SessionPool{Deposit: deposit, open: make(map[string]*Session)}
+}
+
+// Sessions returns the sessions opened so far, open or ended
+func (p *SessionPool) Sessions() []*Session {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sessions := append([]*Session(nil), p.ended...)
+	for _, s := range p.open {
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// Close closes every session not yet closed and returns what was
+// reclaimed. It keeps going past errors and returns the first.
+func (p *SessionPool) Close(ctx context.Context) ([]*SessionClosure, error) {
+	var closures []*SessionClosure
+	var firstErr error
+	for _, s := range p.Sessions() {
+		closure, err := s.Close(ctx)
+		if errors.Is(err, ErrSessionClosed) {
+			continue
+		}
+		if closure != nil {
+			closures = append(closures, closure)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("session %s: %w", s.Token.ID, err)
+		}
+	}
+	return closures, firstErr
+}
+
+func (p *SessionPool) get(origin string) *Session {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.open[origin]
+}
+
+func (p *SessionPool) add(s *Session) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.open[s.Origin] = s
+}
+
+// retire moves a used-up session out of use and closes it to reclaim
+// any remainder
+func (p *SessionPool) retire(ctx context.Context, s *Session) {
+	p.mu.Lock()
+	if p.open[s.Origin] == s {
+		delete(p.open, s.Origin)
+		p.ended = append(p.ended, s)
+	}
+	p.mu.Unlock()
+	s.Close(ctx)
+}
+
+// sessionURL returns the session endpoint an offer names, resolved
+// against the request URL
+func sessionURL(req *http.Request, offer x402.PaymentRequirements) (string, bool) {
+	value, _ := offer.Extra[SessionExtra].(string)
+	if value == "" {
+		return "", false
+	}
+	ref, err := url.Parse(value)
+	if err != nil {
+		return "", false
+	}
+	resolved := req.URL.ResolveReference(ref)
+	if resolved.Host != req.URL.Host || resolved.Scheme != req.URL.Scheme {
+		// A session only pays for the origin that offered it
+		return "", false
+	}
+	return resolved.String(), true
+}
+
+// origin returns scheme://host of a request
+func origin(req *http.Request) string {
+	return req.URL.Scheme + "://" + req.URL.Host
+}
+
@@ -0,0 +1,242 @@
+package payclient_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/payclient/sessiontest"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+const sessionMint = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+
+// newSessionClient returns a client paying up to maxPrice per request
+// with sessions of deposit
+func newSessionClient(t *testing.T, maxPrice, deposit uint64) *payclient.Client {
+	t.Helper()
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"value":{"blockhash":"4uQeVj5tqViQh7yWWGStvkEG1Zmhx6uasJtWCJziofM","lastValidBlockHeight":100}}}`))
+	}))
+	t.Cleanup(rpc.Close)
+	kp, _ := wallet.Generate()
+	return &payclient.Client{
+		Signer:   wallet.NewKeypairSigner(kp),
+		RPC:      solana.NewClient(rpc.URL),
+		Network:  x402.NetworkSolanaDevnet,
+		USDCMint: sessionMint,
+		MaxPrice: maxPrice,
+		Sessions: payclient.NewSessionPool(deposit),
+	}
+}
+
+func get(t *testing.T, c *payclient.Client, url string) *payclient.Payment {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, payment, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); resp.StatusCode != http.StatusOK || string(body) != "paid content" {
+		t.Fatalf("response = %d %s", resp.StatusCode, body)
+	}
+	return payment
+}
+
+func TestSession_PrepaidRequests(t *testing.T) {
+	vendor := sessiontest.NewVendor(x402.NetworkSolanaDevnet, sessionMint, 1000)
+	server := httptest.NewServer(vendor)
+	defer server.Close()
+	client := newSessionClient(t, 1000, 5000)
+	var approved []uint64
+	client.Approve = func(req *http.Request, offer x402.PaymentRequirements, amount uint64) error {
+		approved = append(approved, amount)
+		return nil
+	}
+
+	deposit := get(t, client, server.URL+"/quote")
+	if deposit == nil || deposit.Session == nil || deposit.Amount != 5000 || deposit.Settlement == nil || !deposit.Settlement.Success {
+		t.Fatalf("first request payment = %+v, want the deposit", deposit)
+	}
+	first := deposit.Session
+	for i := 0; i < 4; i++ {
+		if p := get(t, client, server.URL+"/quote"); p != nil {
+			t.Fatalf("session request paid %+v", p)
+		}
+	}
+	if vendor.Settled() != 1 || first.Spent() != 5000 || first.Remaining() != 0 || vendor.Spent(first.Token.ID) != 5000 {
+		t.Errorf("after 5 requests: settled %d, spent %d, remaining %d", vendor.Settled(), first.Spent(), first.Remaining())
+	}
+
+	// The used-up session is closed and a new one opened
+	second := get(t, client, server.URL+"/quote")
+	if second == nil || second.Session == nil || second.Session == first || vendor.Settled() != 2 {
+		t.Fatalf("request after the balance ran out = %+v", second)
+	}
+	if len(approved) != 2 || approved[0] != 5000 {
+		t.Errorf("approvals = %v, want one per deposit", approved)
+	}
+
+	closures, err := client.Sessions.Close(context.Background())
+	if err != nil || len(closures) != 1 || closures[0].Refund != 4000 || closures[0].Receipt.Spent != 1000 {
+		t.Fatalf("Close = %+v, %v", closures, err)
+	}
+	if vendor.Refunded(second.Session.Token.ID) != 4000 || len(client.Sessions.Sessions()) != 2 {
+		t.Errorf("refunded %d; %d sessions", vendor.Refunded(second.Session.Token.ID), len(client.Sessions.Sessions()))
+	}
+	if _, err := second.Session.Close(context.Background()); !errors.Is(err, payclient.ErrSessionClosed) {
+		t.Errorf("second Close = %v, want ErrSessionClosed", err)
+	}
+}
+
+func TestSession_SmallDepositPaysPerRequest(t *testing.T) {
+	vendor := sessiontest.NewVendor(x402.NetworkSolanaDevnet, sessionMint, 1000)
+	server := httptest.NewServer(vendor)
+	defer server.Close()
+	client := newSessionClient(t, 1000, 500)
+
+	for i := 0; i < 2; i++ {
+		if p := get(t, client, server.URL); p == nil || p.Session != nil || p.Amount != 1000 {
+			t.Fatalf("payment = %+v, want a per-request payment", p)
+		}
+	}
+	if vendor.Settled() != 2 || len(client.Sessions.Sessions()) != 0 {
+		t.Errorf("settled %d, sessions %d", vendor.Settled(), len(client.Sessions.Sessions()))
+	}
+}
+
+func TestSession_DishonestVendor(t *testing.T) {
+	vendor := sessiontest.NewVendor(x402.NetworkSolanaDevnet, sessionMint, 1000)
+	forger, _ := wallet.Generate()
+	var forgeReceipt, shortRefund bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		vendor.ServeHTTP(rec, r)
+		for name, values := range rec.Header() {
+			w.Header()[name] = values
+		}
+		body := rec.Body.Bytes()
+		if forgeReceipt && rec.Header().Get(payclient.SessionReceiptHeader) != "" {
+			forged, _ := payclient.SignClaims(payclient.UsageReceipt{Session: "sess_1", Seq: 9, Spent: 1000, Remaining: 4000}, forger.Sign)
+			w.Header().Set(payclient.SessionReceiptHeader, forged)
+		}
+		if shortRefund && r.Method == http.MethodDelete {
+			var closed map[string]interface{}
+			json.Unmarshal(body, &closed)
+			closed["refund"] = 1
+			body, _ = json.Marshal(closed)
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(body)
+	}))
+	defer server.Close()
+	client := newSessionClient(t, 1000, 5000)
+
+	session := get(t, client, server.URL).Session
+
+	forgeReceipt = true
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, _, err := client.Do(req); !errors.Is(err, payclient.ErrBadReceipt) {
+		t.Errorf("forged receipt: Do = %v, want ErrBadReceipt", err)
+	}
+	forgeReceipt = false
+
+	shortRefund = true
+	closure, err := session.Close(context.Background())
+	if !errors.Is(err, payclient.ErrBadReceipt) || closure == nil || !strings.Contains(err.Error(), "less than") {
+		t.Errorf("short refund: Close = %+v, %v", closure, err)
+	}
+}
+
+func TestSession_UntrustedKey(t *testing.T) {
+	vendor := sessiontest.NewVendor(x402.NetworkSolanaDevnet, sessionMint, 1000)
+	forger, _ := wallet.Generate()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		vendor.ServeHTTP(rec, r)
+		body := rec.Body.Bytes()
+		// A token signed by a key the requirements did not name
+		var granted payclient.SessionToken
+		if rec.Code == http.StatusCreated {
+			var out struct{ Token string }
+			json.Unmarshal(body, &out)
+			encoded, _, _ := strings.Cut(out.Token, ".")
+			claims, _ := base64.RawURLEncoding.DecodeString(encoded)
+			json.Unmarshal(claims, &granted)
+			granted.Vendor = forger.PublicKeyBase58()
+			forged, _ := payclient.SignClaims(granted, forger.Sign)
+			body, _ = json.Marshal(map[string]string{"token": forged})
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(body)
+	}))
+	defer server.Close()
+	client := newSessionClient(t, 1000, 5000)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, _, err := client.Do(req); !errors.Is(err, payclient.ErrBadSession) {
+		t.Errorf("token signed by another key: Do = %v, want ErrBadSession", err)
+	}
+}
+
+func TestSession_Expired(t *testing.T) {
+	vendor := sessiontest.NewVendor(x402.NetworkSolanaDevnet, sessionMint, 1000)
+	vendor.TTL = 500 * time.Millisecond
+	var sessionRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.Header.Get(payclient.SessionHeader) != "" {
+			sessionRequests++
+		}
+		vendor.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := newSessionClient(t, 1000, 5000)
+
+	first := get(t, client, server.URL).Session
+	time.Sleep(time.Until(first.Token.Expires))
+	second := get(t, client, server.URL)
+	if second == nil || second.Session == nil || second.Session == first || vendor.Settled() != 2 {
+		t.Errorf("request after the session expired = %+v, want a new deposit", second)
+	}
+	// The first session's token is not sent once it has expired
+	if sessionRequests != 2 {
+		t.Errorf("%d requests carried a session token, want 2", sessionRequests)
+	}
+}
+
+func TestParseSessionToken(t *testing.T) {
+	vendor, _ := wallet.Generate()
+	other, _ := wallet.Generate()
+	key := vendor.PublicKeyBase58()
+	token := payclient.SessionToken{ID: "s", Vendor: key, Deposit: 10}
+
+	signed, _ := payclient.SignClaims(token, vendor.Sign)
+	if got, err := payclient.ParseSessionToken(signed, key); err != nil || got.ID != "s" {
+		t.Errorf("ParseSessionToken = %+v, %v", got, err)
+	}
+	forged, _ := payclient.SignClaims(token, other.Sign)
+	selfSigned, _ := payclient.SignClaims(payclient.SessionToken{ID: "s", Vendor: other.PublicKeyBase58(), Deposit: 10}, other.Sign)
+	expired, _ := payclient.SignClaims(payclient.SessionToken{ID: "s", Vendor: key, Deposit: 10, Expires: time.Now().Add(-time.Second)}, vendor.Sign)
+	for _, bad := range []string{forged, selfSigned, expired, "nodot", signed[:len(signed)-4] + "AAAA"} {
+		if _, err := payclient.ParseSessionToken(bad, key); !errors.Is(err, payclient.ErrBadSession) {
+			t.Errorf("ParseSessionToken(%q) = %v, want ErrBadSession", bad, err)
+		}
+	}
+
+	receipt, _ := payclient.SignClaims(payclient.UsageReceipt{Session: "s", Spent: 4, Remaining: 5}, vendor.Sign)
+	if _, err := payclient.ParseUsageReceipt(receipt, &token); !errors.Is(err, payclient.ErrBadReceipt) {
+		t.Errorf("receipt not adding up to the deposit: %v", err)
+	}
+}
+
@@ -0,0 +1,307 @@
+// ============================================================
+// Session Test - Reference vendor for prepaid sessions
+// ============================================================
+//
+//   v := sessiontest.NewVendor(network, usdcMint, 1000)
+//   server := httptest.NewServer(v)
+//   ...
+//   v.Spent(id), v.Refunded(id), v.Settled()
+//
+// Vendor charges Price for every request except the session
+// endpoint (SessionPath), as payclient's session protocol describes:
+// a deposit opens a session, requests carrying its token are charged
+// against it with a signed receipt, and DELETE refunds the rest. It
+// also takes ordinary per-request x402 payments. Payments are checked
+// with x402.VerifyExact and co-signed by the vendor key as fee payer,
+// never submitted; refunds are recorded, not sent.
+//
+// ============================================================
+
+package sessiontest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// SessionPath is where the vendor serves its session endpoint
+const SessionPath = "/session"
+
+// Vendor is an API that sells requests per payment or per session
+type Vendor struct {
+	Key        *wallet.Keypair // signs tokens and receipts; fee payer
+	PayTo      *wallet.Keypair
+	Network    string
+	Asset      string
+	Price      uint64        // per request, atomic units
+	MinDeposit uint64        // smallest deposit accepted; default Price
+	TTL        time.Duration // session lifetime; default an hour
+	Content    string        // body of paid responses
+
+	mu       sync.Mutex
+	sessions map[string]*vendorSession
+	settled  int
+	nextID   int
+}
+
+type vendorSession struct {
+	token    payclient.SessionToken
+	seq      uint64
+	spent    uint64
+	closed   bool
+	refunded uint64
+}
+
+// NewVendor returns a vendor with fresh keys
+func NewVendor(network, asset string, price uint64) *Vendor {
+	key, _ := wallet.Generate()
+	payTo, _ := wallet.Generate()
+	return &Vendor{
+		Key:      key,
+		PayTo:    payTo,
+		Network:  network,
+		Asset:    asset,
+		Price:    price,
+		TTL:      time.Hour,
+		Content:  "paid content",
+		sessions: make(map[string]*vendorSession),
+	}
+}
+
+// Settled returns how many x402 payments (deposits included) were
+// accepted
+func (v *Vendor) Settled() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.settled
+}
+
+// Spent returns what a session has been charged
+func (v *Vendor) Spent(id string) uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s := v.sessions[id]; s != nil {
+		return s.spent
+	}
+	return 0
+}
+
+// Refunded returns what was refunded when a session closed
+func (v *Vendor) Refunded(id string) uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s := v.sessions[id]; s != nil {
+		return s.refunded
+	}
+	return 0
+}
+
+func (v *Vendor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == SessionPath && r.Method == http.MethodPost:
+		v.open(w, r)
+	case r.URL.Path == SessionPath && r.Method == http.MethodDelete:
+		v.close(w, r)
+	case r.Header.Get(payclient.SessionHeader) != "":
+		v.charge(w, r)
+	case r.Header.Get(x402.HeaderPayment) != "":
+		if _, err := v.settle(w, r.Header.Get(x402.HeaderPayment), v.requirements(r, v.Price)); err != nil {
+			v.paymentRequired(w, r, v.Price, err.Error())
+			return
+		}
+		w.Write([]byte(v.Content))
+	default:
+		v.paymentRequired(w, r, v.Price, "X-PAYMENT header is required")
+	}
+}
+
+// open sells a session for the deposit in the request body
+func (v *Vendor) open(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Deposit uint64 `json:"deposit"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&in); err != nil {
+		http.Error(w, "body must be {\"deposit\": <atomic units>}", http.StatusBadRequest)
+		return
+	}
+	min := v.MinDeposit
+	if min == 0 {
+		min = v.Price
+	}
+	if in.Deposit < min {
+		http.Error(w, fmt.Sprintf("deposit must be at least %d", min), http.StatusBadRequest)
+		return
+	}
+
+	header := r.Header.Get(x402.HeaderPayment)
+	if header == "" {
+		v.paymentRequired(w, r, in.Deposit, "deposit required")
+		return
+	}
+	payment, err := v.settle(w, header, v.requirements(r, in.Deposit))
+	if err != nil {
+		v.paymentRequired(w, r, in.Deposit, err.Error())
+		return
+	}
+
+	v.mu.Lock()
+	v.nextID++
+	token := payclient.SessionToken{
+		ID:      "sess_" + strconv.Itoa(v.nextID),
+		Vendor:  v.Key.PublicKeyBase58(),
+		Payer:   payment.Payer,
+		PayTo:   v.PayTo.PublicKeyBase58(),
+		Network: v.Network,
+		Asset:   v.Asset,
+		Deposit: in.Deposit,
+		Expires: time.Now().Add(v.TTL).UTC(),
+	}
+	v.sessions[token.ID] = &vendorSession{token: token}
+	v.mu.Unlock()
+
+	signed, _ := payclient.SignClaims(token, v.Key.Sign)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"token": signed})
+}
+
+// charge serves a request against its session's balance
+func (v *Vendor) charge(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	s, err := v.session(r)
+	if err == nil && s.token.Deposit-s.spent < v.Price {
+		err = fmt.Errorf("session balance too low")
+	}
+	if err != nil {
+		v.mu.Unlock()
+		v.paymentRequired(w, r, v.Price, err.Error())
+		return
+	}
+	s.seq++
+	s.spent += v.Price
+	receipt := v.receipt(s, r.Method+" "+r.URL.Path, v.Price)
+	v.mu.Unlock()
+
+	w.Header().Set(payclient.SessionReceiptHeader, receipt)
+	w.Write([]byte(v.Content))
+}
+
+// close ends a session and refunds the balance
+func (v *Vendor) close(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	s, err := v.session(r)
+	if err != nil {
+		v.mu.Unlock()
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	s.closed = true
+	s.refunded = s.token.Deposit - s.spent
+	receipt := v.receipt(s, "", 0)
+	refund := s.refunded
+	v.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"receipt": receipt, "refund": refund})
+}
+
+// session returns the open session a request's token names. Callers
+// hold mu.
+func (v *Vendor) session(r *http.Request) (*vendorSession, error) {
+	token, err := payclient.ParseSessionToken(r.Header.Get(payclient.SessionHeader), v.Key.PublicKeyBase58())
+	if err != nil {
+		return nil, fmt.Errorf("invalid session token")
+	}
+	s := v.sessions[token.ID]
+	switch {
+	case s == nil:
+		return nil, fmt.Errorf("unknown session")
+	case s.closed:
+		return nil, fmt.Errorf("session closed")
+	case time.Now().After(s.token.Expires):
+		return nil, fmt.Errorf("session expired")
+	}
+	return s, nil
+}
+
+// receipt signs the session's balance. Callers hold mu.
+func (v *Vendor) receipt(s *vendorSession, request string, charged uint64) string {
+	signed, _ := payclient.SignClaims(payclient.UsageReceipt{
+		Session:   s.token.ID,
+		Seq:       s.seq,
+		Request:   request,
+		Charged:   charged,
+		Spent:     s.spent,
+		Remaining: s.token.Deposit - s.spent,
+		Time:      time.Now().UTC(),
+	}, v.Key.Sign)
+	return signed
+}
+
+func (v *Vendor) requirements(r *http.Request, amount uint64) x402.PaymentRequirements {
+	return x402.PaymentRequirements{
+		Scheme:            x402.SchemeExact,
+		Network:           v.Network,
+		MaxAmountRequired: strconv.FormatUint(amount, 10),
+		Resource:          "http://" + r.Host + r.URL.Path,
+		PayTo:             v.PayTo.PublicKeyBase58(),
+		MaxTimeoutSeconds: 60,
+		Asset:             v.Asset,
+		Extra: map[string]interface{}{
+			"feePayer":                v.Key.PublicKeyBase58(),
+			payclient.SessionExtra:    SessionPath,
+			payclient.SessionKeyExtra: v.Key.PublicKeyBase58(),
+		},
+	}
+}
+
+func (v *Vendor) paymentRequired(w http.ResponseWriter, r *http.Request, amount uint64, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPaymentRequired)
+	json.NewEncoder(w).Encode(x402.PaymentRequired{
+		X402Version: x402.Version,
+		Error:       msg,
+		Accepts:     []x402.PaymentRequirements{v.requirements(r, amount)},
+	})
+}
+
+// settle verifies a payment and co-signs it as fee payer, like a
+// facilitator that does not submit
+func (v *Vendor) settle(w http.ResponseWriter, header string, required x402.PaymentRequirements) (*x402.ExactPayment, error) {
+	payload, err := x402.DecodePaymentHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := x402.VerifyExact(payload, &required)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := solana.TransactionFromBase64(payload.Payload.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.AddSignature(solana.MustPublicKey(v.Key.PublicKeyBase58()), v.Key.Sign(tx.Message.Serialize())); err != nil {
+		return nil, err
+	}
+	if len(tx.MissingSigners()) > 0 {
+		return nil, fmt.Errorf("transaction not fully signed")
+	}
+
+	v.mu.Lock()
+	v.settled++
+	v.mu.Unlock()
+	value, _ := x402.EncodeHeader(x402.SettlementResponse{Success: true, Transaction: tx.Signature(), Network: payload.Network, Payer: payment.Payer})
+	w.Header().Set(x402.HeaderPaymentResponse, value)
+	return payment, nil
+}
+
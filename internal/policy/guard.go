@@ -0,0 +1,482 @@
+// ============================================================
+// Guard - Policy enforcement at signing time
+// ============================================================
+//
+// Every message the CLI signs goes through a Guard:
+//
+//   r, err := guard.Authorize(publicKey, message) // refuse or reserve
+//   sig := sign(message)
+//   r.Commit(sig)                                 // write to the ledger
+//
+// Only USDC token transfers authorised by the signing key count as
+// payments. While a policy is set, messages in which the key moves or
+// hands over funds some other way (SOL transfers, token approvals and
+// authority changes, other tokens, USDC transfers the count would
+// misread) are refused: the limits could not see them. So are
+// instructions for programs outside a short allowlist that are passed
+// the key, since they could call the token program with its signature. The vendor is the owner of the receiving token account,
+// taken from an ATA-create instruction in the same message or by
+// matching the associated token accounts of addresses the policy
+// and address book know; otherwise the token account itself.
+//
+// Versioned messages the parser cannot read (lookup tables, unknown
+// versions) may move funds through accounts the guard cannot see;
+// while a policy is set they are refused rather than signed unchecked.
+//
+// The policy file is re-read on every Authorize, so 'machpay policy
+// set' applies to a running signing agent immediately.
+//
+// ============================================================
+
+package policy
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// SPL Token instruction discriminators
+const (
+	tokenTransfer        = 3
+	tokenApprove         = 4
+	tokenSetAuthority    = 6
+	tokenCloseAccount    = 9
+	tokenTransferChecked = 12
+	tokenApproveChecked  = 13
+
+	// Token-2022 TransferCheckedWithFee (TransferFeeExtension)
+	tokenTransferFeeExtension = 26
+)
+
+// fundsOp is an instruction that moves or hands over funds: the
+// account that authorises it, and what to call it
+type fundsOp struct {
+	authority int
+	what      string
+}
+
+// System and SPL Token instructions the policy cannot limit, by
+// discriminator. USDC transfers are counted instead.
+var (
+	systemFundsOps = map[uint32]fundsOp{
+		0:  {0, "SOL transfer (create account)"},
+		1:  {0, "account assignment"},
+		2:  {0, "SOL transfer"},
+		3:  {0, "SOL transfer (create account)"},
+		5:  {4, "nonce account withdrawal"},
+		10: {1, "account assignment"},
+		11: {1, "SOL transfer"},
+	}
+	tokenFundsOps = map[byte]fundsOp{
+		tokenTransfer:        {2, "transfer of another token"},
+		tokenApprove:         {2, "token approval"},
+		tokenSetAuthority:    {1, "token authority change"},
+		tokenCloseAccount:    {2, "token account closure"},
+		tokenTransferChecked: {3, "transfer of another token"},
+		tokenApproveChecked:  {3, "token approval"},
+
+		tokenTransferFeeExtension: {3, "token transfer with fee"},
+	}
+
+	// Programs that may be passed the payer: none can move its funds
+	// except System and Token, whose instructions are checked above
+	allowedPrograms = map[solana.PublicKey]bool{
+		solana.ComputeBudgetProgramID:   true,
+		solana.AssociatedTokenProgramID: true,
+		solana.MemoProgramID:            true,
+		solana.SystemProgramID:          true,
+		solana.TokenProgramID:           true,
+		solana.Token2022ProgramID:       true,
+	}
+)
+
+// Guard checks signatures against a policy file and ledger
+type Guard struct {
+	policyPath string
+	ledger     *Ledger
+	usdcMint   string
+	labels     func() map[string]string
+	now        func() time.Time
+
+	mu sync.Mutex // serializes this process's use of the ledger lock
+}
+
+// NewGuard creates a guard. labels maps known addresses to labels
+// for vendor resolution and error messages; it may be nil.
+func NewGuard(policyPath string, ledger *Ledger, usdcMint string, labels func() map[string]string) *Guard {
+	if labels == nil {
+		labels = func() map[string]string { return nil }
+	}
+	return &Guard{
+		policyPath: policyPath,
+		ledger:     ledger,
+		usdcMint:   usdcMint,
+		labels:     labels,
+		now:        time.Now,
+	}
+}
+
+// Reservation holds payments that passed the policy but are not
+// signed yet. Pending reservations count against the limits in every
+// process sharing the ledger, so concurrent requests cannot overspend
+// together.
+type Reservation struct {
+	guard    *Guard
+	id       string
+	wallet   string
+	payments []Payment
+}
+
+// Payments returns the payments the reservation covers
+func (r *Reservation) Payments() []Payment {
+	return r.payments
+}
+
+// Authorize checks the payments in message, signed by publicKey,
+// against the policy and the ledger. The returned reservation must
+// be committed once signed, or released if signing fails.
+func (g *Guard) Authorize(publicKey ed25519.PublicKey, message []byte) (*Reservation, error) {
+	r := &Reservation{guard: g, wallet: wallet.Base58Encode(publicKey)}
+
+	pol, err := Load(g.policyPath)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := solana.ParseMessage(message)
+	if err != nil {
+		if len(message) > 0 && message[0]&0x80 != 0 && !pol.Empty() {
+			return nil, fmt.Errorf("%w: cannot check a versioned transaction: %v", ErrViolation, err)
+		}
+		// Not a transaction: nothing it signs can move funds
+		return r, nil
+	}
+	if what := Unchecked(msg, r.wallet, g.usdcMint); what != "" && !pol.Empty() {
+		return nil, fmt.Errorf("%w: the transaction includes a %s, which the USDC limits cannot check", ErrViolation, what)
+	}
+	labels := g.labels()
+	known := pol.Vendors()
+	for addr := range labels {
+		known = append(known, addr)
+	}
+	r.payments = Payments(msg, r.wallet, g.usdcMint, known)
+	if len(r.payments) == 0 {
+		return r, nil
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("reservation id: %w", err)
+	}
+	r.id = hex.EncodeToString(id)
+
+	err = g.locked(func(now time.Time, spent []Entry, pending []reservation) ([]reservation, error) {
+		if err := pol.Check(r.payments, spent, labelFunc(labels)); err != nil {
+			return nil, err
+		}
+		return append(pending, reservation{ID: r.id, Expires: now.Add(ReservationTTL), Entries: r.entries(now, "")}), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Spent returns the payments counted against the limits now: the
+// ledger within the window plus pending reservations
+func (g *Guard) Spent() ([]Entry, error) {
+	var spent []Entry
+	err := g.locked(func(_ time.Time, s []Entry, pending []reservation) ([]reservation, error) {
+		spent = s
+		return pending, nil
+	})
+	return spent, err
+}
+
+// locked runs fn under the ledger lock with the payments counted
+// against the limits and the pending reservations, and saves the
+// reservations fn returns
+func (g *Guard) locked(fn func(now time.Time, spent []Entry, pending []reservation) ([]reservation, error)) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	unlock, err := g.ledger.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	now := g.now()
+	spent, err := g.ledger.Since(now.Add(-Window))
+	if err != nil {
+		return err
+	}
+	pending, err := g.ledger.reservations(now)
+	if err != nil {
+		return err
+	}
+	for _, r := range pending {
+		spent = append(spent, r.Entries...)
+	}
+	kept, err := fn(now, spent, pending)
+	if err != nil {
+		return err
+	}
+	return g.ledger.saveReservations(kept)
+}
+
+// entries lists the reservation's payments as ledger entries
+func (r *Reservation) entries(now time.Time, signature string) []Entry {
+	entries := make([]Entry, 0, len(r.payments))
+	for _, p := range r.payments {
+		entries = append(entries, Entry{
+			Time:      now,
+			Wallet:    r.wallet,
+			Vendor:    p.Vendor,
+			Amount:    p.Amount,
+			Signature: signature,
+		})
+	}
+	return entries
+}
+
+// drop removes the reservation from pending
+func (r *Reservation) drop(pending []reservation) []reservation {
+	kept := pending[:0]
+	for _, p := range pending {
+		if p.ID != r.id {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// Commit records the reservation's payments under signature
+func (r *Reservation) Commit(signature []byte) error {
+	if len(r.payments) == 0 {
+		return nil
+	}
+	return r.guard.locked(func(now time.Time, _ []Entry, pending []reservation) ([]reservation, error) {
+		if err := r.guard.ledger.Append(r.entries(now.UTC(), wallet.Base58Encode(signature))...); err != nil {
+			return nil, err
+		}
+		return r.drop(pending), nil
+	})
+}
+
+// Release drops a reservation that was not signed
+func (r *Reservation) Release() {
+	if len(r.payments) == 0 {
+		return
+	}
+	// Best effort: an unreleased reservation expires after ReservationTTL
+	_ = r.guard.locked(func(_ time.Time, _ []Entry, pending []reservation) ([]reservation, error) {
+		return r.drop(pending), nil
+	})
+}
+
+// ============================================================
+// Guarded Signer
+// ============================================================
+
+// Signer wraps s so every signature passes through the guard
+func (g *Guard) Signer(s wallet.Signer) wallet.Signer {
+	return &guardedSigner{Signer: s, guard: g}
+}
+
+type guardedSigner struct {
+	wallet.Signer
+	guard *Guard
+}
+
+func (s *guardedSigner) Sign(message []byte) ([]byte, error) {
+	r, err := s.guard.Authorize(s.PublicKey(), message)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.Signer.Sign(message)
+	if err != nil {
+		r.Release()
+		return nil, err
+	}
+	if err := r.Commit(sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// ============================================================
+// Payment Extraction
+// ============================================================
+
+// Payments lists the USDC payments payer authorises in msg, one per
+// vendor in order of appearance. known addresses help resolve
+// receiving token accounts to their owners.
+func Payments(msg *solana.Message, payer, usdcMint string, known []string) []Payment {
+	mint, err := solana.ParsePublicKey(usdcMint)
+	if err != nil {
+		return nil
+	}
+	payerKey, err := solana.ParsePublicKey(payer)
+	if err != nil {
+		return nil
+	}
+
+	// Token account -> owner, for every account whose owner we can tell
+	owners := make(map[solana.PublicKey]string)
+	for _, addr := range append(known, payer) {
+		key, err := solana.ParsePublicKey(addr)
+		if err != nil {
+			continue
+		}
+		for _, program := range []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID} {
+			if ata, err := solana.AssociatedTokenAddress(key, mint, program); err == nil {
+				owners[ata] = addr
+			}
+		}
+	}
+	decoded := solana.DecodeInstructions(msg)
+	for _, ix := range decoded {
+		if ix.Program == solana.AssociatedTokenProgramID && len(ix.Accounts) >= 4 && ix.Accounts[3] == mint {
+			owners[ix.Accounts[1]] = ix.Accounts[2].String()
+		}
+	}
+
+	var payments []Payment
+	index := make(map[string]int)
+	usdcAccounts := usdcAccountsOf(payerKey, mint)
+	for _, ix := range decoded {
+		amount, dest, problem, ok := usdcTransfer(ix, payerKey, mint, usdcAccounts)
+		if !ok || problem != "" {
+			continue
+		}
+
+		vendor, ok := owners[dest]
+		if !ok {
+			vendor = dest.String()
+		}
+		if vendor == payer {
+			continue
+		}
+		if i, ok := index[vendor]; ok {
+			payments[i].Amount += amount
+			continue
+		}
+		index[vendor] = len(payments)
+		payments = append(payments, Payment{Vendor: vendor, Amount: amount})
+	}
+	return payments
+}
+
+// usdcAccountsOf returns payer's associated USDC token accounts
+func usdcAccountsOf(payer, mint solana.PublicKey) map[solana.PublicKey]bool {
+	accounts := make(map[solana.PublicKey]bool)
+	for _, program := range []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID} {
+		if ata, err := solana.AssociatedTokenAddress(payer, mint, program); err == nil {
+			accounts[ata] = true
+		}
+	}
+	return accounts
+}
+
+// usdcTransfer reports whether ix transfers USDC: a TransferChecked of
+// mint, or a Transfer (which names no mint) out of one of
+// usdcAccounts. For a USDC transfer, problem names why it cannot count
+// as a payment by payer, or is "" and amount and dest describe it. The
+// token program ignores trailing data, so only the exact lengths are
+// read as payments.
+func usdcTransfer(ix solana.DecodedInstruction, payer, mint solana.PublicKey, usdcAccounts map[solana.PublicKey]bool) (amount uint64, dest solana.PublicKey, problem string, ok bool) {
+	if ix.Program != solana.TokenProgramID && ix.Program != solana.Token2022ProgramID || len(ix.Data) == 0 {
+		return 0, dest, "", false
+	}
+
+	var size, authority int
+	switch {
+	case ix.Data[0] == tokenTransferChecked && len(ix.Accounts) >= 2 && ix.Accounts[1] == mint:
+		size, authority = 10, 3
+		if len(ix.Accounts) > 2 {
+			dest = ix.Accounts[2]
+		}
+	case ix.Data[0] == tokenTransfer && len(ix.Accounts) >= 1 && usdcAccounts[ix.Accounts[0]]:
+		size, authority = 9, 2
+		if len(ix.Accounts) > 1 {
+			dest = ix.Accounts[1]
+		}
+	default:
+		return 0, dest, "", false
+	}
+
+	switch {
+	case len(ix.Data) != size || len(ix.Accounts) <= authority:
+		return 0, dest, "malformed USDC transfer", true
+	case ix.Accounts[authority] != payer:
+		return 0, dest, "USDC transfer by another authority", true
+	}
+	return binary.LittleEndian.Uint64(ix.Data[1:9]), dest, "", true
+}
+
+// Unchecked names the first instruction in msg by which payer moves or
+// hands over funds other than the USDC transfers Payments counts, or
+// could do so through another program, or returns "" when there is
+// none
+func Unchecked(msg *solana.Message, payer, usdcMint string) string {
+	payerKey, err := solana.ParsePublicKey(payer)
+	if err != nil {
+		return ""
+	}
+	mint, _ := solana.ParsePublicKey(usdcMint)
+	usdcAccounts := usdcAccountsOf(payerKey, mint)
+
+	for _, ix := range solana.DecodeInstructions(msg) {
+		// USDC transfers are payments unless Payments would skip them
+		if _, _, problem, ok := usdcTransfer(ix, payerKey, mint, usdcAccounts); ok {
+			if problem != "" {
+				return problem
+			}
+			continue
+		}
+
+		var op fundsOp
+		var ok bool
+		switch ix.Program {
+		case solana.SystemProgramID:
+			if len(ix.Data) >= 4 {
+				op, ok = systemFundsOps[binary.LittleEndian.Uint32(ix.Data)]
+			}
+		case solana.TokenProgramID, solana.Token2022ProgramID:
+			if len(ix.Data) > 0 {
+				op, ok = tokenFundsOps[ix.Data[0]]
+			}
+		default:
+			if allowedPrograms[ix.Program] {
+				continue
+			}
+			for _, account := range ix.Accounts {
+				if account == payerKey {
+					return "call to program " + ix.Program.String()
+				}
+			}
+		}
+		if ok && op.authority < len(ix.Accounts) && ix.Accounts[op.authority] == payerKey {
+			return op.what
+		}
+	}
+	return ""
+}
+
+// labelFunc names addresses as @label when labels has them
+func labelFunc(labels map[string]string) func(string) string {
+	return func(addr string) string {
+		if label, ok := labels[addr]; ok {
+			return "@" + label
+		}
+		return addr
+	}
+}
+
@@ -0,0 +1,293 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+const testMint = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+
+// paymentMessage builds a message in which payer sends amount USDC to
+// each vendor, creating the first vendor's ATA like 'tx build' does
+func paymentMessage(t *testing.T, payer solana.PublicKey, amount uint64, vendors ...string) []byte {
+	t.Helper()
+	mint := solana.MustPublicKey(testMint)
+	source, _ := solana.AssociatedTokenAddress(payer, mint, solana.TokenProgramID)
+
+	var ixs []solana.Instruction
+	for i, v := range vendors {
+		owner := solana.MustPublicKey(v)
+		dest, err := solana.AssociatedTokenAddress(owner, mint, solana.TokenProgramID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			ixs = append(ixs, solana.CreateAssociatedTokenAccountIdempotent(payer, dest, owner, mint, solana.TokenProgramID))
+		}
+		ixs = append(ixs, solana.TokenTransferChecked(solana.TokenProgramID, source, mint, dest, payer, amount, USDCDecimals))
+	}
+	msg, err := solana.NewMessage(payer, ixs, solana.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg.Serialize()
+}
+
+func TestPayments(t *testing.T) {
+	kp, _ := wallet.Generate()
+	payer := solana.MustPublicKey(kp.PublicKeyBase58())
+	other := solana.MustPublicKey(vendorB)
+	mint := solana.MustPublicKey(testMint)
+
+	data := paymentMessage(t, payer, 1_500_000, vendorA, vendorB, vendorA)
+	msg, _ := solana.ParseMessage(data)
+
+	// vendorA comes from the ATA-create; vendorB only when known
+	got := Payments(msg, payer.String(), testMint, []string{vendorB})
+	want := []Payment{{vendorA, 3_000_000}, {vendorB, 1_500_000}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Payments = %+v, want %+v", got, want)
+	}
+
+	got = Payments(msg, payer.String(), testMint, nil)
+	destB, _ := solana.AssociatedTokenAddress(other, mint, solana.TokenProgramID)
+	if len(got) != 2 || got[1].Vendor != destB.String() {
+		t.Errorf("unknown owner: Payments = %+v, want the token account as vendor", got)
+	}
+
+	// Transfers the payer does not authorise are not its payments
+	if got := Payments(msg, vendorB, testMint, nil); len(got) != 0 {
+		t.Errorf("Payments for another signer = %+v", got)
+	}
+	// Nor are transfers of another mint
+	if got := Payments(msg, payer.String(), "So11111111111111111111111111111111111111112", nil); len(got) != 0 {
+		t.Errorf("Payments for another mint = %+v", got)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	ledger := NewLedger(filepath.Join(dir, "ledger.jsonl"))
+	if err := (&Policy{MaxPerRequest: "2", MaxPerDay: "3"}).Save(policyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	kp, _ := wallet.Generate()
+	payer := solana.MustPublicKey(kp.PublicKeyBase58())
+	guard := NewGuard(policyPath, ledger, testMint, func() map[string]string {
+		return map[string]string{vendorA: "vendor-a"}
+	})
+	s := guard.Signer(wallet.NewKeypairSigner(kp))
+
+	// Not a transaction: signed, nothing recorded
+	if _, err := s.Sign([]byte("hello")); err != nil {
+		t.Fatalf("Sign(non-transaction): %v", err)
+	}
+
+	// Over max_per_request
+	_, err := s.Sign(paymentMessage(t, payer, 2_500_000, vendorA))
+	var v *Violation
+	if !errors.As(err, &v) || v.Rule != RuleMaxPerRequest || v.Vendor != "@vendor-a" {
+		t.Fatalf("Sign(2.5) = %v, want max_per_request violation", err)
+	}
+
+	// Allowed and recorded
+	if _, err := s.Sign(paymentMessage(t, payer, 2_000_000, vendorA)); err != nil {
+		t.Fatalf("Sign(2): %v", err)
+	}
+	entries, _ := ledger.Since(time.Now().Add(-time.Minute))
+	if len(entries) != 1 || entries[0].Vendor != vendorA || entries[0].Amount != 2_000_000 ||
+		entries[0].Wallet != payer.String() || entries[0].Signature == "" {
+		t.Fatalf("ledger = %+v", entries)
+	}
+
+	// A pending reservation counts against the daily limit
+	r, err := guard.Authorize(kp.PublicKey, paymentMessage(t, payer, 1_000_000, vendorA))
+	if err != nil {
+		t.Fatalf("Authorize(1): %v", err)
+	}
+	if _, err := guard.Authorize(kp.PublicKey, paymentMessage(t, payer, 500_000, vendorA)); !errors.As(err, &v) || v.Rule != RuleMaxPerDay {
+		t.Fatalf("Authorize with pending = %v, want max_per_day violation", err)
+	}
+	r.Release()
+	if _, err := guard.Authorize(kp.PublicKey, paymentMessage(t, payer, 500_000, vendorA)); err != nil {
+		t.Errorf("Authorize after release: %v", err)
+	}
+}
+
+func TestGuard_Versioned(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	if err := (&Policy{MaxPerRequest: "1"}).Save(policyPath); err != nil {
+		t.Fatal(err)
+	}
+	kp, _ := wallet.Generate()
+	payer := solana.MustPublicKey(kp.PublicKeyBase58())
+	s := NewGuard(policyPath, NewLedger(filepath.Join(dir, "ledger.jsonl")), testMint, nil).Signer(wallet.NewKeypairSigner(kp))
+
+	// A v0 transfer counts like a legacy one
+	msg, _ := solana.ParseMessage(paymentMessage(t, payer, 2_000_000, vendorA))
+	msg.Versioned = true
+	var v *Violation
+	if _, err := s.Sign(msg.Serialize()); !errors.As(err, &v) || v.Rule != RuleMaxPerRequest {
+		t.Fatalf("Sign(v0 transfer) = %v, want max_per_request violation", err)
+	}
+
+	// With a lookup table the accounts are unknown: refused
+	data := msg.Serialize()
+	data = append(append(data[:len(data)-1], 1), make([]byte, 32)...)
+	data = append(data, 1, 0, 0)
+	if _, err := s.Sign(data); !errors.Is(err, ErrViolation) {
+		t.Errorf("Sign(v0 with lookup table) = %v, want ErrViolation", err)
+	}
+}
+
+func TestGuard_SharedLedger(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	ledgerPath := filepath.Join(dir, "ledger.jsonl")
+	if err := (&Policy{MaxPerDay: "3"}).Save(policyPath); err != nil {
+		t.Fatal(err)
+	}
+	kp, _ := wallet.Generate()
+	payer := solana.MustPublicKey(kp.PublicKeyBase58())
+	message := paymentMessage(t, payer, 1_000_000, vendorA)
+
+	// Guards with their own Ledger stand in for separate processes
+	var wg sync.WaitGroup
+	var signed atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := NewGuard(policyPath, NewLedger(ledgerPath), testMint, nil).Signer(wallet.NewKeypairSigner(kp))
+			if _, err := s.Sign(message); err == nil {
+				signed.Add(1)
+			} else if !errors.Is(err, ErrViolation) {
+				t.Errorf("Sign: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if signed.Load() != 3 {
+		t.Fatalf("%d of 8 concurrent 1 USDC payments signed under max_per_day 3", signed.Load())
+	}
+
+	// A reservation held by one guard counts in another until it is
+	// released or expires
+	if err := os.Remove(ledgerPath); err != nil {
+		t.Fatal(err)
+	}
+	a := NewGuard(policyPath, NewLedger(ledgerPath), testMint, nil)
+	b := NewGuard(policyPath, NewLedger(ledgerPath), testMint, nil)
+	r, err := a.Authorize(kp.PublicKey, paymentMessage(t, payer, 2_000_000, vendorA))
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if _, err := b.Authorize(kp.PublicKey, paymentMessage(t, payer, 2_000_000, vendorA)); !errors.Is(err, ErrViolation) {
+		t.Fatalf("Authorize with another guard's reservation = %v, want violation", err)
+	}
+	r.Release()
+	if _, err := b.Authorize(kp.PublicKey, paymentMessage(t, payer, 2_000_000, vendorA)); err != nil {
+		t.Fatalf("Authorize after release: %v", err)
+	}
+	a.now = func() time.Time { return time.Now().Add(ReservationTTL) }
+	if spent, _ := a.Spent(); len(spent) != 0 {
+		t.Errorf("Spent after the reservation expired = %+v", spent)
+	}
+}
+
+func TestGuard_Unchecked(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	kp, _ := wallet.Generate()
+	payer := solana.MustPublicKey(kp.PublicKeyBase58())
+	other := solana.MustPublicKey(vendorA)
+	source, _ := solana.AssociatedTokenAddress(payer, solana.MustPublicKey(testMint), solana.TokenProgramID)
+	approve := solana.Instruction{
+		ProgramID: solana.TokenProgramID,
+		Accounts: []solana.AccountMeta{
+			{PublicKey: source, IsWritable: true},
+			{PublicKey: other},
+			{PublicKey: payer, IsSigner: true},
+		},
+		Data: []byte{tokenApprove, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+	message := func(ixs ...solana.Instruction) []byte {
+		msg, err := solana.NewMessage(payer, ixs, solana.Hash{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg.Serialize()
+	}
+	guard := NewGuard(policyPath, NewLedger(filepath.Join(dir, "ledger.jsonl")), testMint, nil)
+
+	// Without a policy there is nothing to enforce
+	if _, err := guard.Authorize(kp.PublicKey, message(solana.SystemTransfer(payer, other, 1_000_000_000))); err != nil {
+		t.Fatalf("Authorize(SOL transfer) without a policy: %v", err)
+	}
+
+	if err := (&Policy{MaxPerDay: "1"}).Save(policyPath); err != nil {
+		t.Fatal(err)
+	}
+	mint := solana.MustPublicKey(testMint)
+	dest, _ := solana.AssociatedTokenAddress(other, mint, solana.TokenProgramID)
+	padded := solana.TokenTransferChecked(solana.TokenProgramID, source, mint, dest, payer, 1_000_000_000, USDCDecimals)
+	padded.Data = append(padded.Data, 0)
+	delegated := solana.TokenTransferChecked(solana.TokenProgramID, source, mint, dest, other, 1_000_000_000, USDCDecimals)
+	withFee := approve
+	withFee.ProgramID = solana.Token2022ProgramID
+	withFee.Accounts = []solana.AccountMeta{{PublicKey: source, IsWritable: true}, {PublicKey: mint}, {PublicKey: dest, IsWritable: true}, {PublicKey: payer, IsSigner: true}}
+	withFee.Data = append([]byte{tokenTransferFeeExtension}, make([]byte, 17)...)
+	program := solana.MustPublicKey(vendorB)
+	call := solana.Instruction{
+		ProgramID: program,
+		Accounts: []solana.AccountMeta{
+			{PublicKey: payer, IsSigner: true},
+			{PublicKey: source, IsWritable: true},
+			{PublicKey: solana.TokenProgramID},
+		},
+	}
+	for name, data := range map[string][]byte{
+		"SOL transfer":                       message(solana.SystemTransfer(payer, other, 1_000_000_000)),
+		"token approval":                     message(approve),
+		"malformed USDC transfer":            message(padded),
+		"USDC transfer by another authority": message(delegated),
+		"token transfer with fee":            message(withFee),
+		"call to program " + vendorB:         message(call),
+	} {
+		_, err := guard.Authorize(kp.PublicKey, data)
+		if !errors.Is(err, ErrViolation) || !strings.Contains(err.Error(), name) {
+			t.Errorf("Authorize(%s) = %v, want a violation naming it", name, err)
+		}
+	}
+
+	// The count skips what is refused
+	msg, _ := solana.ParseMessage(message(padded))
+	if got := Payments(msg, payer.String(), testMint, nil); len(got) != 0 {
+		t.Errorf("Payments(malformed transfer) = %+v", got)
+	}
+
+	// A program the payer is not passed to cannot use its signature
+	call.Accounts = call.Accounts[1:]
+	if _, err := guard.Authorize(kp.PublicKey, message(call)); err != nil {
+		t.Errorf("Authorize(call without the payer): %v", err)
+	}
+
+	// Funds moved by another signer are not the payer's
+	msg, _ = solana.NewMessage(other, []solana.Instruction{solana.SystemTransfer(other, payer, 1_000_000_000)}, solana.Hash{})
+	if _, err := guard.Authorize(kp.PublicKey, msg.Serialize()); err != nil {
+		t.Errorf("Authorize(SOL transfer to the payer): %v", err)
+	}
+}
+
@@ -0,0 +1,202 @@
+// ============================================================
+// Ledger - Local record of signed payments
+// ============================================================
+//
+// Layout: ~/.machpay/ledger.jsonl, one JSON entry per line:
+//
+//   {"time":"...","wallet":"...","vendor":"...","amount":1500000,
+//    "signature":"..."}
+//
+// Append-only. Entries are written when a payment is signed, so
+// the ledger counts what this machine authorised whether or not
+// the transaction later landed: limits err on the side of caution.
+//
+// Every process signing through a Guard (agent proxy, mcp serve, curl,
+// the SDK) shares the ledger, so checking a payment and recording it
+// happen under an advisory lock on ledger.jsonl.lock. Payments checked
+// but not signed yet are kept in ledger.jsonl.pending, where other
+// processes count them too, for at most ReservationTTL.
+//
+// ============================================================
+
+package policy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Entry is one signed payment
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Wallet    string    `json:"wallet"`
+	Vendor    string    `json:"vendor"`
+	Amount    uint64    `json:"amount"` // raw USDC units
+	Signature string    `json:"signature,omitempty"`
+}
+
+// ReservationTTL bounds how long an unsigned reservation counts, so a
+// process that dies before signing does not hold the limits forever
+const ReservationTTL = 10 * time.Minute
+
+// reservation is a pending payment as other processes see it
+type reservation struct {
+	ID      string    `json:"id"`
+	Expires time.Time `json:"expires"`
+	Entries []Entry   `json:"entries"`
+}
+
+// Ledger is an append-only JSON lines file of payments
+type Ledger struct {
+	path string
+}
+
+// NewLedger opens the ledger at path
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path}
+}
+
+// Append records entries
+func (l *Ledger) Append(entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("create ledger dir: %w", err)
+	}
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("marshal ledger entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open ledger: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("write ledger: %w", err)
+	}
+	return f.Close()
+}
+
+// lock takes the advisory lock shared by every process using the
+// ledger, waiting for it. The returned func releases it.
+func (l *Ledger) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return nil, fmt.Errorf("create ledger dir: %w", err)
+	}
+	f, err := os.OpenFile(l.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open ledger lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lock ledger: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// reservations returns the pending payments that have not expired at
+// now. Callers hold the lock.
+func (l *Ledger) reservations(now time.Time) ([]reservation, error) {
+	data, err := os.ReadFile(l.path + ".pending")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read pending payments: %w", err)
+	}
+	var all []reservation
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("pending payments %s.pending: %w", l.path, err)
+	}
+	live := all[:0]
+	for _, r := range all {
+		if now.Before(r.Expires) {
+			live = append(live, r)
+		}
+	}
+	return live, nil
+}
+
+// saveReservations replaces the pending payments. Callers hold the
+// lock.
+func (l *Ledger) saveReservations(rs []reservation) error {
+	path := l.path + ".pending"
+	if len(rs) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove pending payments: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(rs)
+	if err != nil {
+		return fmt.Errorf("marshal pending payments: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("write pending payments: %w", err)
+	}
+	return nil
+}
+
+// Since returns entries recorded at or after t, oldest first. A
+// missing ledger has no entries.
+func (l *Ledger) Since(t time.Time) ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("ledger %s line %d: %w", l.path, n, err)
+		}
+		if !e.Time.Before(t) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read ledger: %w", err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// Totals sums entries per vendor
+func Totals(entries []Entry) (total uint64, byVendor map[string]uint64) {
+	byVendor = make(map[string]uint64)
+	for _, e := range entries {
+		total += e.Amount
+		byVendor[e.Vendor] += e.Amount
+	}
+	return total, byVendor
+}
+
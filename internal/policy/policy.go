@@ -0,0 +1,258 @@
+// ============================================================
+// Policy Package - Spending limits for autonomous payments
+// ============================================================
+//
+// Layout: ~/.machpay/policy.yaml
+//
+//   max_per_request: "1"      # USDC, any single payment
+//   max_per_day: "25"         # USDC, all vendors, last 24 hours
+//   max_per_vendor: "10"      # USDC, each vendor, last 24 hours
+//   vendor_limits:            # per-vendor overrides of max_per_vendor
+//     7o36UsWR...: "50"
+//   allow: [...]              # if set, only these vendors may be paid
+//   deny: [...]               # never pay these vendors
+//
+// Limits are USDC amounts; an empty limit is no limit. Vendors are
+// wallet addresses (the owner of the receiving token account).
+//
+// ============================================================
+
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+)
+
+// USDCDecimals is the number of decimals limits are expressed in
+const USDCDecimals = 6
+
+// Window is the period max_per_day and max_per_vendor cover
+const Window = 24 * time.Hour
+
+// Rules, as named in violations and in the policy file
+const (
+	RuleDeny          = "deny"
+	RuleAllow         = "allow"
+	RuleMaxPerRequest = "max_per_request"
+	RuleMaxPerDay     = "max_per_day"
+	RuleMaxPerVendor  = "max_per_vendor"
+)
+
+// ErrViolation matches every policy refusal with errors.Is
+var ErrViolation = errors.New("spending policy violation")
+
+// Policy is the spending policy file
+type Policy struct {
+	MaxPerRequest string            `yaml:"max_per_request,omitempty" json:"max_per_request,omitempty"`
+	MaxPerDay     string            `yaml:"max_per_day,omitempty" json:"max_per_day,omitempty"`
+	MaxPerVendor  string            `yaml:"max_per_vendor,omitempty" json:"max_per_vendor,omitempty"`
+	VendorLimits  map[string]string `yaml:"vendor_limits,omitempty" json:"vendor_limits,omitempty"`
+	Allow         []string          `yaml:"allow,omitempty" json:"allow,omitempty"`
+	Deny          []string          `yaml:"deny,omitempty" json:"deny,omitempty"`
+}
+
+// Load reads a policy file. A missing file is an empty policy.
+func Load(path string) (*Policy, error) {
+	p := &Policy{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("parse policy %s: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Save writes the policy file
+func (p *Policy) Save(path string) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create policy dir: %w", err)
+	}
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshal policy: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("write policy: %w", err)
+	}
+	return nil
+}
+
+// Validate checks every amount
+func (p *Policy) Validate() error {
+	for rule, amount := range map[string]string{
+		RuleMaxPerRequest: p.MaxPerRequest,
+		RuleMaxPerDay:     p.MaxPerDay,
+		RuleMaxPerVendor:  p.MaxPerVendor,
+	} {
+		if _, err := parseLimit(amount); err != nil {
+			return fmt.Errorf("%s: %w", rule, err)
+		}
+	}
+	for vendor, amount := range p.VendorLimits {
+		if _, err := parseLimit(amount); err != nil {
+			return fmt.Errorf("vendor_limits %s: %w", vendor, err)
+		}
+	}
+	return nil
+}
+
+// Empty reports whether the policy sets no rule at all
+func (p *Policy) Empty() bool {
+	return p.MaxPerRequest == "" && p.MaxPerDay == "" && p.MaxPerVendor == "" &&
+		len(p.VendorLimits) == 0 && len(p.Allow) == 0 && len(p.Deny) == 0
+}
+
+// Vendors lists every vendor address the policy mentions
+func (p *Policy) Vendors() []string {
+	seen := make(map[string]bool)
+	for _, v := range append(append([]string{}, p.Allow...), p.Deny...) {
+		seen[v] = true
+	}
+	for v := range p.VendorLimits {
+		seen[v] = true
+	}
+	out := make([]string, 0, len(seen))
+	for v := range seen {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// VendorLimit returns the daily limit that applies to vendor, as a
+// decimal string ("" for none)
+func (p *Policy) VendorLimit(vendor string) string {
+	if limit, ok := p.VendorLimits[vendor]; ok {
+		return limit
+	}
+	return p.MaxPerVendor
+}
+
+// parseLimit converts a USDC limit to raw units; "" (no limit) is 0
+func parseLimit(amount string) (uint64, error) {
+	if amount == "" {
+		return 0, nil
+	}
+	return solana.ParseTokenAmount(amount, USDCDecimals)
+}
+
+// ============================================================
+// Checking
+// ============================================================
+
+// Payment is a USDC payment to a vendor, in raw units
+type Payment struct {
+	Vendor string
+	Amount uint64
+}
+
+// Violation explains why a payment is refused
+type Violation struct {
+	Rule   string
+	Vendor string // address, or "@label" when known
+	Amount uint64 // the payment
+	Limit  uint64 // the limit it breaks (limit rules only)
+	Spent  uint64 // already spent within the window (daily rules only)
+}
+
+func (v *Violation) Error() string {
+	usdc := func(n uint64) string { return solana.FormatTokenAmount(n, USDCDecimals) + " USDC" }
+	pay := fmt.Sprintf("payment of %s to %s", usdc(v.Amount), v.Vendor)
+
+	switch v.Rule {
+	case RuleDeny:
+		return fmt.Sprintf("spending policy: %s refused: vendor is on the deny list", pay)
+	case RuleAllow:
+		return fmt.Sprintf("spending policy: %s refused: vendor is not on the allow list", pay)
+	case RuleMaxPerRequest:
+		return fmt.Sprintf("spending policy: %s exceeds max_per_request of %s", pay, usdc(v.Limit))
+	case RuleMaxPerDay:
+		return fmt.Sprintf("spending policy: %s would bring spending in the last %s to %s, over max_per_day of %s (%s already spent)",
+			pay, "24h", usdc(v.Spent+v.Amount), usdc(v.Limit), usdc(v.Spent))
+	case RuleMaxPerVendor:
+		return fmt.Sprintf("spending policy: %s would bring spending with this vendor in the last %s to %s, over its limit of %s (%s already spent)",
+			pay, "24h", usdc(v.Spent+v.Amount), usdc(v.Limit), usdc(v.Spent))
+	}
+	return fmt.Sprintf("spending policy: %s refused by %s", pay, v.Rule)
+}
+
+// Unwrap makes errors.Is(err, ErrViolation) true
+func (v *Violation) Unwrap() error {
+	return ErrViolation
+}
+
+// Check decides whether payments may be made given what was already
+// spent within the window. Payments are checked in order, each on
+// top of the ones before it. label names vendors in violations; it
+// may be nil.
+func (p *Policy) Check(payments []Payment, spent []Entry, label func(string) string) error {
+	if label == nil {
+		label = func(s string) string { return s }
+	}
+
+	total, byVendor := Totals(spent)
+	perRequest, _ := parseLimit(p.MaxPerRequest)
+	perDay, _ := parseLimit(p.MaxPerDay)
+
+	for _, pay := range payments {
+		v := &Violation{Vendor: label(pay.Vendor), Amount: pay.Amount}
+
+		switch {
+		case contains(p.Deny, pay.Vendor):
+			v.Rule = RuleDeny
+			return v
+		case len(p.Allow) > 0 && !contains(p.Allow, pay.Vendor):
+			v.Rule = RuleAllow
+			return v
+		case p.MaxPerRequest != "" && pay.Amount > perRequest:
+			v.Rule, v.Limit = RuleMaxPerRequest, perRequest
+			return v
+		}
+
+		if limitStr := p.VendorLimit(pay.Vendor); limitStr != "" {
+			limit, _ := parseLimit(limitStr)
+			if byVendor[pay.Vendor]+pay.Amount > limit {
+				v.Rule, v.Limit, v.Spent = RuleMaxPerVendor, limit, byVendor[pay.Vendor]
+				return v
+			}
+		}
+		if p.MaxPerDay != "" && total+pay.Amount > perDay {
+			v.Rule, v.Limit, v.Spent = RuleMaxPerDay, perDay, total
+			return v
+		}
+
+		total += pay.Amount
+		byVendor[pay.Vendor] += pay.Amount
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	vendorA = "7pxrn6LqK3rmvca2j1b2oV5jUGMNQaofjxLKiwYm15YN"
+	vendorB = "CcyWFsS6KC6NxaCwFc7vtRGdGgkxioJa58ttBW2LNYnj"
+)
+
+func usdc(t *testing.T, s string) uint64 {
+	t.Helper()
+	n, err := parseLimit(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestPolicy_LoadSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+
+	p, err := Load(path)
+	if err != nil || !p.Empty() {
+		t.Fatalf("Load(missing) = %+v, %v; want empty policy", p, err)
+	}
+
+	p.MaxPerDay = "25"
+	p.VendorLimits = map[string]string{vendorA: "50"}
+	p.Deny = []string{vendorB}
+	if err := p.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.MaxPerDay != "25" || got.VendorLimit(vendorA) != "50" || len(got.Deny) != 1 {
+		t.Errorf("Load = %+v", got)
+	}
+
+	got.MaxPerRequest = "1.0000001"
+	if err := got.Save(path); err == nil {
+		t.Error("Save accepted an amount with 7 decimals")
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	p := &Policy{
+		MaxPerRequest: "5",
+		MaxPerDay:     "20",
+		MaxPerVendor:  "10",
+		VendorLimits:  map[string]string{vendorB: "15"},
+	}
+	spent := []Entry{
+		{Vendor: vendorA, Amount: usdc(t, "8")},
+		{Vendor: vendorB, Amount: usdc(t, "8")},
+	}
+
+	tests := []struct {
+		name    string
+		policy  *Policy
+		vendor  string
+		amount  string
+		rule    string
+		message string
+	}{
+		{"within limits", p, vendorA, "2", "", ""},
+		{"per request", p, vendorA, "6", RuleMaxPerRequest, "exceeds max_per_request of 5 USDC"},
+		{"per vendor", p, vendorA, "2.5", RuleMaxPerVendor, "to 10.5 USDC, over its limit of 10 USDC (8 USDC already spent)"},
+		{"vendor override", p, vendorB, "3", "", ""},
+		{"per day", p, vendorB, "4.5", RuleMaxPerDay, "to 20.5 USDC, over max_per_day of 20 USDC (16 USDC already spent)"},
+		{"deny", &Policy{Deny: []string{vendorA}}, vendorA, "1", RuleDeny, "deny list"},
+		{"allow", &Policy{Allow: []string{vendorB}}, vendorA, "1", RuleAllow, "not on the allow list"},
+		{"no policy", &Policy{}, vendorA, "1000", "", ""},
+	}
+	for _, tt := range tests {
+		err := tt.policy.Check([]Payment{{Vendor: tt.vendor, Amount: usdc(t, tt.amount)}}, spent, nil)
+		if tt.rule == "" {
+			if err != nil {
+				t.Errorf("%s: Check = %v, want allowed", tt.name, err)
+			}
+			continue
+		}
+
+		var v *Violation
+		if !errors.As(err, &v) || v.Rule != tt.rule {
+			t.Errorf("%s: Check = %v, want %s violation", tt.name, err, tt.rule)
+			continue
+		}
+		if !errors.Is(err, ErrViolation) {
+			t.Errorf("%s: not ErrViolation", tt.name)
+		}
+		if !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: message %q does not mention %q", tt.name, err, tt.message)
+		}
+	}
+}
+
+func TestPolicy_CheckAccumulates(t *testing.T) {
+	p := &Policy{MaxPerVendor: "10"}
+	payments := []Payment{
+		{Vendor: vendorA, Amount: usdc(t, "6")},
+		{Vendor: vendorA, Amount: usdc(t, "6")},
+	}
+
+	err := p.Check(payments, nil, func(s string) string { return "@a" })
+	var v *Violation
+	if !errors.As(err, &v) || v.Rule != RuleMaxPerVendor || v.Spent != usdc(t, "6") {
+		t.Fatalf("Check = %v, want the second payment over max_per_vendor", err)
+	}
+	if !strings.Contains(err.Error(), "to @a") {
+		t.Errorf("message %q does not use the label", err)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	l := NewLedger(filepath.Join(t.TempDir(), "ledger.jsonl"))
+
+	if entries, err := l.Since(time.Time{}); err != nil || len(entries) != 0 {
+		t.Fatalf("Since on missing ledger = %v, %v", entries, err)
+	}
+
+	now := time.Now().UTC()
+	err := l.Append(
+		Entry{Time: now.Add(-25 * time.Hour), Vendor: vendorA, Amount: 1},
+		Entry{Time: now.Add(-time.Hour), Vendor: vendorA, Amount: 2},
+	)
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := l.Append(Entry{Time: now, Vendor: vendorB, Amount: 3}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	entries, err := l.Since(now.Add(-Window))
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	total, byVendor := Totals(entries)
+	if len(entries) != 2 || total != 5 || byVendor[vendorA] != 2 || byVendor[vendorB] != 3 {
+		t.Errorf("Since = %+v", entries)
+	}
+}
+
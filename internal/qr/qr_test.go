@@ -0,0 +1,157 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// helloWorldL is "hello world" at level L, checked against an
+// independent encoder
+var helloWorldL = []string{
+	"#######..#.##.#######",
+	"#.....#.##.#..#.....#",
+	"#.###.#.##..#.#.###.#",
+	"#.###.#..#.#..#.###.#",
+	"#.###.#.#...#.#.###.#",
+	"#.....#.#..##.#.....#",
+	"#######.#.#.#.#######",
+	"........#####........",
+	"##.#..##.##...###.##.",
+	"...#.#.####...###..##",
+	"#.#..##..##.#..#.##.#",
+	".##.##.#####..#.##.##",
+	"###.#.##..#.##.##....",
+	"........#.##......#.#",
+	"#######.#.....######.",
+	"#.....#..####..#....#",
+	"#.###.#....#.#.....#.",
+	"#.###.#.###....######",
+	"#.###.#..#..#.#.#.#.#",
+	"#.....#.#..#.#.......",
+	"#######.##..#.##.#.#.",
+}
+
+func render(c *Code) []string {
+	rows := make([]string, c.Size)
+	for y := range rows {
+		var b strings.Builder
+		for x := 0; x < c.Size; x++ {
+			if c.Black(x, y) {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		rows[y] = b.String()
+	}
+	return rows
+}
+
+func TestEncode_Golden(t *testing.T) {
+	c, err := Encode([]byte("hello world"), L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != 1 || c.Mask != 7 {
+		t.Errorf("version %d mask %d, want 1 and 7", c.Version, c.Mask)
+	}
+	got := render(c)
+	for y := range helloWorldL {
+		if got[y] != helloWorldL[y] {
+			t.Errorf("row %2d = %s\n    want %s", y, got[y], helloWorldL[y])
+		}
+	}
+}
+
+func TestEncode_Version(t *testing.T) {
+	tests := []struct {
+		n     int
+		level Level
+		want  int
+	}{
+		{17, L, 1}, // byte capacity of 1-L
+		{18, L, 2},
+		{14, M, 1},
+		{15, M, 2},
+		{7, H, 1},
+		{271, L, 10}, // 10-L holds 271 bytes (16-bit length from here)
+		{2953, L, 40},
+		{1273, H, 40},
+	}
+	for _, tt := range tests {
+		c, err := Encode(bytes.Repeat([]byte("a"), tt.n), tt.level)
+		if err != nil {
+			t.Errorf("%d bytes at %d: %v", tt.n, tt.level, err)
+			continue
+		}
+		if c.Version != tt.want || c.Size != tt.want*4+17 {
+			t.Errorf("%d bytes at %d: version %d size %d, want %d", tt.n, tt.level, c.Version, c.Size, tt.want)
+		}
+	}
+
+	if _, err := Encode(bytes.Repeat([]byte("a"), 2954), L); !errors.Is(err, ErrTooLong) {
+		t.Errorf("2954 bytes: err = %v, want ErrTooLong", err)
+	}
+}
+
+func TestEncode_FormatCopiesAgree(t *testing.T) {
+	c, err := Encode([]byte("solana:9fH94rHgVWxXh3YTA8jYNKU6Z8xs7r76SZUyzyfmwTQb?amount=1"), M)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read both copies of the 15 format bits back
+	var first, second int
+	for i := 0; i <= 5; i++ {
+		first |= bit(c.Black(8, i)) << i
+	}
+	first |= bit(c.Black(8, 7))<<6 | bit(c.Black(8, 8))<<7 | bit(c.Black(7, 8))<<8
+	for i := 9; i < 15; i++ {
+		first |= bit(c.Black(14-i, 8)) << i
+	}
+	for i := 0; i < 8; i++ {
+		second |= bit(c.Black(c.Size-1-i, 8)) << i
+	}
+	for i := 8; i < 15; i++ {
+		second |= bit(c.Black(8, c.Size-15+i)) << i
+	}
+
+	if first != second {
+		t.Fatalf("format copies differ: %015b vs %015b", first, second)
+	}
+	data := (first ^ 0x5412) >> 10
+	if level, mask := data>>3, data&7; level != M.formatBits() || mask != c.Mask {
+		t.Errorf("format level %d mask %d, want %d and %d", level, mask, M.formatBits(), c.Mask)
+	}
+	if !c.Black(8, c.Size-8) {
+		t.Error("dark module missing")
+	}
+}
+
+func bit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestTerminal(t *testing.T) {
+	c, err := Encode([]byte("hello world"), L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(c.Terminal(), "\n"), "\n")
+
+	// 21 modules plus the quiet zone on both sides, two rows per line
+	if want := (21 + 2*quietZone + 1) / 2; len(lines) != want {
+		t.Errorf("%d lines, want %d", len(lines), want)
+	}
+	for i, line := range lines {
+		if n := strings.Count(line, "▀"); n != 21+2*quietZone {
+			t.Errorf("line %d has %d cells, want %d", i, n, 21+2*quietZone)
+		}
+	}
+}
+
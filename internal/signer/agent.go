@@ -0,0 +1,416 @@
+// ============================================================
+// Signer Agent - ssh-agent style signing daemon
+// ============================================================
+//
+// Holds one private key in memory and signs on behalf of local
+// processes that connect to its Unix socket. Clients never see
+// the key.
+//
+// - Policy decides whether each signature needs approval
+// - Spend, when set, refuses payments over the spending policy
+//   before anyone is asked to approve them
+// - After IdleTimeout without requests the key is wiped; the
+//   agent keeps answering with "locked" until restarted
+// - The socket is created 0600 inside the 0700 config dir
+//
+// ============================================================
+
+package signer
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// Approval policies
+const (
+	PolicyAuto   = "auto"   // sign every request
+	PolicyPrompt = "prompt" // ask Approve for every request
+	PolicyDeny   = "deny"   // refuse to sign (public key and status only)
+)
+
+// maxRequestSize bounds a single request line
+const maxRequestSize = 1 << 20
+
+// SignRequest is what an approver is shown before a signature
+type SignRequest struct {
+	Message      []byte
+	Description  string   // client-supplied, untrusted
+	Instructions []string // decoded by the agent when Message is a Solana transaction message
+}
+
+// Options configures an Agent
+type Options struct {
+	Policy      string
+	IdleTimeout time.Duration // 0 disables the idle lock
+
+	// Approve is consulted for every sign request under PolicyPrompt.
+	// Calls are serialized.
+	Approve func(req *SignRequest) bool
+
+	// Spend checks payments against the spending policy and records
+	// them in the ledger once signed; nil disables the check
+	Spend *policy.Guard
+
+	// Logf reports requests; nil disables logging
+	Logf func(format string, args ...interface{})
+}
+
+// Agent serves signatures for a single key
+type Agent struct {
+	opts      Options
+	publicKey ed25519.PublicKey
+	startedAt time.Time
+	now       func() time.Time
+
+	mu         sync.Mutex
+	privateKey ed25519.PrivateKey // nil once locked
+	lastUsed   time.Time
+	signatures int
+	denied     int
+
+	approveMu sync.Mutex
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewAgent creates an agent holding kp's key
+func NewAgent(kp *wallet.Keypair, opts Options) (*Agent, error) {
+	switch opts.Policy {
+	case "":
+		opts.Policy = PolicyPrompt
+	case PolicyAuto, PolicyPrompt, PolicyDeny:
+	default:
+		return nil, fmt.Errorf("unknown policy %q (use auto, prompt or deny)", opts.Policy)
+	}
+	if opts.Policy == PolicyPrompt && opts.Approve == nil {
+		return nil, fmt.Errorf("policy %q requires an approver", PolicyPrompt)
+	}
+
+	// Own copy so wiping it does not touch the caller's keypair
+	key := make(ed25519.PrivateKey, len(kp.PrivateKey))
+	copy(key, kp.PrivateKey)
+
+	a := &Agent{
+		opts:       opts,
+		publicKey:  kp.PublicKey,
+		startedAt:  time.Now(),
+		now:        time.Now,
+		privateKey: key,
+		stop:       make(chan struct{}),
+	}
+	a.lastUsed = a.now()
+	return a, nil
+}
+
+// PublicKey returns the agent's public key
+func (a *Agent) PublicKey() ed25519.PublicKey {
+	return a.publicKey
+}
+
+// Lock wipes the private key from memory. It cannot be undone.
+func (a *Agent) Lock() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lockLocked()
+}
+
+func (a *Agent) lockLocked() {
+	if a.privateKey == nil {
+		return
+	}
+	for i := range a.privateKey {
+		a.privateKey[i] = 0
+	}
+	a.privateKey = nil
+	a.logf("key locked")
+}
+
+// Locked reports whether the key has been wiped
+func (a *Agent) Locked() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checkIdleLocked()
+	return a.privateKey == nil
+}
+
+// checkIdleLocked locks the key if it has been idle too long
+func (a *Agent) checkIdleLocked() {
+	if a.opts.IdleTimeout > 0 && a.privateKey != nil && a.now().Sub(a.lastUsed) >= a.opts.IdleTimeout {
+		a.logf("idle for %s", a.opts.IdleTimeout)
+		a.lockLocked()
+	}
+}
+
+// Stop makes Serve return
+func (a *Agent) Stop() {
+	a.stopOnce.Do(func() { close(a.stop) })
+}
+
+// ============================================================
+// Serving
+// ============================================================
+
+// Listen creates the agent socket at path, replacing a stale one
+// left behind by an agent that is no longer running
+func Listen(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a signing agent is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("secure socket: %w", err)
+	}
+	return l, nil
+}
+
+// Serve accepts connections until ctx is done or Stop is called.
+// The listener is closed and the key wiped on return; requests still
+// waiting for approval then fail as locked.
+func (a *Agent) Serve(ctx context.Context, l net.Listener) error {
+	defer a.Lock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-a.stop:
+		}
+		l.Close()
+	}()
+
+	// Lock promptly on idle even when nobody connects
+	if a.opts.IdleTimeout > 0 {
+		go a.idleLoop(ctx)
+	}
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-a.stop:
+				return nil
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("accept: %w", err)
+		}
+
+		go a.serveConn(ctx, conn)
+	}
+}
+
+func (a *Agent) idleLoop(ctx context.Context) {
+	interval := a.opts.IdleTimeout / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			if a.Locked() {
+				return
+			}
+		}
+	}
+}
+
+func (a *Agent) serveConn(ctx context.Context, conn net.Conn) {
+	// Unblock the reader when the agent shuts down
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-a.stop:
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxRequestSize)
+	enc := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		var req Request
+		var resp *Response
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp = &Response{Error: "invalid request", Code: CodeBadInput}
+		} else {
+			resp = a.Handle(&req)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+		if req.Op == OpStop && resp.Error == "" {
+			a.Stop()
+			return
+		}
+	}
+}
+
+// Handle processes a single request
+func (a *Agent) Handle(req *Request) *Response {
+	switch req.Op {
+	case OpPublicKey:
+		return &Response{PublicKey: wallet.Base58Encode(a.publicKey)}
+
+	case OpStatus:
+		return &Response{Status: a.status()}
+
+	case OpLock:
+		a.Lock()
+		return &Response{}
+
+	case OpStop:
+		a.logf("stop requested")
+		return &Response{}
+
+	case OpSign:
+		return a.sign(req)
+	}
+
+	return &Response{Error: fmt.Sprintf("unknown op %q", req.Op), Code: CodeBadInput}
+}
+
+func (a *Agent) status() *Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checkIdleLocked()
+
+	s := &Status{
+		PublicKey:  wallet.Base58Encode(a.publicKey),
+		Locked:     a.privateKey == nil,
+		Policy:     a.opts.Policy,
+		Signatures: a.signatures,
+		Denied:     a.denied,
+		StartedAt:  a.startedAt.UTC().Format(time.RFC3339),
+	}
+	if a.opts.IdleTimeout > 0 {
+		s.IdleTimeout = a.opts.IdleTimeout.String()
+	}
+	return s
+}
+
+func (a *Agent) sign(req *Request) *Response {
+	if len(req.Message) == 0 {
+		return &Response{Error: "empty message", Code: CodeBadInput}
+	}
+
+	if a.Locked() {
+		return &Response{Error: "agent is locked", Code: CodeLocked}
+	}
+
+	signReq := &SignRequest{Message: req.Message, Description: req.Description}
+	if msg, err := solana.ParseMessage(req.Message); err == nil {
+		for _, ix := range solana.DecodeInstructions(msg) {
+			signReq.Instructions = append(signReq.Instructions, ix.Description)
+		}
+	}
+
+	var reservation *policy.Reservation
+	if a.opts.Spend != nil {
+		r, err := a.opts.Spend.Authorize(a.publicKey, req.Message)
+		if err != nil {
+			a.mu.Lock()
+			a.denied++
+			a.mu.Unlock()
+			a.logf("refused sign request: %v", err)
+			if errors.Is(err, policy.ErrViolation) {
+				return &Response{Error: err.Error(), Code: CodePolicy}
+			}
+			return &Response{Error: err.Error()}
+		}
+		reservation = r
+	}
+
+	if !a.approve(signReq) {
+		a.mu.Lock()
+		a.denied++
+		a.mu.Unlock()
+		a.release(reservation)
+		a.logf("denied sign request (%d bytes)", len(req.Message))
+		return &Response{Error: "request denied", Code: CodeDenied}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// The key may have been locked while waiting for approval
+	if a.privateKey == nil {
+		a.release(reservation)
+		return &Response{Error: "agent is locked", Code: CodeLocked}
+	}
+
+	sig := ed25519.Sign(a.privateKey, req.Message)
+	if reservation != nil {
+		// An unrecorded payment would escape the limits: withhold it
+		if err := reservation.Commit(sig); err != nil {
+			a.logf("refused sign request: %v", err)
+			return &Response{Error: fmt.Sprintf("record payment: %v", err)}
+		}
+	}
+	a.signatures++
+	a.lastUsed = a.now()
+	a.logf("signed %d bytes", len(req.Message))
+	return &Response{Signature: sig}
+}
+
+func (a *Agent) release(r *policy.Reservation) {
+	if r != nil {
+		r.Release()
+	}
+}
+
+func (a *Agent) approve(req *SignRequest) bool {
+	switch a.opts.Policy {
+	case PolicyAuto:
+		return true
+	case PolicyPrompt:
+		a.approveMu.Lock()
+		defer a.approveMu.Unlock()
+		return a.opts.Approve(req)
+	}
+	return false
+}
+
+func (a *Agent) logf(format string, args ...interface{}) {
+	if a.opts.Logf != nil {
+		a.opts.Logf(format, args...)
+	}
+}
+
@@ -0,0 +1,289 @@
+package signer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// startAgent serves an agent on a temporary socket and returns a client
+func startAgent(t *testing.T, opts Options) (*Agent, *Client) {
+	t.Helper()
+
+	kp, err := wallet.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	agent, err := NewAgent(kp, opts)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "s.sock")
+	l, err := Listen(socket)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- agent.Serve(ctx, l) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	client, err := Dial(socket)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return agent, client
+}
+
+func TestAgent_Sign(t *testing.T) {
+	agent, client := startAgent(t, Options{Policy: PolicyAuto})
+
+	if !client.PublicKey().Equal(agent.PublicKey()) {
+		t.Fatal("client public key does not match agent")
+	}
+
+	msg := []byte("hello")
+	sig, err := client.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !ed25519.Verify(client.PublicKey(), msg, sig) {
+		t.Error("signature does not verify")
+	}
+
+	status, err := client.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.Signatures != 1 || status.Locked || status.Policy != PolicyAuto {
+		t.Errorf("Status = %+v", status)
+	}
+}
+
+func TestAgent_PromptPolicy(t *testing.T) {
+	var seen *SignRequest
+	approve := false
+
+	_, client := startAgent(t, Options{
+		Policy: PolicyPrompt,
+		Approve: func(req *SignRequest) bool {
+			seen = req
+			return approve
+		},
+	})
+
+	// A Solana message is decoded for the approver
+	payer, _ := solana.ParsePublicKey(wallet.Base58Encode(client.PublicKey()))
+	to := solana.MustPublicKey("B8UwBUUnKwCyKuGMbFKWaG7exYdDk2ozZrPg72NyVbfj")
+	msg, _ := solana.NewMessage(payer, []solana.Instruction{solana.SystemTransfer(payer, to, 1000)}, solana.Hash{})
+
+	if _, err := client.SignWithDescription(msg.Serialize(), "test payment"); !errors.Is(err, ErrDenied) {
+		t.Fatalf("err = %v, want ErrDenied", err)
+	}
+	if seen == nil || seen.Description != "test payment" || len(seen.Instructions) != 1 {
+		t.Fatalf("approver saw %+v", seen)
+	}
+
+	approve = true
+	if _, err := client.Sign(msg.Serialize()); err != nil {
+		t.Errorf("Sign after approval: %v", err)
+	}
+}
+
+func TestAgent_SpendingPolicy(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	ledger := policy.NewLedger(filepath.Join(dir, "ledger.jsonl"))
+	if err := (&policy.Policy{MaxPerRequest: "1"}).Save(policyPath); err != nil {
+		t.Fatal(err)
+	}
+	const usdcMint = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+
+	asked := 0
+	_, client := startAgent(t, Options{
+		Policy:  PolicyPrompt,
+		Approve: func(*SignRequest) bool { asked++; return true },
+		Spend:   policy.NewGuard(policyPath, ledger, usdcMint, nil),
+	})
+
+	payer, _ := solana.ParsePublicKey(wallet.Base58Encode(client.PublicKey()))
+	mint := solana.MustPublicKey(usdcMint)
+	vendor := solana.MustPublicKey("B8UwBUUnKwCyKuGMbFKWaG7exYdDk2ozZrPg72NyVbfj")
+	source, _ := solana.AssociatedTokenAddress(payer, mint, solana.TokenProgramID)
+	dest, _ := solana.AssociatedTokenAddress(vendor, mint, solana.TokenProgramID)
+	pay := func(amount uint64) []byte {
+		msg, _ := solana.NewMessage(payer, []solana.Instruction{
+			solana.CreateAssociatedTokenAccountIdempotent(payer, dest, vendor, mint, solana.TokenProgramID),
+			solana.TokenTransferChecked(solana.TokenProgramID, source, mint, dest, payer, amount, 6),
+		}, solana.Hash{})
+		return msg.Serialize()
+	}
+
+	// Refused before anyone is asked
+	_, err := client.Sign(pay(1_500_000))
+	if !errors.Is(err, policy.ErrViolation) || !strings.Contains(err.Error(), "max_per_request of 1 USDC") {
+		t.Fatalf("err = %v, want spending policy violation", err)
+	}
+	if asked != 0 {
+		t.Errorf("approver asked %d times for a refused payment", asked)
+	}
+
+	if _, err := client.Sign(pay(1_000_000)); err != nil {
+		t.Fatalf("Sign within policy: %v", err)
+	}
+	entries, _ := ledger.Since(time.Now().Add(-time.Minute))
+	if len(entries) != 1 || entries[0].Vendor != vendor.String() || entries[0].Amount != 1_000_000 {
+		t.Errorf("ledger = %+v", entries)
+	}
+
+	status, _ := client.Status()
+	if status == nil || status.Signatures != 1 || status.Denied != 1 {
+		t.Errorf("Status = %+v", status)
+	}
+}
+
+func TestAgent_DenyPolicy(t *testing.T) {
+	_, client := startAgent(t, Options{Policy: PolicyDeny})
+
+	if _, err := client.Sign([]byte("x")); !errors.Is(err, ErrDenied) {
+		t.Errorf("err = %v, want ErrDenied", err)
+	}
+}
+
+func TestAgent_Lock(t *testing.T) {
+	_, client := startAgent(t, Options{Policy: PolicyAuto})
+
+	if err := client.Lock(); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if _, err := client.Sign([]byte("x")); !errors.Is(err, ErrLocked) {
+		t.Errorf("err = %v, want ErrLocked", err)
+	}
+
+	status, _ := client.Status()
+	if status == nil || !status.Locked {
+		t.Errorf("Status = %+v, want locked", status)
+	}
+}
+
+func TestAgent_IdleLock(t *testing.T) {
+	kp, _ := wallet.Generate()
+	agent, err := NewAgent(kp, Options{Policy: PolicyAuto, IdleTimeout: time.Minute})
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+
+	now := time.Now()
+	agent.now = func() time.Time { return now }
+	agent.lastUsed = now
+
+	now = now.Add(30 * time.Second)
+	if resp := agent.Handle(&Request{Op: OpSign, Message: []byte("x")}); resp.Error != "" {
+		t.Fatalf("sign before timeout: %s", resp.Error)
+	}
+
+	// Signing resets the idle timer
+	now = now.Add(45 * time.Second)
+	if agent.Locked() {
+		t.Fatal("locked before idle timeout")
+	}
+
+	now = now.Add(time.Minute)
+	resp := agent.Handle(&Request{Op: OpSign, Message: []byte("x")})
+	if resp.Code != CodeLocked {
+		t.Errorf("code = %q, want %q", resp.Code, CodeLocked)
+	}
+
+	// The caller's keypair is untouched by the wipe
+	if kp.PrivateKey[0] == 0 && kp.PrivateKey[1] == 0 && kp.PrivateKey[2] == 0 {
+		t.Error("agent wiped the caller's key")
+	}
+}
+
+func TestAgent_Stop(t *testing.T) {
+	kp, _ := wallet.Generate()
+	agent, _ := NewAgent(kp, Options{Policy: PolicyAuto})
+
+	socket := filepath.Join(t.TempDir(), "s.sock")
+	l, err := Listen(socket)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- agent.Serve(context.Background(), l) }()
+
+	// A second agent cannot take over the socket
+	if _, err := Listen(socket); err == nil {
+		t.Error("expected error for socket in use")
+	}
+
+	client, err := Dial(socket)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+	if err := client.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return after stop")
+	}
+	if !agent.Locked() {
+		t.Error("key should be wiped after Serve returns")
+	}
+}
+
+func TestListen_ReplacesStaleSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "s.sock")
+	if err := os.WriteFile(socket, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Listen(socket)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer l.Close()
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestNewAgent_Validation(t *testing.T) {
+	kp, _ := wallet.Generate()
+
+	if _, err := NewAgent(kp, Options{Policy: "sometimes"}); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+	if _, err := NewAgent(kp, Options{Policy: PolicyPrompt}); err == nil {
+		t.Error("expected error for prompt policy without approver")
+	}
+}
+
@@ -0,0 +1,150 @@
+// ============================================================
+// Signer Client - wallet.Signer backed by the signing agent
+// ============================================================
+
+package signer
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// DialTimeout bounds connecting to the agent socket
+const DialTimeout = 2 * time.Second
+
+// Client talks to a running signing agent. It implements wallet.Signer.
+type Client struct {
+	mu        sync.Mutex
+	conn      net.Conn
+	reader    *bufio.Reader
+	publicKey ed25519.PublicKey
+}
+
+var _ wallet.Signer = (*Client)(nil)
+
+// Dial connects to the agent at socketPath and fetches its public key
+func Dial(socketPath string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", socketPath, DialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("connect to signing agent: %w", err)
+	}
+
+	c := &Client{conn: conn, reader: bufio.NewReader(conn)}
+
+	resp, err := c.call(&Request{Op: OpPublicKey})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	pub, err := wallet.ParsePublicKey(resp.PublicKey)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("agent public key: %w", err)
+	}
+	c.publicKey = pub
+
+	return c, nil
+}
+
+// Close closes the connection to the agent
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// PublicKey returns the agent's public key
+func (c *Client) PublicKey() ed25519.PublicKey {
+	return c.publicKey
+}
+
+// Sign asks the agent to sign message. The call blocks while the
+// agent waits for approval.
+func (c *Client) Sign(message []byte) ([]byte, error) {
+	return c.SignWithDescription(message, "")
+}
+
+// SignWithDescription signs message, showing description to the
+// approver alongside the agent's own decoding of the message
+func (c *Client) SignWithDescription(message []byte, description string) ([]byte, error) {
+	resp, err := c.call(&Request{Op: OpSign, Message: message, Description: description})
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(c.publicKey, message, resp.Signature) {
+		return nil, fmt.Errorf("signing agent returned an invalid signature")
+	}
+	return resp.Signature, nil
+}
+
+// Status returns the agent's status
+func (c *Client) Status() (*Status, error) {
+	resp, err := c.call(&Request{Op: OpStatus})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, fmt.Errorf("signing agent returned no status")
+	}
+	return resp.Status, nil
+}
+
+// Lock wipes the agent's key
+func (c *Client) Lock() error {
+	_, err := c.call(&Request{Op: OpLock})
+	return err
+}
+
+// Stop shuts the agent down
+func (c *Client) Stop() error {
+	_, err := c.call(&Request{Op: OpStop})
+	return err
+}
+
+func (c *Client) call(req *Request) (*Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("send to signing agent: %w", err)
+	}
+
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read from signing agent: %w", err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("parse agent response: %w", err)
+	}
+
+	switch resp.Code {
+	case "":
+	case CodeLocked:
+		return nil, ErrLocked
+	case CodeDenied:
+		return nil, ErrDenied
+	case CodePolicy:
+		return nil, fmt.Errorf("%w: %s", policy.ErrViolation, strings.TrimPrefix(resp.Error, "spending policy: "))
+	default:
+		return nil, fmt.Errorf("signing agent: %s", resp.Error)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("signing agent: %s", resp.Error)
+	}
+
+	return &resp, nil
+}
+
@@ -0,0 +1,71 @@
+// ============================================================
+// Signer Protocol - Messages exchanged over the agent socket
+// ============================================================
+//
+// Newline-delimited JSON over a Unix socket. Each request gets
+// exactly one response; a connection may carry many requests.
+//
+//   {"op":"public_key"}                    -> {"public_key":"..."}
+//   {"op":"sign","message":"<base64>"}      -> {"signature":"<base64>"}
+//   {"op":"status"}                        -> {"public_key","locked",...}
+//   {"op":"lock"}                          -> {}
+//   {"op":"stop"}                          -> {}
+//
+// Failures set "error" and, where useful, a machine-readable "code".
+//
+// ============================================================
+
+package signer
+
+import "errors"
+
+// Operations
+const (
+	OpPublicKey = "public_key"
+	OpSign      = "sign"
+	OpStatus    = "status"
+	OpLock      = "lock"
+	OpStop      = "stop"
+)
+
+// Error codes
+const (
+	CodeLocked   = "locked"
+	CodeDenied   = "denied"
+	CodePolicy   = "policy" // refused by the spending policy
+	CodeBadInput = "bad_request"
+)
+
+// Errors returned by Client for the corresponding codes
+var (
+	ErrLocked = errors.New("signing agent is locked; restart it with 'machpay signer start'")
+	ErrDenied = errors.New("signing request was denied")
+)
+
+// Request is a single agent request
+type Request struct {
+	Op          string `json:"op"`
+	Message     []byte `json:"message,omitempty"`     // base64 in JSON
+	Description string `json:"description,omitempty"` // shown at approval; informational only
+}
+
+// Response is the agent's reply to a Request
+type Response struct {
+	PublicKey string  `json:"public_key,omitempty"`
+	Signature []byte  `json:"signature,omitempty"` // base64 in JSON
+	Status    *Status `json:"status,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	Code      string  `json:"code,omitempty"`
+}
+
+// Status describes the running agent
+type Status struct {
+	PublicKey   string `json:"public_key"`
+	Locked      bool   `json:"locked"`
+	Policy      string `json:"policy"`
+	IdleTimeout string `json:"idle_timeout,omitempty"`
+	Signatures  int    `json:"signatures"`
+	Denied      int    `json:"denied"`
+	StartedAt   string `json:"started_at"`
+}
+
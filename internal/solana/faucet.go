@@ -0,0 +1,81 @@
+// ============================================================
+// Faucet Client - Devnet token faucet integration
+// ============================================================
+//
+// Talks to an HTTP token faucet that accepts:
+//   POST <url>  {"address": "<base58>", "amount": <ui amount>}
+// and replies with {"signature": "<tx signature>"}.
+//
+// ============================================================
+
+package solana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// FaucetClient requests test tokens from a faucet service
+type FaucetClient struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewFaucetClient creates a faucet client for the given endpoint
+func NewFaucetClient(url string) *FaucetClient {
+	return &FaucetClient{
+		url:        url,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Request asks the faucet to send amount tokens to address and
+// returns the funding transaction signature
+func (f *FaucetClient) Request(ctx context.Context, address string, amount float64) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"address": address,
+		"amount":  amount,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", f.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "machpay-cli")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("faucet request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read faucet response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("faucet returned HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+
+	var result struct {
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("parse faucet response: %w", err)
+	}
+	if result.Signature == "" {
+		return "", fmt.Errorf("faucet response missing signature")
+	}
+
+	return result.Signature, nil
+}
+
@@ -0,0 +1,44 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFaucetRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Address string  `json:"address"`
+			Amount  float64 `json:"amount"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Address != "addr" || body.Amount != 10 {
+			t.Errorf("Unexpected body: %+v", body)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"signature": "sig123"})
+	}))
+	defer server.Close()
+
+	sig, err := NewFaucetClient(server.URL).Request(context.Background(), "addr", 10)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if sig != "sig123" {
+		t.Errorf("Signature = %s, want sig123", sig)
+	}
+}
+
+func TestFaucetRequest_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "daily limit reached", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	if _, err := NewFaucetClient(server.URL).Request(context.Background(), "addr", 10); err == nil {
+		t.Error("Expected error for HTTP 429")
+	}
+}
+
@@ -0,0 +1,326 @@
+// ============================================================
+// History - Address activity and transfer decoding
+// ============================================================
+//
+// Pages through getSignaturesForAddress and decodes each
+// transaction (jsonParsed encoding) into the SOL and SPL token
+// transfers that moved funds into or out of a wallet.
+//
+// ============================================================
+
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// MaxSignaturesPerPage is the RPC limit for getSignaturesForAddress
+const MaxSignaturesPerPage = 1000
+
+// SignatureInfo is one entry from getSignaturesForAddress
+type SignatureInfo struct {
+	Signature string      `json:"signature"`
+	Slot      uint64      `json:"slot"`
+	BlockTime *int64      `json:"blockTime"`
+	Err       interface{} `json:"err"`
+	Memo      *string     `json:"memo"`
+}
+
+// Time returns the block time, or the zero time if unknown
+func (s *SignatureInfo) Time() time.Time {
+	return unixTime(s.BlockTime)
+}
+
+// SignaturesOptions pages through an address's signatures
+type SignaturesOptions struct {
+	Limit  int    // 1..1000
+	Before string // start searching backwards from this signature
+	Until  string // stop at this signature
+}
+
+// GetSignaturesForAddress returns signatures involving address,
+// newest first
+func (c *Client) GetSignaturesForAddress(ctx context.Context, address string, opts SignaturesOptions) ([]SignatureInfo, error) {
+	config := map[string]interface{}{"commitment": CommitmentConfirmed}
+	if opts.Limit > 0 {
+		config["limit"] = opts.Limit
+	}
+	if opts.Before != "" {
+		config["before"] = opts.Before
+	}
+	if opts.Until != "" {
+		config["until"] = opts.Until
+	}
+
+	var result []SignatureInfo
+	if err := c.Call(ctx, "getSignaturesForAddress", []interface{}{address, config}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ============================================================
+// Parsed Transactions
+// ============================================================
+
+// ParsedTransaction is a getTransaction result in jsonParsed encoding
+type ParsedTransaction struct {
+	Slot        uint64           `json:"slot"`
+	BlockTime   *int64           `json:"blockTime"`
+	Meta        *TransactionMeta `json:"meta"`
+	Transaction struct {
+		Signatures []string `json:"signatures"`
+		Message    struct {
+			AccountKeys []struct {
+				Pubkey   string `json:"pubkey"`
+				Signer   bool   `json:"signer"`
+				Writable bool   `json:"writable"`
+			} `json:"accountKeys"`
+			Instructions []ParsedInstruction `json:"instructions"`
+		} `json:"message"`
+	} `json:"transaction"`
+}
+
+// TransactionMeta is the execution status of a transaction
+type TransactionMeta struct {
+	Err               interface{}    `json:"err"`
+	Fee               uint64         `json:"fee"`
+	PreBalances       []uint64       `json:"preBalances"`
+	PostBalances      []uint64       `json:"postBalances"`
+	PreTokenBalances  []TokenBalance `json:"preTokenBalances"`
+	PostTokenBalances []TokenBalance `json:"postTokenBalances"`
+	InnerInstructions []struct {
+		Index        int                 `json:"index"`
+		Instructions []ParsedInstruction `json:"instructions"`
+	} `json:"innerInstructions"`
+}
+
+// TokenBalance is a token account balance before or after a transaction
+type TokenBalance struct {
+	AccountIndex  int    `json:"accountIndex"`
+	Mint          string `json:"mint"`
+	Owner         string `json:"owner"`
+	UITokenAmount struct {
+		Amount   string `json:"amount"`
+		Decimals uint8  `json:"decimals"`
+	} `json:"uiTokenAmount"`
+}
+
+// ParsedInstruction is an instruction as decoded by the RPC node.
+// Parsed is an object for known programs and a string for some
+// (e.g. memo); it is absent for programs the node cannot parse.
+type ParsedInstruction struct {
+	Program   string          `json:"program"`
+	ProgramID string          `json:"programId"`
+	Parsed    json.RawMessage `json:"parsed"`
+}
+
+// GetTransaction fetches a confirmed transaction in jsonParsed
+// encoding. It returns nil if the node no longer has it.
+func (c *Client) GetTransaction(ctx context.Context, signature string) (*ParsedTransaction, error) {
+	config := map[string]interface{}{
+		"encoding":                       "jsonParsed",
+		"commitment":                     CommitmentConfirmed,
+		"maxSupportedTransactionVersion": 0,
+	}
+
+	var result *ParsedTransaction
+	if err := c.Call(ctx, "getTransaction", []interface{}{signature, config}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ============================================================
+// Transfer Decoding
+// ============================================================
+
+// Transfer directions
+const (
+	DirectionIn   = "in"
+	DirectionOut  = "out"
+	DirectionSelf = "self"
+)
+
+// Transfer is a movement of SOL or tokens into or out of a wallet
+type Transfer struct {
+	Signature    string    `json:"signature"`
+	Slot         uint64    `json:"slot"`
+	Time         time.Time `json:"time"`
+	Direction    string    `json:"direction"`
+	Asset        string    `json:"asset"`          // "SOL" or the token symbol
+	Mint         string    `json:"mint,omitempty"` // empty for SOL
+	Amount       uint64    `json:"amount"`         // raw units
+	Decimals     uint8     `json:"decimals"`
+	Counterparty string    `json:"counterparty"` // wallet address, not token account
+}
+
+// AmountString formats the amount with its decimals
+func (t *Transfer) AmountString() string {
+	return FormatTokenAmount(t.Amount, t.Decimals)
+}
+
+type parsedBody struct {
+	Type string          `json:"type"`
+	Info json.RawMessage `json:"info"`
+}
+
+type transferInfo struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Authority   string `json:"authority"`
+	Mint        string `json:"mint"`
+	Lamports    uint64 `json:"lamports"`
+	Amount      string `json:"amount"`
+	TokenAmount *struct {
+		Amount   string `json:"amount"`
+		Decimals uint8  `json:"decimals"`
+	} `json:"tokenAmount"`
+}
+
+type tokenAccount struct {
+	mint     string
+	owner    string
+	decimals uint8
+}
+
+// DecodeTransfers extracts the transfers into and out of owner.
+// tokens maps the mints to report to their display symbol; other
+// tokens are ignored. Failed transactions yield no transfers.
+func DecodeTransfers(signature string, tx *ParsedTransaction, owner string, tokens map[string]string) []Transfer {
+	if tx == nil || tx.Meta == nil || tx.Meta.Err != nil {
+		return nil
+	}
+
+	// Token account -> mint/owner, from the balance snapshots
+	accounts := make(map[string]tokenAccount)
+	keys := tx.Transaction.Message.AccountKeys
+	for _, balances := range [][]TokenBalance{tx.Meta.PreTokenBalances, tx.Meta.PostTokenBalances} {
+		for _, b := range balances {
+			if b.AccountIndex < len(keys) {
+				accounts[keys[b.AccountIndex].Pubkey] = tokenAccount{
+					mint:     b.Mint,
+					owner:    b.Owner,
+					decimals: b.UITokenAmount.Decimals,
+				}
+			}
+		}
+	}
+
+	// Top-level instructions, each followed by its inner instructions
+	var instructions []ParsedInstruction
+	for i, ix := range tx.Transaction.Message.Instructions {
+		instructions = append(instructions, ix)
+		for _, inner := range tx.Meta.InnerInstructions {
+			if inner.Index == i {
+				instructions = append(instructions, inner.Instructions...)
+			}
+		}
+	}
+
+	base := Transfer{Signature: signature, Slot: tx.Slot, Time: unixTime(tx.BlockTime)}
+	var transfers []Transfer
+	for _, ix := range instructions {
+		var body parsedBody
+		if len(ix.Parsed) == 0 || json.Unmarshal(ix.Parsed, &body) != nil {
+			continue
+		}
+		var info transferInfo
+		if json.Unmarshal(body.Info, &info) != nil {
+			continue
+		}
+
+		t := base
+		switch {
+		case ix.Program == "system" && body.Type == "transfer":
+			t.Asset = "SOL"
+			t.Amount = info.Lamports
+			t.Decimals = 9
+			if !setDirection(&t, owner, info.Source, info.Destination) {
+				continue
+			}
+
+		case ix.Program == "spl-token" && (body.Type == "transfer" || body.Type == "transferChecked"):
+			src, dst := accounts[info.Source], accounts[info.Destination]
+			mint := info.Mint
+			if mint == "" {
+				mint = src.mint
+			}
+			if mint == "" {
+				mint = dst.mint
+			}
+			symbol, ok := tokens[mint]
+			if !ok {
+				continue
+			}
+
+			// Prefer wallet owners over token account addresses
+			from, to := src.owner, dst.owner
+			if from == "" {
+				from = info.Authority
+			}
+			if from == "" {
+				from = info.Source
+			}
+			if to == "" {
+				to = info.Destination
+			}
+			if !setDirection(&t, owner, from, to) {
+				continue
+			}
+			t.Asset = symbol
+			t.Mint = mint
+			t.Decimals = src.decimals
+			if t.Decimals == 0 {
+				t.Decimals = dst.decimals
+			}
+			amount := info.Amount
+			if info.TokenAmount != nil {
+				amount = info.TokenAmount.Amount
+				t.Decimals = info.TokenAmount.Decimals
+			}
+			n, err := strconv.ParseUint(amount, 10, 64)
+			if err != nil {
+				continue
+			}
+			t.Amount = n
+
+		default:
+			continue
+		}
+
+		transfers = append(transfers, t)
+	}
+
+	return transfers
+}
+
+// setDirection fills Direction and Counterparty relative to owner and
+// reports whether the transfer involves owner at all
+func setDirection(t *Transfer, owner, from, to string) bool {
+	switch {
+	case from == owner && to == owner:
+		t.Direction = DirectionSelf
+		t.Counterparty = owner
+	case from == owner:
+		t.Direction = DirectionOut
+		t.Counterparty = to
+	case to == owner:
+		t.Direction = DirectionIn
+		t.Counterparty = from
+	default:
+		return false
+	}
+	return true
+}
+
+func unixTime(seconds *int64) time.Time {
+	if seconds == nil {
+		return time.Time{}
+	}
+	return time.Unix(*seconds, 0).UTC()
+}
+
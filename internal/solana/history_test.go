@@ -0,0 +1,175 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Accounts used by the recorded fixtures in testdata/history
+const (
+	historyOwner = "9fH94rHgVWxXh3YTA8jYNKU6Z8xs7r76SZUyzyfmwTQb"
+	historyAlice = "7pxrn6LqK3rmvca2j1b2oV5jUGMNQaofjxLKiwYm15YN"
+	historyBob   = "CcyWFsS6KC6NxaCwFc7vtRGdGgkxioJa58ttBW2LNYnj"
+	historyCarol = "BP2c8io8CjDeEGeLvaWem3BodnTRzXYzAENTaigv5uYW"
+	historyUSDC  = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+
+	sigUSDCIn    = "36Quqf4bFmSEFhNmyLDmQdMtB3drdeFKuXx6VruqqamNsDrxfQQ6hvuWCKSRezZ1yCBptkLaWysNmQH3yPSVmGmN"
+	sigSOLOut    = "4GRQMTrkvRhtwYyNNmRz8NYkV6fwktZgyy1E8ynNNUc9uzGd2iG98S5pxtThWumosyFLFaYMfCEMAzEzUx7m9hpe"
+	sigUSDCOut   = "4RsKg7iw2nkTheTWAWazKQrE7GkYH4FnLCKw6cAXkS769bn1r4jy5vPRE4sNYDqaCUgUWFvCVgXkMUdfQuYxNvLd"
+	sigOtherMint = "vhFx1A6Xgj3jtXGCdBefr8GwrNoeeszgXr34kdUA7vhZLRk4DLoHDhjWm84jzPgj4bxF1VeevFiPHLitourYTVn"
+	sigSOLIn     = "4uDgcTYNaeNicBQjAVJhPGtm4k5jxEz6qdYqb7nrJ1gooLoVfdFzpJgkxDFVeEnWvdi9RMmpEkaZ33oy53arvVRg"
+)
+
+// loadTxFixture reads a recorded getTransaction response
+func loadTxFixture(t *testing.T, signature string) *ParsedTransaction {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "history", "tx", signature+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		Result *ParsedTransaction `json:"result"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Result
+}
+
+func TestDecodeTransfers(t *testing.T) {
+	tokens := map[string]string{historyUSDC: "USDC"}
+
+	tests := []struct {
+		name      string
+		signature string
+		want      []Transfer
+	}{
+		{
+			name:      "usdc in",
+			signature: sigUSDCIn,
+			want: []Transfer{
+				{Direction: DirectionIn, Asset: "USDC", Mint: historyUSDC, Amount: 12500000, Decimals: 6, Counterparty: historyAlice},
+			},
+		},
+		{
+			name:      "sol out",
+			signature: sigSOLOut,
+			want: []Transfer{
+				{Direction: DirectionOut, Asset: "SOL", Amount: 250000000, Decimals: 9, Counterparty: historyBob},
+			},
+		},
+		{
+			name:      "usdc out with account creation",
+			signature: sigUSDCOut,
+			want: []Transfer{
+				// Rent for the recipient's token account, then the payment
+				{Direction: DirectionOut, Asset: "SOL", Amount: 2039280, Decimals: 9, Counterparty: "8STvLzp6Ee7UqJeUzdtCWBdEtz9PQj5CUinXg3UwtSEw"},
+				{Direction: DirectionOut, Asset: "USDC", Mint: historyUSDC, Amount: 2000000, Decimals: 6, Counterparty: historyCarol},
+			},
+		},
+		{
+			name:      "other token ignored",
+			signature: sigOtherMint,
+			want:      nil,
+		},
+		{
+			name:      "sol in",
+			signature: sigSOLIn,
+			want: []Transfer{
+				{Direction: DirectionIn, Asset: "SOL", Amount: 1000000000, Decimals: 9, Counterparty: historyAlice},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := loadTxFixture(t, tt.signature)
+			got := DecodeTransfers(tt.signature, tx, historyOwner, tokens)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d transfers, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				want.Signature = tt.signature
+				want.Slot = tx.Slot
+				want.Time = time.Unix(*tx.BlockTime, 0).UTC()
+				if got[i] != want {
+					t.Errorf("transfer %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeTransfers_Failed(t *testing.T) {
+	tx := loadTxFixture(t, sigSOLOut)
+	tx.Meta.Err = map[string]interface{}{"InstructionError": []interface{}{0, "InsufficientFunds"}}
+	if got := DecodeTransfers(sigSOLOut, tx, historyOwner, nil); len(got) != 0 {
+		t.Errorf("failed transaction decoded to %+v", got)
+	}
+	if got := DecodeTransfers(sigSOLOut, nil, historyOwner, nil); len(got) != 0 {
+		t.Errorf("missing transaction decoded to %+v", got)
+	}
+}
+
+func TestDecodeTransfers_UnrelatedOwner(t *testing.T) {
+	tx := loadTxFixture(t, sigSOLIn)
+	if got := DecodeTransfers(sigSOLIn, tx, historyCarol, nil); len(got) != 0 {
+		t.Errorf("transfers for uninvolved wallet: %+v", got)
+	}
+}
+
+func TestGetSignaturesForAddress(t *testing.T) {
+	var params []interface{}
+	server := httptestRecorder(t, &params, []map[string]interface{}{
+		{"signature": sigSOLOut, "slot": 312040007, "blockTime": 1736067600, "err": nil},
+		{"signature": sigSOLIn, "slot": 312000456, "blockTime": nil, "err": map[string]interface{}{"InstructionError": []interface{}{0, "X"}}},
+	})
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	sigs, err := client.GetSignaturesForAddress(context.Background(), historyOwner, SignaturesOptions{Limit: 10, Before: sigUSDCIn})
+	if err != nil {
+		t.Fatalf("GetSignaturesForAddress failed: %v", err)
+	}
+	if len(sigs) != 2 || sigs[0].Signature != sigSOLOut || sigs[0].Slot != 312040007 {
+		t.Fatalf("unexpected signatures: %+v", sigs)
+	}
+	if sigs[0].Time() != time.Unix(1736067600, 0).UTC() || !sigs[1].Time().IsZero() {
+		t.Errorf("times = %v, %v", sigs[0].Time(), sigs[1].Time())
+	}
+	if sigs[1].Err == nil {
+		t.Error("failed signature lost its error")
+	}
+
+	if len(params) != 2 || params[0] != historyOwner {
+		t.Fatalf("params = %v", params)
+	}
+	config := params[1].(map[string]interface{})
+	if config["limit"] != float64(10) || config["before"] != sigUSDCIn {
+		t.Errorf("config = %v", config)
+	}
+	if _, ok := config["until"]; ok {
+		t.Error("empty until should be omitted")
+	}
+}
+
+// httptestRecorder serves result for any method and records the params
+func httptestRecorder(t *testing.T, params *[]interface{}, result interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64        `json:"id"`
+			Params []interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		*params = req.Params
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+}
+
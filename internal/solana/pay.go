@@ -0,0 +1,204 @@
+// ============================================================
+// Solana Pay - Transfer request URLs
+// ============================================================
+//
+// Builds and parses Solana Pay transfer requests:
+//
+//   solana:<recipient>?amount=<amount>&spl-token=<mint>
+//          &reference=<key>&label=<label>&message=<message>
+//
+// A reference is a random public key the paying wallet adds to the
+// transfer instruction, so the payment can be found with
+// getSignaturesForAddress without knowing the payer.
+//
+// ============================================================
+
+package solana
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// PayScheme is the URL scheme of Solana Pay requests
+const PayScheme = "solana"
+
+// ErrReferenceNotFound means no transaction carries the reference yet
+var ErrReferenceNotFound = errors.New("no transaction found for reference")
+
+// TransferRequest is a Solana Pay transfer request
+type TransferRequest struct {
+	Recipient  string   // wallet address; token requests pay its associated token account
+	Amount     string   // decimal amount in token units; empty lets the payer choose
+	SPLToken   string   // mint address; empty for SOL
+	References []string // public keys to locate the payment by
+	Label      string   // who is requesting, e.g. a merchant name
+	Message    string   // what the payment is for
+	Memo       string   // recorded on chain by the payer's wallet
+}
+
+// URL encodes the request. Values are percent-encoded with %20 for
+// spaces, as wallets decode them with decodeURIComponent.
+func (r *TransferRequest) URL() string {
+	var params []string
+	add := func(key, value string) {
+		if value != "" {
+			params = append(params, key+"="+strings.ReplaceAll(url.QueryEscape(value), "+", "%20"))
+		}
+	}
+
+	add("amount", r.Amount)
+	add("spl-token", r.SPLToken)
+	for _, ref := range r.References {
+		add("reference", ref)
+	}
+	add("label", r.Label)
+	add("message", r.Message)
+	add("memo", r.Memo)
+
+	u := PayScheme + ":" + r.Recipient
+	if len(params) > 0 {
+		u += "?" + strings.Join(params, "&")
+	}
+	return u
+}
+
+// Validate checks the addresses and amount
+func (r *TransferRequest) Validate() error {
+	if _, err := wallet.ParsePublicKey(r.Recipient); err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+	if r.SPLToken != "" {
+		if _, err := wallet.ParsePublicKey(r.SPLToken); err != nil {
+			return fmt.Errorf("invalid spl-token: %w", err)
+		}
+	}
+	for _, ref := range r.References {
+		if raw, err := wallet.Base58Decode(ref); err != nil || len(raw) != 32 {
+			return fmt.Errorf("invalid reference %q", ref)
+		}
+	}
+	if r.Amount != "" {
+		// Plain decimal only: no sign, exponent or leading/trailing dot
+		if strings.Trim(r.Amount, "0123456789.") != "" || strings.Count(r.Amount, ".") > 1 ||
+			strings.HasPrefix(r.Amount, ".") || strings.HasSuffix(r.Amount, ".") {
+			return fmt.Errorf("invalid amount %q", r.Amount)
+		}
+	}
+	return nil
+}
+
+// ParseTransferRequest decodes and validates a solana: URL
+func ParseTransferRequest(raw string) (*TransferRequest, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse payment URL: %w", err)
+	}
+	if u.Scheme != PayScheme || u.Opaque == "" {
+		return nil, fmt.Errorf("not a Solana Pay transfer request: %q", raw)
+	}
+
+	q := u.Query()
+	r := &TransferRequest{
+		Recipient:  u.Opaque,
+		Amount:     q.Get("amount"),
+		SPLToken:   q.Get("spl-token"),
+		References: q["reference"],
+		Label:      q.Get("label"),
+		Message:    q.Get("message"),
+		Memo:       q.Get("memo"),
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewReference returns a random reference key. It only has to be
+// unique, so it need not be a point on the curve.
+func NewReference() (string, error) {
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return "", fmt.Errorf("generate reference: %w", err)
+	}
+	return wallet.Base58Encode(key[:]), nil
+}
+
+// FindReference returns the oldest successful transaction carrying
+// reference, or ErrReferenceNotFound. Failed attempts are skipped so
+// the payer can retry.
+func (c *Client) FindReference(ctx context.Context, reference string) (*SignatureInfo, error) {
+	sigs, err := c.GetSignaturesForAddress(ctx, reference, SignaturesOptions{Limit: MaxSignaturesPerPage})
+	if err != nil {
+		return nil, err
+	}
+	for i := len(sigs) - 1; i >= 0; i-- {
+		if sigs[i].Err == nil {
+			return &sigs[i], nil
+		}
+	}
+	return nil, ErrReferenceNotFound
+}
+
+// ValidateTransfer checks that tx pays the request: it succeeded,
+// carries every reference and moved at least the requested amount of
+// the requested asset to the recipient. It returns the total received.
+func ValidateTransfer(signature string, tx *ParsedTransaction, r *TransferRequest) (*Transfer, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %s not found", signature)
+	}
+	if tx.Meta == nil || tx.Meta.Err != nil {
+		return nil, fmt.Errorf("transaction %s failed", signature)
+	}
+
+	keys := make(map[string]bool)
+	for _, k := range tx.Transaction.Message.AccountKeys {
+		keys[k.Pubkey] = true
+	}
+	for _, ref := range r.References {
+		if !keys[ref] {
+			return nil, fmt.Errorf("transaction %s does not carry reference %s", signature, ref)
+		}
+	}
+
+	asset := "SOL"
+	tokens := map[string]string{}
+	if r.SPLToken != "" {
+		asset = "TOKEN"
+		tokens[r.SPLToken] = asset
+	}
+
+	var total *Transfer
+	for _, t := range DecodeTransfers(signature, tx, r.Recipient, tokens) {
+		if t.Direction != DirectionIn || t.Asset != asset {
+			continue
+		}
+		if total == nil {
+			total = &t
+			continue
+		}
+		total.Amount += t.Amount
+	}
+	if total == nil {
+		return nil, fmt.Errorf("transaction %s pays nothing to %s", signature, r.Recipient)
+	}
+
+	if r.Amount != "" {
+		want, err := ParseTokenAmount(r.Amount, total.Decimals)
+		if err != nil {
+			return nil, err
+		}
+		if total.Amount < want {
+			return nil, fmt.Errorf("transaction %s pays %s, requested %s",
+				signature, total.AmountString(), r.Amount)
+		}
+	}
+	return total, nil
+}
+
@@ -0,0 +1,152 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTransferRequestURL(t *testing.T) {
+	r := &TransferRequest{
+		Recipient:  historyOwner,
+		Amount:     "12.5",
+		SPLToken:   historyUSDC,
+		References: []string{historyBob},
+		Label:      "MachPay Agent",
+		Message:    "Top up #1 & more",
+	}
+
+	want := "solana:" + historyOwner +
+		"?amount=12.5&spl-token=" + historyUSDC +
+		"&reference=" + historyBob +
+		"&label=MachPay%20Agent&message=Top%20up%20%231%20%26%20more"
+	if got := r.URL(); got != want {
+		t.Errorf("URL() =\n  %s\nwant\n  %s", got, want)
+	}
+
+	parsed, err := ParseTransferRequest(r.URL())
+	if err != nil {
+		t.Fatalf("ParseTransferRequest: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, r) {
+		t.Errorf("round trip = %+v, want %+v", parsed, r)
+	}
+}
+
+func TestTransferRequestURL_Minimal(t *testing.T) {
+	r := &TransferRequest{Recipient: historyOwner}
+	if got := r.URL(); got != "solana:"+historyOwner {
+		t.Errorf("URL() = %s", got)
+	}
+}
+
+func TestParseTransferRequest_Invalid(t *testing.T) {
+	tests := []string{
+		"https://example.com",
+		"solana:",
+		"solana:not-an-address",
+		"solana:" + historyOwner + "?amount=1e3",
+		"solana:" + historyOwner + "?amount=-1",
+		"solana:" + historyOwner + "?amount=.5",
+		"solana:" + historyOwner + "?amount=1.2.3",
+		"solana:" + historyOwner + "?spl-token=nope",
+		"solana:" + historyOwner + "?reference=abc",
+	}
+	for _, raw := range tests {
+		if _, err := ParseTransferRequest(raw); err == nil {
+			t.Errorf("ParseTransferRequest(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestNewReference(t *testing.T) {
+	a, err := NewReference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewReference()
+	if a == b {
+		t.Error("references should be random")
+	}
+	r := &TransferRequest{Recipient: historyOwner, References: []string{a}}
+	if err := r.Validate(); err != nil {
+		t.Errorf("reference %s invalid: %v", a, err)
+	}
+}
+
+func TestValidateTransfer(t *testing.T) {
+	tx := loadTxFixture(t, sigUSDCIn)
+
+	// Any key in the transaction stands in for the reference
+	reference := "41CNgeRv1uDX9iPPWGxEstdqBVSkVoZ3yvYfXhUu7eBz"
+	r := &TransferRequest{
+		Recipient:  historyOwner,
+		Amount:     "12.5",
+		SPLToken:   historyUSDC,
+		References: []string{reference},
+	}
+
+	got, err := ValidateTransfer(sigUSDCIn, tx, r)
+	if err != nil {
+		t.Fatalf("ValidateTransfer: %v", err)
+	}
+	if got.Amount != 12500000 || got.Counterparty != historyAlice {
+		t.Errorf("transfer = %+v", got)
+	}
+
+	// Open amount
+	open := *r
+	open.Amount = ""
+	if _, err := ValidateTransfer(sigUSDCIn, tx, &open); err != nil {
+		t.Errorf("open amount: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *TransferRequest)
+		want   string
+	}{
+		{"underpaid", func(r *TransferRequest) { r.Amount = "20" }, "requested 20"},
+		{"missing reference", func(r *TransferRequest) { r.References = []string{historyBob} }, "does not carry reference"},
+		{"other recipient", func(r *TransferRequest) { r.Recipient = historyCarol }, "pays nothing"},
+		{"SOL requested", func(r *TransferRequest) { r.SPLToken = "" }, "pays nothing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := *r
+			tt.modify(&req)
+			_, err := ValidateTransfer(sigUSDCIn, tx, &req)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindReference(t *testing.T) {
+	server := fakeRPC(t, map[string]interface{}{
+		"getSignaturesForAddress": []map[string]interface{}{
+			{"signature": "newest", "slot": 30, "err": nil},
+			{"signature": "retry-ok", "slot": 20, "err": nil},
+			{"signature": "failed", "slot": 10, "err": map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}}},
+		},
+	})
+	defer server.Close()
+
+	sig, err := NewClient(server.URL).FindReference(context.Background(), historyBob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.Signature != "retry-ok" {
+		t.Errorf("signature = %s, want oldest successful", sig.Signature)
+	}
+
+	empty := fakeRPC(t, map[string]interface{}{"getSignaturesForAddress": []interface{}{}})
+	defer empty.Close()
+	if _, err := NewClient(empty.URL).FindReference(context.Background(), historyBob); !errors.Is(err, ErrReferenceNotFound) {
+		t.Errorf("error = %v, want ErrReferenceNotFound", err)
+	}
+}
+
@@ -0,0 +1,265 @@
+// ============================================================
+// Programs - Instruction builders and decoders
+// ============================================================
+//
+// Builders for the handful of instructions the CLI needs:
+// - System: transfer, advance nonce
+// - SPL Token: transferChecked
+// - Associated Token Account: create idempotent
+// - Compute Budget: unit limit and price
+//
+// DecodeInstructions turns compiled instructions back into
+// human-readable lines so users can review what they sign.
+//
+// ============================================================
+
+package solana
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// Program and sysvar IDs
+var (
+	SystemProgramID          = MustPublicKey(wallet.SystemProgramID)
+	TokenProgramID           = MustPublicKey(wallet.TokenProgramID)
+	Token2022ProgramID       = MustPublicKey(wallet.Token2022ProgramID)
+	AssociatedTokenProgramID = MustPublicKey(wallet.AssociatedTokenAccountProgramID)
+	ComputeBudgetProgramID   = MustPublicKey("ComputeBudget111111111111111111111111111111")
+	MemoProgramID            = MustPublicKey("MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr")
+	SysvarRecentBlockhashes  = MustPublicKey("SysvarRecentB1ockHashes11111111111111111111")
+)
+
+// Instruction discriminators
+const (
+	systemTransfer       = 2
+	systemAdvanceNonce   = 4
+	tokenTransferChecked = 12
+	ataCreateIdempotent  = 1
+	computeUnitLimit     = 2
+	computeUnitPrice     = 3
+)
+
+// ============================================================
+// Builders
+// ============================================================
+
+// SystemTransfer moves lamports between two system accounts
+func SystemTransfer(from, to PublicKey, lamports uint64) Instruction {
+	data := make([]byte, 12)
+	binary.LittleEndian.PutUint32(data[0:4], systemTransfer)
+	binary.LittleEndian.PutUint64(data[4:12], lamports)
+
+	return Instruction{
+		ProgramID: SystemProgramID,
+		Accounts: []AccountMeta{
+			{PublicKey: from, IsSigner: true, IsWritable: true},
+			{PublicKey: to, IsWritable: true},
+		},
+		Data: data,
+	}
+}
+
+// AdvanceNonceAccount consumes a durable nonce. It must be the first
+// instruction of a transaction that uses the nonce as its blockhash.
+func AdvanceNonceAccount(nonceAccount, authority PublicKey) Instruction {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, systemAdvanceNonce)
+
+	return Instruction{
+		ProgramID: SystemProgramID,
+		Accounts: []AccountMeta{
+			{PublicKey: nonceAccount, IsWritable: true},
+			{PublicKey: SysvarRecentBlockhashes},
+			{PublicKey: authority, IsSigner: true},
+		},
+		Data: data,
+	}
+}
+
+// TokenTransferChecked moves SPL tokens between token accounts
+func TokenTransferChecked(tokenProgram, source, mint, destination, owner PublicKey, amount uint64, decimals uint8) Instruction {
+	data := make([]byte, 10)
+	data[0] = tokenTransferChecked
+	binary.LittleEndian.PutUint64(data[1:9], amount)
+	data[9] = decimals
+
+	return Instruction{
+		ProgramID: tokenProgram,
+		Accounts: []AccountMeta{
+			{PublicKey: source, IsWritable: true},
+			{PublicKey: mint},
+			{PublicKey: destination, IsWritable: true},
+			{PublicKey: owner, IsSigner: true},
+		},
+		Data: data,
+	}
+}
+
+// CreateAssociatedTokenAccountIdempotent creates owner's ATA for mint
+// if it does not already exist
+func CreateAssociatedTokenAccountIdempotent(payer, ata, owner, mint, tokenProgram PublicKey) Instruction {
+	return Instruction{
+		ProgramID: AssociatedTokenProgramID,
+		Accounts: []AccountMeta{
+			{PublicKey: payer, IsSigner: true, IsWritable: true},
+			{PublicKey: ata, IsWritable: true},
+			{PublicKey: owner},
+			{PublicKey: mint},
+			{PublicKey: SystemProgramID},
+			{PublicKey: tokenProgram},
+		},
+		Data: []byte{ataCreateIdempotent},
+	}
+}
+
+// SetComputeUnitLimit caps the compute units a transaction may use
+func SetComputeUnitLimit(units uint32) Instruction {
+	data := make([]byte, 5)
+	data[0] = computeUnitLimit
+	binary.LittleEndian.PutUint32(data[1:], units)
+	return Instruction{ProgramID: ComputeBudgetProgramID, Data: data}
+}
+
+// SetComputeUnitPrice sets the priority fee in micro-lamports per unit
+func SetComputeUnitPrice(microLamports uint64) Instruction {
+	data := make([]byte, 9)
+	data[0] = computeUnitPrice
+	binary.LittleEndian.PutUint64(data[1:], microLamports)
+	return Instruction{ProgramID: ComputeBudgetProgramID, Data: data}
+}
+
+// AssociatedTokenAddress derives the ATA for owner and mint
+func AssociatedTokenAddress(owner, mint, tokenProgram PublicKey) (PublicKey, error) {
+	address, _, err := wallet.FindAssociatedTokenAddress(owner.String(), mint.String(), tokenProgram.String())
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return ParsePublicKey(address)
+}
+
+// ============================================================
+// Decoding
+// ============================================================
+
+// DecodedInstruction is a compiled instruction resolved against its message
+type DecodedInstruction struct {
+	Program     PublicKey
+	Accounts    []PublicKey
+	Data        []byte
+	Description string
+}
+
+// DecodeInstructions resolves and describes every instruction in a message
+func DecodeInstructions(m *Message) []DecodedInstruction {
+	decoded := make([]DecodedInstruction, 0, len(m.Instructions))
+	for _, ix := range m.Instructions {
+		d := DecodedInstruction{
+			Program: m.AccountKeys[ix.ProgramIDIndex],
+			Data:    ix.Data,
+		}
+		for _, a := range ix.Accounts {
+			d.Accounts = append(d.Accounts, m.AccountKeys[a])
+		}
+		d.Description = describe(d)
+		decoded = append(decoded, d)
+	}
+	return decoded
+}
+
+func describe(d DecodedInstruction) string {
+	switch d.Program {
+	case SystemProgramID:
+		if len(d.Data) >= 4 {
+			switch binary.LittleEndian.Uint32(d.Data) {
+			case systemTransfer:
+				if len(d.Data) == 12 && len(d.Accounts) >= 2 {
+					lamports := binary.LittleEndian.Uint64(d.Data[4:])
+					return fmt.Sprintf("System: transfer %s SOL from %s to %s",
+						FormatSOL(lamports), d.Accounts[0], d.Accounts[1])
+				}
+			case systemAdvanceNonce:
+				if len(d.Accounts) >= 3 {
+					return fmt.Sprintf("System: advance nonce %s (authority %s)", d.Accounts[0], d.Accounts[2])
+				}
+			}
+		}
+		return "System: unrecognised instruction"
+
+	case TokenProgramID, Token2022ProgramID:
+		if len(d.Data) == 10 && d.Data[0] == tokenTransferChecked && len(d.Accounts) >= 4 {
+			amount := binary.LittleEndian.Uint64(d.Data[1:9])
+			return fmt.Sprintf("Token: transfer %s (mint %s) from %s to %s, owner %s",
+				FormatTokenAmount(amount, d.Data[9]), d.Accounts[1], d.Accounts[0], d.Accounts[2], d.Accounts[3])
+		}
+		return "Token: unrecognised instruction"
+
+	case AssociatedTokenProgramID:
+		if len(d.Accounts) >= 4 && (len(d.Data) == 0 || d.Data[0] <= ataCreateIdempotent) {
+			return fmt.Sprintf("ATA: create %s for owner %s (mint %s)", d.Accounts[1], d.Accounts[2], d.Accounts[3])
+		}
+		return "ATA: unrecognised instruction"
+
+	case ComputeBudgetProgramID:
+		if len(d.Data) == 5 && d.Data[0] == computeUnitLimit {
+			return fmt.Sprintf("Compute budget: limit %d units", binary.LittleEndian.Uint32(d.Data[1:]))
+		}
+		if len(d.Data) == 9 && d.Data[0] == computeUnitPrice {
+			return fmt.Sprintf("Compute budget: price %d micro-lamports/unit", binary.LittleEndian.Uint64(d.Data[1:]))
+		}
+		return "Compute budget: unrecognised instruction"
+	}
+
+	return fmt.Sprintf("Unknown program %s (%d bytes of data)", d.Program, len(d.Data))
+}
+
+// FormatTokenAmount formats a raw token amount using its decimals
+func FormatTokenAmount(amount uint64, decimals uint8) string {
+	s := strconv.FormatUint(amount, 10)
+	if decimals == 0 {
+		return s
+	}
+	for len(s) <= int(decimals) {
+		s = "0" + s
+	}
+	whole, frac := s[:len(s)-int(decimals)], s[len(s)-int(decimals):]
+	for len(frac) > 0 && frac[len(frac)-1] == '0' {
+		frac = frac[:len(frac)-1]
+	}
+	if frac == "" {
+		return whole
+	}
+	return whole + "." + frac
+}
+
+// ParseTokenAmount converts a decimal string (e.g. "1.25") into a raw
+// token amount without going through floating point
+func ParseTokenAmount(s string, decimals uint8) (uint64, error) {
+	whole, frac := s, ""
+	for i := 0; i < len(s); i++ {
+		if s[i] == '.' {
+			whole, frac = s[:i], s[i+1:]
+			break
+		}
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if len(frac) > int(decimals) {
+		return 0, fmt.Errorf("amount %s has more than %d decimal places", s, decimals)
+	}
+	for len(frac) < int(decimals) {
+		frac += "0"
+	}
+
+	amount, err := strconv.ParseUint(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return amount, nil
+}
+
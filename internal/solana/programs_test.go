@@ -0,0 +1,85 @@
+package solana
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatTokenAmount(t *testing.T) {
+	tests := []struct {
+		amount   uint64
+		decimals uint8
+		want     string
+	}{
+		{0, 6, "0"},
+		{1, 6, "0.000001"},
+		{1500000, 6, "1.5"},
+		{25000000, 6, "25"},
+		{123, 0, "123"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatTokenAmount(tt.amount, tt.decimals); got != tt.want {
+			t.Errorf("FormatTokenAmount(%d, %d) = %q, want %q", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestParseTokenAmount(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    uint64
+		wantErr bool
+	}{
+		{"25", 25000000, false},
+		{"0.1", 100000, false},
+		{".5", 500000, false},
+		{"1.000001", 1000001, false},
+		{"1.0000001", 0, true},
+		{"abc", 0, true},
+		{"-1", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTokenAmount(tt.input, 6)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTokenAmount(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTokenAmount(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeInstructions(t *testing.T) {
+	payer, _ := testKey(t)
+	dest, _ := testKey(t)
+	mint := MustPublicKey("4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU")
+
+	src, _ := AssociatedTokenAddress(payer, mint, TokenProgramID)
+	dst, _ := AssociatedTokenAddress(dest, mint, TokenProgramID)
+
+	msg, err := NewMessage(payer, []Instruction{
+		SetComputeUnitLimit(200000),
+		CreateAssociatedTokenAccountIdempotent(payer, dst, dest, mint, TokenProgramID),
+		TokenTransferChecked(TokenProgramID, src, mint, dst, payer, 2500000, 6),
+		{ProgramID: dest, Data: []byte{1, 2}},
+	}, Hash{})
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+
+	decoded := DecodeInstructions(msg)
+	want := []string{
+		"Compute budget: limit 200000 units",
+		"ATA: create " + dst.String(),
+		"Token: transfer 2.5 (mint " + mint.String(),
+		"Unknown program " + dest.String(),
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(decoded[i].Description, prefix) {
+			t.Errorf("instruction %d = %q, want prefix %q", i, decoded[i].Description, prefix)
+		}
+	}
+}
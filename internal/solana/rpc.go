@@ -0,0 +1,365 @@
+// ============================================================
+// Solana RPC Client - Minimal JSON-RPC 2.0 client
+// ============================================================
+//
+// Implements the subset of the Solana JSON-RPC API used by the
+// CLI. Kept dependency-free: plain net/http and encoding/json.
+//
+// ============================================================
+
+package solana
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// LamportsPerSOL is the number of lamports in one SOL
+	LamportsPerSOL = 1_000_000_000
+
+	// DefaultTimeout is the HTTP timeout for a single RPC call
+	DefaultTimeout = 30 * time.Second
+)
+
+// Commitment levels
+const (
+	CommitmentProcessed = "processed"
+	CommitmentConfirmed = "confirmed"
+	CommitmentFinalized = "finalized"
+)
+
+// Client is a Solana JSON-RPC client
+type Client struct {
+	url        string
+	httpClient *http.Client
+	nextID     atomic.Uint64
+}
+
+// NewClient creates a new RPC client for the given endpoint
+func NewClient(url string) *Client {
+	return &Client{
+		url:        url,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// URL returns the RPC endpoint
+func (c *Client) URL() string {
+	return c.url
+}
+
+// ============================================================
+// JSON-RPC Types
+// ============================================================
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// RPCError is an error returned by the RPC node
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// Call performs a raw JSON-RPC call and decodes the result into out
+func (c *Client) Call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "machpay-cli")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: read response: %w", method, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %d: %s", method, resp.StatusCode, string(data))
+	}
+
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(data, &rpcResp); err != nil {
+		return fmt.Errorf("%s: parse response: %w", method, err)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, out); err != nil {
+		return fmt.Errorf("%s: decode result: %w", method, err)
+	}
+	return nil
+}
+
+// ============================================================
+// Account Methods
+// ============================================================
+
+// GetBalance returns the SOL balance of an address in lamports
+func (c *Client) GetBalance(ctx context.Context, address string) (uint64, error) {
+	var result struct {
+		Value uint64 `json:"value"`
+	}
+	params := []interface{}{address, map[string]string{"commitment": CommitmentConfirmed}}
+	if err := c.Call(ctx, "getBalance", params, &result); err != nil {
+		return 0, err
+	}
+	return result.Value, nil
+}
+
+// TokenAmount is an SPL token balance as returned by the RPC
+type TokenAmount struct {
+	Amount         string `json:"amount"`
+	Decimals       int    `json:"decimals"`
+	UIAmountString string `json:"uiAmountString"`
+}
+
+// GetTokenAccountBalance returns the balance of an SPL token account
+func (c *Client) GetTokenAccountBalance(ctx context.Context, tokenAccount string) (*TokenAmount, error) {
+	var result struct {
+		Value TokenAmount `json:"value"`
+	}
+	params := []interface{}{tokenAccount, map[string]string{"commitment": CommitmentConfirmed}}
+	if err := c.Call(ctx, "getTokenAccountBalance", params, &result); err != nil {
+		return nil, err
+	}
+	return &result.Value, nil
+}
+
+// NonceAccount is the state of a durable nonce account
+type NonceAccount struct {
+	Authority PublicKey
+	Nonce     Hash
+}
+
+// GetNonceAccount reads the stored nonce and authority of a nonce account
+func (c *Client) GetNonceAccount(ctx context.Context, address string) (*NonceAccount, error) {
+	var result struct {
+		Value *struct {
+			Data  []string `json:"data"`
+			Owner string   `json:"owner"`
+		} `json:"value"`
+	}
+	params := []interface{}{address, map[string]string{"encoding": "base64", "commitment": CommitmentConfirmed}}
+	if err := c.Call(ctx, "getAccountInfo", params, &result); err != nil {
+		return nil, err
+	}
+	if result.Value == nil {
+		return nil, fmt.Errorf("nonce account %s not found", address)
+	}
+	if result.Value.Owner != SystemProgramID.String() || len(result.Value.Data) == 0 {
+		return nil, fmt.Errorf("%s is not a nonce account", address)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(result.Value.Data[0])
+	if err != nil {
+		return nil, fmt.Errorf("decode nonce account: %w", err)
+	}
+
+	// Layout: version u32 | state u32 | authority [32] | nonce [32] | fee u64
+	if len(data) < 80 || binary.LittleEndian.Uint32(data[4:8]) != 1 {
+		return nil, fmt.Errorf("%s is not an initialized nonce account", address)
+	}
+
+	nonce := &NonceAccount{}
+	copy(nonce.Authority[:], data[8:40])
+	copy(nonce.Nonce[:], data[40:72])
+	return nonce, nil
+}
+
+// Genesis hashes of the public clusters
+const (
+	GenesisMainnet = "5eykt4UsFv8P8NJdTREpY1vzqKqZKvdpKuc147dw2N9d"
+	GenesisDevnet  = "EtWTRABZaYq6iMfeYKouRu166VU2xqa1wcaWoxPkrZBG"
+	GenesisTestnet = "4uhcVJyU9pJkvQyS88uRDiswHXSCkY3zQawwpjk2NsNY"
+)
+
+// GetGenesisHash returns the cluster's genesis hash, which identifies
+// the cluster behind any RPC endpoint
+func (c *Client) GetGenesisHash(ctx context.Context) (string, error) {
+	var hash string
+	if err := c.Call(ctx, "getGenesisHash", nil, &hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// ============================================================
+// Transactions
+// ============================================================
+
+// GetLatestBlockhash returns a recent blockhash and the last block
+// height at which it is valid
+func (c *Client) GetLatestBlockhash(ctx context.Context) (Hash, uint64, error) {
+	var result struct {
+		Value struct {
+			Blockhash            string `json:"blockhash"`
+			LastValidBlockHeight uint64 `json:"lastValidBlockHeight"`
+		} `json:"value"`
+	}
+	params := []interface{}{map[string]string{"commitment": CommitmentConfirmed}}
+	if err := c.Call(ctx, "getLatestBlockhash", params, &result); err != nil {
+		return Hash{}, 0, err
+	}
+	hash, err := ParseHash(result.Value.Blockhash)
+	if err != nil {
+		return Hash{}, 0, err
+	}
+	return hash, result.Value.LastValidBlockHeight, nil
+}
+
+// IsBlockhashValid reports whether a transaction using blockhash can
+// still be processed
+func (c *Client) IsBlockhashValid(ctx context.Context, blockhash Hash) (bool, error) {
+	var result struct {
+		Value bool `json:"value"`
+	}
+	params := []interface{}{blockhash.String(), map[string]string{"commitment": CommitmentConfirmed}}
+	if err := c.Call(ctx, "isBlockhashValid", params, &result); err != nil {
+		return false, err
+	}
+	return result.Value, nil
+}
+
+// SendTransaction submits a signed transaction and returns its signature
+func (c *Client) SendTransaction(ctx context.Context, tx *Transaction) (string, error) {
+	var signature string
+	params := []interface{}{
+		tx.ToBase64(),
+		map[string]interface{}{"encoding": "base64", "preflightCommitment": CommitmentConfirmed},
+	}
+	if err := c.Call(ctx, "sendTransaction", params, &signature); err != nil {
+		return "", err
+	}
+	return signature, nil
+}
+
+// ============================================================
+// Airdrop and Confirmation
+// ============================================================
+
+// RequestAirdrop requests lamports for an address (devnet/testnet only)
+// and returns the airdrop transaction signature
+func (c *Client) RequestAirdrop(ctx context.Context, address string, lamports uint64) (string, error) {
+	var signature string
+	params := []interface{}{address, lamports, map[string]string{"commitment": CommitmentConfirmed}}
+	if err := c.Call(ctx, "requestAirdrop", params, &signature); err != nil {
+		return "", err
+	}
+	return signature, nil
+}
+
+// SignatureStatus is the status of a submitted transaction
+type SignatureStatus struct {
+	Slot               uint64          `json:"slot"`
+	Confirmations      *uint64         `json:"confirmations"`
+	Err                json.RawMessage `json:"err"`
+	ConfirmationStatus string          `json:"confirmationStatus"`
+}
+
+// Failed reports whether the transaction failed on-chain
+func (s *SignatureStatus) Failed() bool {
+	return len(s.Err) > 0 && string(s.Err) != "null"
+}
+
+// GetSignatureStatuses returns the statuses of the given signatures.
+// Entries are nil for signatures the node does not know about.
+func (c *Client) GetSignatureStatuses(ctx context.Context, signatures []string) ([]*SignatureStatus, error) {
+	var result struct {
+		Value []*SignatureStatus `json:"value"`
+	}
+	params := []interface{}{signatures, map[string]bool{"searchTransactionHistory": true}}
+	if err := c.Call(ctx, "getSignatureStatuses", params, &result); err != nil {
+		return nil, err
+	}
+	return result.Value, nil
+}
+
+// WaitForConfirmation polls until the signature reaches at least the
+// "confirmed" commitment, fails on-chain, or ctx is done
+func (c *Client) WaitForConfirmation(ctx context.Context, signature string, interval time.Duration) (*SignatureStatus, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		statuses, err := c.GetSignatureStatuses(ctx, []string{signature})
+		if err != nil {
+			return nil, err
+		}
+		if len(statuses) > 0 && statuses[0] != nil {
+			status := statuses[0]
+			if status.Failed() {
+				return status, fmt.Errorf("transaction failed: %s", string(status.Err))
+			}
+			if status.ConfirmationStatus == CommitmentConfirmed ||
+				status.ConfirmationStatus == CommitmentFinalized {
+				return status, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for confirmation: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// ============================================================
+// Helpers
+// ============================================================
+
+// FormatSOL formats a lamport amount as SOL
+func FormatSOL(lamports uint64) string {
+	return strconv.FormatFloat(float64(lamports)/LamportsPerSOL, 'f', -1, 64)
+}
+
+// SOLToLamports converts a SOL amount to lamports
+func SOLToLamports(sol float64) uint64 {
+	return uint64(sol*LamportsPerSOL + 0.5)
+}
+
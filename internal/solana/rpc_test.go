@@ -0,0 +1,203 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeRPC serves canned results keyed by method name
+func fakeRPC(t *testing.T, results map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		if req.JSONRPC != "2.0" {
+			t.Errorf("jsonrpc = %q, want 2.0", req.JSONRPC)
+		}
+
+		result, ok := results[req.Method]
+		if !ok {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      req.ID,
+				"error":   map[string]interface{}{"code": -32601, "message": "Method not found"},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  result,
+		})
+	}))
+}
+
+func TestGetBalance(t *testing.T) {
+	server := fakeRPC(t, map[string]interface{}{
+		"getBalance": map[string]interface{}{"context": map[string]int{"slot": 1}, "value": 1500000000},
+	})
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	lamports, err := client.GetBalance(context.Background(), "addr")
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if lamports != 1500000000 {
+		t.Errorf("GetBalance = %d, want 1500000000", lamports)
+	}
+}
+
+func TestCall_RPCError(t *testing.T) {
+	server := fakeRPC(t, map[string]interface{}{})
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	_, err := client.GetBalance(context.Background(), "addr")
+	rpcErr, ok := err.(*RPCError)
+	if !ok {
+		t.Fatalf("Expected *RPCError, got %T (%v)", err, err)
+	}
+	if rpcErr.Code != -32601 {
+		t.Errorf("Code = %d, want -32601", rpcErr.Code)
+	}
+}
+
+func TestCall_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	if _, err := client.GetBalance(context.Background(), "addr"); err == nil {
+		t.Error("Expected error for HTTP 429")
+	}
+}
+
+func TestRequestAirdrop(t *testing.T) {
+	server := fakeRPC(t, map[string]interface{}{
+		"requestAirdrop": "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW",
+	})
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	sig, err := client.RequestAirdrop(context.Background(), "addr", LamportsPerSOL)
+	if err != nil {
+		t.Fatalf("RequestAirdrop failed: %v", err)
+	}
+	if sig == "" {
+		t.Error("Expected signature")
+	}
+}
+
+func TestWaitForConfirmation(t *testing.T) {
+	server := fakeRPC(t, map[string]interface{}{
+		"getSignatureStatuses": map[string]interface{}{
+			"value": []interface{}{
+				map[string]interface{}{"slot": 10, "err": nil, "confirmationStatus": "confirmed"},
+			},
+		},
+	})
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	status, err := client.WaitForConfirmation(context.Background(), "sig", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForConfirmation failed: %v", err)
+	}
+	if status.Slot != 10 {
+		t.Errorf("Slot = %d, want 10", status.Slot)
+	}
+}
+
+func TestWaitForConfirmation_Failed(t *testing.T) {
+	server := fakeRPC(t, map[string]interface{}{
+		"getSignatureStatuses": map[string]interface{}{
+			"value": []interface{}{
+				map[string]interface{}{"slot": 10, "err": map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}}},
+			},
+		},
+	})
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	if _, err := client.WaitForConfirmation(context.Background(), "sig", 10*time.Millisecond); err == nil {
+		t.Error("Expected error for failed transaction")
+	}
+}
+
+func TestWaitForConfirmation_Timeout(t *testing.T) {
+	server := fakeRPC(t, map[string]interface{}{
+		"getSignatureStatuses": map[string]interface{}{"value": []interface{}{nil}},
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := NewClient(server.URL)
+	if _, err := client.WaitForConfirmation(ctx, "sig", 10*time.Millisecond); err == nil {
+		t.Error("Expected timeout error")
+	}
+}
+
+func TestFormatSOL(t *testing.T) {
+	tests := []struct {
+		lamports uint64
+		want     string
+	}{
+		{0, "0"},
+		{1, "0.000000001"},
+		{LamportsPerSOL, "1"},
+		{1500000000, "1.5"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSOL(tt.lamports); got != tt.want {
+			t.Errorf("FormatSOL(%d) = %s, want %s", tt.lamports, got, tt.want)
+		}
+	}
+}
+
+func TestSOLToLamports(t *testing.T) {
+	if got := SOLToLamports(0.1); got != 100000000 {
+		t.Errorf("SOLToLamports(0.1) = %d, want 100000000", got)
+	}
+}
+
+func TestGetGenesisHash(t *testing.T) {
+	server := fakeRPC(t, map[string]interface{}{"getGenesisHash": GenesisDevnet})
+	defer server.Close()
+
+	hash, err := NewClient(server.URL).GetGenesisHash(context.Background())
+	if err != nil {
+		t.Fatalf("GetGenesisHash failed: %v", err)
+	}
+	if hash != GenesisDevnet {
+		t.Errorf("GetGenesisHash = %s, want %s", hash, GenesisDevnet)
+	}
+}
+
+
+func TestIsBlockhashValid(t *testing.T) {
+	server := fakeRPC(t, map[string]interface{}{
+		"isBlockhashValid": map[string]interface{}{"context": map[string]int{"slot": 1}, "value": false},
+	})
+	defer server.Close()
+
+	valid, err := NewClient(server.URL).IsBlockhashValid(context.Background(), Hash{1})
+	if err != nil {
+		t.Fatalf("IsBlockhashValid failed: %v", err)
+	}
+	if valid {
+		t.Error("IsBlockhashValid = true, want false")
+	}
+}
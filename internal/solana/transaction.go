@@ -0,0 +1,563 @@
+// ============================================================
+// Transactions - Message and transaction wire format
+// ============================================================
+//
+// Builds, serializes and parses Solana legacy transactions:
+//
+//   transaction = compact(len) signatures[64] || message
+//   message     = header[3] || compact(len) keys[32] ||
+//                 blockhash[32] || compact(len) instructions
+//
+// Version 0 messages parse too when they use no address lookup
+// table: 0x80 || legacy message || compact(0).
+//
+// ============================================================
+
+package solana
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// SignatureLength is the size of an ed25519 signature
+const SignatureLength = 64
+
+// versionPrefix starts a version 0 message
+const versionPrefix = 0x80
+
+// ErrLookupTables is returned for v0 messages that load accounts from
+// address lookup tables
+var ErrLookupTables = errors.New("address lookup tables are not supported")
+
+// PublicKey is a 32-byte Solana address
+type PublicKey [32]byte
+
+// ParsePublicKey decodes a base58 address
+func ParsePublicKey(address string) (PublicKey, error) {
+	var pk PublicKey
+	raw, err := wallet.ParsePublicKey(address)
+	if err != nil {
+		return pk, err
+	}
+	copy(pk[:], raw)
+	return pk, nil
+}
+
+// MustPublicKey decodes a base58 address and panics on error.
+// Only use it for compile-time constants.
+func MustPublicKey(address string) PublicKey {
+	pk, err := ParsePublicKey(address)
+	if err != nil {
+		panic(err)
+	}
+	return pk
+}
+
+// String returns the base58 address
+func (pk PublicKey) String() string {
+	return wallet.Base58Encode(pk[:])
+}
+
+// Hash is a 32-byte blockhash
+type Hash [32]byte
+
+// ParseHash decodes a base58 blockhash
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	pk, err := ParsePublicKey(s)
+	if err != nil {
+		return h, fmt.Errorf("invalid blockhash: %w", err)
+	}
+	copy(h[:], pk[:])
+	return h, nil
+}
+
+// String returns the base58 blockhash
+func (h Hash) String() string {
+	return wallet.Base58Encode(h[:])
+}
+
+// ============================================================
+// Instructions
+// ============================================================
+
+// AccountMeta describes an account referenced by an instruction
+type AccountMeta struct {
+	PublicKey  PublicKey
+	IsSigner   bool
+	IsWritable bool
+}
+
+// Instruction is an uncompiled program instruction
+type Instruction struct {
+	ProgramID PublicKey
+	Accounts  []AccountMeta
+	Data      []byte
+}
+
+// CompiledInstruction references accounts by index into the message keys
+type CompiledInstruction struct {
+	ProgramIDIndex uint8
+	Accounts       []uint8
+	Data           []byte
+}
+
+// ============================================================
+// Message
+// ============================================================
+
+// MessageHeader counts the signer and read-only accounts
+type MessageHeader struct {
+	NumRequiredSignatures       uint8
+	NumReadonlySignedAccounts   uint8
+	NumReadonlyUnsignedAccounts uint8
+}
+
+// Message is a transaction message: legacy, or version 0 without
+// address lookup tables
+type Message struct {
+	Versioned       bool // v0: version prefix and an empty lookup table list
+	Header          MessageHeader
+	AccountKeys     []PublicKey
+	RecentBlockhash Hash
+	Instructions    []CompiledInstruction
+}
+
+// NewMessage compiles instructions into a message. Accounts are
+// ordered fee payer first, then writable signers, read-only signers,
+// writable non-signers and read-only non-signers.
+func NewMessage(feePayer PublicKey, instructions []Instruction, blockhash Hash) (*Message, error) {
+	type entry struct {
+		key      PublicKey
+		signer   bool
+		writable bool
+	}
+
+	var entries []*entry
+	index := make(map[PublicKey]*entry)
+	add := func(key PublicKey, signer, writable bool) {
+		if e, ok := index[key]; ok {
+			e.signer = e.signer || signer
+			e.writable = e.writable || writable
+			return
+		}
+		e := &entry{key: key, signer: signer, writable: writable}
+		index[key] = e
+		entries = append(entries, e)
+	}
+
+	add(feePayer, true, true)
+	for _, ix := range instructions {
+		for _, acc := range ix.Accounts {
+			add(acc.PublicKey, acc.IsSigner, acc.IsWritable)
+		}
+	}
+	for _, ix := range instructions {
+		add(ix.ProgramID, false, false)
+	}
+
+	msg := &Message{RecentBlockhash: blockhash}
+	groups := []struct{ signer, writable bool }{
+		{true, true}, {true, false}, {false, true}, {false, false},
+	}
+	for _, g := range groups {
+		for _, e := range entries {
+			if e.signer != g.signer || e.writable != g.writable {
+				continue
+			}
+			msg.AccountKeys = append(msg.AccountKeys, e.key)
+			switch {
+			case e.signer && !e.writable:
+				msg.Header.NumRequiredSignatures++
+				msg.Header.NumReadonlySignedAccounts++
+			case e.signer:
+				msg.Header.NumRequiredSignatures++
+			case !e.writable:
+				msg.Header.NumReadonlyUnsignedAccounts++
+			}
+		}
+	}
+
+	if len(msg.AccountKeys) > 256 {
+		return nil, fmt.Errorf("too many accounts: %d", len(msg.AccountKeys))
+	}
+
+	positions := make(map[PublicKey]uint8, len(msg.AccountKeys))
+	for i, key := range msg.AccountKeys {
+		positions[key] = uint8(i)
+	}
+	for _, ix := range instructions {
+		compiled := CompiledInstruction{
+			ProgramIDIndex: positions[ix.ProgramID],
+			Data:           ix.Data,
+		}
+		for _, acc := range ix.Accounts {
+			compiled.Accounts = append(compiled.Accounts, positions[acc.PublicKey])
+		}
+		msg.Instructions = append(msg.Instructions, compiled)
+	}
+
+	return msg, nil
+}
+
+// Signers returns the accounts that must sign the message
+func (m *Message) Signers() []PublicKey {
+	return m.AccountKeys[:m.Header.NumRequiredSignatures]
+}
+
+// IsWritable reports whether the account at index i is writable
+func (m *Message) IsWritable(i int) bool {
+	h := m.Header
+	if i < int(h.NumRequiredSignatures) {
+		return i < int(h.NumRequiredSignatures-h.NumReadonlySignedAccounts)
+	}
+	return i < len(m.AccountKeys)-int(h.NumReadonlyUnsignedAccounts)
+}
+
+// Serialize encodes the message in wire format
+func (m *Message) Serialize() []byte {
+	var buf []byte
+	if m.Versioned {
+		buf = append(buf, versionPrefix)
+	}
+	buf = append(buf,
+		m.Header.NumRequiredSignatures,
+		m.Header.NumReadonlySignedAccounts,
+		m.Header.NumReadonlyUnsignedAccounts,
+	)
+
+	buf = appendCompactU16(buf, len(m.AccountKeys))
+	for _, key := range m.AccountKeys {
+		buf = append(buf, key[:]...)
+	}
+	buf = append(buf, m.RecentBlockhash[:]...)
+
+	buf = appendCompactU16(buf, len(m.Instructions))
+	for _, ix := range m.Instructions {
+		buf = append(buf, ix.ProgramIDIndex)
+		buf = appendCompactU16(buf, len(ix.Accounts))
+		buf = append(buf, ix.Accounts...)
+		buf = appendCompactU16(buf, len(ix.Data))
+		buf = append(buf, ix.Data...)
+	}
+	if m.Versioned {
+		buf = appendCompactU16(buf, 0)
+	}
+
+	return buf
+}
+
+// ============================================================
+// Transaction
+// ============================================================
+
+// Transaction is a message plus one signature per required signer.
+// Missing signatures are all zeros.
+type Transaction struct {
+	Signatures [][SignatureLength]byte
+	Message    Message
+}
+
+// NewTransaction creates an unsigned transaction for a message
+func NewTransaction(msg *Message) *Transaction {
+	return &Transaction{
+		Signatures: make([][SignatureLength]byte, msg.Header.NumRequiredSignatures),
+		Message:    *msg,
+	}
+}
+
+// AddSignature sets the signature for signer after verifying it
+func (tx *Transaction) AddSignature(signer PublicKey, signature []byte) error {
+	if len(signature) != SignatureLength {
+		return fmt.Errorf("invalid signature length: %d", len(signature))
+	}
+	for i, key := range tx.Message.Signers() {
+		if key != signer {
+			continue
+		}
+		if !ed25519.Verify(key[:], tx.Message.Serialize(), signature) {
+			return fmt.Errorf("signature does not verify for %s", signer)
+		}
+		copy(tx.Signatures[i][:], signature)
+		return nil
+	}
+	return fmt.Errorf("%s is not a required signer", signer)
+}
+
+// MissingSigners returns the required signers without a signature
+func (tx *Transaction) MissingSigners() []PublicKey {
+	var missing []PublicKey
+	for i, key := range tx.Message.Signers() {
+		if tx.Signatures[i] == [SignatureLength]byte{} {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// VerifySignatures checks every present signature against the message
+func (tx *Transaction) VerifySignatures() error {
+	msg := tx.Message.Serialize()
+	for i, key := range tx.Message.Signers() {
+		if tx.Signatures[i] == [SignatureLength]byte{} {
+			continue
+		}
+		if !ed25519.Verify(key[:], msg, tx.Signatures[i][:]) {
+			return fmt.Errorf("invalid signature for %s", key)
+		}
+	}
+	return nil
+}
+
+// Signature returns the transaction ID (first signature) in base58
+func (tx *Transaction) Signature() string {
+	if len(tx.Signatures) == 0 {
+		return ""
+	}
+	return wallet.Base58Encode(tx.Signatures[0][:])
+}
+
+// Serialize encodes the transaction in wire format
+func (tx *Transaction) Serialize() []byte {
+	buf := appendCompactU16(nil, len(tx.Signatures))
+	for _, sig := range tx.Signatures {
+		buf = append(buf, sig[:]...)
+	}
+	return append(buf, tx.Message.Serialize()...)
+}
+
+// ToBase64 encodes the serialized transaction as base64
+func (tx *Transaction) ToBase64() string {
+	return base64.StdEncoding.EncodeToString(tx.Serialize())
+}
+
+// TransactionFromBase64 parses a base64 wire-format transaction
+func TransactionFromBase64(s string) (*Transaction, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode base64: %w", err)
+	}
+	return ParseTransaction(data)
+}
+
+// ParseTransaction decodes a wire-format transaction
+func ParseTransaction(data []byte) (*Transaction, error) {
+	r := &reader{buf: data}
+
+	numSigs, err := r.compactU16()
+	if err != nil {
+		return nil, fmt.Errorf("signature count: %w", err)
+	}
+	// Check the count against the input before allocating for it
+	if numSigs > r.remaining()/SignatureLength {
+		return nil, fmt.Errorf("%d signatures: unexpected end of data", numSigs)
+	}
+	tx := &Transaction{Signatures: make([][SignatureLength]byte, numSigs)}
+	for i := range tx.Signatures {
+		sig, err := r.bytes(SignatureLength)
+		if err != nil {
+			return nil, fmt.Errorf("signature %d: %w", i, err)
+		}
+		copy(tx.Signatures[i][:], sig)
+	}
+
+	msg, err := parseMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.remaining() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after message", r.remaining())
+	}
+	if int(msg.Header.NumRequiredSignatures) != len(tx.Signatures) {
+		return nil, fmt.Errorf("message requires %d signatures, transaction has %d",
+			msg.Header.NumRequiredSignatures, len(tx.Signatures))
+	}
+	tx.Message = *msg
+
+	return tx, nil
+}
+
+// ParseMessage decodes a wire-format message, e.g. the bytes a signer
+// is asked to sign
+func ParseMessage(data []byte) (*Message, error) {
+	r := &reader{buf: data}
+	msg, err := parseMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.remaining() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after message", r.remaining())
+	}
+	return msg, nil
+}
+
+func parseMessage(r *reader) (*Message, error) {
+	header, err := r.bytes(3)
+	if err != nil {
+		return nil, fmt.Errorf("message header: %w", err)
+	}
+	versioned := header[0]&versionPrefix != 0
+	if versioned {
+		if header[0] != versionPrefix {
+			return nil, fmt.Errorf("message version %d is not supported", header[0]&^versionPrefix)
+		}
+		// The header follows the version prefix
+		rest, err := r.bytes(1)
+		if err != nil {
+			return nil, fmt.Errorf("message header: %w", err)
+		}
+		header = append(header[1:3:3], rest[0])
+	}
+	msg := &Message{Versioned: versioned, Header: MessageHeader{
+		NumRequiredSignatures:       header[0],
+		NumReadonlySignedAccounts:   header[1],
+		NumReadonlyUnsignedAccounts: header[2],
+	}}
+
+	numKeys, err := r.compactU16()
+	if err != nil {
+		return nil, fmt.Errorf("account count: %w", err)
+	}
+	for i := 0; i < numKeys; i++ {
+		key, err := r.bytes(32)
+		if err != nil {
+			return nil, fmt.Errorf("account %d: %w", i, err)
+		}
+		var pk PublicKey
+		copy(pk[:], key)
+		msg.AccountKeys = append(msg.AccountKeys, pk)
+	}
+	if msg.Header.NumRequiredSignatures == 0 {
+		return nil, fmt.Errorf("message has no fee payer")
+	}
+	if int(msg.Header.NumRequiredSignatures) > numKeys {
+		return nil, fmt.Errorf("header requires more signers than accounts")
+	}
+
+	blockhash, err := r.bytes(32)
+	if err != nil {
+		return nil, fmt.Errorf("blockhash: %w", err)
+	}
+	copy(msg.RecentBlockhash[:], blockhash)
+
+	numIx, err := r.compactU16()
+	if err != nil {
+		return nil, fmt.Errorf("instruction count: %w", err)
+	}
+	for i := 0; i < numIx; i++ {
+		var ix CompiledInstruction
+		program, err := r.bytes(1)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		ix.ProgramIDIndex = program[0]
+
+		numAccounts, err := r.compactU16()
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		accounts, err := r.bytes(numAccounts)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d accounts: %w", i, err)
+		}
+		ix.Accounts = append([]uint8(nil), accounts...)
+
+		dataLen, err := r.compactU16()
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		data, err := r.bytes(dataLen)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d data: %w", i, err)
+		}
+		ix.Data = append([]byte(nil), data...)
+
+		if int(ix.ProgramIDIndex) >= numKeys {
+			return nil, fmt.Errorf("instruction %d: program index out of range", i)
+		}
+		for _, a := range ix.Accounts {
+			if int(a) >= numKeys {
+				return nil, fmt.Errorf("instruction %d: account index out of range", i)
+			}
+		}
+		msg.Instructions = append(msg.Instructions, ix)
+	}
+
+	if versioned {
+		numLookups, err := r.compactU16()
+		if err != nil {
+			return nil, fmt.Errorf("lookup table count: %w", err)
+		}
+		// Accounts loaded from a table are not in the message, so
+		// nothing reading it could tell what the transaction touches
+		if numLookups != 0 {
+			return nil, ErrLookupTables
+		}
+	}
+
+	return msg, nil
+}
+
+// ============================================================
+// Wire Helpers
+// ============================================================
+
+// appendCompactU16 appends a Solana "shortvec" length
+func appendCompactU16(buf []byte, n int) []byte {
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(buf, b)
+		}
+		buf = append(buf, b|0x80)
+	}
+}
+
+type reader struct {
+	buf []byte
+	pos int
+}
+
+func (r *reader) remaining() int {
+	return len(r.buf) - r.pos
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.remaining() < n {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// compactU16 reads a shortvec length, accepting only the canonical
+// (shortest) encoding of a value up to 0xffff, like the runtime
+func (r *reader) compactU16() (int, error) {
+	value := 0
+	for i := 0; i < 3; i++ {
+		b, err := r.bytes(1)
+		if err != nil {
+			return 0, err
+		}
+		if i > 0 && b[0] == 0 {
+			return 0, fmt.Errorf("non-canonical compact-u16")
+		}
+		value |= int(b[0]&0x7f) << (7 * i)
+		if b[0]&0x80 == 0 {
+			if value > math.MaxUint16 {
+				return 0, fmt.Errorf("compact-u16 overflows 16 bits")
+			}
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid compact-u16")
+}
+
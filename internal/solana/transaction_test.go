@@ -0,0 +1,270 @@
+package solana
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func testKey(t *testing.T) (PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	var pk PublicKey
+	copy(pk[:], pub)
+	return pk, priv
+}
+
+func TestAppendCompactU16(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7f, []byte{0x7f}},
+		{0x80, []byte{0x80, 0x01}},
+		{0x3fff, []byte{0xff, 0x7f}},
+		{0x4000, []byte{0x80, 0x80, 0x01}},
+		{0xffff, []byte{0xff, 0xff, 0x03}},
+	}
+
+	for _, tt := range tests {
+		got := appendCompactU16(nil, tt.n)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("appendCompactU16(%#x) = %x, want %x", tt.n, got, tt.want)
+		}
+		r := &reader{buf: got}
+		n, err := r.compactU16()
+		if err != nil || n != tt.n {
+			t.Errorf("compactU16(%x) = %d, %v; want %d", got, n, err, tt.n)
+		}
+	}
+}
+
+func TestNewMessage_AccountOrder(t *testing.T) {
+	payer, _ := testKey(t)
+	owner, _ := testKey(t)
+	nonce, _ := testKey(t)
+	dest, _ := testKey(t)
+
+	msg, err := NewMessage(payer, []Instruction{
+		AdvanceNonceAccount(nonce, owner),
+		SystemTransfer(owner, dest, 1000),
+	}, Hash{1})
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+
+	// payer, owner (writable signers) | nonce, dest (writable) | sysvar, system
+	want := []PublicKey{payer, owner, nonce, dest, SysvarRecentBlockhashes, SystemProgramID}
+	if len(msg.AccountKeys) != len(want) {
+		t.Fatalf("AccountKeys = %v, want %v", msg.AccountKeys, want)
+	}
+	for i := range want {
+		if msg.AccountKeys[i] != want[i] {
+			t.Errorf("AccountKeys[%d] = %s, want %s", i, msg.AccountKeys[i], want[i])
+		}
+	}
+
+	if msg.Header != (MessageHeader{2, 0, 2}) {
+		t.Errorf("Header = %+v", msg.Header)
+	}
+	if !msg.IsWritable(1) || !msg.IsWritable(3) || msg.IsWritable(4) {
+		t.Error("IsWritable disagrees with header")
+	}
+	if msg.Instructions[0].ProgramIDIndex != 5 {
+		t.Errorf("ProgramIDIndex = %d, want 5", msg.Instructions[0].ProgramIDIndex)
+	}
+}
+
+func TestTransaction_SerializeRoundTrip(t *testing.T) {
+	payer, priv := testKey(t)
+	dest, _ := testKey(t)
+
+	msg, err := NewMessage(payer, []Instruction{SystemTransfer(payer, dest, 42)}, Hash{9})
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+	tx := NewTransaction(msg)
+	if err := tx.AddSignature(payer, ed25519.Sign(priv, msg.Serialize())); err != nil {
+		t.Fatalf("AddSignature: %v", err)
+	}
+
+	parsed, err := TransactionFromBase64(tx.ToBase64())
+	if err != nil {
+		t.Fatalf("TransactionFromBase64: %v", err)
+	}
+	if !bytes.Equal(parsed.Serialize(), tx.Serialize()) {
+		t.Error("round trip changed the transaction")
+	}
+	if parsed.Signature() != tx.Signature() {
+		t.Errorf("Signature = %s, want %s", parsed.Signature(), tx.Signature())
+	}
+	if err := parsed.VerifySignatures(); err != nil {
+		t.Errorf("VerifySignatures: %v", err)
+	}
+
+	// Trailing garbage and truncation are rejected
+	raw := tx.Serialize()
+	if _, err := ParseTransaction(append(raw, 0)); err == nil {
+		t.Error("expected error for trailing bytes")
+	}
+	if _, err := ParseTransaction(raw[:len(raw)-1]); err == nil {
+		t.Error("expected error for truncated transaction")
+	}
+}
+
+func TestTransaction_AddSignature(t *testing.T) {
+	payer, payerKey := testKey(t)
+	owner, ownerKey := testKey(t)
+	dest, _ := testKey(t)
+
+	msg, _ := NewMessage(payer, []Instruction{SystemTransfer(owner, dest, 1)}, Hash{})
+	tx := NewTransaction(msg)
+
+	if got := tx.MissingSigners(); len(got) != 2 {
+		t.Fatalf("MissingSigners = %v, want 2", got)
+	}
+
+	// Wrong key for the signer slot
+	if err := tx.AddSignature(owner, ed25519.Sign(payerKey, msg.Serialize())); err == nil {
+		t.Error("expected error for signature by the wrong key")
+	}
+	// Not a signer
+	if err := tx.AddSignature(dest, ed25519.Sign(payerKey, msg.Serialize())); err == nil {
+		t.Error("expected error for non-signer")
+	}
+
+	if err := tx.AddSignature(owner, ed25519.Sign(ownerKey, msg.Serialize())); err != nil {
+		t.Fatalf("AddSignature: %v", err)
+	}
+	missing := tx.MissingSigners()
+	if len(missing) != 1 || missing[0] != payer {
+		t.Errorf("MissingSigners = %v, want [%s]", missing, payer)
+	}
+}
+
+func TestParseTransaction_Versioned(t *testing.T) {
+	payer, _ := testKey(t)
+	dest, _ := testKey(t)
+	msg, _ := NewMessage(payer, []Instruction{SystemTransfer(payer, dest, 5)}, Hash{3})
+	msg.Versioned = true
+	raw := NewTransaction(msg).Serialize()
+	if raw[1+SignatureLength] != 0x80 || raw[len(raw)-1] != 0 {
+		t.Fatalf("v0 transaction = % x, want version prefix and no lookup tables", raw)
+	}
+
+	tx, err := ParseTransaction(raw)
+	if err != nil || !tx.Message.Versioned || tx.Message.AccountKeys[0] != payer {
+		t.Fatalf("ParseTransaction(v0) = %+v, %v", tx, err)
+	}
+	if !bytes.Equal(tx.Serialize(), raw) {
+		t.Error("v0 round trip changed the transaction")
+	}
+
+	lookup := append(append([]byte{}, raw[:len(raw)-1]...), 1)
+	lookup = append(append(lookup, make([]byte, 32)...), 1, 0, 0)
+	if _, err := ParseTransaction(lookup); !errors.Is(err, ErrLookupTables) {
+		t.Errorf("v0 with a lookup table: err = %v, want ErrLookupTables", err)
+	}
+
+	raw[1+SignatureLength] = 0x81
+	if _, err := ParseTransaction(raw); err == nil || !strings.Contains(err.Error(), "version 1") {
+		t.Errorf("v1: err = %v, want unsupported version", err)
+	}
+}
+
+func TestParseTransaction_RejectsBadLengths(t *testing.T) {
+	for name, raw := range map[string][]byte{
+		"huge signature count": {0xff, 0xff, 0x7f},
+		"count past the input": {0xff, 0xff, 0x03, 0x00},
+		"redundant zero byte":  {0x81, 0x00},
+		"over 16 bits":         {0xff, 0xff, 0x04},
+		"four bytes":           {0x80, 0x80, 0x80, 0x01},
+	} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := ParseTransaction(raw)
+		runtime.ReadMemStats(&after)
+		if err == nil {
+			t.Errorf("%s: ParseTransaction accepted % x", name, raw)
+		}
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Errorf("%s: allocated %d bytes for a %d-byte input", name, allocated, len(raw))
+		}
+	}
+}
+
+func FuzzParseTransaction(f *testing.F) {
+	payer := PublicKey{1}
+	msg, _ := NewMessage(payer, []Instruction{SystemTransfer(payer, PublicKey{2}, 5)}, Hash{3})
+	f.Add(NewTransaction(msg).Serialize())
+	f.Add([]byte{0xff, 0xff, 0x7f})
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		tx, err := ParseTransaction(raw)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(tx.Serialize(), raw) {
+			t.Errorf("parsed transaction re-serializes differently")
+		}
+	})
+}
+
+func TestTxFile_DetectsTampering(t *testing.T) {
+	payer, _ := testKey(t)
+	dest, _ := testKey(t)
+	msg, _ := NewMessage(payer, []Instruction{SystemTransfer(payer, dest, 5)}, Hash{3})
+
+	path := filepath.Join(t.TempDir(), "tx.json")
+	f := NewTxFile("devnet", "test", NewTransaction(msg), 100)
+	if err := f.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, _, err := LoadTxFile(path); err != nil {
+		t.Fatalf("LoadTxFile: %v", err)
+	}
+
+	f.Instructions = []string{"System: transfer 0.000000001 SOL to a friend"}
+	if err := f.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, _, err := LoadTxFile(path); err == nil {
+		t.Error("expected error for edited summary")
+	}
+}
+
+func TestTxFile_NonceAccount(t *testing.T) {
+	payer, _ := testKey(t)
+	nonce, _ := testKey(t)
+	dest, _ := testKey(t)
+
+	msg, _ := NewMessage(payer, []Instruction{
+		AdvanceNonceAccount(nonce, payer),
+		SystemTransfer(payer, dest, 5),
+	}, Hash{7})
+	f := NewTxFile("devnet", "", NewTransaction(msg), 100)
+
+	if f.NonceAccount != nonce.String() {
+		t.Errorf("NonceAccount = %q, want %s", f.NonceAccount, nonce)
+	}
+	if f.LastValidBlockHeight != 0 {
+		t.Errorf("LastValidBlockHeight = %d, want 0 for durable nonce", f.LastValidBlockHeight)
+	}
+
+	// Advance nonce that is not first does not count
+	msg, _ = NewMessage(payer, []Instruction{
+		SystemTransfer(payer, dest, 5),
+		AdvanceNonceAccount(nonce, payer),
+	}, Hash{7})
+	if _, ok := NonceAccountOf(msg); ok {
+		t.Error("NonceAccountOf should require the advance instruction first")
+	}
+}
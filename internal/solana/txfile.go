@@ -0,0 +1,163 @@
+// ============================================================
+// Transaction Files - Offline signing envelope
+// ============================================================
+//
+// A TxFile carries a transaction between an online host (build,
+// broadcast) and an air-gapped host (sign). The base64 wire
+// transaction is authoritative; every human-readable field is
+// re-derived from it on load, so an edited summary cannot hide
+// what is actually being signed.
+//
+// ============================================================
+
+package solana
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// TxFileVersion is the current envelope format version
+const TxFileVersion = 1
+
+// TxFile is the JSON envelope for an offline transaction
+type TxFile struct {
+	Version              int               `json:"version"`
+	Network              string            `json:"network"`
+	CreatedAt            time.Time         `json:"created_at"`
+	Description          string            `json:"description,omitempty"`
+	FeePayer             string            `json:"fee_payer"`
+	Signers              []string          `json:"signers"`
+	Blockhash            string            `json:"blockhash"`
+	NonceAccount         string            `json:"nonce_account,omitempty"`
+	LastValidBlockHeight uint64            `json:"last_valid_block_height,omitempty"`
+	Instructions         []string          `json:"instructions"`
+	Signatures           map[string]string `json:"signatures,omitempty"`
+	Transaction          string            `json:"transaction"`
+}
+
+// NewTxFile wraps a transaction in an envelope
+func NewTxFile(network, description string, tx *Transaction, lastValidBlockHeight uint64) *TxFile {
+	f := &TxFile{
+		Version:     TxFileVersion,
+		Network:     network,
+		CreatedAt:   time.Now().UTC(),
+		Description: description,
+	}
+	if _, ok := NonceAccountOf(&tx.Message); !ok {
+		f.LastValidBlockHeight = lastValidBlockHeight
+	}
+	f.SetTransaction(tx)
+	return f
+}
+
+// SetTransaction stores tx and refreshes all derived fields
+func (f *TxFile) SetTransaction(tx *Transaction) {
+	msg := &tx.Message
+
+	f.FeePayer = msg.AccountKeys[0].String()
+	f.Blockhash = msg.RecentBlockhash.String()
+	f.Signers = nil
+	f.Signatures = nil
+	for i, key := range msg.Signers() {
+		f.Signers = append(f.Signers, key.String())
+		if tx.Signatures[i] != [SignatureLength]byte{} {
+			if f.Signatures == nil {
+				f.Signatures = make(map[string]string)
+			}
+			f.Signatures[key.String()] = wallet.Base58Encode(tx.Signatures[i][:])
+		}
+	}
+
+	f.NonceAccount = ""
+	if nonce, ok := NonceAccountOf(msg); ok {
+		f.NonceAccount = nonce.String()
+	}
+
+	f.Instructions = nil
+	for _, ix := range DecodeInstructions(msg) {
+		f.Instructions = append(f.Instructions, ix.Description)
+	}
+
+	f.Transaction = tx.ToBase64()
+}
+
+// Decode parses the wire transaction and checks that the summary
+// fields match it
+func (f *TxFile) Decode() (*Transaction, error) {
+	if f.Version != TxFileVersion {
+		return nil, fmt.Errorf("unsupported transaction file version %d", f.Version)
+	}
+
+	tx, err := TransactionFromBase64(f.Transaction)
+	if err != nil {
+		return nil, fmt.Errorf("decode transaction: %w", err)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		return nil, err
+	}
+
+	expected := *f
+	expected.SetTransaction(tx)
+	if expected.FeePayer != f.FeePayer ||
+		expected.Blockhash != f.Blockhash ||
+		expected.NonceAccount != f.NonceAccount ||
+		fmt.Sprint(expected.Signers) != fmt.Sprint(f.Signers) ||
+		fmt.Sprint(expected.Signatures) != fmt.Sprint(f.Signatures) ||
+		fmt.Sprint(expected.Instructions) != fmt.Sprint(f.Instructions) {
+		return nil, fmt.Errorf("transaction file summary does not match the encoded transaction")
+	}
+
+	return tx, nil
+}
+
+// LoadTxFile reads and decodes a transaction file
+func LoadTxFile(path string) (*TxFile, *Transaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var f TxFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, nil, fmt.Errorf("parse transaction file: %w", err)
+	}
+
+	tx, err := f.Decode()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &f, tx, nil
+}
+
+// Save writes the transaction file as indented JSON
+func (f *TxFile) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal transaction file: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	return nil
+}
+
+// NonceAccountOf returns the durable nonce account if the message
+// starts with an AdvanceNonceAccount instruction
+func NonceAccountOf(msg *Message) (PublicKey, bool) {
+	if len(msg.Instructions) == 0 {
+		return PublicKey{}, false
+	}
+	ix := msg.Instructions[0]
+	if msg.AccountKeys[ix.ProgramIDIndex] != SystemProgramID ||
+		len(ix.Data) != 4 || binary.LittleEndian.Uint32(ix.Data) != systemAdvanceNonce || len(ix.Accounts) < 3 {
+		return PublicKey{}, false
+	}
+	return msg.AccountKeys[ix.Accounts[0]], true
+}
+
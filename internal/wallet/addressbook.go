@@ -0,0 +1,278 @@
+// ============================================================
+// Address Book - Labelled vendor and counterparty addresses
+// ============================================================
+//
+// Layout: ~/.machpay/addressbook.yaml
+//
+// Every saved address is a valid 32-byte base58 key on the ed25519
+// curve (unless explicitly allowed off-curve, e.g. a program vault),
+// so a label always resolves to an address a wallet can own.
+//
+// Similar-but-different addresses are flagged: address poisoning
+// plants look-alike addresses, matching the first and last few
+// characters, in a victim's history hoping they get copied.
+//
+// ============================================================
+
+package wallet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// similarChars is how many leading or trailing characters two
+// addresses must share to count as look-alikes. Wallets usually
+// abbreviate addresses to the first and last four.
+const similarChars = 4
+
+// Address book errors
+var (
+	ErrLabelExists     = fmt.Errorf("label already exists")
+	ErrLabelNotFound   = fmt.Errorf("label not found")
+	ErrAddressExists   = fmt.Errorf("address already saved")
+	ErrInvalidLabel    = fmt.Errorf("invalid label (use letters, digits, '-', '_' or '.', max 32 chars)")
+	ErrAddressOffCurve = fmt.Errorf("address is not on the ed25519 curve (a program-derived address, not a wallet)")
+)
+
+var labelPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,31}$`)
+
+// AddressEntry is a labelled address
+type AddressEntry struct {
+	Label    string    `yaml:"label" json:"label"`
+	Address  string    `yaml:"address" json:"address"`
+	Note     string    `yaml:"note,omitempty" json:"note,omitempty"`
+	OffCurve bool      `yaml:"off_curve,omitempty" json:"off_curve,omitempty"`
+	AddedAt  time.Time `yaml:"added_at" json:"added_at"`
+}
+
+// AddressBook stores labelled addresses in a YAML file
+type AddressBook struct {
+	path string
+}
+
+type addressBookFile struct {
+	Addresses []AddressEntry `yaml:"addresses"`
+}
+
+// NewAddressBook opens the address book at path
+func NewAddressBook(path string) *AddressBook {
+	return &AddressBook{path: path}
+}
+
+// ValidateLabel checks that a label can be referenced as @label
+func ValidateLabel(label string) error {
+	if !labelPattern.MatchString(label) {
+		return ErrInvalidLabel
+	}
+	return nil
+}
+
+// ValidateAddress checks that address decodes to 32 bytes and, unless
+// allowOffCurve is set, that it is a point on the ed25519 curve
+func ValidateAddress(address string, allowOffCurve bool) error {
+	key, err := ParsePublicKey(address)
+	if err != nil {
+		return err
+	}
+	if !allowOffCurve && !IsOnCurve(key) {
+		return ErrAddressOffCurve
+	}
+	return nil
+}
+
+// SimilarAddresses reports whether a and b differ but share their
+// first or last few characters
+func SimilarAddresses(a, b string) bool {
+	if a == b || len(a) < similarChars || len(b) < similarChars {
+		return false
+	}
+	return a[:similarChars] == b[:similarChars] ||
+		a[len(a)-similarChars:] == b[len(b)-similarChars:]
+}
+
+// ============================================================
+// Queries
+// ============================================================
+
+// List returns all entries sorted by label
+func (b *AddressBook) List() ([]AddressEntry, error) {
+	book, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(book.Addresses, func(i, j int) bool {
+		return book.Addresses[i].Label < book.Addresses[j].Label
+	})
+	return book.Addresses, nil
+}
+
+// Get returns the entry for a label
+func (b *AddressBook) Get(label string) (*AddressEntry, error) {
+	book, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	if i := book.findLabel(label); i >= 0 {
+		return &book.Addresses[i], nil
+	}
+	return nil, fmt.Errorf("%w: @%s", ErrLabelNotFound, label)
+}
+
+// Lookup returns the entry saved for address, or nil
+func (b *AddressBook) Lookup(address string) (*AddressEntry, error) {
+	book, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	if i := book.findAddress(address); i >= 0 {
+		return &book.Addresses[i], nil
+	}
+	return nil, nil
+}
+
+// Similar returns saved entries that look like address without being it
+func (b *AddressBook) Similar(address string) ([]AddressEntry, error) {
+	book, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	var out []AddressEntry
+	for _, e := range book.Addresses {
+		if SimilarAddresses(e.Address, address) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Labels maps every saved address to its label
+func (b *AddressBook) Labels() (map[string]string, error) {
+	book, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]string, len(book.Addresses))
+	for _, e := range book.Addresses {
+		labels[e.Address] = e.Label
+	}
+	return labels, nil
+}
+
+// ============================================================
+// Mutations
+// ============================================================
+
+// Add saves a labelled address. Each label and each address may
+// appear only once.
+func (b *AddressBook) Add(label, address, note string, allowOffCurve bool) (*AddressEntry, error) {
+	if err := ValidateLabel(label); err != nil {
+		return nil, err
+	}
+	address = strings.TrimSpace(address)
+	if err := ValidateAddress(address, allowOffCurve); err != nil {
+		return nil, err
+	}
+
+	book, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	if book.findLabel(label) >= 0 {
+		return nil, fmt.Errorf("%w: @%s", ErrLabelExists, label)
+	}
+	if i := book.findAddress(address); i >= 0 {
+		return nil, fmt.Errorf("%w as @%s", ErrAddressExists, book.Addresses[i].Label)
+	}
+
+	key, _ := ParsePublicKey(address)
+	entry := AddressEntry{
+		Label:    label,
+		Address:  address,
+		Note:     note,
+		OffCurve: !IsOnCurve(key),
+		AddedAt:  time.Now().UTC(),
+	}
+	book.Addresses = append(book.Addresses, entry)
+	if err := b.save(book); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Remove deletes a labelled address
+func (b *AddressBook) Remove(label string) error {
+	book, err := b.load()
+	if err != nil {
+		return err
+	}
+	i := book.findLabel(label)
+	if i < 0 {
+		return fmt.Errorf("%w: @%s", ErrLabelNotFound, label)
+	}
+	book.Addresses = append(book.Addresses[:i], book.Addresses[i+1:]...)
+	return b.save(book)
+}
+
+// ============================================================
+// File
+// ============================================================
+
+func (b *AddressBook) load() (*addressBookFile, error) {
+	book := &addressBookFile{}
+
+	data, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return book, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read address book: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, book); err != nil {
+		return nil, fmt.Errorf("parse address book: %w", err)
+	}
+	return book, nil
+}
+
+func (b *AddressBook) save(book *addressBookFile) error {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0700); err != nil {
+		return fmt.Errorf("create address book dir: %w", err)
+	}
+
+	data, err := yaml.Marshal(book)
+	if err != nil {
+		return fmt.Errorf("marshal address book: %w", err)
+	}
+
+	if err := os.WriteFile(b.path, data, 0600); err != nil {
+		return fmt.Errorf("write address book: %w", err)
+	}
+	return nil
+}
+
+func (f *addressBookFile) findLabel(label string) int {
+	for i := range f.Addresses {
+		if f.Addresses[i].Label == label {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *addressBookFile) findAddress(address string) int {
+	for i := range f.Addresses {
+		if f.Addresses[i].Address == address {
+			return i
+		}
+	}
+	return -1
+}
+
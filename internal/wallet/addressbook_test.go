@@ -0,0 +1,143 @@
+package wallet
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// ataAddress is an associated token account: a valid key off the curve
+const ataAddress = "DNDiJE4tFhXhoGm1wLLkvNcDHtjgiCqaPvR5uLt3Zf2P"
+
+func newTestBook(t *testing.T) *AddressBook {
+	t.Helper()
+	return NewAddressBook(filepath.Join(t.TempDir(), "addressbook.yaml"))
+}
+
+func TestAddressBook_AddGetRemove(t *testing.T) {
+	book := newTestBook(t)
+	kp, _ := Generate()
+
+	entry, err := book.Add("vendor-a", kp.PublicKeyBase58(), "inference", false)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if entry.OffCurve || entry.AddedAt.IsZero() {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	got, err := book.Get("vendor-a")
+	if err != nil || got.Address != kp.PublicKeyBase58() || got.Note != "inference" {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	if found, _ := book.Lookup(kp.PublicKeyBase58()); found == nil || found.Label != "vendor-a" {
+		t.Errorf("Lookup = %+v", found)
+	}
+
+	if _, err := book.Add("vendor-a", ataAddress, "", true); !errors.Is(err, ErrLabelExists) {
+		t.Errorf("duplicate label: err = %v", err)
+	}
+	if _, err := book.Add("vendor-b", kp.PublicKeyBase58(), "", false); !errors.Is(err, ErrAddressExists) {
+		t.Errorf("duplicate address: err = %v", err)
+	}
+
+	if err := book.Remove("vendor-a"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := book.Get("vendor-a"); !errors.Is(err, ErrLabelNotFound) {
+		t.Errorf("after Remove: err = %v", err)
+	}
+	if err := book.Remove("vendor-a"); !errors.Is(err, ErrLabelNotFound) {
+		t.Errorf("second Remove: err = %v", err)
+	}
+}
+
+func TestAddressBook_Validation(t *testing.T) {
+	book := newTestBook(t)
+
+	tests := []struct {
+		name    string
+		label   string
+		address string
+		want    error
+	}{
+		{"bad label", "no spaces", onCurveAddress(t), ErrInvalidLabel},
+		{"label with @", "@vendor", onCurveAddress(t), ErrInvalidLabel},
+		{"not base58", "vendor", "0OIl0OIl0OIl0OIl0OIl0OIl0OIl0OIl", ErrInvalidPublicKey},
+		{"wrong length", "vendor", "3yZe7d", ErrInvalidPublicKey},
+		{"off curve", "vendor", ataAddress, ErrAddressOffCurve},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := book.Add(tt.label, tt.address, "", false); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Program-owned accounts are allowed when asked for
+	entry, err := book.Add("vault", ataAddress, "", true)
+	if err != nil {
+		t.Fatalf("Add off-curve: %v", err)
+	}
+	if !entry.OffCurve {
+		t.Error("entry should be marked off-curve")
+	}
+}
+
+// onCurveAddress returns a fixed on-curve wallet address
+func onCurveAddress(t *testing.T) string {
+	t.Helper()
+	const addr = "9fH94rHgVWxXh3YTA8jYNKU6Z8xs7r76SZUyzyfmwTQb"
+	if err := ValidateAddress(addr, false); err != nil {
+		t.Fatalf("fixture address: %v", err)
+	}
+	return addr
+}
+
+func TestSimilarAddresses(t *testing.T) {
+	a := "9fH94rHgVWxXh3YTA8jYNKU6Z8xs7r76SZUyzyfmwTQb"
+	tests := []struct {
+		b    string
+		want bool
+	}{
+		{a, false},
+		{"9fH9" + "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx", true},
+		{"xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx" + "wTQb", true},
+		{"9fH8" + a[4:40] + "wTQc", false},
+		{"abc", false},
+	}
+	for _, tt := range tests {
+		if got := SimilarAddresses(a, tt.b); got != tt.want {
+			t.Errorf("SimilarAddresses(%s) = %v, want %v", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAddressBook_Similar(t *testing.T) {
+	book := newTestBook(t)
+	saved := onCurveAddress(t)
+	if _, err := book.Add("vendor", saved, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same first and last four characters, different middle
+	poisoned := saved[:10] + "z" + saved[11:]
+	if _, err := ParsePublicKey(poisoned); err != nil {
+		t.Fatalf("poisoned fixture: %v", err)
+	}
+
+	similar, err := book.Similar(poisoned)
+	if err != nil || len(similar) != 1 || similar[0].Label != "vendor" {
+		t.Errorf("Similar = %+v, %v", similar, err)
+	}
+	if similar, _ := book.Similar(saved); len(similar) != 0 {
+		t.Errorf("an address is not similar to itself: %+v", similar)
+	}
+
+	labels, _ := book.Labels()
+	if labels[saved] != "vendor" {
+		t.Errorf("Labels = %v", labels)
+	}
+}
+
@@ -18,6 +18,15 @@ var (
 
 // Base58Encode encodes a byte slice to a base58 string
 func Base58Encode(input []byte) string {
+	if len(input) == PublicKeyLength {
+		var buf [base58KeyLen]byte
+		return string(appendBase58Key(buf[:0], input))
+	}
+	return base58EncodeBig(input)
+}
+
+// base58EncodeBig encodes input of any length using math/big
+func base58EncodeBig(input []byte) string {
 	if len(input) == 0 {
 		return ""
 	}
@@ -57,6 +66,60 @@ func Base58Encode(input []byte) string {
 	return string(encoded)
 }
 
+// base58KeyLen is the longest base58 encoding of a 32-byte key
+const base58KeyLen = 44
+
+// base58Chunk is 58^5, the largest power of 58 that fits in 32 bits
+const base58Chunk = 58 * 58 * 58 * 58 * 58
+
+// appendBase58Key appends the base58 encoding of a 32-byte key to dst
+// without allocating. Hot path for addresses (e.g. vanity search).
+func appendBase58Key(dst []byte, key []byte) []byte {
+	// Big-endian 32-bit limbs
+	var limbs [8]uint32
+	for i := range limbs {
+		limbs[i] = uint32(key[4*i])<<24 | uint32(key[4*i+1])<<16 | uint32(key[4*i+2])<<8 | uint32(key[4*i+3])
+	}
+
+	// Repeated division by 58^5 yields five digits at a time, least
+	// significant first
+	var digits [base58KeyLen + 5]byte
+	n := 0
+	for start := 0; ; {
+		for start < len(limbs) && limbs[start] == 0 {
+			start++
+		}
+		if start == len(limbs) {
+			break
+		}
+		var rem uint64
+		for i := start; i < len(limbs); i++ {
+			cur := rem<<32 | uint64(limbs[i])
+			limbs[i] = uint32(cur / base58Chunk)
+			rem = cur % base58Chunk
+		}
+		for j := 0; j < 5; j++ {
+			digits[n] = byte(rem % 58)
+			rem /= 58
+			n++
+		}
+	}
+	for n > 0 && digits[n-1] == 0 {
+		n--
+	}
+
+	for _, b := range key {
+		if b != 0 {
+			break
+		}
+		dst = append(dst, alphabet[0])
+	}
+	for i := n - 1; i >= 0; i-- {
+		dst = append(dst, alphabet[digits[i]])
+	}
+	return dst
+}
+
 // Base58Decode decodes a base58 string to bytes
 func Base58Decode(input string) ([]byte, error) {
 	if len(input) == 0 {
@@ -0,0 +1,238 @@
+// ============================================================
+// Key Import - Secret key format detection
+// ============================================================
+//
+// Accepts the formats wallets commonly export:
+// - Solana CLI JSON byte array (64-byte keypair or 32-byte seed)
+// - Base58 64-byte secret key (Phantom, Solflare "export private key")
+// - Base58 32-byte seed
+// - Hex, 64 or 32 bytes
+// - BIP39 recovery phrase, derived on the Phantom/Solflare path
+//
+// Whenever a format carries the public key, it must match the one
+// derived from the seed.
+//
+// ============================================================
+
+package wallet
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyFormat identifies how a secret key was encoded
+type KeyFormat string
+
+// Supported key formats
+const (
+	FormatJSON       KeyFormat = "json"
+	FormatBase58     KeyFormat = "base58"
+	FormatBase58Seed KeyFormat = "base58-seed"
+	FormatHex        KeyFormat = "hex"
+	FormatMnemonic   KeyFormat = "mnemonic"
+)
+
+// Description returns a human-readable name for the format
+func (f KeyFormat) Description() string {
+	switch f {
+	case FormatJSON:
+		return "Solana CLI keypair (JSON)"
+	case FormatBase58:
+		return "Base58 secret key"
+	case FormatBase58Seed:
+		return "Base58 seed"
+	case FormatHex:
+		return "Hex secret key"
+	case FormatMnemonic:
+		return "Recovery phrase"
+	}
+	return string(f)
+}
+
+// SolanaDerivationPath is the path Phantom and Solflare use for the
+// first account of a recovery phrase
+const SolanaDerivationPath = "m/44'/501'/0'/0'"
+
+var (
+	// ErrPublicKeyMismatch means the key's embedded public key does not
+	// belong to its seed: the key is corrupt or was tampered with
+	ErrPublicKeyMismatch = errors.New("embedded public key does not match the secret key")
+
+	// ErrUnknownKeyFormat means no supported format matched
+	ErrUnknownKeyFormat = errors.New("unrecognized key format (expected JSON array, base58, hex or recovery phrase)")
+
+	errMnemonicChecksum = errors.New("recovery phrase checksum mismatch (typo?)")
+)
+
+// ParseKeypair decodes a secret key in any supported format
+func ParseKeypair(data []byte) (*Keypair, KeyFormat, error) {
+	text := strings.TrimSpace(string(data))
+
+	switch {
+	case strings.HasPrefix(text, "["), strings.HasPrefix(text, `"`):
+		// A JSON string is the base64 form older MachPay versions wrote
+		var raw []byte
+		if err := json.Unmarshal([]byte(text), &raw); err != nil {
+			return nil, "", fmt.Errorf("parse keypair JSON: %w", err)
+		}
+		kp, err := keypairFromBytes(raw)
+		return kp, FormatJSON, err
+
+	case len(strings.Fields(text)) > 1:
+		kp, err := KeypairFromMnemonic(text)
+		return kp, FormatMnemonic, err
+
+	case isHexKey(text):
+		raw, err := hex.DecodeString(strings.TrimPrefix(text, "0x"))
+		if err != nil {
+			return nil, "", fmt.Errorf("parse hex key: %w", err)
+		}
+		kp, err := keypairFromBytes(raw)
+		return kp, FormatHex, err
+
+	case text != "":
+		raw, err := Base58Decode(text)
+		if err != nil {
+			return nil, "", ErrUnknownKeyFormat
+		}
+		format := FormatBase58
+		if len(raw) == ed25519.SeedSize {
+			format = FormatBase58Seed
+		}
+		kp, err := keypairFromBytes(raw)
+		return kp, format, err
+	}
+
+	return nil, "", ErrUnknownKeyFormat
+}
+
+// isHexKey reports whether s is a 32- or 64-byte hex string. Base58
+// keys are 43-44 or 87-88 characters, so the lengths never overlap.
+func isHexKey(s string) bool {
+	s = strings.TrimPrefix(s, "0x")
+	if len(s) != 2*ed25519.SeedSize && len(s) != 2*ed25519.PrivateKeySize {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// keypairFromBytes builds a keypair from a 32-byte seed or a 64-byte
+// seed||public key, checking the public key half of the latter
+func keypairFromBytes(raw []byte) (*Keypair, error) {
+	switch len(raw) {
+	case ed25519.SeedSize:
+		priv := ed25519.NewKeyFromSeed(raw)
+		return &Keypair{PublicKey: priv.Public().(ed25519.PublicKey), PrivateKey: priv}, nil
+	case ed25519.PrivateKeySize:
+		priv := ed25519.NewKeyFromSeed(raw[:ed25519.SeedSize])
+		pub := priv.Public().(ed25519.PublicKey)
+		if !bytes.Equal(pub, raw[ed25519.SeedSize:]) {
+			return nil, ErrPublicKeyMismatch
+		}
+		return &Keypair{PublicKey: pub, PrivateKey: priv}, nil
+	}
+	return nil, fmt.Errorf("invalid key length: got %d bytes, want 64 or 32", len(raw))
+}
+
+// ============================================================
+// Recovery Phrases
+// ============================================================
+
+// KeypairFromMnemonic derives the first account of a BIP39 English
+// recovery phrase on SolanaDerivationPath
+func KeypairFromMnemonic(mnemonic string) (*Keypair, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if err := validateMnemonic(words); err != nil {
+		return nil, err
+	}
+
+	seed := pbkdf2SHA512([]byte(strings.Join(words, " ")), []byte("mnemonic"), 2048)
+	key, chain := slip10Master(seed)
+	for _, index := range []uint32{44, 501, 0, 0} {
+		key, chain = slip10Child(key, chain, index)
+	}
+	return keypairFromBytes(key)
+}
+
+// validateMnemonic checks the word count, words and checksum
+func validateMnemonic(words []string) error {
+	n := len(words)
+	if n < 12 || n > 24 || n%3 != 0 {
+		return fmt.Errorf("recovery phrase must have 12, 15, 18, 21 or 24 words, got %d", n)
+	}
+
+	raw := make([]byte, (n*11+7)/8)
+	for i, w := range words {
+		v, ok := wordIndex[w]
+		if !ok {
+			return fmt.Errorf("%w %q (word %d)", errBadWord, w, i+1)
+		}
+		writeBits(raw, i*11, 11, v)
+	}
+
+	entropyBits := n * 11 * 32 / 33
+	checksumBits := n * 11 / 33
+	sum := sha256.Sum256(raw[:entropyBits/8])
+	if readBits(raw, entropyBits, checksumBits) != readBits(sum[:], 0, checksumBits) {
+		return errMnemonicChecksum
+	}
+	return nil
+}
+
+// pbkdf2SHA512 is PBKDF2 with HMAC-SHA512 for a single 64-byte block,
+// which is all BIP39 needs
+func pbkdf2SHA512(password, salt []byte, iterations int) []byte {
+	mac := hmac.New(sha512.New, password)
+	mac.Write(salt)
+	mac.Write([]byte{0, 0, 0, 1})
+	u := mac.Sum(nil)
+
+	out := make([]byte, len(u))
+	copy(out, u)
+	for i := 1; i < iterations; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range out {
+			out[j] ^= u[j]
+		}
+	}
+	return out
+}
+
+// slip10Master derives the SLIP-0010 ed25519 master key and chain code
+func slip10Master(seed []byte) (key, chain []byte) {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
+// slip10Child derives a hardened child; ed25519 has no unhardened
+// derivation, so index is always hardened
+func slip10Child(key, chain []byte, index uint32) ([]byte, []byte) {
+	var data [1 + 32 + 4]byte
+	copy(data[1:], key)
+	binary.BigEndian.PutUint32(data[33:], index|0x80000000)
+
+	mac := hmac.New(sha512.New, chain)
+	mac.Write(data[:])
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
@@ -0,0 +1,153 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const abandonMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestPBKDF2SHA512_BIP39Vector(t *testing.T) {
+	seed := pbkdf2SHA512([]byte(abandonMnemonic), []byte("mnemonic"), 2048)
+	want := "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4"
+	if got := hex.EncodeToString(seed); got != want {
+		t.Errorf("seed = %s, want %s", got, want)
+	}
+}
+
+func TestSLIP10_Vector1(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	key, chain := slip10Master(seed)
+	if hex.EncodeToString(key) != "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7" ||
+		hex.EncodeToString(chain) != "90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb" {
+		t.Fatalf("master = %x / %x", key, chain)
+	}
+	key, chain = slip10Child(key, chain, 0)
+	if hex.EncodeToString(key) != "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3" ||
+		hex.EncodeToString(chain) != "8b59aa11380b624e81507a27fedda59fea6d0b779a778918a2fd3590e16e9c69" {
+		t.Errorf("m/0' = %x / %x", key, chain)
+	}
+}
+
+func TestKeypairFromMnemonic(t *testing.T) {
+	kp, err := KeypairFromMnemonic("  Abandon abandon abandon abandon abandon abandon\nabandon abandon abandon abandon abandon about ")
+	if err != nil {
+		t.Fatalf("KeypairFromMnemonic failed: %v", err)
+	}
+	// Address Phantom shows for this phrase
+	if got := kp.PublicKeyBase58(); got != "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk" {
+		t.Errorf("address = %s", got)
+	}
+
+	bad := strings.Replace(abandonMnemonic, "about", "abandon", 1)
+	if _, err := KeypairFromMnemonic(bad); !errors.Is(err, errMnemonicChecksum) {
+		t.Errorf("Expected checksum error, got %v", err)
+	}
+	if _, err := KeypairFromMnemonic("abandon about"); err == nil {
+		t.Error("Expected error for short phrase")
+	}
+	if _, err := KeypairFromMnemonic(strings.Replace(abandonMnemonic, "about", "aboot", 1)); !errors.Is(err, errBadWord) {
+		t.Errorf("Expected unknown word error, got %v", err)
+	}
+}
+
+func TestParseKeypair_Formats(t *testing.T) {
+	kp, _ := Generate()
+	seed := kp.PrivateKey.Seed()
+	array, _ := json.Marshal(toNumbers(kp.PrivateKey))
+	legacy, _ := json.Marshal([]byte(kp.PrivateKey))
+
+	tests := []struct {
+		name   string
+		input  string
+		format KeyFormat
+	}{
+		{"json array", string(array), FormatJSON},
+		{"json array, seed only", mustJSON(toNumbers(seed)), FormatJSON},
+		{"legacy base64 json", string(legacy), FormatJSON},
+		{"base58 secret", Base58Encode(kp.PrivateKey) + "\n", FormatBase58},
+		{"base58 seed", Base58Encode(seed), FormatBase58Seed},
+		{"hex secret", hex.EncodeToString(kp.PrivateKey), FormatHex},
+		{"hex seed with prefix", "0x" + hex.EncodeToString(seed), FormatHex},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, format, err := ParseKeypair([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("ParseKeypair failed: %v", err)
+			}
+			if format != tt.format {
+				t.Errorf("format = %s, want %s", format, tt.format)
+			}
+			if got.PublicKeyBase58() != kp.PublicKeyBase58() {
+				t.Errorf("address = %s, want %s", got.PublicKeyBase58(), kp.PublicKeyBase58())
+			}
+		})
+	}
+
+	if _, format, err := ParseKeypair([]byte(abandonMnemonic)); err != nil || format != FormatMnemonic {
+		t.Errorf("mnemonic: format %s, err %v", format, err)
+	}
+}
+
+func TestParseKeypair_PublicKeyMismatch(t *testing.T) {
+	kp, _ := Generate()
+	other, _ := Generate()
+	forged := append(append([]byte{}, kp.PrivateKey.Seed()...), other.PublicKey...)
+
+	for name, input := range map[string]string{
+		"json":   mustJSON(toNumbers(forged)),
+		"base58": Base58Encode(forged),
+		"hex":    hex.EncodeToString(forged),
+	} {
+		if _, _, err := ParseKeypair([]byte(input)); !errors.Is(err, ErrPublicKeyMismatch) {
+			t.Errorf("%s: expected ErrPublicKeyMismatch, got %v", name, err)
+		}
+	}
+}
+
+func TestParseKeypair_Invalid(t *testing.T) {
+	for _, input := range []string{"", "not-a-key!", "[1,2,3]", Base58Encode([]byte("short"))} {
+		if _, _, err := ParseKeypair([]byte(input)); err == nil {
+			t.Errorf("ParseKeypair(%q) should fail", input)
+		}
+	}
+}
+
+func TestSaveToFile_SolanaCLIFormat(t *testing.T) {
+	kp, _ := Generate()
+	path := filepath.Join(t.TempDir(), "id.json")
+	if err := kp.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var numbers []int
+	if err := json.Unmarshal(data, &numbers); err != nil || len(numbers) != 64 {
+		t.Fatalf("file is not a 64-number JSON array: %s", data)
+	}
+
+	_, format, err := LoadKeyFile(path)
+	if err != nil || format != FormatJSON {
+		t.Errorf("LoadKeyFile: format %s, err %v", format, err)
+	}
+}
+
+func toNumbers(b []byte) []int {
+	out := make([]int, len(b))
+	for i, v := range b {
+		out[i] = int(v)
+	}
+	return out
+}
+
+func mustJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
@@ -0,0 +1,199 @@
+// ============================================================
+// Program Derived Addresses - Offline PDA and ATA derivation
+// ============================================================
+//
+// Implements Solana's create_program_address / find_program_address
+// and the associated token account (ATA) derivation on top of them.
+//
+// A PDA must NOT be a valid ed25519 point, so derivation needs an
+// on-curve check. It is done with math/big over the curve25519 field
+// to keep the package free of external crypto dependencies.
+//
+// ============================================================
+
+package wallet
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// PublicKeyLength is the size of a Solana public key in bytes
+	PublicKeyLength = 32
+
+	// MaxSeeds is the maximum number of seeds for a program address
+	MaxSeeds = 16
+
+	// MaxSeedLength is the maximum length of a single seed in bytes
+	MaxSeedLength = 32
+
+	// pdaMarker is appended to the hash input for program addresses
+	pdaMarker = "ProgramDerivedAddress"
+)
+
+// Well-known program IDs
+const (
+	SystemProgramID                 = "11111111111111111111111111111111"
+	TokenProgramID                  = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
+	Token2022ProgramID              = "TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb"
+	AssociatedTokenAccountProgramID = "ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL"
+)
+
+// PDA errors
+var (
+	ErrMaxSeedsExceeded = fmt.Errorf("too many seeds (max %d)", MaxSeeds)
+	ErrMaxSeedLength    = fmt.Errorf("seed too long (max %d bytes)", MaxSeedLength)
+	ErrInvalidSeeds     = fmt.Errorf("seeds produce a point on the ed25519 curve")
+	ErrNoViableBumpSeed = fmt.Errorf("unable to find a viable program address bump seed")
+	ErrInvalidPublicKey = fmt.Errorf("invalid public key")
+)
+
+// ParsePublicKey decodes a base58 Solana address into its 32 raw bytes
+func ParsePublicKey(address string) ([]byte, error) {
+	key, err := Base58Decode(address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+	if len(key) != PublicKeyLength {
+		return nil, fmt.Errorf("%w: got %d bytes, want %d", ErrInvalidPublicKey, len(key), PublicKeyLength)
+	}
+	return key, nil
+}
+
+// CreateProgramAddress derives a program address from seeds and a program ID.
+// It returns ErrInvalidSeeds if the resulting address lies on the curve.
+func CreateProgramAddress(seeds [][]byte, programID []byte) ([]byte, error) {
+	if len(seeds) > MaxSeeds {
+		return nil, ErrMaxSeedsExceeded
+	}
+
+	h := sha256.New()
+	for _, seed := range seeds {
+		if len(seed) > MaxSeedLength {
+			return nil, ErrMaxSeedLength
+		}
+		h.Write(seed)
+	}
+	h.Write(programID)
+	h.Write([]byte(pdaMarker))
+	address := h.Sum(nil)
+
+	if IsOnCurve(address) {
+		return nil, ErrInvalidSeeds
+	}
+	return address, nil
+}
+
+// FindProgramAddress searches for a valid program address, starting with
+// bump seed 255 and counting down. It returns the address and its bump.
+func FindProgramAddress(seeds [][]byte, programID []byte) ([]byte, uint8, error) {
+	if len(seeds) >= MaxSeeds {
+		return nil, 0, ErrMaxSeedsExceeded
+	}
+
+	withBump := make([][]byte, len(seeds)+1)
+	copy(withBump, seeds)
+
+	for bump := 255; bump >= 0; bump-- {
+		withBump[len(seeds)] = []byte{byte(bump)}
+		address, err := CreateProgramAddress(withBump, programID)
+		if err == nil {
+			return address, uint8(bump), nil
+		}
+		if err != ErrInvalidSeeds {
+			return nil, 0, err
+		}
+	}
+
+	return nil, 0, ErrNoViableBumpSeed
+}
+
+// FindAssociatedTokenAddress derives the associated token account for an
+// owner and mint under the given token program (classic SPL Token or
+// Token-2022). All arguments are base58 addresses.
+func FindAssociatedTokenAddress(owner, mint, tokenProgram string) (string, uint8, error) {
+	ownerKey, err := ParsePublicKey(owner)
+	if err != nil {
+		return "", 0, fmt.Errorf("owner: %w", err)
+	}
+	mintKey, err := ParsePublicKey(mint)
+	if err != nil {
+		return "", 0, fmt.Errorf("mint: %w", err)
+	}
+	tokenProgramKey, err := ParsePublicKey(tokenProgram)
+	if err != nil {
+		return "", 0, fmt.Errorf("token program: %w", err)
+	}
+	ataProgramKey, _ := ParsePublicKey(AssociatedTokenAccountProgramID)
+
+	address, bump, err := FindProgramAddress(
+		[][]byte{ownerKey, tokenProgramKey, mintKey},
+		ataProgramKey,
+	)
+	if err != nil {
+		return "", 0, err
+	}
+	return Base58Encode(address), bump, nil
+}
+
+// ============================================================
+// Ed25519 On-Curve Check
+// ============================================================
+
+var (
+	// Field prime p = 2^255 - 19
+	curveP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+	// Edwards curve constant d = -121665/121666 mod p
+	curveD = func() *big.Int {
+		num := new(big.Int).Neg(big.NewInt(121665))
+		den := new(big.Int).ModInverse(big.NewInt(121666), curveP)
+		d := num.Mul(num, den)
+		return d.Mod(d, curveP)
+	}()
+)
+
+// IsOnCurve reports whether a 32-byte compressed Edwards Y point
+// decompresses to a valid ed25519 point. It mirrors the behaviour of
+// curve25519-dalek, which Solana uses: the y coordinate is reduced
+// mod p and the point is valid when x^2 = (y^2-1)/(d*y^2+1) has a root.
+func IsOnCurve(key []byte) bool {
+	if len(key) != PublicKeyLength {
+		return false
+	}
+
+	// y is little-endian; reverse it for big.Int and drop the sign bit
+	be := make([]byte, PublicKeyLength)
+	for i := range key {
+		be[PublicKeyLength-1-i] = key[i]
+	}
+	be[0] &= 0x7f
+	y := new(big.Int).SetBytes(be)
+	y.Mod(y, curveP)
+
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, curveP)
+
+	// u = y^2 - 1, v = d*y^2 + 1
+	u := new(big.Int).Sub(y2, big.NewInt(1))
+	u.Mod(u, curveP)
+	v := new(big.Int).Mul(curveD, y2)
+	v.Add(v, big.NewInt(1))
+	v.Mod(v, curveP)
+
+	if u.Sign() == 0 {
+		return true
+	}
+
+	vInv := new(big.Int).ModInverse(v, curveP)
+	if vInv == nil {
+		return false
+	}
+	x2 := u.Mul(u, vInv)
+	x2.Mod(x2, curveP)
+
+	return big.Jacobi(x2, curveP) == 1
+}
+
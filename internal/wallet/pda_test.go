@@ -0,0 +1,156 @@
+package wallet
+
+import (
+	"testing"
+)
+
+func TestCreateProgramAddress(t *testing.T) {
+	// Vectors from @solana/web3.js
+	programID, err := ParsePublicKey("BPFLoader1111111111111111111111111111111111")
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+	seedKey, err := ParsePublicKey("SeedPubey1111111111111111111111111111111111")
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		seeds [][]byte
+		want  string
+	}{
+		{"empty seed with bump", [][]byte{[]byte(""), {1}}, "3gF2KMe9KiC6FNVBmfg9i267aMPvK37FewCip4eGBFcT"},
+		{"unicode seed", [][]byte{[]byte("☉")}, "7ytmC1nT1xY4RfxCV2ZgyA7UakC93do5ZdyhdF3EtPj7"},
+		{"two seeds", [][]byte{[]byte("Talking"), []byte("Squirrels")}, "HwRVBufQ4haG5XSgpspwKtNd3PC9GM9m1196uJW36vds"},
+		{"public key seed", [][]byte{seedKey}, "GUs5qLUfsEHkcMB9T38vjr18ypEhRuNWiePW2LoK4E3K"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CreateProgramAddress(tt.seeds, programID)
+			if err != nil {
+				t.Fatalf("CreateProgramAddress failed: %v", err)
+			}
+			if Base58Encode(got) != tt.want {
+				t.Errorf("CreateProgramAddress = %s, want %s", Base58Encode(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateProgramAddress_SeedLimits(t *testing.T) {
+	programID, _ := ParsePublicKey(SystemProgramID)
+
+	long := make([]byte, MaxSeedLength+1)
+	if _, err := CreateProgramAddress([][]byte{long}, programID); err != ErrMaxSeedLength {
+		t.Errorf("Expected ErrMaxSeedLength, got %v", err)
+	}
+
+	many := make([][]byte, MaxSeeds+1)
+	if _, err := CreateProgramAddress(many, programID); err != ErrMaxSeedsExceeded {
+		t.Errorf("Expected ErrMaxSeedsExceeded, got %v", err)
+	}
+}
+
+func TestFindProgramAddress(t *testing.T) {
+	programID, _ := ParsePublicKey("BPFLoader1111111111111111111111111111111111")
+	seeds := [][]byte{[]byte("")}
+
+	address, bump, err := FindProgramAddress(seeds, programID)
+	if err != nil {
+		t.Fatalf("FindProgramAddress failed: %v", err)
+	}
+
+	// The result must match create_program_address with the bump appended
+	expected, err := CreateProgramAddress([][]byte{[]byte(""), {bump}}, programID)
+	if err != nil {
+		t.Fatalf("CreateProgramAddress failed: %v", err)
+	}
+	if Base58Encode(address) != Base58Encode(expected) {
+		t.Errorf("FindProgramAddress = %s, want %s", Base58Encode(address), Base58Encode(expected))
+	}
+	if IsOnCurve(address) {
+		t.Error("Program address must be off curve")
+	}
+}
+
+func TestFindAssociatedTokenAddress(t *testing.T) {
+	tests := []struct {
+		name  string
+		owner string
+		mint  string
+		want  string
+	}{
+		{
+			// Vector from @solana/spl-token
+			name:  "spl-token vector",
+			owner: "B8UwBUUnKwCyKuGMbFKWaG7exYdDk2ozZrPg72NyVbfj",
+			mint:  "7o36UsWR1JQLpZ9PE2gn9L4SQ69CNNiWAXd4Jt7rqz9Z",
+			want:  "DShWnroshVbeUp28oopA3Pu7oFPDBtC1DBmPECXXAQ9n",
+		},
+		{
+			// Mainnet USDC held by the Solana Pay spec's example merchant
+			name:  "mainnet USDC",
+			owner: "mvines9iiHiQTysrwkJjGf2gb9Ex9jXJX8ns3qwf2kN",
+			mint:  "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+			want:  "5ZGPSxMzV9xV5s3Wep73r8k5MsPAtLYs11dGDdknznM5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := FindAssociatedTokenAddress(tt.owner, tt.mint, TokenProgramID)
+			if err != nil {
+				t.Fatalf("FindAssociatedTokenAddress failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FindAssociatedTokenAddress = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindAssociatedTokenAddress_InvalidOwner(t *testing.T) {
+	_, _, err := FindAssociatedTokenAddress("not-an-address", TokenProgramID, TokenProgramID)
+	if err == nil {
+		t.Error("Expected error for invalid owner")
+	}
+}
+
+func TestIsOnCurve(t *testing.T) {
+	// Real ed25519 public keys are on the curve
+	kp, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if !IsOnCurve(kp.PublicKey) {
+		t.Error("Generated public key should be on curve")
+	}
+
+	// Known PDA (associated token account) is off the curve
+	ata, _ := ParsePublicKey("DShWnroshVbeUp28oopA3Pu7oFPDBtC1DBmPECXXAQ9n")
+	if IsOnCurve(ata) {
+		t.Error("Associated token account should be off curve")
+	}
+
+	// Wrong length is never on the curve
+	if IsOnCurve([]byte{1, 2, 3}) {
+		t.Error("Short key should not be on curve")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key, err := ParsePublicKey(TokenProgramID)
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+	if len(key) != PublicKeyLength {
+		t.Errorf("Key length = %d, want %d", len(key), PublicKeyLength)
+	}
+
+	if _, err := ParsePublicKey("abc"); err == nil {
+		t.Error("Expected error for short key")
+	}
+}
+
@@ -0,0 +1,334 @@
+// ============================================================
+// Wallet Registry - Multiple named wallets
+// ============================================================
+//
+// Layout (under ~/.machpay/wallets):
+//   <name>.json     Keypair in Solana CLI format
+//   wallets.yaml    Metadata for every registered wallet
+//
+// Wallets can also be "linked": the metadata points at a keypair
+// file elsewhere on disk that the registry never copies or deletes.
+//
+// ============================================================
+
+package wallet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Wallet sources
+const (
+	SourceGenerated = "generated"
+	SourceImported  = "imported"
+	SourceLinked    = "linked"
+)
+
+// Registry errors
+var (
+	ErrWalletExists   = fmt.Errorf("wallet already exists")
+	ErrWalletNotFound = fmt.Errorf("wallet not found")
+	ErrInvalidName    = fmt.Errorf("invalid wallet name (use letters, digits, '-' or '_', max 32 chars)")
+)
+
+var walletNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,31}$`)
+
+// WalletInfo is the metadata stored for a registered wallet
+type WalletInfo struct {
+	Name      string    `yaml:"name"`
+	PublicKey string    `yaml:"public_key"`
+	Path      string    `yaml:"path"`
+	Source    string    `yaml:"source"`
+	CreatedAt time.Time `yaml:"created_at"`
+}
+
+// Linked reports whether the keypair file lives outside the registry
+func (w *WalletInfo) Linked() bool {
+	return w.Source == SourceLinked
+}
+
+// Registry manages named wallets in a directory
+type Registry struct {
+	dir string
+}
+
+type registryFile struct {
+	Wallets []WalletInfo `yaml:"wallets"`
+}
+
+// NewRegistry creates a registry rooted at dir
+func NewRegistry(dir string) *Registry {
+	return &Registry{dir: dir}
+}
+
+// Dir returns the registry directory
+func (r *Registry) Dir() string {
+	return r.dir
+}
+
+// ValidateName checks that a wallet name is usable as a file name
+func ValidateName(name string) error {
+	if !walletNamePattern.MatchString(name) {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+// ============================================================
+// Queries
+// ============================================================
+
+// List returns all registered wallets sorted by name
+func (r *Registry) List() ([]WalletInfo, error) {
+	index, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(index.Wallets, func(i, j int) bool {
+		return index.Wallets[i].Name < index.Wallets[j].Name
+	})
+	return index.Wallets, nil
+}
+
+// Get returns the metadata for a named wallet
+func (r *Registry) Get(name string) (*WalletInfo, error) {
+	index, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range index.Wallets {
+		if index.Wallets[i].Name == name {
+			return &index.Wallets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrWalletNotFound, name)
+}
+
+// FindByPublicKey returns the first wallet with the given address
+func (r *Registry) FindByPublicKey(publicKey string) (*WalletInfo, error) {
+	index, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range index.Wallets {
+		if index.Wallets[i].PublicKey == publicKey {
+			return &index.Wallets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrWalletNotFound, publicKey)
+}
+
+// Load reads the keypair for a named wallet
+func (r *Registry) Load(name string) (*Keypair, error) {
+	info, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return LoadFromFile(info.Path)
+}
+
+// ============================================================
+// Mutations
+// ============================================================
+
+// Add saves a keypair under a new name. It never overwrites an
+// existing wallet or keypair file.
+func (r *Registry) Add(name string, kp *Keypair, source string) (*WalletInfo, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	index, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	if index.find(name) >= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrWalletExists, name)
+	}
+
+	path := filepath.Join(r.dir, name+".json")
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrWalletExists, path)
+	}
+	if err := kp.SaveToFile(path); err != nil {
+		return nil, err
+	}
+
+	info := WalletInfo{
+		Name:      name,
+		PublicKey: kp.PublicKeyBase58(),
+		Path:      path,
+		Source:    source,
+		CreatedAt: time.Now().UTC(),
+	}
+	index.Wallets = append(index.Wallets, info)
+	if err := r.save(index); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Link registers an existing keypair file without copying it
+func (r *Registry) Link(name, path string) (*WalletInfo, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	kp, err := LoadFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	if index.find(name) >= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrWalletExists, name)
+	}
+
+	info := WalletInfo{
+		Name:      name,
+		PublicKey: kp.PublicKeyBase58(),
+		Path:      expandHome(path),
+		Source:    SourceLinked,
+		CreatedAt: time.Now().UTC(),
+	}
+	index.Wallets = append(index.Wallets, info)
+	if err := r.save(index); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Rename changes a wallet's name and moves its keypair file
+func (r *Registry) Rename(oldName, newName string) (*WalletInfo, error) {
+	if err := ValidateName(newName); err != nil {
+		return nil, err
+	}
+
+	index, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	i := index.find(oldName)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrWalletNotFound, oldName)
+	}
+	if index.find(newName) >= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrWalletExists, newName)
+	}
+
+	info := &index.Wallets[i]
+	if !info.Linked() {
+		newPath := filepath.Join(r.dir, newName+".json")
+		if _, err := os.Stat(newPath); err == nil {
+			return nil, fmt.Errorf("%w: %s", ErrWalletExists, newPath)
+		}
+		if err := os.Rename(info.Path, newPath); err != nil {
+			return nil, fmt.Errorf("move keypair: %w", err)
+		}
+		info.Path = newPath
+	}
+	info.Name = newName
+
+	if err := r.save(index); err != nil {
+		return nil, err
+	}
+	renamed := *info
+	return &renamed, nil
+}
+
+// Remove unregisters a wallet. Keypair files owned by the registry
+// are deleted; linked files are left untouched.
+func (r *Registry) Remove(name string) error {
+	index, err := r.load()
+	if err != nil {
+		return err
+	}
+	i := index.find(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrWalletNotFound, name)
+	}
+
+	info := index.Wallets[i]
+	if !info.Linked() {
+		if err := os.Remove(info.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("delete keypair: %w", err)
+		}
+	}
+
+	index.Wallets = append(index.Wallets[:i], index.Wallets[i+1:]...)
+	return r.save(index)
+}
+
+// UniqueName returns base, or base-2, base-3, ... if base is taken
+func (r *Registry) UniqueName(base string) (string, error) {
+	index, err := r.load()
+	if err != nil {
+		return "", err
+	}
+	name := base
+	for n := 2; index.find(name) >= 0; n++ {
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	return name, nil
+}
+
+// ============================================================
+// Index File
+// ============================================================
+
+func (r *Registry) indexPath() string {
+	return filepath.Join(r.dir, "wallets.yaml")
+}
+
+func (r *Registry) load() (*registryFile, error) {
+	index := &registryFile{}
+
+	data, err := os.ReadFile(r.indexPath())
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read wallet index: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("parse wallet index: %w", err)
+	}
+	return index, nil
+}
+
+func (r *Registry) save(index *registryFile) error {
+	if err := os.MkdirAll(r.dir, 0700); err != nil {
+		return fmt.Errorf("create wallet dir: %w", err)
+	}
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("marshal wallet index: %w", err)
+	}
+
+	if err := os.WriteFile(r.indexPath(), data, 0600); err != nil {
+		return fmt.Errorf("write wallet index: %w", err)
+	}
+	return nil
+}
+
+func (f *registryFile) find(name string) int {
+	for i := range f.Wallets {
+		if f.Wallets[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
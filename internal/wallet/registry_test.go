@@ -0,0 +1,160 @@
+package wallet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry_AddAndGet(t *testing.T) {
+	reg := NewRegistry(t.TempDir())
+
+	kp, _ := Generate()
+	info, err := reg.Add("agent", kp, SourceGenerated)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if info.PublicKey != kp.PublicKeyBase58() {
+		t.Errorf("PublicKey = %s, want %s", info.PublicKey, kp.PublicKeyBase58())
+	}
+
+	got, err := reg.Get("agent")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Path != filepath.Join(reg.Dir(), "agent.json") {
+		t.Errorf("Path = %s, want agent.json in registry", got.Path)
+	}
+
+	loaded, err := reg.Load("agent")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.PublicKeyBase58() != kp.PublicKeyBase58() {
+		t.Error("Loaded keypair does not match")
+	}
+}
+
+func TestRegistry_AddNeverOverwrites(t *testing.T) {
+	reg := NewRegistry(t.TempDir())
+
+	first, _ := Generate()
+	if _, err := reg.Add("main", first, SourceGenerated); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	second, _ := Generate()
+	if _, err := reg.Add("main", second, SourceGenerated); !errors.Is(err, ErrWalletExists) {
+		t.Errorf("Expected ErrWalletExists, got %v", err)
+	}
+
+	// An unregistered file at the target path must not be clobbered either
+	stray := filepath.Join(reg.Dir(), "stray.json")
+	if err := os.WriteFile(stray, []byte("keep me"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := reg.Add("stray", second, SourceGenerated); !errors.Is(err, ErrWalletExists) {
+		t.Errorf("Expected ErrWalletExists for stray file, got %v", err)
+	}
+
+	loaded, _ := reg.Load("main")
+	if loaded.PublicKeyBase58() != first.PublicKeyBase58() {
+		t.Error("Original keypair was overwritten")
+	}
+}
+
+func TestRegistry_InvalidName(t *testing.T) {
+	reg := NewRegistry(t.TempDir())
+	kp, _ := Generate()
+
+	for _, name := range []string{"", "../escape", "has space", "-leading"} {
+		if _, err := reg.Add(name, kp, SourceGenerated); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Add(%q) error = %v, want ErrInvalidName", name, err)
+		}
+	}
+}
+
+func TestRegistry_Link(t *testing.T) {
+	reg := NewRegistry(t.TempDir())
+
+	external := filepath.Join(t.TempDir(), "id.json")
+	kp, _ := Generate()
+	if err := kp.SaveToFile(external); err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+
+	info, err := reg.Link("cli", external)
+	if err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+	if !info.Linked() || info.Path != external {
+		t.Errorf("Linked wallet = %+v, want path %s", info, external)
+	}
+
+	// Removing a linked wallet keeps the file
+	if err := reg.Remove("cli"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(external); err != nil {
+		t.Error("Linked keypair file should not be deleted")
+	}
+}
+
+func TestRegistry_Rename(t *testing.T) {
+	reg := NewRegistry(t.TempDir())
+	kp, _ := Generate()
+	reg.Add("old", kp, SourceGenerated)
+
+	info, err := reg.Rename("old", "new")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if info.Name != "new" || filepath.Base(info.Path) != "new.json" {
+		t.Errorf("Renamed wallet = %+v", info)
+	}
+	if _, err := reg.Get("old"); !errors.Is(err, ErrWalletNotFound) {
+		t.Errorf("Old name should be gone, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(reg.Dir(), "old.json")); !os.IsNotExist(err) {
+		t.Error("Old keypair file should be moved")
+	}
+}
+
+func TestRegistry_RemoveAndList(t *testing.T) {
+	reg := NewRegistry(t.TempDir())
+	for _, name := range []string{"b", "a", "c"} {
+		kp, _ := Generate()
+		reg.Add(name, kp, SourceGenerated)
+	}
+
+	if err := reg.Remove("b"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(reg.Dir(), "b.json")); !os.IsNotExist(err) {
+		t.Error("Keypair file should be deleted")
+	}
+
+	wallets, err := reg.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(wallets) != 2 || wallets[0].Name != "a" || wallets[1].Name != "c" {
+		t.Errorf("List = %+v, want [a c]", wallets)
+	}
+}
+
+func TestRegistry_UniqueName(t *testing.T) {
+	reg := NewRegistry(t.TempDir())
+	kp, _ := Generate()
+	reg.Add("agent", kp, SourceGenerated)
+
+	name, err := reg.UniqueName("agent")
+	if err != nil {
+		t.Fatalf("UniqueName failed: %v", err)
+	}
+	if name != "agent-2" {
+		t.Errorf("UniqueName = %s, want agent-2", name)
+	}
+}
+
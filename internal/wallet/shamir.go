@@ -0,0 +1,324 @@
+// ============================================================
+// Shamir Backup - Split a wallet seed into recovery shares
+// ============================================================
+//
+// The 32-byte ed25519 seed is split with Shamir's secret sharing
+// over GF(2^8): any threshold shares recover it, fewer reveal
+// nothing. Each share is self-describing:
+//
+//   version[1] | key id[2] | threshold[1] | index[1] | data[32]
+//   || checksum[4]   (first 4 bytes of sha256 of the rest)
+//
+// and is written either as base58 or as 30 BIP39 English words
+// (11 bits per word, the last 2 bits zero).
+//
+// ============================================================
+
+package wallet
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Share limits
+const (
+	MaxShares    = 255
+	shareVersion = 1
+	shareHeader  = 5
+	shareDataLen = ed25519.SeedSize
+	shareSumLen  = 4
+	shareLen     = shareHeader + shareDataLen + shareSumLen
+	shareWords   = (shareLen*8 + 10) / 11
+)
+
+// Share errors
+var (
+	ErrShareChecksum   = errors.New("share checksum mismatch (typo?)")
+	ErrShareMismatch   = errors.New("shares belong to different backups")
+	ErrNotEnoughShares = errors.New("not enough shares")
+)
+
+//go:embed wordlist_english.txt
+var wordlistData string
+
+var (
+	wordlist   = strings.Fields(wordlistData)
+	wordIndex  = indexWords(wordlist)
+	errBadWord = errors.New("unknown word")
+)
+
+func indexWords(words []string) map[string]int {
+	m := make(map[string]int, len(words))
+	for i, w := range words {
+		m[w] = i
+	}
+	return m
+}
+
+// Share is one piece of a split seed
+type Share struct {
+	KeyID     [2]byte // first two bytes of the public key
+	Threshold byte
+	Index     byte // x coordinate, 1..255
+	Data      []byte
+}
+
+// ============================================================
+// Split and Combine
+// ============================================================
+
+// SplitSeed splits kp's seed into n shares, any threshold of which
+// recover it
+func SplitSeed(kp *Keypair, n, threshold int) ([]*Share, error) {
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if n < threshold {
+		return nil, fmt.Errorf("shares (%d) must be at least the threshold (%d)", n, threshold)
+	}
+	if n > MaxShares {
+		return nil, fmt.Errorf("at most %d shares are supported", MaxShares)
+	}
+
+	seed := kp.PrivateKey.Seed()
+	shares := make([]*Share, n)
+	for i := range shares {
+		shares[i] = &Share{Threshold: byte(threshold), Index: byte(i + 1), Data: make([]byte, len(seed))}
+		copy(shares[i].KeyID[:], kp.PublicKey[:2])
+	}
+
+	// One random polynomial of degree threshold-1 per seed byte
+	coeffs := make([]byte, threshold)
+	for b, secret := range seed {
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, fmt.Errorf("random coefficients: %w", err)
+		}
+		coeffs[0] = secret
+		for _, s := range shares {
+			s.Data[b] = evalPoly(coeffs, s.Index)
+		}
+	}
+	for i := range coeffs {
+		coeffs[i] = 0
+	}
+
+	return shares, nil
+}
+
+// CombineShares recovers the keypair from at least threshold shares
+func CombineShares(shares []*Share) (*Keypair, error) {
+	if len(shares) == 0 {
+		return nil, ErrNotEnoughShares
+	}
+
+	first := shares[0]
+	seen := make(map[byte]bool)
+	var unique []*Share
+	for _, s := range shares {
+		if s.KeyID != first.KeyID || s.Threshold != first.Threshold || len(s.Data) != shareDataLen {
+			return nil, ErrShareMismatch
+		}
+		if s.Index == 0 {
+			return nil, fmt.Errorf("invalid share index 0")
+		}
+		if seen[s.Index] {
+			if !bytes.Equal(s.Data, findShare(unique, s.Index).Data) {
+				return nil, fmt.Errorf("conflicting copies of share %d", s.Index)
+			}
+			continue
+		}
+		seen[s.Index] = true
+		unique = append(unique, s)
+	}
+	if len(unique) < int(first.Threshold) {
+		return nil, fmt.Errorf("%w: have %d, need %d", ErrNotEnoughShares, len(unique), first.Threshold)
+	}
+	unique = unique[:first.Threshold]
+
+	// Lagrange interpolation at x = 0
+	seed := make([]byte, shareDataLen)
+	for i, si := range unique {
+		basis := byte(1)
+		for j, sj := range unique {
+			if i == j {
+				continue
+			}
+			// x_j / (x_j - x_i); subtraction is xor in GF(2^8)
+			basis = gfMul(basis, gfMul(sj.Index, gfInv(sj.Index^si.Index)))
+		}
+		for b := range seed {
+			seed[b] ^= gfMul(si.Data[b], basis)
+		}
+	}
+
+	priv := ed25519.NewKeyFromSeed(seed)
+	for i := range seed {
+		seed[i] = 0
+	}
+
+	kp := &Keypair{PrivateKey: priv, PublicKey: priv.Public().(ed25519.PublicKey)}
+	if !bytes.Equal(kp.PublicKey[:2], first.KeyID[:]) {
+		return nil, fmt.Errorf("recovered key does not match the shares' key id")
+	}
+	return kp, nil
+}
+
+func findShare(shares []*Share, index byte) *Share {
+	for _, s := range shares {
+		if s.Index == index {
+			return s
+		}
+	}
+	return nil
+}
+
+// ============================================================
+// GF(2^8) arithmetic (AES polynomial x^8 + x^4 + x^3 + x + 1)
+// ============================================================
+
+// gfMul multiplies without lookup tables so timing does not depend
+// on secret bytes
+func gfMul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		carry := -(a >> 7) & 0x1b
+		a = a<<1 ^ carry
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv returns a^254 = a^-1 (and 0 for 0)
+func gfInv(a byte) byte {
+	result := byte(1)
+	for e := 254; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result = gfMul(result, a)
+		}
+		a = gfMul(a, a)
+	}
+	return result
+}
+
+// evalPoly evaluates coeffs (constant term first) at x using Horner's rule
+func evalPoly(coeffs []byte, x byte) byte {
+	var y byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ coeffs[i]
+	}
+	return y
+}
+
+// ============================================================
+// Encoding
+// ============================================================
+
+func (s *Share) bytes() []byte {
+	buf := make([]byte, 0, shareLen)
+	buf = append(buf, shareVersion, s.KeyID[0], s.KeyID[1], s.Threshold, s.Index)
+	buf = append(buf, s.Data...)
+	sum := sha256.Sum256(buf)
+	return append(buf, sum[:shareSumLen]...)
+}
+
+func parseShareBytes(raw []byte) (*Share, error) {
+	if len(raw) != shareLen {
+		return nil, fmt.Errorf("invalid share length")
+	}
+	body, checksum := raw[:shareLen-shareSumLen], raw[shareLen-shareSumLen:]
+	sum := sha256.Sum256(body)
+	if !bytes.Equal(sum[:shareSumLen], checksum) {
+		return nil, ErrShareChecksum
+	}
+	if body[0] != shareVersion {
+		return nil, fmt.Errorf("unsupported share version %d", body[0])
+	}
+
+	s := &Share{Threshold: body[3], Index: body[4], Data: append([]byte(nil), body[shareHeader:]...)}
+	copy(s.KeyID[:], body[1:3])
+	if s.Threshold < 2 || s.Index == 0 {
+		return nil, fmt.Errorf("invalid share header")
+	}
+	return s, nil
+}
+
+// Base58 encodes the share as a base58 string
+func (s *Share) Base58() string {
+	return Base58Encode(s.bytes())
+}
+
+// Mnemonic encodes the share as space-separated BIP39 English words
+func (s *Share) Mnemonic() string {
+	raw := s.bytes()
+	words := make([]string, shareWords)
+	for i := range words {
+		words[i] = wordlist[readBits(raw, i*11, 11)]
+	}
+	return strings.Join(words, " ")
+}
+
+// ParseShare decodes a share in either mnemonic or base58 form
+func ParseShare(text string) (*Share, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	switch {
+	case len(fields) == shareWords:
+		return parseMnemonicShare(fields)
+	case len(fields) == 1:
+		raw, err := Base58Decode(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base58 share: %w", err)
+		}
+		return parseShareBytes(raw)
+	}
+	return nil, fmt.Errorf("expected %d words or one base58 string, got %d words", shareWords, len(fields))
+}
+
+func parseMnemonicShare(words []string) (*Share, error) {
+	raw := make([]byte, (shareWords*11+7)/8)
+	for i, w := range words {
+		v, ok := wordIndex[w]
+		if !ok {
+			return nil, fmt.Errorf("%w %q (word %d)", errBadWord, w, i+1)
+		}
+		writeBits(raw, i*11, 11, v)
+	}
+
+	// Padding bits after the share must be zero
+	if readBits(raw, shareLen*8, shareWords*11-shareLen*8) != 0 {
+		return nil, ErrShareChecksum
+	}
+	return parseShareBytes(raw[:shareLen])
+}
+
+// readBits reads n bits starting at bit offset off (big-endian);
+// bits past the end of buf read as zero
+func readBits(buf []byte, off, n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		bit := off + i
+		v <<= 1
+		if bit/8 < len(buf) && buf[bit/8]&(0x80>>(bit%8)) != 0 {
+			v |= 1
+		}
+	}
+	return v
+}
+
+// writeBits writes the low n bits of v at bit offset off (big-endian)
+func writeBits(buf []byte, off, n, v int) {
+	for i := 0; i < n; i++ {
+		if v&(1<<(n-1-i)) != 0 {
+			bit := off + i
+			buf[bit/8] |= 0x80 >> (bit % 8)
+		}
+	}
+}
+
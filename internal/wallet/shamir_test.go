@@ -0,0 +1,140 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGFArithmetic(t *testing.T) {
+	// FIPS-197 section 4.2 examples
+	if got := gfMul(0x57, 0x83); got != 0xc1 {
+		t.Errorf("gfMul(0x57, 0x83) = %#x, want 0xc1", got)
+	}
+	if got := gfMul(0x57, 0x13); got != 0xfe {
+		t.Errorf("gfMul(0x57, 0x13) = %#x, want 0xfe", got)
+	}
+	if got := gfInv(0x53); got != 0xca {
+		t.Errorf("gfInv(0x53) = %#x, want 0xca", got)
+	}
+	for a := 1; a < 256; a++ {
+		if gfMul(byte(a), gfInv(byte(a))) != 1 {
+			t.Fatalf("a * a^-1 != 1 for a = %#x", a)
+		}
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	kp, _ := Generate()
+
+	shares, err := SplitSeed(kp, 5, 3)
+	if err != nil {
+		t.Fatalf("SplitSeed failed: %v", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("got %d shares, want 5", len(shares))
+	}
+
+	// Every 3-share subset recovers the key
+	for a := 0; a < 5; a++ {
+		for b := a + 1; b < 5; b++ {
+			for c := b + 1; c < 5; c++ {
+				got, err := CombineShares([]*Share{shares[c], shares[a], shares[b]})
+				if err != nil {
+					t.Fatalf("CombineShares(%d,%d,%d) failed: %v", a, b, c, err)
+				}
+				if got.PublicKeyBase58() != kp.PublicKeyBase58() {
+					t.Fatalf("CombineShares(%d,%d,%d) recovered the wrong key", a, b, c)
+				}
+			}
+		}
+	}
+
+	// Two shares, or a duplicated share, are not enough
+	if _, err := CombineShares(shares[:2]); !errors.Is(err, ErrNotEnoughShares) {
+		t.Errorf("err = %v, want ErrNotEnoughShares", err)
+	}
+	if _, err := CombineShares([]*Share{shares[0], shares[1], shares[1]}); !errors.Is(err, ErrNotEnoughShares) {
+		t.Errorf("duplicate share: err = %v, want ErrNotEnoughShares", err)
+	}
+
+	// Shares from another backup are rejected
+	other, _ := Generate()
+	otherShares, _ := SplitSeed(other, 3, 3)
+	otherShares[0].KeyID = shares[0].KeyID
+	otherShares[0].Threshold = 2
+	if _, err := CombineShares([]*Share{shares[0], otherShares[0], shares[2]}); !errors.Is(err, ErrShareMismatch) {
+		t.Errorf("mixed backups: err = %v, want ErrShareMismatch", err)
+	}
+}
+
+func TestSplitSeed_Validation(t *testing.T) {
+	kp, _ := Generate()
+
+	cases := []struct{ n, k int }{{3, 1}, {2, 3}, {256, 3}}
+	for _, c := range cases {
+		if _, err := SplitSeed(kp, c.n, c.k); err == nil {
+			t.Errorf("SplitSeed(n=%d, k=%d) should fail", c.n, c.k)
+		}
+	}
+}
+
+func TestShareEncoding(t *testing.T) {
+	kp, _ := Generate()
+	shares, _ := SplitSeed(kp, 3, 2)
+
+	for _, s := range shares {
+		words := s.Mnemonic()
+		if n := len(strings.Fields(words)); n != 30 {
+			t.Fatalf("mnemonic has %d words, want 30", n)
+		}
+
+		for _, text := range []string{words, strings.ToUpper(words), s.Base58()} {
+			parsed, err := ParseShare(text)
+			if err != nil {
+				t.Fatalf("ParseShare(%q) failed: %v", text, err)
+			}
+			if parsed.Index != s.Index || parsed.Threshold != 2 || string(parsed.Data) != string(s.Data) {
+				t.Errorf("ParseShare(%q) = %+v, want %+v", text, parsed, s)
+			}
+		}
+	}
+
+	// A swapped word fails the checksum
+	words := strings.Fields(shares[0].Mnemonic())
+	if words[5] == "abandon" {
+		words[5] = "ability"
+	} else {
+		words[5] = "abandon"
+	}
+	if _, err := ParseShare(strings.Join(words, " ")); !errors.Is(err, ErrShareChecksum) {
+		t.Errorf("typo: err = %v, want ErrShareChecksum", err)
+	}
+
+	// Unknown words and wrong lengths are reported
+	words[0] = "notaword"
+	if _, err := ParseShare(strings.Join(words, " ")); err == nil || !strings.Contains(err.Error(), "notaword") {
+		t.Errorf("unknown word: err = %v", err)
+	}
+	if _, err := ParseShare("abandon ability"); err == nil {
+		t.Error("expected error for short mnemonic")
+	}
+
+	// A mistyped base58 character fails the checksum
+	b58 := []byte(shares[0].Base58())
+	if b58[10] == '2' {
+		b58[10] = '3'
+	} else {
+		b58[10] = '2'
+	}
+	if _, err := ParseShare(string(b58)); err == nil {
+		t.Error("expected error for mistyped base58 share")
+	}
+}
+
+func TestWordlist(t *testing.T) {
+	if len(wordlist) != 2048 || wordlist[0] != "abandon" || wordlist[2047] != "zoo" {
+		t.Errorf("wordlist has %d words (%s..%s), want BIP39 English", len(wordlist), wordlist[0], wordlist[len(wordlist)-1])
+	}
+}
+
@@ -0,0 +1,58 @@
+// ============================================================
+// Signer - Abstract signing backend
+// ============================================================
+//
+// Code that needs a signature takes a Signer instead of a raw
+// *Keypair, so the key can live in a file, in the signing agent
+// (internal/signer), or anywhere else that can produce ed25519
+// signatures.
+//
+// ============================================================
+
+package wallet
+
+import "crypto/ed25519"
+
+// Signer produces ed25519 signatures for a single public key
+type Signer interface {
+	// PublicKey returns the key signatures verify against
+	PublicKey() ed25519.PublicKey
+
+	// Sign signs message. Remote signers may refuse or fail.
+	Sign(message []byte) ([]byte, error)
+}
+
+// KeypairSigner signs with an in-memory keypair
+type KeypairSigner struct {
+	kp *Keypair
+}
+
+// NewKeypairSigner wraps a keypair as a Signer
+func NewKeypairSigner(kp *Keypair) *KeypairSigner {
+	return &KeypairSigner{kp: kp}
+}
+
+// NewFileSigner loads a Solana CLI keypair file as a Signer
+func NewFileSigner(path string) (*KeypairSigner, error) {
+	kp, err := LoadFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeypairSigner(kp), nil
+}
+
+// PublicKey returns the keypair's public key
+func (s *KeypairSigner) PublicKey() ed25519.PublicKey {
+	return s.kp.PublicKey
+}
+
+// Sign signs message with the keypair's private key
+func (s *KeypairSigner) Sign(message []byte) ([]byte, error) {
+	return s.kp.Sign(message), nil
+}
+
+// SignerAddress returns the base58 address of a signer
+func SignerAddress(s Signer) string {
+	return Base58Encode(s.PublicKey())
+}
+
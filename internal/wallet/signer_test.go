@@ -0,0 +1,39 @@
+package wallet
+
+import (
+	"crypto/ed25519"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSigner(t *testing.T) {
+	kp, _ := Generate()
+	path := filepath.Join(t.TempDir(), "id.json")
+	if err := kp.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+
+	var s Signer
+	s, err := NewFileSigner(path)
+	if err != nil {
+		t.Fatalf("NewFileSigner failed: %v", err)
+	}
+
+	if SignerAddress(s) != kp.PublicKeyBase58() {
+		t.Errorf("SignerAddress = %s, want %s", SignerAddress(s), kp.PublicKeyBase58())
+	}
+
+	msg := []byte("message")
+	sig, err := s.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if !ed25519.Verify(s.PublicKey(), msg, sig) {
+		t.Error("signature does not verify")
+	}
+
+	if _, err := NewFileSigner(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
@@ -0,0 +1,182 @@
+// ============================================================
+// Vanity Addresses - Brute-force search for address patterns
+// ============================================================
+//
+// Generates keypairs on every worker until one's base58 address
+// starts and/or ends with the requested pattern. Each extra
+// character multiplies the expected work by about 58 (29 when
+// ignoring case for letters that exist in both cases).
+//
+// ============================================================
+
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// vanityBatch is how many attempts a worker makes between updates
+// of the shared counter and cancellation checks
+const vanityBatch = 256
+
+// VanityPattern describes the address to search for
+type VanityPattern struct {
+	Prefix     string
+	Suffix     string
+	IgnoreCase bool
+}
+
+// Validate checks that the pattern can occur in a base58 address
+func (p VanityPattern) Validate() error {
+	if p.Prefix == "" && p.Suffix == "" {
+		return fmt.Errorf("a prefix or suffix is required")
+	}
+	if len(p.Prefix)+len(p.Suffix) > base58KeyLen {
+		return fmt.Errorf("pattern is longer than an address")
+	}
+	for _, part := range []string{p.Prefix, p.Suffix} {
+		for _, c := range part {
+			if p.variants(c) == 0 {
+				return fmt.Errorf("%q is not a base58 character (base58 excludes 0, O, I and l)", c)
+			}
+		}
+	}
+	return nil
+}
+
+// variants counts the alphabet characters that c matches
+func (p VanityPattern) variants(c rune) int {
+	n := 0
+	if strings.ContainsRune(alphabet, c) {
+		n++
+	}
+	if p.IgnoreCase {
+		if other := swapCase(c); other != c && strings.ContainsRune(alphabet, other) {
+			n++
+		}
+	}
+	return n
+}
+
+// Difficulty returns the expected number of attempts to find a match.
+// It treats address characters as uniform, which is close except for
+// the first character of a prefix.
+func (p VanityPattern) Difficulty() float64 {
+	d := 1.0
+	for _, c := range p.Prefix + p.Suffix {
+		if n := p.variants(c); n > 0 {
+			d *= float64(len(alphabet)) / float64(n)
+		}
+	}
+	return d
+}
+
+// Match reports whether address satisfies the pattern
+func (p VanityPattern) Match(address string) bool {
+	return p.matchBytes([]byte(address))
+}
+
+func (p VanityPattern) matchBytes(address []byte) bool {
+	if len(address) < len(p.Prefix)+len(p.Suffix) {
+		return false
+	}
+	head := address[:len(p.Prefix)]
+	tail := address[len(address)-len(p.Suffix):]
+	if p.IgnoreCase {
+		return bytes.EqualFold(head, []byte(p.Prefix)) && bytes.EqualFold(tail, []byte(p.Suffix))
+	}
+	return string(head) == p.Prefix && string(tail) == p.Suffix
+}
+
+// SearchVanity runs workers goroutines until one finds a keypair
+// matching p or ctx is cancelled. attempts, if non-nil, is updated
+// as the search runs so callers can report progress.
+func SearchVanity(ctx context.Context, p VanityPattern, workers int, attempts *atomic.Uint64) (*Keypair, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if attempts == nil {
+		attempts = new(atomic.Uint64)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	found := make(chan *Keypair, 1)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			kp, err := vanityWorker(ctx, p, attempts)
+			switch {
+			case err != nil:
+				errs <- err
+				cancel()
+			case kp != nil:
+				select {
+				case found <- kp:
+					cancel()
+				default:
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case kp := <-found:
+		return kp, nil
+	default:
+	}
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+	return nil, ctx.Err()
+}
+
+func vanityWorker(ctx context.Context, p VanityPattern, attempts *atomic.Uint64) (*Keypair, error) {
+	var buf [base58KeyLen]byte
+	for {
+		for i := 0; i < vanityBatch; i++ {
+			pub, priv, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return nil, fmt.Errorf("generate keypair: %w", err)
+			}
+			if p.matchBytes(appendBase58Key(buf[:0], pub)) {
+				attempts.Add(uint64(i + 1))
+				return &Keypair{PublicKey: pub, PrivateKey: priv}, nil
+			}
+		}
+		attempts.Add(vanityBatch)
+
+		if ctx.Err() != nil {
+			return nil, nil
+		}
+	}
+}
+
+func swapCase(c rune) rune {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return c - 'a' + 'A'
+	case c >= 'A' && c <= 'Z':
+		return c - 'A' + 'a'
+	}
+	return c
+}
+
@@ -0,0 +1,119 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBase58Encode_KeyFastPath(t *testing.T) {
+	cases := [][]byte{
+		make([]byte, 32),
+		append([]byte{0, 0, 1}, make([]byte, 29)...),
+	}
+	full := make([]byte, 32)
+	for i := range full {
+		full[i] = 0xff
+	}
+	cases = append(cases, full)
+	for i := 0; i < 200; i++ {
+		pub, _, _ := ed25519.GenerateKey(rand.Reader)
+		cases = append(cases, pub)
+	}
+
+	for _, key := range cases {
+		if got, want := Base58Encode(key), base58EncodeBig(key); got != want {
+			t.Fatalf("Base58Encode(%x) = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestVanityPattern_Validate(t *testing.T) {
+	tests := []struct {
+		pattern VanityPattern
+		wantErr bool
+	}{
+		{VanityPattern{Prefix: "abc"}, false},
+		{VanityPattern{Suffix: "Pay"}, false},
+		{VanityPattern{}, true},
+		{VanityPattern{Prefix: "0x"}, true},
+		{VanityPattern{Prefix: "Ol"}, true},
+		{VanityPattern{Prefix: "Ol", IgnoreCase: true}, false}, // o and L exist
+		{VanityPattern{Prefix: "0", IgnoreCase: true}, true},
+		{VanityPattern{Prefix: strings.Repeat("a", 45)}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.pattern.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+		}
+	}
+}
+
+func TestVanityPattern_Difficulty(t *testing.T) {
+	if got := (VanityPattern{Prefix: "ab"}).Difficulty(); got != 58*58 {
+		t.Errorf("Difficulty(ab) = %v, want %v", got, 58*58)
+	}
+	// 'a' matches a or A, '1' only itself
+	if got := (VanityPattern{Prefix: "a1", IgnoreCase: true}).Difficulty(); got != 29*58 {
+		t.Errorf("Difficulty(a1, ignore case) = %v, want %v", got, 29*58)
+	}
+}
+
+func TestVanityPattern_Match(t *testing.T) {
+	addr := "MachXyz111111111111111111111111111111111Pay"
+
+	tests := []struct {
+		pattern VanityPattern
+		want    bool
+	}{
+		{VanityPattern{Prefix: "Mach"}, true},
+		{VanityPattern{Prefix: "mach"}, false},
+		{VanityPattern{Prefix: "mach", IgnoreCase: true}, true},
+		{VanityPattern{Suffix: "Pay"}, true},
+		{VanityPattern{Prefix: "Mach", Suffix: "pay", IgnoreCase: true}, true},
+		{VanityPattern{Prefix: "Mach", Suffix: "Fee"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.pattern.Match(addr); got != tt.want {
+			t.Errorf("Match(%+v) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestSearchVanity(t *testing.T) {
+	p := VanityPattern{Prefix: "a", IgnoreCase: true}
+	var attempts atomic.Uint64
+
+	kp, err := SearchVanity(context.Background(), p, 4, &attempts)
+	if err != nil {
+		t.Fatalf("SearchVanity failed: %v", err)
+	}
+	if !p.Match(kp.PublicKeyBase58()) {
+		t.Errorf("address %s does not match", kp.PublicKeyBase58())
+	}
+	if attempts.Load() == 0 {
+		t.Error("attempts not counted")
+	}
+	if !ed25519.Verify(kp.PublicKey, []byte("m"), kp.Sign([]byte("m"))) {
+		t.Error("returned keypair is inconsistent")
+	}
+}
+
+func TestSearchVanity_Cancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Practically unreachable pattern
+	_, err := SearchVanity(ctx, VanityPattern{Prefix: "zzzzzzzzzz"}, 2, nil)
+	if err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
@@ -4,7 +4,8 @@
 //
 // Supports:
 // - Generating new Ed25519 keypairs
-// - Loading keypairs from Solana CLI format (JSON array)
+// - Loading keypairs from Solana CLI format (JSON array) and the
+//   other export formats detected in import.go
 // - Saving keypairs in Solana CLI format
 // - Base58 address encoding
 //
@@ -39,54 +40,26 @@ func Generate() (*Keypair, error) {
 	}, nil
 }
 
-// LoadFromFile loads a keypair from a Solana CLI format file
-// The file format is a JSON array of 64 bytes (32 private + 32 public)
+// LoadFromFile loads a keypair from a file in any format ParseKeypair
+// accepts; Solana CLI files are a JSON array of 64 bytes
+// (32 seed + 32 public)
 func LoadFromFile(path string) (*Keypair, error) {
-	// Expand home directory
-	if len(path) > 0 && path[0] == '~' {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("get home dir: %w", err)
-		}
-		path = filepath.Join(home, path[1:])
-	}
+	kp, _, err := LoadKeyFile(path)
+	return kp, err
+}
 
-	data, err := os.ReadFile(path)
+// LoadKeyFile is LoadFromFile that also reports the detected format
+func LoadKeyFile(path string) (*Keypair, KeyFormat, error) {
+	data, err := os.ReadFile(expandHome(path))
 	if err != nil {
-		return nil, fmt.Errorf("read file: %w", err)
-	}
-
-	// Parse as JSON array of bytes
-	var bytes []byte
-	if err := json.Unmarshal(data, &bytes); err != nil {
-		return nil, fmt.Errorf("parse keypair JSON: %w", err)
-	}
-
-	if len(bytes) != 64 {
-		return nil, fmt.Errorf("invalid keypair length: got %d bytes, want 64", len(bytes))
+		return nil, "", fmt.Errorf("read file: %w", err)
 	}
-
-	// First 32 bytes are the seed (not used directly in ed25519.PrivateKey)
-	// The full 64 bytes are the private key in Go's ed25519 format
-	privateKey := ed25519.PrivateKey(bytes)
-	publicKey := privateKey.Public().(ed25519.PublicKey)
-
-	return &Keypair{
-		PublicKey:  publicKey,
-		PrivateKey: privateKey,
-	}, nil
+	return ParseKeypair(data)
 }
 
 // SaveToFile saves the keypair in Solana CLI format
 func (k *Keypair) SaveToFile(path string) error {
-	// Expand home directory
-	if len(path) > 0 && path[0] == '~' {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("get home dir: %w", err)
-		}
-		path = filepath.Join(home, path[1:])
-	}
+	path = expandHome(path)
 
 	// Ensure directory exists
 	dir := filepath.Dir(path)
@@ -94,9 +67,14 @@ func (k *Keypair) SaveToFile(path string) error {
 		return fmt.Errorf("create directory: %w", err)
 	}
 
-	// Marshal private key as JSON array of bytes
-	// ed25519.PrivateKey is 64 bytes (seed + public key)
-	data, err := json.Marshal([]byte(k.PrivateKey))
+	// Marshal private key as JSON array of numbers, like solana-keygen.
+	// ed25519.PrivateKey is 64 bytes (seed + public key); a plain []byte
+	// would marshal as a base64 string.
+	numbers := make([]int, len(k.PrivateKey))
+	for i, b := range k.PrivateKey {
+		numbers[i] = int(b)
+	}
+	data, err := json.Marshal(numbers)
 	if err != nil {
 		return fmt.Errorf("marshal keypair: %w", err)
 	}
@@ -129,3 +107,15 @@ func (k *Keypair) Verify(message, signature []byte) bool {
 	return ed25519.Verify(k.PublicKey, message, signature)
 }
 
+// expandHome expands a leading ~ to the user's home directory
+func expandHome(path string) string {
+	if len(path) > 0 && path[0] == '~' {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return path
+		}
+		return filepath.Join(home, path[1:])
+	}
+	return path
+}
+
@@ -0,0 +1,566 @@
+// ============================================================
+// HTTP Signatures - Agent identity on requests (RFC 9421)
+// ============================================================
+//
+// Agents sign requests with their wallet's ed25519 key:
+//
+//   signer := httpsig.NewSigner(address, sign) // sign: ed25519, may fail
+//   client := &http.Client{Transport: &httpsig.Transport{Signer: signer}}
+//
+// Vendors check them:
+//
+//   id, err := httpsig.Verify(r)             // id.KeyID is the agent
+//   http.ListenAndServe(":8080", (&httpsig.Verifier{}).Handler(mux))
+//
+// Each request gets, per RFC 9421 and RFC 9530:
+//
+//   Content-Digest:  sha-256=:<base64>:            (when it has a body)
+//   Signature-Input: machpay=("@method" "@authority" "@path" "@query"
+//                    "content-digest" "x-payment");created=1700000000;
+//                    keyid="<base58 public key>";alg="ed25519";tag="machpay"
+//   Signature:       machpay=:<base64 ed25519 signature>:
+//
+// covering the method, host, path and query, and the Content-Digest,
+// Content-Type and X-PAYMENT headers when present, so a signature
+// binds the agent to the exact request and any payment it carries.
+// Verify refuses signatures that leave out the query, body digest or
+// X-PAYMENT of a request that has them.
+// The key ID is the signer's Solana address, so no key lookup is
+// needed to verify; Verifier.Keys can restrict or map keys.
+//
+// The key signs SigningPrefix followed by the RFC 9421 signature base,
+// not the base alone: the wallet key also signs transactions and other
+// messages, and the prefix keeps a request signature from standing in
+// for any of them. Generic RFC 9421 verifiers must prepend it too.
+//
+// ============================================================
+
+package httpsig
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+)
+
+// Signature parameters
+const (
+	Label     = "machpay" // signature label in Signature-Input and Signature
+	Algorithm = "ed25519"
+	Tag       = "machpay"
+
+	DefaultMaxAge = 5 * time.Minute
+
+	// maxClockSkew is how far in the future a signature may be created
+	maxClockSkew = time.Minute
+
+	// maxBody bounds the bodies read to check Content-Digest
+	maxBody = 32 << 20
+)
+
+// SigningPrefix precedes the signature base in the signed message
+const SigningPrefix = "MachPay HTTP request signature\n"
+
+// Header names
+const (
+	HeaderSignature      = "Signature"
+	HeaderSignatureInput = "Signature-Input"
+	HeaderContentDigest  = "Content-Digest"
+)
+
+// requiredComponents must be covered by every signature, @query too
+// when the request has one, and x-payment when it carries a payment
+var requiredComponents = []string{"@method", "@authority", "@path"}
+
+// DefaultHeaders are covered when the request has them
+var DefaultHeaders = []string{"content-digest", "content-type", "x-payment"}
+
+// Errors
+var (
+	ErrNoSignature      = errors.New("request is not signed")
+	ErrInvalidSignature = errors.New("invalid request signature")
+)
+
+// ============================================================
+// Signing
+// ============================================================
+
+// Signer signs requests with an ed25519 key
+type Signer struct {
+	KeyID   string                               // the key's base58 Solana address
+	Sign    func(message []byte) ([]byte, error) // ed25519, e.g. a wallet signer's Sign
+	Headers []string                             // covered when present; nil uses DefaultHeaders
+
+	now func() time.Time
+}
+
+// NewSigner returns a signer for a key
+func NewSigner(keyID string, sign func(message []byte) ([]byte, error)) *Signer {
+	return &Signer{KeyID: keyID, Sign: sign}
+}
+
+// SignRequest adds Content-Digest (for a body), Signature-Input and
+// Signature to r. A body is read and replaced.
+func (s *Signer) SignRequest(r *http.Request) error {
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return fmt.Errorf("read body to sign: %w", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		sum := sha256.Sum256(body)
+		r.Header.Set(HeaderContentDigest, "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+	}
+
+	headers := s.Headers
+	if headers == nil {
+		headers = DefaultHeaders
+	}
+	components := append(append([]string(nil), requiredComponents...), "@query")
+	for _, name := range headers {
+		if name = strings.ToLower(name); r.Header.Get(name) != "" {
+			components = append(components, name)
+		}
+	}
+
+	params := serializeParams(components, s.clock().Unix(), s.KeyID)
+	base, err := signatureBase(r, components, params)
+	if err != nil {
+		return err
+	}
+	signature, err := s.Sign(signedMessage(base))
+	if err != nil {
+		return fmt.Errorf("sign request: %w", err)
+	}
+	r.Header.Set(HeaderSignatureInput, Label+"="+params)
+	r.Header.Set(HeaderSignature, Label+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+// signedMessage is what the key signs for a signature base
+func signedMessage(base []byte) []byte {
+	return append([]byte(SigningPrefix), base...)
+}
+
+func (s *Signer) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// Transport signs every request it sends
+type Transport struct {
+	Signer *Signer
+	Base   http.RoundTripper // nil uses http.DefaultTransport
+}
+
+// RoundTrip signs a copy of r and sends it
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	out := r.Clone(r.Context())
+	if r.Body != nil && r.Body != http.NoBody && r.GetBody != nil {
+		// Leave r's body for the caller; sign a fresh copy
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+		r.Body.Close()
+	}
+	if err := t.Signer.SignRequest(out); err != nil {
+		if out.Body != nil {
+			out.Body.Close()
+		}
+		return nil, err
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(out)
+}
+
+// ============================================================
+// Verification
+// ============================================================
+
+// Identity is a verified request signer
+type Identity struct {
+	KeyID   string // base58 public key
+	Created time.Time
+	Covered []string // components the signature covers
+}
+
+// Verifier checks request signatures
+type Verifier struct {
+	// Keys returns the public key for a key ID; nil accepts any key
+	// ID that is a base58 ed25519 public key
+	Keys func(keyID string) (ed25519.PublicKey, error)
+
+	MaxAge   time.Duration // oldest signature accepted; default DefaultMaxAge
+	Required bool          // Handler refuses unsigned requests
+
+	now func() time.Time
+}
+
+// Verify checks r's signature with a default Verifier
+func Verify(r *http.Request) (*Identity, error) {
+	return (&Verifier{}).Verify(r)
+}
+
+// Verify checks r's signature and, when it covers Content-Digest, the
+// body against the digest. The body is read and replaced.
+func (v *Verifier) Verify(r *http.Request) (*Identity, error) {
+	inputs := r.Header.Values(HeaderSignatureInput)
+	if len(inputs) == 0 {
+		return nil, ErrNoSignature
+	}
+	label, params, err := findSignature(strings.Join(inputs, ", "))
+	if err != nil {
+		return nil, invalid("Signature-Input: %v", err)
+	}
+	components, values, err := parseParams(params)
+	if err != nil {
+		return nil, invalid("Signature-Input: %v", err)
+	}
+	signature, err := signatureValue(strings.Join(r.Header.Values(HeaderSignature), ", "), label)
+	if err != nil {
+		return nil, invalid("Signature: %v", err)
+	}
+
+	required := append([]string(nil), requiredComponents...)
+	if r.URL.RawQuery != "" {
+		required = append(required, "@query")
+	}
+	if r.Header.Get("X-Payment") != "" {
+		// Otherwise a signed request could carry someone else's payment
+		required = append(required, "x-payment")
+	}
+	for _, c := range required {
+		if !contains(components, c) {
+			return nil, invalid("signature does not cover %s", c)
+		}
+	}
+	if alg, ok := values["alg"]; ok && alg != Algorithm {
+		return nil, invalid("unsupported algorithm %q", alg)
+	}
+	created, err := strconv.ParseInt(values["created"], 10, 64)
+	if err != nil {
+		return nil, invalid("missing created time")
+	}
+	now := v.clock()
+	maxAge := v.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	createdAt := time.Unix(created, 0)
+	if createdAt.Before(now.Add(-maxAge)) || createdAt.After(now.Add(maxClockSkew)) {
+		return nil, invalid("signature created %s, outside the %s allowed", createdAt.UTC().Format(time.RFC3339), maxAge)
+	}
+	if expires, ok := values["expires"]; ok {
+		if t, err := strconv.ParseInt(expires, 10, 64); err != nil || now.Unix() > t {
+			return nil, invalid("signature expired")
+		}
+	}
+
+	keyID := values["keyid"]
+	key, err := v.key(keyID)
+	if err != nil {
+		return nil, invalid("key %q: %v", keyID, err)
+	}
+	base, err := signatureBase(r, components, params)
+	if err != nil {
+		return nil, invalid("%v", err)
+	}
+	if !ed25519.Verify(key, signedMessage(base), signature) {
+		return nil, invalid("signature does not match the request")
+	}
+
+	if err := checkBody(r, contains(components, "content-digest")); err != nil {
+		return nil, err
+	}
+	return &Identity{KeyID: keyID, Created: createdAt, Covered: components}, nil
+}
+
+func (v *Verifier) key(keyID string) (ed25519.PublicKey, error) {
+	if v.Keys != nil {
+		return v.Keys(keyID)
+	}
+	pub, err := solana.ParsePublicKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.PublicKey(pub[:]), nil
+}
+
+func (v *Verifier) clock() time.Time {
+	if v.now != nil {
+		return v.now()
+	}
+	return time.Now()
+}
+
+type contextKey struct{}
+
+// Handler verifies signed requests before next serves them, with the
+// signer in the request context (FromContext). Invalid signatures,
+// and unsigned requests if Required, get 401 Unauthorized.
+func (v *Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := v.Verify(r)
+		switch {
+		case errors.Is(err, ErrNoSignature) && !v.Required:
+			next.ServeHTTP(w, r)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		default:
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+		}
+	})
+}
+
+// FromContext returns the verified signer of the request being served
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(*Identity)
+	return id, ok
+}
+
+// checkBody compares the body with Content-Digest. A body must be
+// covered by the signature through the digest.
+func checkBody(r *http.Request, covered bool) error {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		r.Body.Close()
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+		if len(body) > maxBody {
+			return invalid("body too large to check its digest")
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if !covered {
+		if len(body) > 0 {
+			return invalid("signature does not cover the body (content-digest)")
+		}
+		return nil
+	}
+
+	for _, member := range splitTopLevel(r.Header.Get(HeaderContentDigest)) {
+		alg, value, _ := strings.Cut(member, "=")
+		want, err := byteSequence(value)
+		if err != nil {
+			continue
+		}
+		var got []byte
+		switch strings.TrimSpace(alg) {
+		case "sha-256":
+			sum := sha256.Sum256(body)
+			got = sum[:]
+		case "sha-512":
+			sum := sha512.Sum512(body)
+			got = sum[:]
+		default:
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			return invalid("body does not match Content-Digest")
+		}
+		return nil
+	}
+	return invalid("no sha-256 or sha-512 Content-Digest")
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSignature, fmt.Sprintf(format, args...))
+}
+
+// ============================================================
+// Signature base
+// ============================================================
+
+// signatureBase builds the bytes signed (RFC 9421 section 2.5)
+func signatureBase(r *http.Request, components []string, params string) ([]byte, error) {
+	var b strings.Builder
+	for _, c := range components {
+		value, err := componentValue(r, c)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "%q: %s\n", c, value)
+	}
+	fmt.Fprintf(&b, "%q: %s", "@signature-params", params)
+	return []byte(b.String()), nil
+}
+
+func componentValue(r *http.Request, component string) (string, error) {
+	switch component {
+	case "@method":
+		return strings.ToUpper(r.Method), nil
+	case "@authority":
+		host := r.Host
+		if host == "" {
+			host = r.URL.Host
+		}
+		return strings.ToLower(host), nil
+	case "@path":
+		if path := r.URL.EscapedPath(); path != "" {
+			return path, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + r.URL.RawQuery, nil
+	}
+	if strings.HasPrefix(component, "@") {
+		return "", fmt.Errorf("unsupported component %s", component)
+	}
+	values := r.Header.Values(component)
+	if len(values) == 0 {
+		return "", fmt.Errorf("covered header %s is missing", component)
+	}
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(values, ", "), nil
+}
+
+// serializeParams writes the signature parameters as a structured
+// field inner list
+func serializeParams(components []string, created int64, keyID string) string {
+	quoted := make([]string, len(components))
+	for i, c := range components {
+		quoted[i] = strconv.Quote(c)
+	}
+	return fmt.Sprintf("(%s);created=%d;keyid=%s;alg=%q;tag=%q",
+		strings.Join(quoted, " "), created, strconv.Quote(keyID), Algorithm, Tag)
+}
+
+// findSignature returns the label and parameters of the signature to
+// check: ours if present, else the first
+func findSignature(header string) (label, params string, err error) {
+	members := splitTopLevel(header)
+	if len(members) == 0 {
+		return "", "", errors.New("empty")
+	}
+	for _, m := range members {
+		l, p, ok := strings.Cut(m, "=")
+		if ok && strings.TrimSpace(l) == Label {
+			return Label, strings.TrimSpace(p), nil
+		}
+	}
+	l, p, ok := strings.Cut(members[0], "=")
+	if !ok {
+		return "", "", fmt.Errorf("malformed member %q", members[0])
+	}
+	return strings.TrimSpace(l), strings.TrimSpace(p), nil
+}
+
+// parseParams reads an inner list of component names and its
+// parameters
+func parseParams(params string) (components []string, values map[string]string, err error) {
+	if !strings.HasPrefix(params, "(") {
+		return nil, nil, errors.New("not an inner list")
+	}
+	end := strings.Index(params, ")")
+	if end < 0 {
+		return nil, nil, errors.New("unterminated inner list")
+	}
+	for _, item := range strings.Fields(params[1:end]) {
+		name, err := strconv.Unquote(item)
+		if err != nil || name == "" || name != strings.ToLower(name) {
+			return nil, nil, fmt.Errorf("bad component %s", item)
+		}
+		if contains(components, name) {
+			return nil, nil, fmt.Errorf("component %s repeated", item)
+		}
+		components = append(components, name)
+	}
+
+	values = make(map[string]string)
+	for _, p := range strings.Split(params[end+1:], ";") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(p, "=")
+		if strings.HasPrefix(value, `"`) {
+			if value, err = strconv.Unquote(value); err != nil {
+				return nil, nil, fmt.Errorf("bad parameter %s", p)
+			}
+		}
+		values[key] = value
+	}
+	return components, values, nil
+}
+
+// signatureValue returns the signature bytes for label
+func signatureValue(header, label string) ([]byte, error) {
+	for _, m := range splitTopLevel(header) {
+		l, value, ok := strings.Cut(m, "=")
+		if ok && strings.TrimSpace(l) == label {
+			return byteSequence(value)
+		}
+	}
+	return nil, fmt.Errorf("no signature labelled %s", label)
+}
+
+// byteSequence decodes a structured field byte sequence, :base64:
+func byteSequence(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+		return nil, errors.New("not a byte sequence")
+	}
+	return base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+}
+
+// splitTopLevel splits a structured field dictionary on the commas
+// outside inner lists and strings
+func splitTopLevel(s string) []string {
+	var members []string
+	depth, quoted, start := 0, false, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			members = append(members, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		members = append(members, last)
+	}
+	return members
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
@@ -0,0 +1,289 @@
+package httpsig
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// TestSignatureBase_RFC9421 checks the base and ed25519 signature of
+// RFC 9421 appendix B.2.6
+func TestSignatureBase_RFC9421(t *testing.T) {
+	der, _ := base64.StdEncoding.DecodeString("MC4CAQAwBQYDK2VwBCIEIJ+DYvh6SEqVTm50DFtMDoQikTmiCqirVv9mWG9qfSnF")
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := parsed.(ed25519.PrivateKey)
+
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+	r.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Length", "18")
+	params := `("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`
+	components, _, err := parseParams(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := signatureBase(r, components, params)
+	want := `"date": Tue, 20 Apr 2021 02:07:55 GMT
+"@method": POST
+"@path": /foo
+"@authority": example.com
+"content-type": application/json
+"content-length": 18
+"@signature-params": ` + params
+	if err != nil || string(base) != want {
+		t.Fatalf("signatureBase = %q, %v\nwant %q", base, err, want)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, base))
+	if sig != "wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==" {
+		t.Errorf("signature = %s", sig)
+	}
+}
+
+func newSigner(t *testing.T) (*Signer, *wallet.Keypair) {
+	t.Helper()
+	kp, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSigner(kp.PublicKeyBase58(), wallet.NewKeypairSigner(kp).Sign), kp
+}
+
+func signedRequest(t *testing.T, s *Signer, method, url, body string) *http.Request {
+	t.Helper()
+	var r *http.Request
+	if body == "" {
+		r = httptest.NewRequest(method, url, nil)
+	} else {
+		r = httptest.NewRequest(method, url, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+	}
+	r.Header.Set("X-Payment", "eyJwYXkiOjF9")
+	if err := s.SignRequest(r); err != nil {
+		t.Fatalf("SignRequest: %v", err)
+	}
+	return r
+}
+
+func TestSignVerify(t *testing.T) {
+	s, kp := newSigner(t)
+	r := signedRequest(t, s, http.MethodPost, "http://api.example.com/v1/quote?symbol=SOL", `{"qty":2}`)
+
+	input := r.Header.Get(HeaderSignatureInput)
+	for _, part := range []string{`machpay=("@method" "@authority" "@path" "@query" "content-digest" "content-type" "x-payment")`, `keyid="` + kp.PublicKeyBase58() + `"`, `alg="ed25519"`} {
+		if !strings.Contains(input, part) {
+			t.Errorf("Signature-Input %s lacks %s", input, part)
+		}
+	}
+	if r.Header.Get(HeaderContentDigest) != "sha-256=:H8fX0zPcSkHw/L3jZ0Xy+rxEGmrg6Eb/zTLOtEONzCo=:" {
+		t.Errorf("Content-Digest = %s", r.Header.Get(HeaderContentDigest))
+	}
+
+	id, err := Verify(r)
+	if err != nil || id.KeyID != kp.PublicKeyBase58() || len(id.Covered) != 7 {
+		t.Fatalf("Verify = %+v, %v", id, err)
+	}
+	if body, _ := io.ReadAll(r.Body); string(body) != `{"qty":2}` {
+		t.Errorf("body after Verify = %q", body)
+	}
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	s, _ := newSigner(t)
+	other, _ := newSigner(t)
+	tests := []struct {
+		name   string
+		tamper func(r *http.Request)
+	}{
+		{"method", func(r *http.Request) { r.Method = http.MethodPut }},
+		{"host", func(r *http.Request) { r.Host = "evil.example.com" }},
+		{"path", func(r *http.Request) { r.URL.Path = "/v1/admin" }},
+		{"query", func(r *http.Request) { r.URL.RawQuery = "symbol=BTC" }},
+		{"payment", func(r *http.Request) { r.Header.Set("X-Payment", "other") }},
+		{"body", func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader(`{"qty":200}`)) }},
+		{"digest", func(r *http.Request) {
+			r.Header.Set(HeaderContentDigest, "sha-256=:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=:")
+		}},
+		{"key", func(r *http.Request) {
+			r.Header.Set(HeaderSignatureInput, strings.Replace(r.Header.Get(HeaderSignatureInput), s.KeyID, other.KeyID, 1))
+		}},
+		{"signature", func(r *http.Request) { r.Header.Set(HeaderSignature, "machpay=:AAAA:") }},
+		{"uncovered query", func(r *http.Request) {
+			r.Header.Set(HeaderSignatureInput, strings.Replace(r.Header.Get(HeaderSignatureInput), ` "@query"`, "", 1))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := signedRequest(t, s, http.MethodPost, "http://api.example.com/v1/quote?symbol=SOL", `{"qty":2}`)
+			tt.tamper(r)
+			if _, err := Verify(r); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Verify = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerify_RequiresPaymentCoverage(t *testing.T) {
+	s, _ := newSigner(t)
+	s.Headers = []string{"content-digest", "content-type"}
+	r := signedRequest(t, s, http.MethodGet, "http://api.example.com/", "")
+	_, err := Verify(r)
+	if !errors.Is(err, ErrInvalidSignature) || !strings.Contains(err.Error(), "x-payment") {
+		t.Errorf("Verify with X-PAYMENT not covered = %v", err)
+	}
+
+	// Without a payment there is nothing to cover
+	r.Header.Del("X-Payment")
+	if _, err := Verify(r); err != nil {
+		t.Errorf("Verify without X-PAYMENT: %v", err)
+	}
+}
+
+func TestSigner_Prefix(t *testing.T) {
+	s, kp := newSigner(t)
+	var signed []byte
+	s.Sign = func(message []byte) ([]byte, error) {
+		signed = message
+		return kp.Sign(message), nil
+	}
+	r := signedRequest(t, s, http.MethodGet, "http://api.example.com/", "")
+	if !strings.HasPrefix(string(signed), SigningPrefix+`"@method": GET`) {
+		t.Errorf("signed %q, want the prefix and the signature base", signed)
+	}
+
+	// A signature of the bare base is not a request signature
+	raw := kp.Sign(signed[len(SigningPrefix):])
+	r.Header.Set(HeaderSignature, Label+"=:"+base64.StdEncoding.EncodeToString(raw)+":")
+	if _, err := Verify(r); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify(unprefixed signature) = %v, want ErrInvalidSignature", err)
+	}
+
+	s.Sign = func([]byte) ([]byte, error) { return nil, errors.New("agent is locked") }
+	r = httptest.NewRequest(http.MethodGet, "http://api.example.com/", nil)
+	if err := s.SignRequest(r); err == nil || !strings.Contains(err.Error(), "agent is locked") || r.Header.Get(HeaderSignature) != "" {
+		t.Errorf("SignRequest with a failing key = %v, headers %v", err, r.Header)
+	}
+}
+
+func TestVerify_Age(t *testing.T) {
+	s, _ := newSigner(t)
+	signedAt := time.Now()
+	s.now = func() time.Time { return signedAt }
+	r := signedRequest(t, s, http.MethodGet, "http://api.example.com/", "")
+
+	v := &Verifier{MaxAge: time.Minute}
+	for _, tt := range []struct {
+		at time.Time
+		ok bool
+	}{
+		{signedAt.Add(30 * time.Second), true},
+		{signedAt.Add(2 * time.Minute), false},
+		{signedAt.Add(-2 * time.Minute), false},
+	} {
+		v.now = func() time.Time { return tt.at }
+		if _, err := v.Verify(r); (err == nil) != tt.ok {
+			t.Errorf("Verify at %s = %v", tt.at.Sub(signedAt), err)
+		}
+	}
+}
+
+func TestVerifier_Keys(t *testing.T) {
+	s, kp := newSigner(t)
+	s.KeyID = "agent-7"
+	r := signedRequest(t, s, http.MethodGet, "http://api.example.com/", "")
+
+	if _, err := Verify(r); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify with a non-address key ID = %v", err)
+	}
+	v := &Verifier{Keys: func(keyID string) (ed25519.PublicKey, error) {
+		if keyID != "agent-7" {
+			return nil, errors.New("unknown agent")
+		}
+		return kp.PublicKey, nil
+	}}
+	if id, err := v.Verify(r); err != nil || id.KeyID != "agent-7" {
+		t.Errorf("Verify = %+v, %v", id, err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	s, kp := newSigner(t)
+	var got *Identity
+	handler := (&Verifier{Required: true}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{Signer: s}}
+	resp, err := client.Post(server.URL+"/echo", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" || got == nil || got.KeyID != kp.PublicKeyBase58() {
+		t.Fatalf("signed POST = %d %q, identity %+v", resp.StatusCode, body, got)
+	}
+
+	resp, err = http.Get(server.URL + "/echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unsigned request with Required = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestHandler_Optional(t *testing.T) {
+	var signed bool
+	server := httptest.NewServer((&Verifier{}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, signed = FromContext(r.Context())
+	})))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || signed {
+		t.Errorf("unsigned request = %d, signed %v", resp.StatusCode, signed)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set(HeaderSignatureInput, `machpay=("@method");created=1`)
+	req.Header.Set(HeaderSignature, "machpay=:AAAA:")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("bad signature = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestSplitTopLevel(t *testing.T) {
+	got := splitTopLevel(`a=("x" "y,z");k="1,2", b=:QQ==:,c`)
+	want := []string{`a=("x" "y,z");k="1,2"`, `b=:QQ==:`, `c`}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitTopLevel = %q", got)
+	}
+}
+
@@ -0,0 +1,95 @@
+// ============================================================
+// Account - Balances and spending
+// ============================================================
+//
+//   bal, err := client.Balance(ctx)   // SOL and USDC of the wallet
+//   s, err := client.Spending()       // last 24h, as the policy counts it
+//   token := client.AuthToken()       // 'machpay login', for ConsoleURL
+//
+// ============================================================
+
+package machpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// ErrPolicyViolation matches payments refused by the spending policy
+// ('machpay policy') with errors.Is
+var ErrPolicyViolation = policy.ErrViolation
+
+// Balance is what the wallet holds
+type Balance struct {
+	Address  string
+	Lamports uint64 // SOL, for transaction fees
+	USDC     uint64 // atomic USDC units
+}
+
+// Balance returns the wallet's SOL and USDC balances. A wallet without
+// a USDC account has a USDC balance of 0.
+func (c *Client) Balance(ctx context.Context) (*Balance, error) {
+	b := &Balance{Address: c.Address()}
+	var err error
+	if b.Lamports, err = c.rpc.GetBalance(ctx, b.Address); err != nil {
+		return nil, err
+	}
+
+	ata, _, err := wallet.FindAssociatedTokenAddress(b.Address, c.usdcMint, wallet.TokenProgramID)
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.rpc.GetTokenAccountBalance(ctx, ata)
+	var rpcErr *solana.RPCError
+	if errors.As(err, &rpcErr) && strings.Contains(rpcErr.Message, "could not find account") {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.USDC, err = strconv.ParseUint(token.Amount, 10, 64); err != nil {
+		return nil, fmt.Errorf("USDC balance %q: %w", token.Amount, err)
+	}
+	return b, nil
+}
+
+// Spending is what was paid within the spending policy's window
+type Spending struct {
+	Since    time.Time
+	Total    uint64            // atomic USDC units
+	ByVendor map[string]uint64 // vendor address to atomic USDC units
+}
+
+// Spending returns the payments of the last 24 hours from the spend
+// ledger shared with the CLI, the figures the policy's daily limits
+// are checked against
+func (c *Client) Spending() (*Spending, error) {
+	entries, err := c.guard.Spent()
+	if err != nil {
+		return nil, err
+	}
+	total, byVendor := policy.Totals(entries)
+	return &Spending{Since: time.Now().Add(-policy.Window), Total: total, ByVendor: byVendor}, nil
+}
+
+// AuthToken returns the console token 'machpay login' saved, or ""
+// when the CLI is not logged in. The console API at ConsoleURL takes
+// it as a bearer token.
+func (c *Client) AuthToken() string {
+	return c.authToken
+}
+
+// ConsoleURL returns the console API base URL for the client's network
+// (MACHPAY_API_URL overrides it)
+func (c *Client) ConsoleURL() string {
+	return c.consoleURL
+}
+
@@ -0,0 +1,257 @@
+// ============================================================
+// MachPay - Go SDK for agents paying for APIs
+// ============================================================
+//
+// Usage:
+//
+//   client, err := machpay.NewClient()   // ~/.machpay, like the CLI
+//   client.MaxPrice = 0.01                 // USDC per request
+//   resp, payment, err := client.Do(req)   // pays 402s automatically
+//
+// The client shares the CLI's state: the config and network in
+// ~/.machpay/config.yaml, the active wallet (or the signing agent
+// when it holds that wallet's key), the spending policy, the spend
+// ledger and the 'machpay login' token (AuthToken, for ConsoleURL). Payments made here show up in 'machpay policy show' and
+// count towards the same limits as payments made with the CLI.
+//
+// BeforePayment and AfterPayment let the program add its own rules
+// and bookkeeping on top of the policy.
+//
+// ============================================================
+
+package machpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/signer"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// DefaultMaxPrice is the most a new client pays for one request, in USDC
+const DefaultMaxPrice = 0.01
+
+// Errors
+var (
+	ErrNoWallet = errors.New("no wallet configured: run 'machpay setup' or set Options.Wallet or Options.KeypairPath")
+
+	// ErrDeclined wraps every payment the client would not or could not
+	// make; the response was the server's 402
+	ErrDeclined = errors.New("payment declined")
+)
+
+// Options selects the state a client uses. The zero value is the
+// CLI's defaults.
+type Options struct {
+	ConfigPath  string // default: ~/.machpay/config.yaml
+	Wallet      string // registered wallet name (default: the active wallet)
+	KeypairPath string // keypair file, instead of a registered wallet
+	NoAgent     bool   // sign with the wallet file even if the signing agent runs
+}
+
+// Quote is a payment the client is about to make
+type Quote struct {
+	Request     *http.Request
+	PayTo       string // vendor wallet
+	Amount      uint64 // atomic USDC units
+	Network     string
+	Description string
+}
+
+// Payment is a payment the client made
+type Payment struct {
+	Quote
+	Settlement *x402.SettlementResponse // nil if the server sent no receipt
+}
+
+// USDC returns Amount in USDC
+func (q *Quote) USDC() float64 {
+	return float64(q.Amount) / math.Pow10(policy.USDCDecimals)
+}
+
+// Client is an HTTP client that pays x402 invoices from the MachPay
+// wallet
+type Client struct {
+	// MaxPrice is the most to pay for a single request, in USDC
+	MaxPrice float64
+
+	// BeforePayment, if set, is called before each payment; an error
+	// declines it. The spending policy has not been checked yet.
+	BeforePayment func(ctx context.Context, q *Quote) error
+
+	// AfterPayment, if set, is called after each payment the server
+	// accepted
+	AfterPayment func(p *Payment)
+
+	// HTTP sends requests; nil uses http.DefaultClient
+	HTTP *http.Client
+
+	signer   wallet.Signer
+	rpc      *solana.Client
+	network  string
+	usdcMint string
+	guard    *policy.Guard
+
+	authToken  string
+	consoleURL string
+}
+
+// NewClient returns a client using the CLI's config and active wallet
+func NewClient() (*Client, error) {
+	return NewClientWithOptions(Options{})
+}
+
+// NewClientWithOptions returns a client using the given config or
+// wallet
+func NewClientWithOptions(opts Options) (*Client, error) {
+	if err := config.Init(opts.ConfigPath); err != nil {
+		return nil, err
+	}
+	guard := policy.NewGuard(config.GetPolicyPath(), policy.NewLedger(config.GetLedgerPath()),
+		config.GetUSDCMint(), addressLabels)
+
+	s, err := loadSigner(opts, guard)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		MaxPrice: DefaultMaxPrice,
+		signer:   s,
+		rpc:      solana.NewClient(config.GetRPCURL()),
+		network:  x402.NetworkForCluster(config.GetCluster()),
+		usdcMint: config.GetUSDCMint(),
+		guard:    guard,
+
+		authToken:  config.Get().Auth.AccessToken,
+		consoleURL: config.GetAPIURL(),
+	}, nil
+}
+
+// loadSigner picks the signer like the CLI: the signing agent when it
+// holds the active wallet's key, otherwise the wallet file guarded by
+// the spending policy
+func loadSigner(opts Options, guard *policy.Guard) (wallet.Signer, error) {
+	if opts.Wallet == "" && opts.KeypairPath == "" && !opts.NoAgent {
+		if client, err := signer.Dial(config.GetSignerSocket()); err == nil {
+			active := config.Get().Wallet.PublicKey
+			if active == "" || active == wallet.SignerAddress(client) {
+				return client, nil
+			}
+			client.Close()
+		}
+	}
+
+	var kp *wallet.Keypair
+	var err error
+	switch {
+	case opts.KeypairPath != "":
+		kp, err = wallet.LoadFromFile(opts.KeypairPath)
+	case opts.Wallet != "":
+		kp, err = wallet.NewRegistry(config.GetWalletsDir()).Load(opts.Wallet)
+	case config.Get().Wallet.KeypairPath != "":
+		kp, err = wallet.LoadFromFile(config.Get().Wallet.KeypairPath)
+	default:
+		return nil, ErrNoWallet
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load wallet: %w", err)
+	}
+	return guard.Signer(wallet.NewKeypairSigner(kp)), nil
+}
+
+func addressLabels() map[string]string {
+	labels, err := wallet.NewAddressBook(config.GetAddressBookPath()).Labels()
+	if err != nil {
+		return nil
+	}
+	return labels
+}
+
+// Address returns the wallet the client pays from
+func (c *Client) Address() string {
+	return wallet.SignerAddress(c.signer)
+}
+
+// Network returns the x402 network the client pays on
+func (c *Client) Network() string {
+	return c.network
+}
+
+// Close releases the connection to the signing agent, if any
+func (c *Client) Close() error {
+	if closer, ok := c.signer.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Do sends req, paying when the server answers 402 Payment Required
+// with an offer within MaxPrice, BeforePayment and the spending
+// policy. The Payment is nil when none was needed. Declined payments
+// return an error wrapping ErrDeclined. A request with a body must
+// have GetBody set, as http.NewRequest does for in-memory bodies.
+func (c *Client) Do(req *http.Request) (*http.Response, *Payment, error) {
+	maxPrice, err := usdcUnits(c.MaxPrice)
+	if err != nil {
+		return nil, nil, fmt.Errorf("MaxPrice: %w", err)
+	}
+
+	var quote *Quote
+	pc := &payclient.Client{
+		HTTP:     c.HTTP,
+		Signer:   c.signer,
+		RPC:      c.rpc,
+		Network:  c.network,
+		USDCMint: c.usdcMint,
+		MaxPrice: maxPrice,
+		Approve: func(req *http.Request, offer x402.PaymentRequirements, amount uint64) error {
+			quote = &Quote{Request: req, PayTo: offer.PayTo, Amount: amount, Network: offer.Network, Description: offer.Description}
+			if c.BeforePayment != nil {
+				return c.BeforePayment(req.Context(), quote)
+			}
+			return nil
+		},
+	}
+
+	resp, paid, err := pc.Do(req)
+	var declined *payclient.DeclinedError
+	if errors.As(err, &declined) {
+		return nil, nil, fmt.Errorf("%w: %w", ErrDeclined, declined.Err)
+	}
+	if err != nil || paid == nil {
+		return resp, nil, err
+	}
+
+	payment := &Payment{Quote: *quote, Settlement: paid.Settlement}
+	if c.AfterPayment != nil {
+		c.AfterPayment(payment)
+	}
+	return resp, payment, nil
+}
+
+// Get pays for and fetches url
+func (c *Client) Get(url string) (*http.Response, *Payment, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Do(req)
+}
+
+// usdcUnits converts a USDC amount to atomic units
+func usdcUnits(usdc float64) (uint64, error) {
+	if math.IsNaN(usdc) || usdc < 0 || usdc > math.MaxUint64/math.Pow10(policy.USDCDecimals) {
+		return 0, fmt.Errorf("invalid USDC amount %g", usdc)
+	}
+	return uint64(math.Round(usdc * math.Pow10(policy.USDCDecimals))), nil
+}
+
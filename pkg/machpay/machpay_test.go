@@ -0,0 +1,251 @@
+package machpay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/machpay-xyz/machpay-cli/internal/config"
+	"github.com/machpay-xyz/machpay-cli/internal/policy"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// newFakeRPC answers the calls a client makes. A nil usdc makes the
+// wallet's USDC account missing.
+func newFakeRPC(t *testing.T, usdc *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": 1}
+		switch req.Method {
+		case "getLatestBlockhash":
+			resp["result"] = map[string]interface{}{
+				"value": map[string]interface{}{"blockhash": "4uQeVj5tqViQh7yWWGStvkEG1Zmhx6uasJtWCJziofM", "lastValidBlockHeight": 100},
+			}
+		case "getBalance":
+			resp["result"] = map[string]interface{}{"value": 5000000}
+		case "getTokenAccountBalance":
+			if usdc == nil {
+				resp["error"] = map[string]interface{}{"code": -32602, "message": "Invalid param: could not find account"}
+				break
+			}
+			resp["result"] = map[string]interface{}{"value": map[string]interface{}{"amount": *usdc, "decimals": 6, "uiAmountString": "?"}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newVendor charges price for every request and settles payments
+// that pass x402.VerifyExact
+func newVendor(t *testing.T, price uint64) (server *httptest.Server, payTo string) {
+	t.Helper()
+	vendor, _ := wallet.Generate()
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		required := x402.PaymentRequirements{
+			Scheme:            x402.SchemeExact,
+			Network:           x402.NetworkSolanaDevnet,
+			MaxAmountRequired: fmt.Sprint(price),
+			Resource:          "http://" + r.Host + r.URL.Path,
+			Description:       "Quote",
+			PayTo:             vendor.PublicKeyBase58(),
+			MaxTimeoutSeconds: 60,
+			Asset:             x402.USDCMintSolanaDevnet,
+		}
+		payload, err := x402.DecodePaymentHeader(r.Header.Get(x402.HeaderPayment))
+		var payment *x402.ExactPayment
+		if err == nil {
+			payment, err = x402.VerifyExact(payload, &required)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusPaymentRequired)
+			json.NewEncoder(w).Encode(x402.PaymentRequired{X402Version: x402.Version, Error: err.Error(), Accepts: []x402.PaymentRequirements{required}})
+			return
+		}
+		value, _ := x402.EncodeHeader(x402.SettlementResponse{
+			Success:     true,
+			Transaction: wallet.Base58Encode(make([]byte, 64)),
+			Network:     x402.NetworkSolanaDevnet,
+			Payer:       payment.Payer,
+		})
+		w.Header().Set(x402.HeaderPaymentResponse, value)
+		io.WriteString(w, "quote")
+	}))
+	t.Cleanup(server.Close)
+	return server, vendor.PublicKeyBase58()
+}
+
+// setupState writes a MachPay config with a wallet on a test network
+// and returns its path
+func setupState(t *testing.T, rpcURL string) (configPath, address string) {
+	t.Helper()
+	dir := t.TempDir()
+	kp, _ := wallet.Generate()
+	keyPath := filepath.Join(dir, "wallet.json")
+	if err := kp.SaveToFile(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	configPath = filepath.Join(dir, "config.yaml")
+	data := fmt.Sprintf(`network: test
+networks:
+  test:
+    rpc_url: %s
+    cluster: devnet
+wallet:
+  keypair_path: %s
+  public_key: %s
+`, rpcURL, keyPath, kp.PublicKeyBase58())
+	if err := os.WriteFile(configPath, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return configPath, kp.PublicKeyBase58()
+}
+
+func TestClient_Do(t *testing.T) {
+	rpc := newFakeRPC(t, nil)
+	configPath, address := setupState(t, rpc.URL)
+	vendor, payTo := newVendor(t, 1500)
+
+	client, err := NewClientWithOptions(Options{ConfigPath: configPath})
+	if err != nil {
+		t.Fatalf("NewClientWithOptions: %v", err)
+	}
+	defer client.Close()
+	if client.Address() != address || client.Network() != x402.NetworkSolanaDevnet {
+		t.Errorf("client is %s on %s", client.Address(), client.Network())
+	}
+
+	var quoted *Quote
+	var paid []*Payment
+	client.BeforePayment = func(ctx context.Context, q *Quote) error {
+		quoted = q
+		return nil
+	}
+	client.AfterPayment = func(p *Payment) { paid = append(paid, p) }
+
+	resp, payment, err := client.Get(vendor.URL + "/quote")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "quote" {
+		t.Errorf("body = %q", body)
+	}
+	if quoted == nil || quoted.PayTo != payTo || quoted.Amount != 1500 || quoted.USDC() != 0.0015 || quoted.Description != "Quote" {
+		t.Errorf("quote = %+v", quoted)
+	}
+	if payment == nil || len(paid) != 1 || payment.Settlement == nil || payment.Settlement.Payer != address {
+		t.Errorf("payment = %+v, AfterPayment got %d", payment, len(paid))
+	}
+
+	// The payment is in the ledger the CLI reads
+	spending, err := client.Spending()
+	if err != nil {
+		t.Fatalf("Spending: %v", err)
+	}
+	if spending.Total != 1500 || spending.ByVendor[payTo] != 1500 {
+		t.Errorf("spending = %+v", spending)
+	}
+
+	// MaxPrice
+	client.MaxPrice = 0.001
+	if _, _, err := client.Get(vendor.URL + "/quote"); !errors.Is(err, ErrDeclined) {
+		t.Errorf("Get over MaxPrice = %v, want ErrDeclined", err)
+	}
+}
+
+func TestClient_Hooks(t *testing.T) {
+	rpc := newFakeRPC(t, nil)
+	configPath, _ := setupState(t, rpc.URL)
+	vendor, _ := newVendor(t, 1500)
+
+	client, err := NewClientWithOptions(Options{ConfigPath: configPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// BeforePayment declines
+	budget := errors.New("over the agent's budget")
+	client.BeforePayment = func(ctx context.Context, q *Quote) error { return budget }
+	if _, _, err := client.Get(vendor.URL); !errors.Is(err, ErrDeclined) || !errors.Is(err, budget) {
+		t.Errorf("Get = %v, want the hook's refusal", err)
+	}
+
+	// The spending policy applies as in the CLI
+	client.BeforePayment = nil
+	if err := (&policy.Policy{MaxPerRequest: "0.001"}).Save(config.GetPolicyPath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := client.Get(vendor.URL); !errors.Is(err, ErrPolicyViolation) || !errors.Is(err, ErrDeclined) {
+		t.Errorf("Get = %v, want a policy violation", err)
+	}
+	if spending, _ := client.Spending(); spending.Total != 0 {
+		t.Errorf("spent %d after refusals", spending.Total)
+	}
+}
+
+func TestClient_Balance(t *testing.T) {
+	usdc := "2500000"
+	rpc := newFakeRPC(t, &usdc)
+	configPath, address := setupState(t, rpc.URL)
+	client, err := NewClientWithOptions(Options{ConfigPath: configPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := client.Balance(context.Background())
+	if err != nil {
+		t.Fatalf("Balance: %v", err)
+	}
+	if b.Address != address || b.Lamports != 5000000 || b.USDC != 2500000 {
+		t.Errorf("balance = %+v", b)
+	}
+
+	// No USDC account yet
+	rpc = newFakeRPC(t, nil)
+	configPath, _ = setupState(t, rpc.URL)
+	if client, err = NewClientWithOptions(Options{ConfigPath: configPath}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := client.Balance(context.Background()); err != nil || b.USDC != 0 {
+		t.Errorf("Balance = %+v, %v; want 0 USDC", b, err)
+	}
+}
+
+func TestClient_AuthToken(t *testing.T) {
+	configPath, _ := setupState(t, "http://127.0.0.1:1")
+	f, _ := os.OpenFile(configPath, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString("auth:\n  access_token: console-token\n")
+	f.Close()
+	t.Setenv("MACHPAY_API_URL", "https://console.example.com/api")
+
+	client, err := NewClientWithOptions(Options{ConfigPath: configPath, NoAgent: true})
+	if err != nil {
+		t.Fatalf("NewClientWithOptions: %v", err)
+	}
+	if client.AuthToken() != "console-token" || client.ConsoleURL() != "https://console.example.com/api" {
+		t.Errorf("AuthToken, ConsoleURL = %q, %q", client.AuthToken(), client.ConsoleURL())
+	}
+}
+
+func TestNewClient_NoWallet(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if _, err := NewClientWithOptions(Options{ConfigPath: configPath}); !errors.Is(err, ErrNoWallet) {
+		t.Errorf("NewClientWithOptions = %v, want ErrNoWallet", err)
+	}
+}
+
@@ -0,0 +1,340 @@
+// ============================================================
+// Paywall - x402 payment middleware for net/http
+// ============================================================
+//
+// Usage:
+//
+//   pw, err := paywall.New(paywall.Config{
+//       Pricing:     paywall.Pricing{PricePerRequest: 0.001},
+//       PayTo:       "B8Uw...",            // vendor wallet
+//       Network:     x402.NetworkSolana,
+//       Facilitator: x402.NewFacilitator("https://facilitator.machpay.xyz"),
+//   })
+//   http.ListenAndServe(":8080", pw.Handler(mux))
+//
+// A priced request without a payment gets 402 Payment Required with
+// the requirements. A request with X-PAYMENT is verified by the
+// facilitator, served with the payment in its context (FromContext),
+// and settled once the handler succeeds; the receipt goes back in
+// X-PAYMENT-RESPONSE. Failed requests (status 400 and above) are not
+// settled, so payers are not charged for errors.
+//
+// Responses to paid requests are buffered until settlement, so paid
+// routes cannot stream.
+//
+// ============================================================
+
+package paywall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// DefaultMaxTimeoutSeconds is how long a payer has to pay after a 402
+const DefaultMaxTimeoutSeconds = 60
+
+// Backoff between failed /supported calls while the fee payer is unknown
+const (
+	supportedRetryMin = time.Second
+	supportedRetryMax = time.Minute
+)
+
+// Config configures the middleware
+type Config struct {
+	Pricing     Pricing
+	PayTo       string            // vendor wallet receiving payments
+	Network     string            // x402.NetworkSolana or x402.NetworkSolanaDevnet
+	Asset       string            // default: USDC on Network
+	Facilitator *x402.Facilitator // verifies and settles payments
+
+	// FeePayer is the facilitator's fee payer, named in the
+	// requirements. Default: the one it advertises in /supported.
+	FeePayer string
+
+	MaxTimeoutSeconds int // default: DefaultMaxTimeoutSeconds
+
+	// OnSettled, if set, is called after each settlement attempt
+	OnSettled func(r *http.Request, p *Payment, s *x402.SettlementResponse, err error)
+}
+
+// Payment is a verified payment, available to handlers through
+// FromContext
+type Payment struct {
+	Payer        string // wallet that paid
+	Amount       uint64 // atomic USDC units
+	Requirements *x402.PaymentRequirements
+	Payload      *x402.PaymentPayload
+}
+
+type contextKey struct{}
+
+// FromContext returns the payment for the request being served
+func FromContext(ctx context.Context) (*Payment, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Payment)
+	return p, ok
+}
+
+// Payer returns the wallet that paid for r, or ""
+func Payer(r *http.Request) string {
+	if p, ok := FromContext(r.Context()); ok {
+		return p.Payer
+	}
+	return ""
+}
+
+// Paywall enforces x402 payment for priced routes
+type Paywall struct {
+	cfg    Config
+	routes []compiledRoute
+
+	mu       sync.Mutex
+	feePayer string
+	asked    bool          // the facilitator's /supported has answered
+	fetching chan struct{} // closed when the /supported call in flight returns
+	failures int           // /supported calls failed in a row
+	retryAt  time.Time     // no /supported call before this
+}
+
+// New validates the config and returns the middleware
+func New(cfg Config) (*Paywall, error) {
+	routes, err := cfg.Pricing.compile()
+	if err != nil {
+		return nil, err
+	}
+	if !x402.IsSolanaNetwork(cfg.Network) {
+		return nil, fmt.Errorf("unsupported network %q", cfg.Network)
+	}
+	if cfg.Asset == "" {
+		cfg.Asset = x402.USDCMint(cfg.Network)
+	}
+	if cfg.MaxTimeoutSeconds == 0 {
+		cfg.MaxTimeoutSeconds = DefaultMaxTimeoutSeconds
+	}
+	if cfg.Facilitator == nil {
+		return nil, errors.New("no facilitator")
+	}
+
+	// Validate the static part of the requirements once
+	probe := x402.PaymentRequirements{
+		Scheme:            x402.SchemeExact,
+		Network:           cfg.Network,
+		MaxAmountRequired: "1",
+		Resource:          "http://localhost/",
+		PayTo:             cfg.PayTo,
+		MaxTimeoutSeconds: cfg.MaxTimeoutSeconds,
+		Asset:             cfg.Asset,
+	}
+	if cfg.FeePayer != "" {
+		probe.Extra = map[string]interface{}{"feePayer": cfg.FeePayer}
+	}
+	if err := probe.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Paywall{cfg: cfg, routes: routes, feePayer: cfg.FeePayer}, nil
+}
+
+// Price returns what r costs, or nil if it is free
+func (pw *Paywall) Price(r *http.Request) *Price {
+	for i := range pw.routes {
+		if pw.routes[i].matches(r) {
+			return &pw.routes[i].price
+		}
+	}
+	return nil
+}
+
+// Handler wraps next, requiring payment for priced routes
+func (pw *Paywall) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		price := pw.Price(r)
+		if price == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		req := pw.requirements(r, price)
+
+		value := r.Header.Get(x402.HeaderPayment)
+		if value == "" {
+			writePaymentRequired(w, req, "X-PAYMENT header is required")
+			return
+		}
+		payload, err := x402.DecodePaymentHeader(value)
+		if err != nil {
+			writePaymentRequired(w, req, err.Error())
+			return
+		}
+		if payload.Scheme != req.Scheme || payload.Network != req.Network {
+			writePaymentRequired(w, req, fmt.Sprintf("paid with %s on %s, this resource accepts %s on %s",
+				payload.Scheme, payload.Network, req.Scheme, req.Network))
+			return
+		}
+
+		verified, err := pw.cfg.Facilitator.Verify(r.Context(), payload, req)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, "payment verification failed: "+err.Error())
+			return
+		}
+		if err := verified.Err(); err != nil {
+			writePaymentRequired(w, req, err.Error())
+			return
+		}
+
+		payment := &Payment{Payer: verified.Payer, Amount: price.Amount, Requirements: req, Payload: payload}
+		buf := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(buf, r.WithContext(context.WithValue(r.Context(), contextKey{}, payment)))
+		if buf.status >= http.StatusBadRequest {
+			buf.writeTo(w)
+			return
+		}
+
+		settled, err := pw.cfg.Facilitator.Settle(r.Context(), payload, req)
+		if pw.cfg.OnSettled != nil {
+			pw.cfg.OnSettled(r, payment, settled, err)
+		}
+		if err != nil {
+			writeError(w, http.StatusBadGateway, "payment settlement failed: "+err.Error())
+			return
+		}
+		if !settled.Success {
+			writePaymentRequired(w, req, "settlement failed: "+settled.ErrorReason)
+			return
+		}
+		if header, err := x402.EncodeHeader(settled); err == nil {
+			buf.header.Set(x402.HeaderPaymentResponse, header)
+		}
+		buf.writeTo(w)
+	})
+}
+
+// requirements returns the payment requirements for r
+func (pw *Paywall) requirements(r *http.Request, price *Price) *x402.PaymentRequirements {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	req := &x402.PaymentRequirements{
+		Scheme:            x402.SchemeExact,
+		Network:           pw.cfg.Network,
+		MaxAmountRequired: fmt.Sprint(price.Amount),
+		Resource:          scheme + "://" + r.Host + r.URL.Path,
+		Description:       price.Description,
+		PayTo:             pw.cfg.PayTo,
+		MaxTimeoutSeconds: pw.cfg.MaxTimeoutSeconds,
+		Asset:             pw.cfg.Asset,
+	}
+	if fp := pw.feePayerFor(r.Context()); fp != "" {
+		req.Extra = map[string]interface{}{"feePayer": fp}
+	}
+	return req
+}
+
+// feePayerFor returns the configured fee payer, or asks the
+// facilitator for it until it answers. One request at a time asks,
+// without holding the lock; the others wait for its answer. After a
+// failure requests go without a fee payer until the backoff passes.
+func (pw *Paywall) feePayerFor(ctx context.Context) string {
+	pw.mu.Lock()
+	if pw.feePayer != "" || pw.asked {
+		defer pw.mu.Unlock()
+		return pw.feePayer
+	}
+	if wait := pw.fetching; wait != nil {
+		pw.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ""
+		}
+		pw.mu.Lock()
+		defer pw.mu.Unlock()
+		return pw.feePayer
+	}
+	if time.Now().Before(pw.retryAt) {
+		pw.mu.Unlock()
+		return ""
+	}
+	done := make(chan struct{})
+	pw.fetching = done
+	pw.mu.Unlock()
+
+	// Other requests wait on this call: the caller leaving must not
+	// cancel it
+	supported, err := pw.cfg.Facilitator.Supported(context.WithoutCancel(ctx))
+
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	pw.fetching = nil
+	close(done)
+	if err != nil {
+		pw.failures++
+		backoff := supportedRetryMax
+		if pw.failures < 8 {
+			backoff = min(supportedRetryMin<<(pw.failures-1), supportedRetryMax)
+		}
+		pw.retryAt = time.Now().Add(backoff)
+		return ""
+	}
+	pw.asked = true
+	if k := supported.Find(x402.SchemeExact, pw.cfg.Network); k != nil {
+		pw.feePayer = k.FeePayer()
+	}
+	return pw.feePayer
+}
+
+func writePaymentRequired(w http.ResponseWriter, req *x402.PaymentRequirements, reason string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPaymentRequired)
+	json.NewEncoder(w).Encode(x402.PaymentRequired{
+		X402Version: x402.Version,
+		Error:       reason,
+		Accepts:     []x402.PaymentRequirements{*req},
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// bufferedResponse holds a paid response until it is settled
+type bufferedResponse struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status, b.wroteHeader = status, true
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) writeTo(w http.ResponseWriter) {
+	for name, values := range b.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(b.status)
+	w.Write(b.body.Bytes())
+}
+
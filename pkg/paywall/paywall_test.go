@@ -0,0 +1,390 @@
+package paywall
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/machpay-xyz/machpay-cli/internal/payclient"
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+	"github.com/machpay-xyz/machpay-cli/pkg/x402"
+)
+
+// fakeFacilitator verifies payments with x402.VerifyExact and settles
+// them without a network
+type fakeFacilitator struct {
+	feePayer *wallet.Keypair
+
+	mu        sync.Mutex
+	verified  int
+	settled   int
+	settleErr string // reported by /settle when set
+}
+
+func newFakeFacilitator(t *testing.T) (*fakeFacilitator, *x402.Facilitator) {
+	t.Helper()
+	f := &fakeFacilitator{}
+	f.feePayer, _ = wallet.Generate()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/supported" {
+			json.NewEncoder(w).Encode(x402.SupportedResponse{Kinds: []x402.SupportedKind{{
+				X402Version: x402.Version,
+				Scheme:      x402.SchemeExact,
+				Network:     x402.NetworkSolanaDevnet,
+				Extra:       map[string]interface{}{"feePayer": f.feePayer.PublicKeyBase58()},
+			}}})
+			return
+		}
+
+		var req x402.FacilitatorRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		payment, err := x402.VerifyExact(&req.PaymentPayload, &req.PaymentRequirements)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch r.URL.Path {
+		case "/verify":
+			f.verified++
+			if err != nil {
+				json.NewEncoder(w).Encode(x402.VerifyResponse{InvalidReason: err.Error()})
+				return
+			}
+			json.NewEncoder(w).Encode(x402.VerifyResponse{IsValid: true, Payer: payment.Payer})
+		case "/settle":
+			if err == nil && f.settleErr != "" {
+				err = errors.New(f.settleErr)
+			}
+			if err != nil {
+				json.NewEncoder(w).Encode(x402.SettlementResponse{ErrorReason: err.Error()})
+				return
+			}
+			f.settled++
+			json.NewEncoder(w).Encode(x402.SettlementResponse{
+				Success:     true,
+				Transaction: wallet.Base58Encode(make([]byte, 64)),
+				Network:     req.PaymentRequirements.Network,
+				Payer:       payment.Payer,
+			})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return f, x402.NewFacilitator(server.URL)
+}
+
+func (f *fakeFacilitator) counts() (verified, settled int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.verified, f.settled
+}
+
+// newPayer returns a client paying from a new wallet
+func newPayer(t *testing.T) (*payclient.Client, string) {
+	t.Helper()
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": map[string]interface{}{
+				"value": map[string]interface{}{
+					"blockhash":            "4uQeVj5tqViQh7yWWGStvkEG1Zmhx6uasJtWCJziofM",
+					"lastValidBlockHeight": 100,
+				},
+			},
+		})
+	}))
+	t.Cleanup(rpc.Close)
+
+	kp, _ := wallet.Generate()
+	return &payclient.Client{
+		Signer:   wallet.NewKeypairSigner(kp),
+		RPC:      solana.NewClient(rpc.URL),
+		Network:  x402.NetworkSolanaDevnet,
+		USDCMint: x402.USDCMintSolanaDevnet,
+		MaxPrice: math.MaxUint64,
+	}, kp.PublicKeyBase58()
+}
+
+// newTestServer serves "paid <payer>" behind a paywall
+func newTestServer(t *testing.T, pricing Pricing, facilitator *x402.Facilitator) *httptest.Server {
+	t.Helper()
+	vendor, _ := wallet.Generate()
+	pw, err := New(Config{
+		Pricing:     pricing,
+		PayTo:       vendor.PublicKeyBase58(),
+		Network:     x402.NetworkSolanaDevnet,
+		Facilitator: facilitator,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "paid "+Payer(r))
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream broke", http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(pw.Handler(mux))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, client *payclient.Client, url string) (*http.Response, string, *payclient.Payment, error) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, payment, err := client.Do(req)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body), payment, nil
+}
+
+func TestPaywall_Pays(t *testing.T) {
+	f, facilitator := newFakeFacilitator(t)
+	server := newTestServer(t, Pricing{Routes: []Route{
+		{Pattern: "GET /quote", Price: 0.0025, Description: "A quote"},
+		{Pattern: "/fail"},
+	}, PricePerRequest: 0.001}, facilitator)
+	client, payer := newPayer(t)
+
+	// The 402 names the price, description and the facilitator's fee payer
+	resp, err := http.Get(server.URL + "/quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	required, err := x402.ParsePaymentRequired(body)
+	if resp.StatusCode != http.StatusPaymentRequired || err != nil {
+		t.Fatalf("unpaid request = %d %s (%v)", resp.StatusCode, body, err)
+	}
+	offer := required.Accepts[0]
+	if offer.MaxAmountRequired != "2500" || offer.Description != "A quote" || offer.FeePayer() != f.feePayer.PublicKeyBase58() {
+		t.Errorf("offer = %+v", offer)
+	}
+
+	resp, text, payment, err := get(t, client, server.URL+"/quote")
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || text != "paid "+payer {
+		t.Errorf("paid request = %d %q, want the payer in the context", resp.StatusCode, text)
+	}
+	if payment == nil || payment.Amount != 2500 || payment.Settlement == nil || !payment.Settlement.Success {
+		t.Errorf("payment = %+v", payment)
+	}
+	if verified, settled := f.counts(); verified != 1 || settled != 1 {
+		t.Errorf("verified %d, settled %d; want 1 each", verified, settled)
+	}
+
+	// Failed requests are not settled
+	resp, _, _, err = get(t, client, server.URL+"/fail")
+	if err != nil || resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("failing route = %v, %v", resp, err)
+	}
+	if _, settled := f.counts(); settled != 1 {
+		t.Errorf("settled %d, want the failed request unsettled", settled)
+	}
+
+	// Unpriced routes are free
+	resp, text, payment, err = get(t, client, server.URL+"/free")
+	if err != nil || resp.StatusCode != http.StatusOK || payment != nil || text != "paid " {
+		t.Errorf("free route = %v %q %+v %v", resp, text, payment, err)
+	}
+}
+
+func TestPaywall_Rejects(t *testing.T) {
+	f, facilitator := newFakeFacilitator(t)
+	server := newTestServer(t, Pricing{PricePerRequest: 0.001}, facilitator)
+	other := newTestServer(t, Pricing{PricePerRequest: 0.001}, facilitator)
+	client, _ := newPayer(t)
+
+	// A payment made out to another vendor
+	resp, err := http.Get(other.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	required, err := readRequired(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := client.Pay(context.Background(), required.Accepts[0], 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/", nil)
+	req.Header.Set(x402.HeaderPayment, header)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	required, err = readRequired(resp)
+	if resp.StatusCode != http.StatusPaymentRequired || err != nil || !strings.Contains(required.Error, "invalid payment") {
+		t.Errorf("misdirected payment = %d %+v (%v)", resp.StatusCode, required, err)
+	}
+
+	// A payment the facilitator cannot settle
+	f.mu.Lock()
+	f.settleErr = "blockhash expired"
+	f.mu.Unlock()
+	_, _, _, err = get(t, client, server.URL+"/")
+	if !errors.Is(err, payclient.ErrPaymentRejected) || !strings.Contains(err.Error(), "blockhash expired") {
+		t.Errorf("unsettled payment = %v, want the settlement failure", err)
+	}
+
+	if _, settled := f.counts(); settled != 0 {
+		t.Errorf("settled %d, want 0", settled)
+	}
+}
+
+func readRequired(resp *http.Response) (*x402.PaymentRequired, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return x402.ParsePaymentRequired(body)
+}
+
+func TestPaywall_FeePayerLookup(t *testing.T) {
+	feePayer, _ := wallet.Generate()
+	var calls atomic.Int32
+	fail := true
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail {
+			http.Error(w, "down", http.StatusBadRequest)
+			return
+		}
+		<-release
+		json.NewEncoder(w).Encode(x402.SupportedResponse{Kinds: []x402.SupportedKind{{
+			X402Version: x402.Version,
+			Scheme:      x402.SchemeExact,
+			Network:     x402.NetworkSolanaDevnet,
+			Extra:       map[string]interface{}{"feePayer": feePayer.PublicKeyBase58()},
+		}}})
+	}))
+	defer server.Close()
+	vendor, _ := wallet.Generate()
+	pw, err := New(Config{
+		Pricing:     Pricing{PricePerRequest: 0.001},
+		PayTo:       vendor.PublicKeyBase58(),
+		Network:     x402.NetworkSolanaDevnet,
+		Facilitator: &x402.Facilitator{URL: server.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failure backs off instead of asking on every request
+	ctx := context.Background()
+	if fp := pw.feePayerFor(ctx); fp != "" || calls.Load() != 1 {
+		t.Fatalf("failed lookup = %q after %d calls", fp, calls.Load())
+	}
+	if fp := pw.feePayerFor(ctx); fp != "" || calls.Load() != 1 {
+		t.Fatalf("lookup during backoff = %q after %d calls", fp, calls.Load())
+	}
+
+	// Once the backoff passes, one request asks and the rest wait for
+	// its answer, without the lock held
+	fail = false
+	pw.mu.Lock()
+	pw.retryAt = time.Time{}
+	pw.mu.Unlock()
+	results := make(chan string, 5)
+	for i := 0; i < 5; i++ {
+		go func() { results <- pw.feePayerFor(ctx) }()
+	}
+	for calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if !pw.mu.TryLock() {
+		t.Fatal("lock held during the /supported call")
+	}
+	pw.mu.Unlock()
+	close(release)
+	for i := 0; i < 5; i++ {
+		if fp := <-results; fp != feePayer.PublicKeyBase58() {
+			t.Errorf("lookup = %q", fp)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("%d /supported calls, want 2", calls.Load())
+	}
+}
+
+func TestPricing(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	os.WriteFile(configFile, []byte(`role: vendor
+vendor:
+  upstream_url: http://localhost:8080
+  price_per_request: 0.001
+  routes:
+    - pattern: GET /v1/quote
+      price: 0.0025
+    - pattern: /v1/search/*
+`), 0600)
+	bareFile := filepath.Join(dir, "pricing.yaml")
+	os.WriteFile(bareFile, []byte("price_per_request: 0.5\n"), 0600)
+
+	p, err := LoadPricing(configFile)
+	if err != nil {
+		t.Fatalf("LoadPricing(config): %v", err)
+	}
+	routes, _ := p.compile()
+	for target, want := range map[string]uint64{
+		"GET /v1/quote":         2500,
+		"HEAD /v1/quote":        2500,
+		"POST /v1/quote":        0,
+		"POST /v1/search":       1000,
+		"GET /v1/search/a/b":    1000,
+		"GET /v1/searchengine":  0,
+		"GET /v1/quote/details": 0,
+	} {
+		method, path, _ := strings.Cut(target, " ")
+		r := httptest.NewRequest(method, path, nil)
+		pw := &Paywall{routes: routes}
+		got := uint64(0)
+		if price := pw.Price(r); price != nil {
+			got = price.Amount
+		}
+		if got != want {
+			t.Errorf("price of %s = %d, want %d", target, got, want)
+		}
+	}
+
+	if p, err := LoadPricing(bareFile); err != nil || p.PricePerRequest != 0.5 {
+		t.Errorf("LoadPricing(bare) = %+v, %v", p, err)
+	}
+
+	for _, bad := range []Pricing{
+		{PricePerRequest: -1},
+		{PricePerRequest: 0.0000001},
+		{Routes: []Route{{Pattern: "/free-for-all"}}},
+		{PricePerRequest: 1, Routes: []Route{{Pattern: "v1/quote"}}},
+		{PricePerRequest: 1, Routes: []Route{{Pattern: "/v1/*/quote"}}},
+		{PricePerRequest: 1, Routes: []Route{{Pattern: "GET /a /b"}}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", bad)
+		}
+	}
+}
+
@@ -0,0 +1,186 @@
+// ============================================================
+// Pricing - Vendor price lists
+// ============================================================
+//
+// The price list has the format of the vendor section of
+// ~/.machpay/config.yaml, so a service can share it with the gateway:
+//
+//   vendor:
+//     price_per_request: 0.001        # USDC, for every request...
+//     routes:                         # ...or only for these
+//       - pattern: GET /v1/quote
+//         price: 0.0025
+//         description: Real-time quote
+//       - pattern: /v1/search/*       # any method, path prefix
+//
+// Routes are matched in order and the first match wins; a route
+// without a price costs price_per_request. With routes configured,
+// requests that match none are free.
+//
+// ============================================================
+
+package paywall
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// usdcDecimals is the number of decimals of USDC
+const usdcDecimals = 6
+
+// Pricing is a vendor's price list. Prices are in USDC.
+type Pricing struct {
+	PricePerRequest float64 `yaml:"price_per_request,omitempty"`
+	Routes          []Route `yaml:"routes,omitempty"`
+}
+
+// Route prices the requests matching Pattern: "[METHOD] /path", where
+// a path ending in /* matches everything below it and "*" matches all
+// paths
+type Route struct {
+	Pattern     string  `yaml:"pattern"`
+	Price       float64 `yaml:"price,omitempty"`       // default: price_per_request
+	Description string  `yaml:"description,omitempty"` // shown to payers in the 402
+}
+
+// Price is what a request costs
+type Price struct {
+	Amount      uint64 // atomic USDC units
+	Description string
+}
+
+// LoadPricing reads a price list from a YAML file: a MachPay config
+// (its vendor section) or a file holding just the price list
+func LoadPricing(path string) (*Pricing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Vendor  *Pricing `yaml:"vendor"`
+		Pricing `yaml:",inline"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	p := &file.Pricing
+	if file.Vendor != nil {
+		p = file.Vendor
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.PricePerRequest == 0 && len(p.Routes) == 0 {
+		return nil, fmt.Errorf("%s: no prices set", path)
+	}
+	return p, nil
+}
+
+// Validate checks the prices and route patterns
+func (p *Pricing) Validate() error {
+	if _, err := p.compile(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// compiledRoute is a parsed Route
+type compiledRoute struct {
+	method string // "" for any
+	path   string
+	prefix bool
+	price  Price
+}
+
+func (p *Pricing) compile() ([]compiledRoute, error) {
+	def, err := atomicPrice(p.PricePerRequest)
+	if err != nil {
+		return nil, fmt.Errorf("price_per_request: %w", err)
+	}
+	if len(p.Routes) == 0 {
+		if def == 0 {
+			return nil, nil
+		}
+		return []compiledRoute{{path: "/", prefix: true, price: Price{Amount: def}}}, nil
+	}
+
+	routes := make([]compiledRoute, 0, len(p.Routes))
+	for _, r := range p.Routes {
+		c, err := parsePattern(r.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := atomicPrice(r.Price)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", r.Pattern, err)
+		}
+		if amount == 0 {
+			amount = def
+		}
+		if amount == 0 {
+			return nil, fmt.Errorf("route %q has no price and price_per_request is not set", r.Pattern)
+		}
+		c.price = Price{Amount: amount, Description: r.Description}
+		routes = append(routes, c)
+	}
+	return routes, nil
+}
+
+// parsePattern parses "[METHOD] /path[/*]" or "[METHOD] *"
+func parsePattern(pattern string) (compiledRoute, error) {
+	var c compiledRoute
+	fields := strings.Fields(pattern)
+	switch len(fields) {
+	case 1:
+		c.path = fields[0]
+	case 2:
+		c.method, c.path = strings.ToUpper(fields[0]), fields[1]
+	default:
+		return c, fmt.Errorf("route %q: want \"[METHOD] /path\"", pattern)
+	}
+
+	switch {
+	case c.path == "*":
+		c.path, c.prefix = "/", true
+	case !strings.HasPrefix(c.path, "/"):
+		return c, fmt.Errorf("route %q: path must start with /", pattern)
+	case strings.HasSuffix(c.path, "/*"):
+		c.path, c.prefix = strings.TrimSuffix(c.path, "*"), true
+	case strings.Contains(c.path, "*"):
+		return c, fmt.Errorf("route %q: * is only allowed at the end of the path", pattern)
+	}
+	return c, nil
+}
+
+func (c *compiledRoute) matches(r *http.Request) bool {
+	if c.method != "" && c.method != r.Method && !(c.method == http.MethodGet && r.Method == http.MethodHead) {
+		return false
+	}
+	if c.prefix {
+		return strings.HasPrefix(r.URL.Path, c.path) || r.URL.Path+"/" == c.path
+	}
+	return r.URL.Path == c.path
+}
+
+// atomicPrice converts a USDC price to atomic units
+func atomicPrice(usdc float64) (uint64, error) {
+	if math.IsNaN(usdc) || math.IsInf(usdc, 0) || usdc < 0 {
+		return 0, errors.New("price must be a positive number")
+	}
+	amount := math.Round(usdc * math.Pow10(usdcDecimals))
+	if usdc > 0 && amount == 0 {
+		return 0, fmt.Errorf("price %g is below the smallest USDC unit", usdc)
+	}
+	if amount >= math.MaxUint64 {
+		return 0, fmt.Errorf("price %g is too large", usdc)
+	}
+	return uint64(amount), nil
+}
+
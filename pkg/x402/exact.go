@@ -0,0 +1,181 @@
+// ============================================================
+// Exact Scheme - Verify Solana "exact" payments
+// ============================================================
+//
+// A valid payment transaction contains only:
+//
+//   Compute budget  unit limit / unit price (price capped)
+//   ATA             create payTo's token account (idempotent),
+//                   paid for by the payer, never the fee payer
+//   Token           exactly one transferChecked of the required
+//                   amount of the asset, from the payer to payTo's
+//                   associated token account
+//
+// signed by the payer (the transfer authority). The fee payer's
+// signature is left to the facilitator, which must not be asked to
+// sign anything that moves its own funds.
+//
+// ============================================================
+
+package x402
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/machpay-xyz/machpay-cli/internal/solana"
+)
+
+// MaxComputeUnitPrice caps the priority fee, in micro-lamports per
+// compute unit, that a payment may make the fee payer spend
+const MaxComputeUnitPrice = 5_000_000
+
+// Instruction discriminators accepted in a payment
+const (
+	ixComputeUnitLimit    = 2
+	ixComputeUnitPrice    = 3
+	ixATACreateIdempotent = 1
+	ixTransferChecked     = 12
+)
+
+// ErrInvalidPayment is returned when a payment does not satisfy its
+// requirements
+var ErrInvalidPayment = errors.New("invalid payment")
+
+// ExactPayment is a verified Solana "exact" payment
+type ExactPayment struct {
+	Payer       string // transfer authority; signed the transaction
+	FeePayer    string // pays the transaction fee
+	Amount      uint64 // atomic units of the asset
+	Source      string // payer's token account
+	Destination string // payTo's associated token account
+	Signed      bool   // every required signature, fee payer's included, is present
+}
+
+// VerifyExact checks that payload pays the requirements: the right
+// network, asset, amount and recipient, a valid payer signature and
+// nothing else in the transaction. It does not check balances or the
+// blockhash, which need the network.
+func VerifyExact(payload *PaymentPayload, req *PaymentRequirements) (*ExactPayment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+	if payload.Scheme != req.Scheme || payload.Network != req.Network {
+		return nil, paymentError("paid with %s on %s, requirements are %s on %s",
+			payload.Scheme, payload.Network, req.Scheme, req.Network)
+	}
+	amount, _ := req.Amount()
+	mint := solana.MustPublicKey(req.Asset)
+	payTo := solana.MustPublicKey(req.PayTo)
+
+	tx, err := solana.TransactionFromBase64(payload.Payload.Transaction)
+	if err != nil {
+		return nil, paymentError("transaction: %v", err)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		return nil, paymentError("%v", err)
+	}
+
+	feePayer := tx.Message.AccountKeys[0]
+	if fp := req.FeePayer(); fp != "" && feePayer.String() != fp {
+		return nil, paymentError("fee payer is %s, requirements name %s", feePayer, fp)
+	}
+	sponsored := req.FeePayer() != ""
+
+	var transfer *solana.DecodedInstruction
+	for _, ix := range solana.DecodeInstructions(&tx.Message) {
+		if len(ix.Data) == 0 {
+			return nil, paymentError("instruction without data for %s", ix.Program)
+		}
+		switch ix.Program {
+		case solana.ComputeBudgetProgramID:
+			switch {
+			case ix.Data[0] == ixComputeUnitLimit && len(ix.Data) == 5:
+			case ix.Data[0] == ixComputeUnitPrice && len(ix.Data) == 9:
+				if price := binary.LittleEndian.Uint64(ix.Data[1:]); price > MaxComputeUnitPrice {
+					return nil, paymentError("compute unit price %d exceeds %d", price, MaxComputeUnitPrice)
+				}
+			default:
+				return nil, paymentError("unexpected compute budget instruction")
+			}
+
+		case solana.AssociatedTokenProgramID:
+			if ix.Data[0] != ixATACreateIdempotent || len(ix.Accounts) < 6 {
+				return nil, paymentError("unexpected associated token account instruction")
+			}
+			if ix.Accounts[2] != payTo || ix.Accounts[3] != mint {
+				return nil, paymentError("creates a token account other than payTo's")
+			}
+			if sponsored && ix.Accounts[0] == feePayer {
+				return nil, paymentError("token account rent charged to the fee payer")
+			}
+
+		case solana.TokenProgramID, solana.Token2022ProgramID:
+			if ix.Data[0] != ixTransferChecked || len(ix.Data) != 10 || len(ix.Accounts) < 4 {
+				return nil, paymentError("unexpected token instruction")
+			}
+			if transfer != nil {
+				return nil, paymentError("more than one transfer")
+			}
+			ix := ix
+			transfer = &ix
+
+		default:
+			return nil, paymentError("unexpected instruction for program %s", ix.Program)
+		}
+	}
+	if transfer == nil {
+		return nil, paymentError("no token transfer")
+	}
+
+	source, mintAccount, destination, authority := transfer.Accounts[0], transfer.Accounts[1], transfer.Accounts[2], transfer.Accounts[3]
+	if mintAccount != mint {
+		return nil, paymentError("transfers mint %s, requirements ask for %s", mintAccount, mint)
+	}
+	want, err := solana.AssociatedTokenAddress(payTo, mint, transfer.Program)
+	if err != nil {
+		return nil, paymentError("derive payTo token account: %v", err)
+	}
+	if destination != want {
+		return nil, paymentError("pays token account %s, not payTo's %s", destination, want)
+	}
+	if paid := binary.LittleEndian.Uint64(transfer.Data[1:9]); paid != amount {
+		return nil, paymentError("pays %d, requirements ask for %d", paid, amount)
+	}
+	if sponsored && authority == feePayer {
+		return nil, paymentError("transfer authorized by the fee payer")
+	}
+
+	authoritySigns := false
+	for _, signer := range tx.Message.Signers() {
+		authoritySigns = authoritySigns || signer == authority
+	}
+	if !authoritySigns {
+		return nil, paymentError("transfer authority %s is not a signer", authority)
+	}
+	signed := true
+	for _, missing := range tx.MissingSigners() {
+		if missing == authority {
+			return nil, paymentError("missing the payer's signature")
+		}
+		signed = false
+	}
+
+	return &ExactPayment{
+		Payer:       authority.String(),
+		FeePayer:    feePayer.String(),
+		Amount:      amount,
+		Source:      source.String(),
+		Destination: destination.String(),
+		Signed:      signed,
+	}, nil
+}
+
+func paymentError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPayment, fmt.Sprintf(format, args...))
+}
+
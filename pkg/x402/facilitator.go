@@ -0,0 +1,293 @@
+// ============================================================
+// Facilitator - Client for the x402 facilitator API
+// ============================================================
+//
+// A facilitator verifies and settles payments for vendors:
+//
+//   POST /verify     {x402Version, paymentPayload, paymentRequirements}
+//                    → {isValid, invalidReason, payer}
+//   POST /settle     same request → SettlementResponse
+//   GET  /supported  → {kinds: [{x402Version, scheme, network, extra}]}
+//
+// Transient failures (network errors, 429 and 5xx) are retried with
+// exponential backoff. Retrying /settle is safe for Solana: a
+// transaction's signature can only land once.
+//
+// Responses are decoded leniently, unlike the protocol messages,
+// since facilitators add fields of their own.
+//
+// ============================================================
+
+package x402
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Facilitator client defaults
+const (
+	DefaultFacilitatorTimeout = 10 * time.Second
+	DefaultFacilitatorRetries = 2
+	DefaultFacilitatorBackoff = 250 * time.Millisecond
+
+	maxFacilitatorResponse = 1 << 20
+)
+
+// ErrFacilitator is wrapped by every FacilitatorError
+var ErrFacilitator = errors.New("facilitator error")
+
+// FacilitatorError is an unsuccessful response from a facilitator
+type FacilitatorError struct {
+	Op         string // "verify", "settle" or "supported"
+	StatusCode int
+	Message    string
+}
+
+func (e *FacilitatorError) Error() string {
+	return fmt.Sprintf("facilitator %s: HTTP %d: %s", e.Op, e.StatusCode, e.Message)
+}
+
+func (e *FacilitatorError) Unwrap() error {
+	return ErrFacilitator
+}
+
+// Temporary reports whether the request may succeed if retried
+func (e *FacilitatorError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// FacilitatorRequest is the body of /verify and /settle
+type FacilitatorRequest struct {
+	X402Version         int                 `json:"x402Version"`
+	PaymentPayload      PaymentPayload      `json:"paymentPayload"`
+	PaymentRequirements PaymentRequirements `json:"paymentRequirements"`
+}
+
+// VerifyResponse is the facilitator's verdict on a payment
+type VerifyResponse struct {
+	IsValid       bool   `json:"isValid"`
+	InvalidReason string `json:"invalidReason,omitempty"`
+	Payer         string `json:"payer,omitempty"`
+}
+
+// Err returns nil for a valid payment and an ErrInvalidPayment
+// carrying the reason otherwise
+func (r *VerifyResponse) Err() error {
+	if r.IsValid {
+		return nil
+	}
+	reason := r.InvalidReason
+	if reason == "" {
+		reason = "rejected by the facilitator"
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidPayment, reason)
+}
+
+// SupportedKind is a scheme and network a facilitator settles
+type SupportedKind struct {
+	X402Version int                    `json:"x402Version"`
+	Scheme      string                 `json:"scheme"`
+	Network     string                 `json:"network"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+// FeePayer returns the account the facilitator pays fees from, if
+// it advertises one
+func (k *SupportedKind) FeePayer() string {
+	s, _ := k.Extra["feePayer"].(string)
+	return s
+}
+
+// SupportedResponse lists what a facilitator settles
+type SupportedResponse struct {
+	Kinds []SupportedKind `json:"kinds"`
+}
+
+// Find returns the kind for scheme and network, or nil
+func (r *SupportedResponse) Find(scheme, network string) *SupportedKind {
+	for i := range r.Kinds {
+		if r.Kinds[i].Scheme == scheme && r.Kinds[i].Network == network {
+			return &r.Kinds[i]
+		}
+	}
+	return nil
+}
+
+// Facilitator is a client for a facilitator's HTTP API
+type Facilitator struct {
+	URL     string        // base URL, e.g. https://facilitator.machpay.xyz
+	HTTP    *http.Client  // nil uses http.DefaultClient
+	Header  http.Header   // added to every request, e.g. an API key
+	Timeout time.Duration // per attempt; 0 means no limit beyond ctx
+	Retries int           // further attempts after a transient failure
+	Backoff time.Duration // wait before the first retry; doubles each time
+}
+
+// NewFacilitator returns a client with the default timeout and retries
+func NewFacilitator(baseURL string) *Facilitator {
+	return &Facilitator{
+		URL:     strings.TrimSuffix(baseURL, "/"),
+		Timeout: DefaultFacilitatorTimeout,
+		Retries: DefaultFacilitatorRetries,
+		Backoff: DefaultFacilitatorBackoff,
+	}
+}
+
+// Verify asks the facilitator whether payload pays req. An invalid
+// payment is a response with IsValid false, not an error.
+func (f *Facilitator) Verify(ctx context.Context, payload *PaymentPayload, req *PaymentRequirements) (*VerifyResponse, error) {
+	var resp VerifyResponse
+	err := f.do(ctx, "verify", http.MethodPost, "/verify", newFacilitatorRequest(payload, req), &resp, func() bool {
+		return resp.InvalidReason != ""
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Settle asks the facilitator to submit the payment. A failed
+// settlement is a response with Success false, not an error.
+func (f *Facilitator) Settle(ctx context.Context, payload *PaymentPayload, req *PaymentRequirements) (*SettlementResponse, error) {
+	var resp SettlementResponse
+	err := f.do(ctx, "settle", http.MethodPost, "/settle", newFacilitatorRequest(payload, req), &resp, func() bool {
+		return resp.ErrorReason != ""
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Supported lists the schemes and networks the facilitator settles
+func (f *Facilitator) Supported(ctx context.Context) (*SupportedResponse, error) {
+	var resp SupportedResponse
+	if err := f.do(ctx, "supported", http.MethodGet, "/supported", nil, &resp, nil); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func newFacilitatorRequest(payload *PaymentPayload, req *PaymentRequirements) *FacilitatorRequest {
+	return &FacilitatorRequest{X402Version: Version, PaymentPayload: *payload, PaymentRequirements: *req}
+}
+
+// do sends a request, retrying transient failures. A 4xx response
+// whose body decodes into out with a reason (answered reports it) is
+// a verdict, not an error.
+func (f *Facilitator) do(ctx context.Context, op, method, path string, in, out interface{}, answered func() bool) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	backoff := f.Backoff
+	for attempt := 0; ; attempt++ {
+		err := f.attempt(ctx, op, method, path, body, out, answered)
+		if err == nil || attempt >= f.Retries || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (f *Facilitator) attempt(ctx context.Context, op, method, path string, body []byte, out interface{}, answered func() bool) error {
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, f.URL+path, reader)
+	if err != nil {
+		return err
+	}
+	for name, values := range f.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := f.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("facilitator %s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFacilitatorResponse))
+	if err != nil {
+		return fmt.Errorf("facilitator %s: read response: %w", op, err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("facilitator %s: decode response: %w", op, err)
+		}
+		return nil
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && answered != nil {
+		if json.Unmarshal(data, out) == nil && answered() {
+			return nil
+		}
+	}
+	return &FacilitatorError{Op: op, StatusCode: resp.StatusCode, Message: errorMessage(data, resp.Status)}
+}
+
+// retryable reports whether a failed attempt is worth repeating:
+// transport errors (timeouts included) and temporary HTTP statuses
+func retryable(err error) bool {
+	var fe *FacilitatorError
+	if errors.As(err, &fe) {
+		return fe.Temporary()
+	}
+	var ue *url.Error
+	return errors.As(err, &ue)
+}
+
+// errorMessage extracts a readable message from an error body
+func errorMessage(body []byte, status string) string {
+	var e struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &e) == nil {
+		if e.Error != "" {
+			return e.Error
+		}
+		if e.Message != "" {
+			return e.Message
+		}
+	}
+	if text := strings.TrimSpace(string(body)); text != "" && len(text) <= 200 {
+		return text
+	}
+	return status
+}
+
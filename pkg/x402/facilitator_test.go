@@ -0,0 +1,241 @@
+package x402
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// standIn is a local facilitator answering each path with scripted
+// responses, the last one repeating
+type standIn struct {
+	mu       sync.Mutex
+	replies  map[string][]reply
+	requests map[string][]FacilitatorRequest
+}
+
+type reply struct {
+	status int
+	body   string
+	delay  time.Duration
+}
+
+func newStandIn(t *testing.T, replies map[string][]reply) (*standIn, *Facilitator) {
+	t.Helper()
+	s := &standIn{replies: replies, requests: map[string][]FacilitatorRequest{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		if r.Method == http.MethodPost {
+			var req FacilitatorRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			s.requests[r.URL.Path] = append(s.requests[r.URL.Path], req)
+		}
+		queue := s.replies[r.URL.Path]
+		if len(queue) == 0 {
+			s.mu.Unlock()
+			http.NotFound(w, r)
+			return
+		}
+		next := queue[0]
+		if len(queue) > 1 {
+			s.replies[r.URL.Path] = queue[1:]
+		}
+		s.mu.Unlock()
+
+		if next.delay > 0 {
+			select {
+			case <-time.After(next.delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(next.status)
+		w.Write([]byte(next.body))
+	}))
+	t.Cleanup(server.Close)
+
+	f := NewFacilitator(server.URL + "/")
+	f.Backoff = time.Millisecond
+	return s, f
+}
+
+func (s *standIn) calls(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests[path])
+}
+
+func testPayment() (*PaymentPayload, *PaymentRequirements) {
+	req := &PaymentRequirements{
+		Scheme:            SchemeExact,
+		Network:           NetworkSolanaDevnet,
+		MaxAmountRequired: "1000",
+		Resource:          "https://api.example.com/quote",
+		PayTo:             "B8UwBUUnKwCyKuGMbFKWaG7exYdDk2ozZrPg72NyVbfj",
+		MaxTimeoutSeconds: 60,
+		Asset:             "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
+	}
+	payload := &PaymentPayload{
+		X402Version: Version,
+		Scheme:      SchemeExact,
+		Network:     NetworkSolanaDevnet,
+		Payload:     ExactPayload{Transaction: "AQID"},
+	}
+	return payload, req
+}
+
+func TestFacilitator_Verify(t *testing.T) {
+	s, f := newStandIn(t, map[string][]reply{
+		"/verify": {
+			{status: http.StatusServiceUnavailable, body: `{"error":"warming up"}`},
+			{status: http.StatusOK, body: `{"isValid":true,"payer":"payer","fee":"5000"}`},
+		},
+	})
+	payload, req := testPayment()
+
+	resp, err := f.Verify(context.Background(), payload, req)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !resp.IsValid || resp.Payer != "payer" || resp.Err() != nil {
+		t.Errorf("response = %+v", resp)
+	}
+	if n := s.calls("/verify"); n != 2 {
+		t.Errorf("calls = %d, want 2 (one retry)", n)
+	}
+	got := s.requests["/verify"][1]
+	if got.X402Version != Version || got.PaymentPayload != *payload || got.PaymentRequirements.PayTo != req.PayTo {
+		t.Errorf("request = %+v", got)
+	}
+}
+
+func TestFacilitator_VerifyInvalid(t *testing.T) {
+	s, f := newStandIn(t, map[string][]reply{
+		"/verify": {{status: http.StatusBadRequest, body: `{"isValid":false,"invalidReason":"insufficient_funds"}`}},
+	})
+	payload, req := testPayment()
+
+	resp, err := f.Verify(context.Background(), payload, req)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if resp.IsValid || !errors.Is(resp.Err(), ErrInvalidPayment) || resp.InvalidReason != "insufficient_funds" {
+		t.Errorf("response = %+v, Err() = %v", resp, resp.Err())
+	}
+	if n := s.calls("/verify"); n != 1 {
+		t.Errorf("calls = %d, a verdict must not be retried", n)
+	}
+}
+
+func TestFacilitator_Settle(t *testing.T) {
+	_, f := newStandIn(t, map[string][]reply{
+		"/settle": {{status: http.StatusOK, body: `{"success":true,"transaction":"sig","network":"solana-devnet","payer":"payer"}`}},
+	})
+	payload, req := testPayment()
+
+	resp, err := f.Settle(context.Background(), payload, req)
+	if err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if !resp.Success || resp.Transaction != "sig" || resp.Network != NetworkSolanaDevnet {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestFacilitator_Supported(t *testing.T) {
+	_, f := newStandIn(t, map[string][]reply{
+		"/supported": {{status: http.StatusOK, body: `{"kinds":[
+			{"x402Version":1,"scheme":"exact","network":"solana","extra":{"feePayer":"fee"}},
+			{"x402Version":1,"scheme":"exact","network":"base"}
+		]}`}},
+	})
+
+	resp, err := f.Supported(context.Background())
+	if err != nil {
+		t.Fatalf("Supported: %v", err)
+	}
+	if len(resp.Kinds) != 2 {
+		t.Fatalf("kinds = %+v", resp.Kinds)
+	}
+	if k := resp.Find(SchemeExact, NetworkSolana); k == nil || k.FeePayer() != "fee" {
+		t.Errorf("Find(solana) = %+v", k)
+	}
+	if k := resp.Find(SchemeExact, NetworkSolanaDevnet); k != nil {
+		t.Errorf("Find(solana-devnet) = %+v, want nil", k)
+	}
+}
+
+func TestFacilitator_Errors(t *testing.T) {
+	payload, req := testPayment()
+
+	t.Run("client error", func(t *testing.T) {
+		s, f := newStandIn(t, map[string][]reply{
+			"/settle": {{status: http.StatusUnauthorized, body: `{"error":"bad api key"}`}},
+		})
+		_, err := f.Settle(context.Background(), payload, req)
+		var fe *FacilitatorError
+		if !errors.As(err, &fe) || fe.StatusCode != http.StatusUnauthorized || fe.Message != "bad api key" || fe.Temporary() {
+			t.Fatalf("Settle = %v, want a permanent FacilitatorError", err)
+		}
+		if !errors.Is(err, ErrFacilitator) {
+			t.Errorf("error does not wrap ErrFacilitator")
+		}
+		if n := s.calls("/settle"); n != 1 {
+			t.Errorf("calls = %d, want 1", n)
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		s, f := newStandIn(t, map[string][]reply{
+			"/settle": {{status: http.StatusBadGateway, body: "upstream down"}},
+		})
+		_, err := f.Settle(context.Background(), payload, req)
+		var fe *FacilitatorError
+		if !errors.As(err, &fe) || fe.StatusCode != http.StatusBadGateway || !fe.Temporary() {
+			t.Fatalf("Settle = %v, want a temporary FacilitatorError", err)
+		}
+		if n := s.calls("/settle"); n != 1+DefaultFacilitatorRetries {
+			t.Errorf("calls = %d, want %d", n, 1+DefaultFacilitatorRetries)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		s, f := newStandIn(t, map[string][]reply{
+			"/verify": {
+				{status: http.StatusOK, body: `{"isValid":true}`, delay: time.Second},
+				{status: http.StatusOK, body: `{"isValid":true}`},
+			},
+		})
+		f.Timeout = 50 * time.Millisecond
+		resp, err := f.Verify(context.Background(), payload, req)
+		if err != nil || !resp.IsValid {
+			t.Fatalf("Verify = %+v, %v; want success after a timed-out attempt", resp, err)
+		}
+		if n := s.calls("/verify"); n != 2 {
+			t.Errorf("calls = %d, want 2", n)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		_, f := newStandIn(t, map[string][]reply{
+			"/supported": {{status: http.StatusServiceUnavailable}},
+		})
+		f.Backoff = time.Hour
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if _, err := f.Supported(ctx); err == nil {
+			t.Fatal("Supported succeeded")
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Error("backoff ignored the context")
+		}
+	})
+}
+
@@ -0,0 +1,107 @@
+package x402
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// vectors mirrors testdata/vectors.json
+type vectors struct {
+	PaymentRequired []struct {
+		Name  string `json:"name"`
+		Body  string `json:"body"`
+		Valid bool   `json:"valid"`
+	} `json:"paymentRequired"`
+	Requirements []struct {
+		Name  string          `json:"name"`
+		JSON  json.RawMessage `json:"json"`
+		Valid bool            `json:"valid"`
+	} `json:"requirements"`
+	Headers []struct {
+		Name   string `json:"name"`
+		Header string `json:"header"`
+		Value  string `json:"value"`
+		Valid  bool   `json:"valid"`
+	} `json:"headers"`
+	Exact []struct {
+		Name         string              `json:"name"`
+		Requirements PaymentRequirements `json:"requirements"`
+		Payment      string              `json:"payment"`
+		Valid        bool                `json:"valid"`
+		Payer        string              `json:"payer"`
+		Signed       bool                `json:"signed"`
+	} `json:"exact"`
+}
+
+func loadVectors(t *testing.T) *vectors {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "vectors.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v vectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func TestVectors_PaymentRequired(t *testing.T) {
+	for _, tc := range loadVectors(t).PaymentRequired {
+		_, err := ParsePaymentRequired([]byte(tc.Body))
+		if (err == nil) != tc.Valid {
+			t.Errorf("%s: ParsePaymentRequired = %v, want valid %v", tc.Name, err, tc.Valid)
+		}
+	}
+}
+
+func TestVectors_Requirements(t *testing.T) {
+	for _, tc := range loadVectors(t).Requirements {
+		var r PaymentRequirements
+		err := unmarshalStrict(tc.JSON, &r)
+		if err == nil {
+			err = r.Validate()
+		}
+		if (err == nil) != tc.Valid {
+			t.Errorf("%s: %v, want valid %v", tc.Name, err, tc.Valid)
+		}
+	}
+}
+
+func TestVectors_Headers(t *testing.T) {
+	for _, tc := range loadVectors(t).Headers {
+		var err error
+		switch tc.Header {
+		case HeaderPayment:
+			_, err = DecodePaymentHeader(tc.Value)
+		case HeaderPaymentResponse:
+			_, err = DecodeSettlementHeader(tc.Value)
+		default:
+			t.Fatalf("%s: unknown header %q", tc.Name, tc.Header)
+		}
+		if (err == nil) != tc.Valid {
+			t.Errorf("%s: %v, want valid %v", tc.Name, err, tc.Valid)
+		}
+	}
+}
+
+func TestVectors_Exact(t *testing.T) {
+	for _, tc := range loadVectors(t).Exact {
+		payload, err := DecodePaymentHeader(tc.Payment)
+		if err != nil {
+			t.Errorf("%s: DecodePaymentHeader: %v", tc.Name, err)
+			continue
+		}
+		payment, err := VerifyExact(payload, &tc.Requirements)
+		if (err == nil) != tc.Valid {
+			t.Errorf("%s: VerifyExact = %v, want valid %v", tc.Name, err, tc.Valid)
+			continue
+		}
+		if err == nil && (payment.Payer != tc.Payer || payment.Signed != tc.Signed) {
+			t.Errorf("%s: payment = %+v", tc.Name, payment)
+		}
+	}
+}
+
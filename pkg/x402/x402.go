@@ -0,0 +1,357 @@
+// ============================================================
+// x402 - HTTP 402 payment protocol messages
+// ============================================================
+//
+// A paid resource answers 402 with the payments it accepts:
+//
+//   HTTP/1.1 402 Payment Required
+//   {"x402Version":1,"accepts":[{"scheme":"exact",...}]}
+//
+// The client retries with a signed payment in X-PAYMENT and the
+// vendor reports settlement in X-PAYMENT-RESPONSE. Both headers are
+// base64-encoded JSON.
+//
+// For the Solana "exact" scheme the payment is a partially signed
+// transaction moving the exact amount of the asset to payTo; the
+// facilitator named in extra.feePayer co-signs and submits it
+// (see exact.go).
+//
+// Decoding is strict: unknown fields, trailing data and non-canonical
+// base64 are rejected, and the Decode/Parse functions validate what
+// they return. testdata/vectors.json holds test vectors shared with
+// implementations in other languages.
+//
+// ============================================================
+
+package x402
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/machpay-xyz/machpay-cli/internal/wallet"
+)
+
+// Version is the protocol version this package speaks
+const Version = 1
+
+// Headers
+const (
+	HeaderPayment         = "X-PAYMENT"
+	HeaderPaymentResponse = "X-PAYMENT-RESPONSE"
+)
+
+// SchemeExact pays exactly the required amount
+const SchemeExact = "exact"
+
+// Solana networks
+const (
+	NetworkSolana       = "solana"
+	NetworkSolanaDevnet = "solana-devnet"
+)
+
+// USDC mints of the Solana networks
+const (
+	USDCMintSolana       = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	USDCMintSolanaDevnet = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+)
+
+// MaxHeaderSize bounds encoded header values. A Solana transaction is
+// at most 1232 bytes, so real payments are far smaller.
+const MaxHeaderSize = 8 << 10
+
+// signatureLength is the size of an ed25519 transaction signature
+const signatureLength = 64
+
+// Errors
+var (
+	ErrInvalidHeader  = errors.New("invalid x402 header")
+	ErrInvalidMessage = errors.New("invalid x402 message")
+)
+
+// PaymentRequired is the body of a 402 response
+type PaymentRequired struct {
+	X402Version int                   `json:"x402Version"`
+	Error       string                `json:"error,omitempty"`
+	Accepts     []PaymentRequirements `json:"accepts"`
+}
+
+// PaymentRequirements describes one way to pay for a resource
+type PaymentRequirements struct {
+	Scheme            string                 `json:"scheme"`
+	Network           string                 `json:"network"`
+	MaxAmountRequired string                 `json:"maxAmountRequired"` // atomic units of Asset
+	Resource          string                 `json:"resource"`
+	Description       string                 `json:"description,omitempty"`
+	MimeType          string                 `json:"mimeType,omitempty"`
+	PayTo             string                 `json:"payTo"`
+	MaxTimeoutSeconds int                    `json:"maxTimeoutSeconds"`
+	Asset             string                 `json:"asset"` // token mint
+	OutputSchema      json.RawMessage        `json:"outputSchema,omitempty"`
+	Extra             map[string]interface{} `json:"extra,omitempty"`
+}
+
+// FeePayer returns the facilitator that pays the transaction fee,
+// or "" when the payer is expected to
+func (r *PaymentRequirements) FeePayer() string {
+	s, _ := r.Extra["feePayer"].(string)
+	return s
+}
+
+// Amount returns MaxAmountRequired in atomic units
+func (r *PaymentRequirements) Amount() (uint64, error) {
+	return ParseAmount(r.MaxAmountRequired)
+}
+
+// PaymentPayload is the decoded X-PAYMENT header
+type PaymentPayload struct {
+	X402Version int          `json:"x402Version"`
+	Scheme      string       `json:"scheme"`
+	Network     string       `json:"network"`
+	Payload     ExactPayload `json:"payload"`
+}
+
+// ExactPayload carries a Solana "exact" payment
+type ExactPayload struct {
+	Transaction string `json:"transaction"` // base64 wire-format, partially signed
+}
+
+// SettlementResponse is the decoded X-PAYMENT-RESPONSE header
+type SettlementResponse struct {
+	Success     bool   `json:"success"`
+	ErrorReason string `json:"errorReason,omitempty"`
+	Transaction string `json:"transaction,omitempty"` // signature of the settled transaction
+	Network     string `json:"network,omitempty"`
+	Payer       string `json:"payer,omitempty"`
+}
+
+// ============================================================
+// Validation
+// ============================================================
+
+// Validate checks the version and that some payment is offered. The
+// offers themselves may be for other chains and are not checked.
+func (pr *PaymentRequired) Validate() error {
+	if pr.X402Version != Version {
+		return invalid("unsupported x402 version %d", pr.X402Version)
+	}
+	if len(pr.Accepts) == 0 {
+		return invalid("402 response lists no accepted payments")
+	}
+	return nil
+}
+
+// Validate checks that r is a payable Solana "exact" offer
+func (r *PaymentRequirements) Validate() error {
+	if r.Scheme != SchemeExact {
+		return invalid("unsupported scheme %q", r.Scheme)
+	}
+	if !IsSolanaNetwork(r.Network) {
+		return invalid("unsupported network %q", r.Network)
+	}
+	amount, err := r.Amount()
+	if err != nil {
+		return invalid("maxAmountRequired: %v", err)
+	}
+	if amount == 0 {
+		return invalid("maxAmountRequired is zero")
+	}
+	if u, err := url.Parse(r.Resource); err != nil || !u.IsAbs() {
+		return invalid("resource %q is not an absolute URL", r.Resource)
+	}
+	if err := validateKey("payTo", r.PayTo); err != nil {
+		return err
+	}
+	if err := validateKey("asset", r.Asset); err != nil {
+		return err
+	}
+	if r.MaxTimeoutSeconds <= 0 {
+		return invalid("maxTimeoutSeconds must be positive")
+	}
+	if fp, ok := r.Extra["feePayer"]; ok {
+		s, isString := fp.(string)
+		if !isString {
+			return invalid("extra.feePayer must be a string")
+		}
+		if err := validateKey("extra.feePayer", s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the envelope of a Solana "exact" payment. The
+// transaction itself is checked by VerifyExact.
+func (p *PaymentPayload) Validate() error {
+	if p.X402Version != Version {
+		return invalid("unsupported x402 version %d", p.X402Version)
+	}
+	if p.Scheme != SchemeExact {
+		return invalid("unsupported scheme %q", p.Scheme)
+	}
+	if !IsSolanaNetwork(p.Network) {
+		return invalid("unsupported network %q", p.Network)
+	}
+	if p.Payload.Transaction == "" {
+		return invalid("payload.transaction is empty")
+	}
+	if _, err := base64.StdEncoding.Strict().DecodeString(p.Payload.Transaction); err != nil {
+		return invalid("payload.transaction is not base64: %v", err)
+	}
+	return nil
+}
+
+// Validate checks that a successful settlement names its transaction
+// and a failed one its reason
+func (s *SettlementResponse) Validate() error {
+	if !s.Success {
+		if s.ErrorReason == "" {
+			return invalid("failed settlement without errorReason")
+		}
+		return nil
+	}
+	if sig, err := wallet.Base58Decode(s.Transaction); err != nil || len(sig) != signatureLength {
+		return invalid("transaction %q is not a transaction signature", s.Transaction)
+	}
+	if !IsSolanaNetwork(s.Network) {
+		return invalid("unsupported network %q", s.Network)
+	}
+	if s.Payer != "" {
+		return validateKey("payer", s.Payer)
+	}
+	return nil
+}
+
+// IsSolanaNetwork reports whether network is a Solana x402 network
+func IsSolanaNetwork(network string) bool {
+	return network == NetworkSolana || network == NetworkSolanaDevnet
+}
+
+// ParseAmount parses an atomic amount: decimal digits without sign or
+// leading zeros that fit in 64 bits
+func ParseAmount(s string) (uint64, error) {
+	if s == "" || strings.Trim(s, "0123456789") != "" || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q out of range", s)
+	}
+	return n, nil
+}
+
+func validateKey(field, address string) error {
+	if _, err := wallet.ParsePublicKey(address); err != nil {
+		return invalid("%s: %v", field, err)
+	}
+	return nil
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidMessage, fmt.Sprintf(format, args...))
+}
+
+// ============================================================
+// Encoding
+// ============================================================
+
+// ParsePaymentRequired strictly decodes and validates a 402 response
+// body
+func ParsePaymentRequired(body []byte) (*PaymentRequired, error) {
+	var pr PaymentRequired
+	if err := unmarshalStrict(body, &pr); err != nil {
+		return nil, fmt.Errorf("parse payment requirements: %w", err)
+	}
+	if err := pr.Validate(); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// DecodePaymentHeader decodes and validates an X-PAYMENT value
+func DecodePaymentHeader(value string) (*PaymentPayload, error) {
+	var p PaymentPayload
+	if err := DecodeHeader(value, &p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DecodeSettlementHeader decodes and validates an X-PAYMENT-RESPONSE
+// value
+func DecodeSettlementHeader(value string) (*SettlementResponse, error) {
+	var s SettlementResponse
+	if err := DecodeHeader(value, &s); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// EncodeHeader encodes v as a header value
+func EncodeHeader(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecodeHeader strictly decodes a header value into v without
+// validating it
+func DecodeHeader(value string, v interface{}) error {
+	if len(value) > MaxHeaderSize {
+		return fmt.Errorf("%w: %d bytes exceeds %d", ErrInvalidHeader, len(value), MaxHeaderSize)
+	}
+	data, err := base64.StdEncoding.Strict().DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if err := unmarshalStrict(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	return nil
+}
+
+// unmarshalStrict is json.Unmarshal rejecting unknown fields and
+// anything after the value
+func unmarshalStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// USDCMint returns the USDC mint of a Solana network
+func USDCMint(network string) string {
+	if network == NetworkSolana {
+		return USDCMintSolana
+	}
+	return USDCMintSolanaDevnet
+}
+
+// NetworkForCluster returns the x402 network name of a cluster
+func NetworkForCluster(cluster string) string {
+	if cluster == "mainnet" {
+		return NetworkSolana
+	}
+	return NetworkSolanaDevnet
+}
+
@@ -0,0 +1,91 @@
+package x402
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePaymentRequired(t *testing.T) {
+	body := []byte(`{
+		"x402Version": 1,
+		"error": "X-PAYMENT header is required",
+		"accepts": [{
+			"scheme": "exact",
+			"network": "solana-devnet",
+			"maxAmountRequired": "1000",
+			"resource": "https://api.example.com/quote",
+			"payTo": "B8UwBUUnKwCyKuGMbFKWaG7exYdDk2ozZrPg72NyVbfj",
+			"maxTimeoutSeconds": 60,
+			"asset": "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
+			"extra": {"feePayer": "7pxrn6LqK3rmvca2j1b2oV5jUGMNQaofjxLKiwYm15YN"}
+		}]
+	}`)
+
+	pr, err := ParsePaymentRequired(body)
+	if err != nil {
+		t.Fatalf("ParsePaymentRequired: %v", err)
+	}
+	r := pr.Accepts[0]
+	if r.Scheme != SchemeExact || r.MaxAmountRequired != "1000" || r.FeePayer() != "7pxrn6LqK3rmvca2j1b2oV5jUGMNQaofjxLKiwYm15YN" {
+		t.Errorf("requirements = %+v", r)
+	}
+
+	for _, bad := range []string{
+		`not json`,
+		`{"x402Version": 2, "accepts": [{}]}`,
+		`{"x402Version": 1, "accepts": []}`,
+	} {
+		if _, err := ParsePaymentRequired([]byte(bad)); err == nil {
+			t.Errorf("ParsePaymentRequired(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestHeaderRoundTrip(t *testing.T) {
+	in := SettlementResponse{Success: true, Transaction: "sig", Network: NetworkSolanaDevnet, Payer: "payer"}
+	value, err := EncodeHeader(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out SettlementResponse
+	if err := DecodeHeader(value, &out); err != nil {
+		t.Fatalf("DecodeHeader: %v", err)
+	}
+	if out != in {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+
+	for _, bad := range []string{"%%%", "bm90IGpzb24="} {
+		if err := DecodeHeader(bad, &out); !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("DecodeHeader(%q) = %v, want ErrInvalidHeader", bad, err)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for s, want := range map[string]uint64{"0": 0, "1": 1, "10000": 10000, "18446744073709551615": 1<<64 - 1} {
+		if got, err := ParseAmount(s); err != nil || got != want {
+			t.Errorf("ParseAmount(%q) = %d, %v; want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "01", "+1", "-1", "1.0", "1e6", " 1", "18446744073709551616"} {
+		if _, err := ParseAmount(s); err == nil {
+			t.Errorf("ParseAmount(%q) succeeded", s)
+		}
+	}
+}
+
+func TestDecodeHeader_Strict(t *testing.T) {
+	var out SettlementResponse
+	for _, bad := range []string{
+		strings.Repeat("A", MaxHeaderSize+4),
+		"eyJzdWNjZXNzIjp0cnVlfXt9", // {"success":true}{}
+	} {
+		if err := DecodeHeader(bad, &out); !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("DecodeHeader(%.20q) = %v, want ErrInvalidHeader", bad, err)
+		}
+	}
+}
+